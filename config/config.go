@@ -1,6 +1,8 @@
 package config
 
 import (
+	"strconv"
+	"strings"
 	"time"
 
 	ct "github.com/launchdarkly/go-configtypes"
@@ -11,6 +13,10 @@ const (
 	// DefaultPort is the port that Relay runs on if not otherwise specified.
 	DefaultPort = 8030
 
+	// DefaultSocketMode is the file mode of the Unix domain socket that Relay listens on, if
+	// MainConfig.SocketPath is set and MainConfig.SocketMode is not.
+	DefaultSocketMode = 0660
+
 	// DefaultBaseURI is the default value for Config.BaseURI. This is the base URI of LaunchDarkly
 	// services for server-side SDKs other than streaming, such as polling and Big Segment services.
 	DefaultBaseURI = "https://sdk.launchdarkly.com"
@@ -35,9 +41,60 @@ const (
 	// DefaultEventCapacity is the default value for EventsConfig.Capacity if not specified.
 	DefaultEventCapacity = 1000
 
+	// DefaultEventsMaxAttempts is the default value for EventsConfig.MaxAttempts if not specified.
+	DefaultEventsMaxAttempts = 2
+
+	// DefaultEventsRetryDelay is the default value for EventsConfig.RetryDelay if not specified.
+	DefaultEventsRetryDelay = time.Second
+
+	// DefaultEventsMaxRetryDelay is the default value for EventsConfig.MaxRetryDelay if not specified.
+	DefaultEventsMaxRetryDelay = time.Second * 30
+
+	// DefaultDiagnosticEventQueueSize is the default value for EventsConfig.DiagnosticQueueSize if not
+	// specified.
+	DefaultDiagnosticEventQueueSize = 100
+
+	// DefaultEventsContextKeysCapacity is the default value for EventsConfig.ContextKeysCapacity if not
+	// specified.
+	DefaultEventsContextKeysCapacity = 1000
+
+	// DefaultEventsContextKeysFlushInterval is the default value for EventsConfig.ContextKeysFlushInterval
+	// if not specified.
+	DefaultEventsContextKeysFlushInterval = time.Minute * 5
+
 	// DefaultHeartbeatInterval is the default value for MainConfig.HeartBeatInterval if not specified.
 	DefaultHeartbeatInterval = time.Minute * 3
 
+	// DefaultWebhookQueueSize is the default value for WebhooksConfig.QueueSize if not specified.
+	DefaultWebhookQueueSize = 1000
+
+	// DefaultWebhookMaxAttempts is the default value for WebhooksConfig.MaxAttempts if not specified.
+	DefaultWebhookMaxAttempts = 3
+
+	// DefaultWebhookRetryDelay is the default value for WebhooksConfig.RetryDelay if not specified.
+	DefaultWebhookRetryDelay = time.Second
+
+	// DefaultAuditLogMaxFileSize is the default value for AuditLogConfig.MaxFileSize if not specified.
+	DefaultAuditLogMaxFileSize = 100 * 1024 * 1024
+
+	// DefaultAuditLogMaxBackups is the default value for AuditLogConfig.MaxBackups if not specified.
+	DefaultAuditLogMaxBackups = 5
+
+	// DefaultEventSinkMaxFileSize is the default value for EventSinkConfig.MaxFileSize if not specified.
+	DefaultEventSinkMaxFileSize = 100 * 1024 * 1024
+
+	// DefaultEventSinkMaxBackups is the default value for EventSinkConfig.MaxBackups if not specified.
+	DefaultEventSinkMaxBackups = 5
+
+	// DefaultMaxStreamBufferSize is the default value for MainConfig.MaxStreamBufferSize if not specified.
+	DefaultMaxStreamBufferSize = 16 * 1024 * 1024
+
+	// DefaultReadHeaderTimeout is the default value for MainConfig.ReadHeaderTimeout if not specified.
+	DefaultReadHeaderTimeout = time.Second * 10
+
+	// DefaultMaxRequestBodySize is the default value for MainConfig.MaxRequestBodySize if not specified.
+	DefaultMaxRequestBodySize = 10 * 1024 * 1024
+
 	// DefaultEventsFlushInterval is the default value for EventsConfig.FlushInterval if not specified.
 	DefaultEventsFlushInterval = time.Second * 5
 
@@ -53,6 +110,43 @@ const (
 	// DefaultBigSegmentsStaleThreshold is the default value for MainConfig.BigSegmentsStaleThreshold if not specified.
 	DefaultBigSegmentsStaleThreshold = time.Minute * 5
 
+	// RouteGroupServerSide is the ListenerConfig.Routes value for endpoints used by server-side SDKs,
+	// including other Relay instances, other than event endpoints.
+	RouteGroupServerSide = "serverSide"
+
+	// RouteGroupMobile is the ListenerConfig.Routes value for endpoints used by mobile SDKs, other than
+	// event endpoints.
+	RouteGroupMobile = "mobile"
+
+	// RouteGroupClientSide is the ListenerConfig.Routes value for endpoints used by JavaScript-based
+	// client-side SDKs, other than event endpoints.
+	RouteGroupClientSide = "clientSide"
+
+	// RouteGroupEvents is the ListenerConfig.Routes value for endpoints that receive events from any
+	// kind of SDK.
+	RouteGroupEvents = "events"
+
+	// RouteGroupStatus is the ListenerConfig.Routes value for the status endpoint.
+	RouteGroupStatus = "status"
+
+	// RouteGroupAdmin is the ListenerConfig.Routes value for the admin endpoints.
+	RouteGroupAdmin = "admin"
+
+	// EventSinkTypeFile is the EventSinkConfig.Type value for writing events to a file.
+	EventSinkTypeFile = "file"
+
+	// EventSinkTypeStdout is the EventSinkConfig.Type value for writing events to standard output.
+	EventSinkTypeStdout = "stdout"
+
+	// EventSinkTypeKafka is the EventSinkConfig.Type value for producing events to a Kafka topic.
+	EventSinkTypeKafka = "kafka"
+
+	// EventSinkKindAnalytics is the EventSinkConfig.Kinds value for analytics events.
+	EventSinkKindAnalytics = "analytics"
+
+	// EventSinkKindDiagnostic is the EventSinkConfig.Kinds value for diagnostic events.
+	EventSinkKindDiagnostic = "diagnostic"
+
 	// AutoConfigEnvironmentIDPlaceholder is a string that can appear within
 	// AutoConfigConfig.EnvDataStorePrefix or AutoConfigConfig.EnvDataStoreTableName to indicate that
 	// the environment ID should be substituted at that point.
@@ -79,6 +173,45 @@ var (
 	defaultRedisURL, _          = ct.NewOptURLAbsoluteFromString("redis://localhost:6379")       //nolint:gochecknoglobals
 )
 
+// AllRouteGroups returns all of the allowable values for MainConfig.Routes and ListenerConfig.Routes.
+func AllRouteGroups() []string {
+	return []string{RouteGroupServerSide, RouteGroupMobile, RouteGroupClientSide, RouteGroupEvents,
+		RouteGroupStatus, RouteGroupAdmin}
+}
+
+// AllEventSinkTypes returns all of the allowable values for EventSinkConfig.Type.
+func AllEventSinkTypes() []string {
+	return []string{EventSinkTypeFile, EventSinkTypeStdout, EventSinkTypeKafka}
+}
+
+// AllEventSinkKinds returns all of the allowable values for EventSinkConfig.Kinds.
+func AllEventSinkKinds() []string {
+	return []string{EventSinkKindAnalytics, EventSinkKindDiagnostic}
+}
+
+// AllEventKinds returns the kinds of analytics events that SDKs send, which are the allowable values for
+// EnvConfig.DropEventKinds.
+func AllEventKinds() []string {
+	return []string{"feature", "debug", "custom", "identify", "index", "alias", "summary", "migration_op",
+		"click", "pageview"}
+}
+
+// ParseEventSampleRate parses one of the values of EnvConfig.EventSampleRates or
+// EnvConfig.CustomEventSampleRates, which are in the format "name=rate", where rate is the fraction of
+// events from 0 to 1 that should be kept. It returns false if the value is not in that format.
+func ParseEventSampleRate(value string) (name string, rate float64, ok bool) {
+	i := strings.LastIndex(value, "=")
+	if i < 0 {
+		return "", 0, false
+	}
+	name = strings.TrimSpace(value[:i])
+	rate, err := strconv.ParseFloat(strings.TrimSpace(value[i+1:]), 64)
+	if name == "" || err != nil || rate < 0 || rate > 1 {
+		return "", 0, false
+	}
+	return name, rate, true
+}
+
 // DefaultLoggers is the default logging configuration used by Relay.
 //
 // Output goes to stdout, except Error level which goes to stderr. Debug level is disabled.
@@ -98,20 +231,29 @@ var DefaultLoggers = logging.MakeDefaultLoggers() //nolint:gochecknoglobals
 // variables, individual fields are not documented here; instead, see the `README.md` section on
 // configuration.
 type Config struct {
-	Main        MainConfig
-	AutoConfig  AutoConfigConfig
-	OfflineMode OfflineModeConfig
-	Events      EventsConfig
-	Redis       RedisConfig
-	Consul      ConsulConfig
-	DynamoDB    DynamoDBConfig
-	Environment map[string]*EnvConfig
-	Proxy       ProxyConfig
+	Main             MainConfig
+	AutoConfig       AutoConfigConfig
+	OfflineMode      OfflineModeConfig
+	Downstream       DownstreamConfig
+	Webhooks         WebhooksConfig
+	AuditLog         AuditLogConfig
+	Events           EventsConfig
+	Redis            RedisConfig
+	Consul           ConsulConfig
+	DynamoDB         DynamoDBConfig
+	Environment      map[string]*EnvConfig
+	Listener         map[string]*ListenerConfig
+	EventSink        map[string]*EventSinkConfig
+	EventDestination map[string]*EventDestinationConfig
+	Proxy            ProxyConfig
 
 	// Optional configuration for metrics integrations. Note that unlike the other fields in Config,
 	// MetricsConfig is not the name of a configuration file section; the actual sections are the
 	// structs within this struct (Datadog, etc.).
 	MetricsConfig
+
+	secretRefs []secretRefState          // set by ValidateConfig; see GetSecretRefs
+	sources    map[string]PropertySource // set by TrackPropertySource; see GetPropertySource
 }
 
 // MainConfig contains global configuration options for Relay.
@@ -122,25 +264,44 @@ type Config struct {
 // variables, individual fields are not documented here; instead, see the `README.md` section on
 // configuration.
 type MainConfig struct {
-	ExitOnError                 bool                     `conf:"EXIT_ON_ERROR"`
-	ExitAlways                  bool                     `conf:"EXIT_ALWAYS"`
-	IgnoreConnectionErrors      bool                     `conf:"IGNORE_CONNECTION_ERRORS"`
-	StreamURI                   ct.OptURLAbsolute        `conf:"STREAM_URI"`
-	BaseURI                     ct.OptURLAbsolute        `conf:"BASE_URI"`
-	ClientSideBaseURI           ct.OptURLAbsolute        `conf:"CLIENT_SIDE_BASE_URI"`
-	Port                        ct.OptIntGreaterThanZero `conf:"PORT"`
-	InitTimeout                 ct.OptDuration           `conf:"INIT_TIMEOUT"`
-	HeartbeatInterval           ct.OptDuration           `conf:"HEARTBEAT_INTERVAL"`
-	MaxClientConnectionTime     ct.OptDuration           `conf:"MAX_CLIENT_CONNECTION_TIME"`
-	DisconnectedStatusTime      ct.OptDuration           `conf:"DISCONNECTED_STATUS_TIME"`
-	DisableInternalUsageMetrics bool                     `conf:"DISABLE_INTERNAL_USAGE_METRICS"`
-	TLSEnabled                  bool                     `conf:"TLS_ENABLED"`
-	TLSCert                     string                   `conf:"TLS_CERT"`
-	TLSKey                      string                   `conf:"TLS_KEY"`
-	TLSMinVersion               OptTLSVersion            `conf:"TLS_MIN_VERSION"`
-	LogLevel                    OptLogLevel              `conf:"LOG_LEVEL"`
-	BigSegmentsStaleAsDegraded  bool                     `conf:"BIG_SEGMENTS_STALE_AS_DEGRADED"`
-	BigSegmentsStaleThreshold   ct.OptDuration           `conf:"BIG_SEGMENTS_STALE_THRESHOLD"`
+	ExitOnError                       bool                     `conf:"EXIT_ON_ERROR"`
+	ExitAlways                        bool                     `conf:"EXIT_ALWAYS"`
+	IgnoreConnectionErrors            bool                     `conf:"IGNORE_CONNECTION_ERRORS"`
+	StreamURI                         ct.OptURLAbsolute        `conf:"STREAM_URI"`
+	BaseURI                           ct.OptURLAbsolute        `conf:"BASE_URI"`
+	ClientSideBaseURI                 ct.OptURLAbsolute        `conf:"CLIENT_SIDE_BASE_URI"`
+	Port                              ct.OptIntGreaterThanZero `conf:"PORT"`
+	SocketPath                        string                   `conf:"SOCKET_PATH"`
+	SocketMode                        OptFileMode              `conf:"SOCKET_MODE"`
+	InitTimeout                       ct.OptDuration           `conf:"INIT_TIMEOUT"`
+	HeartbeatInterval                 ct.OptDuration           `conf:"HEARTBEAT_INTERVAL"`
+	ServerSideHeartbeatInterval       ct.OptDuration           `conf:"SERVER_SIDE_HEARTBEAT_INTERVAL"`
+	MobileHeartbeatInterval           ct.OptDuration           `conf:"MOBILE_HEARTBEAT_INTERVAL"`
+	JSClientHeartbeatInterval         ct.OptDuration           `conf:"JS_CLIENT_HEARTBEAT_INTERVAL"`
+	MaxClientConnectionTime           ct.OptDuration           `conf:"MAX_CLIENT_CONNECTION_TIME"`
+	ServerSideMaxClientConnectionTime ct.OptDuration           `conf:"SERVER_SIDE_MAX_CLIENT_CONNECTION_TIME"`
+	MobileMaxClientConnectionTime     ct.OptDuration           `conf:"MOBILE_MAX_CLIENT_CONNECTION_TIME"`
+	JSClientMaxClientConnectionTime   ct.OptDuration           `conf:"JS_CLIENT_MAX_CLIENT_CONNECTION_TIME"`
+	MaxClientConnectionTimeJitter     ct.OptDuration           `conf:"MAX_CLIENT_CONNECTION_TIME_JITTER"`
+	MaxStreamBufferSize               ct.OptIntGreaterThanZero `conf:"MAX_STREAM_BUFFER_SIZE"`
+	DisconnectedStatusTime            ct.OptDuration           `conf:"DISCONNECTED_STATUS_TIME"`
+	DisableInternalUsageMetrics       bool                     `conf:"DISABLE_INTERNAL_USAGE_METRICS"`
+	TLSEnabled                        bool                     `conf:"TLS_ENABLED"`
+	TLSCert                           string                   `conf:"TLS_CERT"`
+	TLSKey                            string                   `conf:"TLS_KEY"`
+	TLSMinVersion                     OptTLSVersion            `conf:"TLS_MIN_VERSION"`
+	H2CEnabled                        bool                     `conf:"H2C_ENABLED"`
+	Routes                            ct.OptStringList         `conf:"ROUTES"`
+	ReadHeaderTimeout                 ct.OptDuration           `conf:"READ_HEADER_TIMEOUT"`
+	ReadTimeout                       ct.OptDuration           `conf:"READ_TIMEOUT"`
+	WriteTimeout                      ct.OptDuration           `conf:"WRITE_TIMEOUT"`
+	IdleTimeout                       ct.OptDuration           `conf:"IDLE_TIMEOUT"`
+	MaxHeaderBytes                    ct.OptIntGreaterThanZero `conf:"MAX_HEADER_BYTES"`
+	MaxRequestBodySize                ct.OptIntGreaterThanZero `conf:"MAX_REQUEST_BODY_SIZE"`
+	LogLevel                          OptLogLevel              `conf:"LOG_LEVEL"`
+	BigSegmentsStaleAsDegraded        bool                     `conf:"BIG_SEGMENTS_STALE_AS_DEGRADED"`
+	BigSegmentsStaleThreshold         ct.OptDuration           `conf:"BIG_SEGMENTS_STALE_THRESHOLD"`
+	AdminKey                          string                   `conf:"ADMIN_KEY" credential:"true"`
 }
 
 // AutoConfigConfig contains configuration parameters for the auto-configuration feature.
@@ -161,17 +322,51 @@ type OfflineModeConfig struct {
 	EnvAllowedHeader      ct.OptStringList `conf:"ENV_ALLOWED_HEADER"`
 }
 
+// DownstreamConfig contains configuration parameters for serving environment configuration to other
+// Relay instances that use this Relay as their data source.
+type DownstreamConfig struct {
+	AutoConfigKey AutoConfigKey `conf:"DOWNSTREAM_AUTO_CONFIG_KEY"`
+}
+
+// WebhooksConfig contains configuration parameters for flag change notifications. The URL and Secret
+// apply to every environment that does not have its own WebhookURL.
+type WebhooksConfig struct {
+	URL         ct.OptURLAbsolute        `conf:"WEBHOOK_URL"`
+	Secret      string                   `conf:"WEBHOOK_SECRET" credential:"true"`
+	QueueSize   ct.OptIntGreaterThanZero `conf:"WEBHOOK_QUEUE_SIZE"`
+	MaxAttempts ct.OptIntGreaterThanZero `conf:"WEBHOOK_MAX_ATTEMPTS"`
+	RetryDelay  ct.OptDuration           `conf:"WEBHOOK_RETRY_DELAY"`
+}
+
+// AuditLogConfig contains configuration parameters for the audit log of data changes. The log is written
+// to File if that is set, or to standard output if Stdout is true; it is an error to set both.
+type AuditLogConfig struct {
+	File        string                   `conf:"AUDIT_LOG_FILE"`
+	Stdout      bool                     `conf:"AUDIT_LOG_STDOUT"`
+	MaxFileSize ct.OptIntGreaterThanZero `conf:"AUDIT_LOG_MAX_FILE_SIZE"`
+	MaxBackups  ct.OptIntGreaterThanZero `conf:"AUDIT_LOG_MAX_BACKUPS"`
+}
+
 // EventsConfig contains configuration parameters for proxying events.
 //
 // Since configuration options can be set either programmatically, or from a file, or from environment
 // variables, individual fields are not documented here; instead, see the `README.md` section on
 // configuration.
 type EventsConfig struct {
-	EventsURI     ct.OptURLAbsolute        `conf:"EVENTS_HOST"`
-	SendEvents    bool                     `conf:"USE_EVENTS"`
-	FlushInterval ct.OptDuration           `conf:"EVENTS_FLUSH_INTERVAL"`
-	Capacity      ct.OptIntGreaterThanZero `conf:"EVENTS_CAPACITY"`
-	InlineUsers   bool                     `conf:"EVENTS_INLINE_USERS"`
+	EventsURI                ct.OptURLAbsolute        `conf:"EVENTS_HOST"`
+	SendEvents               bool                     `conf:"USE_EVENTS"`
+	FlushInterval            ct.OptDuration           `conf:"EVENTS_FLUSH_INTERVAL"`
+	Capacity                 ct.OptIntGreaterThanZero `conf:"EVENTS_CAPACITY"`
+	InlineUsers              bool                     `conf:"EVENTS_INLINE_USERS"`
+	EnableCompression        bool                     `conf:"EVENTS_ENABLE_COMPRESSION"`
+	MaxAttempts              ct.OptIntGreaterThanZero `conf:"EVENTS_MAX_ATTEMPTS"`
+	RetryDelay               ct.OptDuration           `conf:"EVENTS_RETRY_DELAY"`
+	MaxRetryDelay            ct.OptDuration           `conf:"EVENTS_MAX_RETRY_DELAY"`
+	DiagnosticQueueSize      ct.OptIntGreaterThanZero `conf:"EVENTS_DIAGNOSTIC_QUEUE_SIZE"`
+	EnableAggregation        bool                     `conf:"EVENTS_ENABLE_AGGREGATION"`
+	ContextKeysCapacity      ct.OptIntGreaterThanZero `conf:"EVENTS_CONTEXT_KEYS_CAPACITY"`
+	ContextKeysFlushInterval ct.OptDuration           `conf:"EVENTS_CONTEXT_KEYS_FLUSH_INTERVAL"`
+	EnableValidation         bool                     `conf:"EVENTS_ENABLE_VALIDATION"`
 }
 
 // RedisConfig configures the optional Redis integration.
@@ -192,7 +387,7 @@ type RedisConfig struct {
 	LocalTTL ct.OptDuration    `conf:"CACHE_TTL"`
 	TLS      bool              `conf:"REDIS_TLS"`
 	Username string            `conf:"REDIS_USERNAME"`
-	Password string            `conf:"REDIS_PASSWORD"`
+	Password string            `conf:"REDIS_PASSWORD" credential:"true"`
 }
 
 // ConsulConfig configures the optional Consul integration.
@@ -207,7 +402,7 @@ type RedisConfig struct {
 type ConsulConfig struct {
 	Host      string         `conf:"CONSUL_HOST"`
 	LocalTTL  ct.OptDuration `conf:"CACHE_TTL"`
-	Token     string         `conf:"CONSUL_TOKEN"`
+	Token     string         `conf:"CONSUL_TOKEN" credential:"true"`
 	TokenFile string         `conf:"CONSUL_TOKEN_FILE"`
 }
 
@@ -234,16 +429,90 @@ type DynamoDBConfig struct {
 // variables, individual fields are not documented here; instead, see the `README.md` section on
 // configuration.
 type EnvConfig struct {
-	SDKKey        SDKKey           // set from env var LD_ENV_envname
-	MobileKey     MobileKey        `conf:"LD_MOBILE_KEY_"`
-	EnvID         EnvironmentID    `conf:"LD_CLIENT_SIDE_ID_"`
-	Prefix        string           `conf:"LD_PREFIX_"`     // used only if Redis, Consul, or DynamoDB is enabled
-	TableName     string           `conf:"LD_TABLE_NAME_"` // used only if DynamoDB is enabled
-	AllowedOrigin ct.OptStringList `conf:"LD_ALLOWED_ORIGIN_"`
-	AllowedHeader ct.OptStringList `conf:"LD_ALLOWED_HEADER_"`
-	SecureMode    bool             `conf:"LD_SECURE_MODE_"`
-	LogLevel      OptLogLevel      `conf:"LD_LOG_LEVEL_"`
-	TTL           ct.OptDuration   `conf:"LD_TTL_"`
+	SDKKey                  SDKKey            // set from env var LD_ENV_envname
+	MobileKey               MobileKey         `conf:"LD_MOBILE_KEY_"`
+	EnvID                   EnvironmentID     `conf:"LD_CLIENT_SIDE_ID_"`
+	Prefix                  string            `conf:"LD_PREFIX_"`     // used only if Redis, Consul, or DynamoDB is enabled
+	TableName               string            `conf:"LD_TABLE_NAME_"` // used only if DynamoDB is enabled
+	AllowedOrigin           ct.OptStringList  `conf:"LD_ALLOWED_ORIGIN_"`
+	AllowedHeader           ct.OptStringList  `conf:"LD_ALLOWED_HEADER_"`
+	SecureMode              bool              `conf:"LD_SECURE_MODE_"`
+	LogLevel                OptLogLevel       `conf:"LD_LOG_LEVEL_"`
+	TTL                     ct.OptDuration    `conf:"LD_TTL_"`
+	HeartbeatInterval       ct.OptDuration    `conf:"LD_HEARTBEAT_INTERVAL_"`
+	MaxClientConnectionTime ct.OptDuration    `conf:"LD_MAX_CLIENT_CONNECTION_TIME_"`
+	WebhookURL              ct.OptURLAbsolute `conf:"LD_WEBHOOK_URL_"`
+	WebhookSecret           string            `conf:"LD_WEBHOOK_SECRET_" credential:"true"`
+	RedactAttributes        ct.OptStringList  `conf:"LD_REDACT_ATTRIBUTES_"`
+	HashAttributes          ct.OptStringList  `conf:"LD_HASH_ATTRIBUTES_"`
+	DropEventKinds          ct.OptStringList  `conf:"LD_DROP_EVENT_KINDS_"`
+	DropFlagKeys            ct.OptStringList  `conf:"LD_DROP_FLAG_KEYS_"`
+	EventSampleRates        ct.OptStringList  `conf:"LD_EVENT_SAMPLE_RATES_"`
+	CustomEventSampleRates  ct.OptStringList  `conf:"LD_CUSTOM_EVENT_SAMPLE_RATES_"`
+}
+
+// ListenerConfig describes an additional port that Relay listens on, which serves only the specified
+// groups of endpoints. There may be any number of these; the main port that is set in MainConfig serves
+// the groups in MainConfig.Routes, which by default is all of them.
+//
+// This corresponds to one of the [listener "name"] sections in the configuration file. In the
+// Config.Listener map, each key is a listener name and each value is a ListenerConfig.
+//
+// Since configuration options can be set either programmatically, or from a file, or from environment
+// variables, individual fields are not documented here; instead, see the `README.md` section on
+// configuration.
+type ListenerConfig struct {
+	Port          ct.OptIntGreaterThanZero // set from env var LISTENER_PORT_listenername
+	TLSEnabled    bool                     `conf:"LISTENER_TLS_ENABLED_"`
+	TLSCert       string                   `conf:"LISTENER_TLS_CERT_"`
+	TLSKey        string                   `conf:"LISTENER_TLS_KEY_"`
+	TLSMinVersion OptTLSVersion            `conf:"LISTENER_TLS_MIN_VERSION_"`
+	H2CEnabled    bool                     `conf:"LISTENER_H2C_ENABLED_"`
+	Routes        ct.OptStringList         `conf:"LISTENER_ROUTES_"`
+}
+
+// EventSinkConfig describes a destination other than LaunchDarkly that events received from SDKs are
+// written to, in addition to or instead of being forwarded to LaunchDarkly. There may be any number of
+// these.
+//
+// This corresponds to one of the [eventSink "name"] sections in the configuration file. In the
+// Config.EventSink map, each key is a sink name and each value is an EventSinkConfig.
+//
+// Since configuration options can be set either programmatically, or from a file, or from environment
+// variables, individual fields are not documented here; instead, see the `README.md` section on
+// configuration.
+type EventSinkConfig struct {
+	Type           string                   // set from env var EVENT_SINK_TYPE_sinkname
+	File           string                   `conf:"EVENT_SINK_FILE_"`
+	MaxFileSize    ct.OptIntGreaterThanZero `conf:"EVENT_SINK_MAX_FILE_SIZE_"`
+	MaxBackups     ct.OptIntGreaterThanZero `conf:"EVENT_SINK_MAX_BACKUPS_"`
+	RotateInterval ct.OptDuration           `conf:"EVENT_SINK_ROTATE_INTERVAL_"`
+	Kinds          ct.OptStringList         `conf:"EVENT_SINK_KINDS_"`
+	Environments   ct.OptStringList         `conf:"EVENT_SINK_ENVIRONMENTS_"`
+	Brokers        ct.OptStringList         `conf:"EVENT_SINK_BROKERS_"`
+	Topic          string                   `conf:"EVENT_SINK_TOPIC_"`
+	TLSEnabled     bool                     `conf:"EVENT_SINK_TLS_ENABLED_"`
+	FlushInterval  ct.OptDuration           `conf:"EVENT_SINK_FLUSH_INTERVAL_"`
+	Capacity       ct.OptIntGreaterThanZero `conf:"EVENT_SINK_CAPACITY_"`
+}
+
+// EventDestinationConfig describes an additional place that analytics events for one environment are
+// forwarded to, with its own credentials, besides the environment's own LaunchDarkly credentials and
+// the events URI in EventsConfig. There may be any number of these.
+//
+// This corresponds to one of the [eventDestination "name"] sections in the configuration file. In the
+// Config.EventDestination map, each key is a destination name and each value is an
+// EventDestinationConfig.
+//
+// Since configuration options can be set either programmatically, or from a file, or from environment
+// variables, individual fields are not documented here; instead, see the `README.md` section on
+// configuration.
+type EventDestinationConfig struct {
+	Environment string            // set from env var EVENT_DESTINATION_ENV_destinationname
+	EventsURI   ct.OptURLAbsolute `conf:"EVENT_DESTINATION_URI_"`
+	SDKKey      SDKKey            `conf:"EVENT_DESTINATION_SDK_KEY_"`
+	MobileKey   MobileKey         `conf:"EVENT_DESTINATION_MOBILE_KEY_"`
+	EnvID       EnvironmentID     `conf:"EVENT_DESTINATION_CLIENT_SIDE_ID_"`
 }
 
 // ProxyConfig represents all the supported proxy options.
@@ -252,12 +521,23 @@ type EnvConfig struct {
 // variables, individual fields are not documented here; instead, see the `README.md` section on
 // configuration.
 type ProxyConfig struct {
-	URL         ct.OptURLAbsolute `conf:"PROXY_URL"`
-	NTLMAuth    bool              `conf:"PROXY_AUTH_NTLM"`
-	User        string            `conf:"PROXY_AUTH_USER"`
-	Password    string            `conf:"PROXY_AUTH_PASSWORD"`
-	Domain      string            `conf:"PROXY_AUTH_DOMAIN"`
-	CACertFiles ct.OptStringList  `conf:"PROXY_CA_CERTS"`
+	URL            ct.OptURLAbsolute `conf:"PROXY_URL"`
+	NTLMAuth       bool              `conf:"PROXY_AUTH_NTLM"`
+	User           string            `conf:"PROXY_AUTH_USER"`
+	Password       string            `conf:"PROXY_AUTH_PASSWORD" credential:"true"`
+	Domain         string            `conf:"PROXY_AUTH_DOMAIN"`
+	CACertFiles    ct.OptStringList  `conf:"PROXY_CA_CERTS"`
+	ConnectTimeout ct.OptDuration    `conf:"PROXY_CONNECT_TIMEOUT"`
+	ReadTimeout    ct.OptDuration    `conf:"PROXY_READ_TIMEOUT"`
+	ClientCertFile string            `conf:"PROXY_CLIENT_CERT_FILE"`
+	ClientKeyFile  string            `conf:"PROXY_CLIENT_KEY_FILE"`
+	Headers        ct.OptStringList  `conf:"PROXY_HEADERS" credential:"true"`
+	NoProxy        ct.OptStringList  `conf:"PROXY_NO_PROXY"`
+	StreamURL      ct.OptURLAbsolute `conf:"PROXY_STREAM_URL"`
+	BaseURL        ct.OptURLAbsolute `conf:"PROXY_BASE_URL"`
+	EventsURL      ct.OptURLAbsolute `conf:"PROXY_EVENTS_URL"`
+	BigSegmentsURL ct.OptURLAbsolute `conf:"PROXY_BIG_SEGMENTS_URL"`
+	AutoConfigURL  ct.OptURLAbsolute `conf:"PROXY_AUTO_CONFIG_URL"`
 }
 
 // MetricsConfig contains configurations for optional metrics integrations.
@@ -3,6 +3,8 @@ package config
 import (
 	"crypto/tls"
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
@@ -16,6 +18,10 @@ func errBadTLSVersion(s string) error {
 	return fmt.Errorf("%q is not a valid TLS version", s)
 }
 
+func errBadFileMode(s string) error {
+	return fmt.Errorf("%q is not a valid file mode; must be an octal number such as 0660", s)
+}
+
 // SDKKey is a type tag to indicate when a string is used as a server-side SDK key for a LaunchDarkly
 // environment.
 type SDKKey string
@@ -137,6 +143,14 @@ func (o *OptLogLevel) UnmarshalText(data []byte) error {
 	return err
 }
 
+// String returns the name of the log level, or an empty string if there is no value.
+func (o OptLogLevel) String() string {
+	if o.level == 0 {
+		return ""
+	}
+	return o.level.Name()
+}
+
 // OptTLSVersion represents an optional TLS level parameter. When represented as a string, it must be
 // "1.0", "1.1", "1.2", or "1.3". This is converted into a uint16 value as defined by crypto/tls.
 type OptTLSVersion struct {
@@ -205,3 +219,61 @@ func (o OptTLSVersion) String() string {
 		return fmt.Sprintf("unknown (%d)", o.value)
 	}
 }
+
+// OptFileMode represents an optional file permissions parameter. When represented as a string, it must be
+// an octal number such as "0660"; only the permission bits (0777) are allowed.
+//
+// The zero value OptFileMode{} is valid and undefined (IsDefined() is false).
+type OptFileMode struct {
+	mode    os.FileMode
+	defined bool
+}
+
+// NewOptFileMode creates an OptFileMode that wraps the given value.
+func NewOptFileMode(mode os.FileMode) OptFileMode {
+	return OptFileMode{mode: mode, defined: true}
+}
+
+// NewOptFileModeFromString creates an OptFileMode from a string that must either be an octal number
+// or an empty string.
+func NewOptFileModeFromString(s string) (OptFileMode, error) {
+	if s == "" {
+		return OptFileMode{}, nil
+	}
+	n, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || n&^uint64(os.ModePerm) != 0 {
+		return OptFileMode{}, errBadFileMode(s)
+	}
+	return NewOptFileMode(os.FileMode(n)), nil
+}
+
+// IsDefined returns true if the instance contains a value.
+func (o OptFileMode) IsDefined() bool {
+	return o.defined
+}
+
+// GetOrElse returns the wrapped value, or the alternative value if there is no value.
+func (o OptFileMode) GetOrElse(orElseValue os.FileMode) os.FileMode {
+	if !o.defined {
+		return orElseValue
+	}
+	return o.mode
+}
+
+// UnmarshalText attempts to parse the value from a byte string, using the same logic as
+// NewOptFileModeFromString.
+func (o *OptFileMode) UnmarshalText(data []byte) error {
+	opt, err := NewOptFileModeFromString(string(data))
+	if err == nil {
+		*o = opt
+	}
+	return err
+}
+
+// String returns the value as an octal number, or an empty string if there is no value.
+func (o OptFileMode) String() string {
+	if !o.defined {
+		return ""
+	}
+	return fmt.Sprintf("%04o", uint32(o.mode))
+}
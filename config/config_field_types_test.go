@@ -2,6 +2,7 @@ package config
 
 import (
 	"crypto/tls"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -91,3 +92,45 @@ func TestOptTLSVersion(t *testing.T) {
 		assert.Equal(t, "unknown (9999)", NewOptTLSVersion(9999).String())
 	})
 }
+
+func TestOptFileMode(t *testing.T) {
+	t.Run("zero value", func(t *testing.T) {
+		o := OptFileMode{}
+		assert.False(t, o.IsDefined())
+		assert.Equal(t, os.FileMode(0600), o.GetOrElse(0600))
+	})
+
+	t.Run("new from valid string", func(t *testing.T) {
+		for _, val := range []struct {
+			s string
+			m os.FileMode
+		}{{"0660", 0660}, {"660", 0660}, {"0777", 0777}, {"0", 0}} {
+			t.Run(val.s, func(t *testing.T) {
+				o, err := NewOptFileModeFromString(val.s)
+				assert.NoError(t, err)
+				assert.True(t, o.IsDefined())
+				assert.Equal(t, val.m, o.GetOrElse(0600))
+			})
+		}
+	})
+
+	t.Run("new from empty string", func(t *testing.T) {
+		o, err := NewOptFileModeFromString("")
+		assert.NoError(t, err)
+		assert.Equal(t, OptFileMode{}, o)
+	})
+
+	t.Run("new from invalid string", func(t *testing.T) {
+		for _, s := range []string{"x", "0999", "1777", "-1"} {
+			o, err := NewOptFileModeFromString(s)
+			assert.Equal(t, errBadFileMode(s), err)
+			assert.Equal(t, OptFileMode{}, o)
+		}
+	})
+
+	t.Run("get string value", func(t *testing.T) {
+		assert.Equal(t, "", OptFileMode{}.String())
+		assert.Equal(t, "0660", NewOptFileMode(0660).String())
+		assert.Equal(t, "0000", NewOptFileMode(0).String())
+	})
+}
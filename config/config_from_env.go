@@ -43,6 +43,12 @@ func LoadConfigFromEnvironmentBase(c *Config, loggers ldlog.Loggers) ct.Validati
 
 	reader.ReadStruct(&c.OfflineMode, false)
 
+	reader.ReadStruct(&c.Downstream, false)
+
+	reader.ReadStruct(&c.Webhooks, false)
+
+	reader.ReadStruct(&c.AuditLog, false)
+
 	// The following properties have the same environment variable names in AutoConfigConfig and in
 	// OfflineModeConfig, because only one of those can be used at a time. We'll blank them out for
 	// whichever section is not being used.
@@ -61,12 +67,23 @@ func LoadConfigFromEnvironmentBase(c *Config, loggers ldlog.Loggers) ct.Validati
 	reader.ReadStruct(&c.Events, false)
 	rejectObsoleteVariableName("EVENTS_SAMPLING_INTERVAL", "", reader)
 
-	for envName, envKey := range reader.FindPrefixedValues("LD_ENV_") {
+	envKeys := reader.FindPrefixedValues("LD_ENV_")
+	// An environment that is only used for forwarding events may have no SDK key, so it can also be
+	// defined by its mobile key or client-side ID.
+	envNames := make(map[string]bool, len(envKeys))
+	for _, prefix := range []string{"LD_ENV_", "LD_MOBILE_KEY_", "LD_CLIENT_SIDE_ID_"} {
+		for envName := range reader.FindPrefixedValues(prefix) {
+			envNames[envName] = true
+		}
+	}
+	for envName := range envNames {
 		var ec EnvConfig
 		if c.Environment[envName] != nil {
 			ec = *c.Environment[envName]
 		}
-		ec.SDKKey = SDKKey(envKey)
+		if envKey, ok := envKeys[envName]; ok {
+			ec.SDKKey = SDKKey(envKey)
+		}
 		subReader := reader.WithVarNameSuffix(envName)
 		subReader.ReadStruct(&ec, false)
 		rejectObsoleteVariableName("LD_TTL_MINUTES_"+envName, "LD_TTL_"+envName, reader)
@@ -76,6 +93,50 @@ func LoadConfigFromEnvironmentBase(c *Config, loggers ldlog.Loggers) ct.Validati
 		c.Environment[envName] = &ec
 	}
 
+	for listenerName, portStr := range reader.FindPrefixedValues("LISTENER_PORT_") {
+		var lc ListenerConfig
+		if c.Listener[listenerName] != nil {
+			lc = *c.Listener[listenerName]
+		}
+		if err := lc.Port.UnmarshalText([]byte(portStr)); err != nil {
+			reader.AddError(ct.ValidationPath{"LISTENER_PORT_" + listenerName}, err)
+		}
+		subReader := reader.WithVarNameSuffix(listenerName)
+		subReader.ReadStruct(&lc, false)
+		if c.Listener == nil {
+			c.Listener = make(map[string]*ListenerConfig)
+		}
+		c.Listener[listenerName] = &lc
+	}
+
+	for sinkName, sinkType := range reader.FindPrefixedValues("EVENT_SINK_TYPE_") {
+		var sc EventSinkConfig
+		if c.EventSink[sinkName] != nil {
+			sc = *c.EventSink[sinkName]
+		}
+		sc.Type = sinkType
+		subReader := reader.WithVarNameSuffix(sinkName)
+		subReader.ReadStruct(&sc, false)
+		if c.EventSink == nil {
+			c.EventSink = make(map[string]*EventSinkConfig)
+		}
+		c.EventSink[sinkName] = &sc
+	}
+
+	for destName, envName := range reader.FindPrefixedValues("EVENT_DESTINATION_ENV_") {
+		var dc EventDestinationConfig
+		if c.EventDestination[destName] != nil {
+			dc = *c.EventDestination[destName]
+		}
+		dc.Environment = envName
+		subReader := reader.WithVarNameSuffix(destName)
+		subReader.ReadStruct(&dc, false)
+		if c.EventDestination == nil {
+			c.EventDestination = make(map[string]*EventDestinationConfig)
+		}
+		c.EventDestination[destName] = &dc
+	}
+
 	useRedis := false
 	reader.Read("USE_REDIS", &useRedis)
 	if useRedis || c.Redis.Host != "" || c.Redis.URL.IsDefined() {
@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"gopkg.in/gcfg.v1"
@@ -16,10 +17,20 @@ func errLoadingConfigFile(path string, err error) error {
 
 // LoadConfigFile reads a configuration file into a Config struct and performs basic validation.
 //
+// If the file name ends in ".yaml", ".yml", or ".json", it is parsed as YAML or JSON; otherwise it is
+// parsed in the gcfg (INI-like) format.
+//
 // The Config parameter should be initialized with default values first.
 func LoadConfigFile(c *Config, path string, loggers ldlog.Loggers) error {
-	if err := gcfg.ReadFileInto(c, path); err != nil {
-		return errLoadingConfigFile(path, FilterGcfgError(err))
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		if err := loadConfigYAMLFile(c, path); err != nil {
+			return errLoadingConfigFile(path, err)
+		}
+	default:
+		if err := gcfg.ReadFileInto(c, path); err != nil {
+			return errLoadingConfigFile(path, FilterGcfgError(err))
+		}
 	}
 
 	return ValidateConfig(c, loggers)
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/gcfg.v1/types"
+	"gopkg.in/yaml.v3"
+
+	ct "github.com/launchdarkly/go-configtypes"
+)
+
+var errYAMLRootNotMap = errors.New("top level of file must be a map of section names to sections")
+
+func errYAMLUnknownSection(line int, section string) error {
+	return fmt.Errorf("line %d: unsupported or misspelled section %q", line, section)
+}
+
+func errYAMLUnknownVariable(line int, section, name string) error {
+	return fmt.Errorf("line %d: unsupported or misspelled section %q, variable %q", line, section, name)
+}
+
+func errYAMLWrongShape(line int, name, expected string) error {
+	return fmt.Errorf("line %d: %q must be %s", line, name, expected)
+}
+
+func errYAMLBadValue(line int, name string, err error) error {
+	return fmt.Errorf("line %d: invalid value for %q: %w", line, name, err)
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem() //nolint:gochecknoglobals
+	optStringListType   = reflect.TypeOf(ct.OptStringList{})                      //nolint:gochecknoglobals
+)
+
+// loadConfigYAMLFile reads a YAML or JSON configuration file into a Config struct.
+//
+// The file has the same structure as the gcfg format: the top level is a map of section names to
+// sections, and each section is a map of property names to values, except for Environment, which is a
+// map of environment names to sections. Section and property names are case-insensitive, as they are in
+// gcfg. JSON files are parsed by the YAML parser, since YAML is a superset of JSON; that way, errors in
+// either format can be reported with line numbers.
+//
+// Values are parsed from their string representations with the same logic that is used for gcfg files,
+// so the same go-configtypes validation applies, and booleans can be written as yes/no or on/off as well
+// as true/false. A property that accepts multiple values, such as
+// AllowedOrigin, can be either a single value or a list.
+func loadConfigYAMLFile(c *Config, path string) error {
+	data, err := os.ReadFile(path) //nolint:gosec // the path comes from the command line
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		return nil // empty file
+	}
+	root := resolveYAMLAlias(doc.Content[0])
+	if root.Kind != yaml.MappingNode {
+		return errYAMLRootNotMap
+	}
+	return decodeYAMLStruct(root, reflect.ValueOf(c).Elem(), "")
+}
+
+func resolveYAMLAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+func isYAMLNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+// decodeYAMLStruct decodes a map node into a struct. If section is empty, the struct is the top-level
+// Config and each key is a section name.
+func decodeYAMLStruct(node *yaml.Node, target reflect.Value, section string) error {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], resolveYAMLAlias(node.Content[i+1])
+		field, ok := findFieldIgnoringCase(target, keyNode.Value)
+		if !ok {
+			if section == "" {
+				return errYAMLUnknownSection(keyNode.Line, keyNode.Value)
+			}
+			return errYAMLUnknownVariable(keyNode.Line, section, keyNode.Value)
+		}
+		if isYAMLNull(valueNode) {
+			continue
+		}
+		name := keyNode.Value
+		if section != "" {
+			name = section + "." + keyNode.Value
+		}
+		if err := decodeYAMLValue(valueNode, field, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeYAMLValue(node *yaml.Node, target reflect.Value, name string) error {
+	if target.CanAddr() && target.Addr().Type().Implements(textUnmarshalerType) {
+		return decodeYAMLText(node, target, name)
+	}
+	switch target.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return errYAMLWrongShape(node.Line, name, "a map")
+		}
+		return decodeYAMLStruct(node, target, name)
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return errYAMLWrongShape(node.Line, name, "a map")
+		}
+		if target.IsNil() {
+			target.Set(reflect.MakeMap(target.Type()))
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], resolveYAMLAlias(node.Content[i+1])
+			elem := reflect.New(target.Type().Elem()).Elem()
+			if elem.Kind() == reflect.Ptr {
+				elem.Set(reflect.New(elem.Type().Elem()))
+			}
+			if !isYAMLNull(valueNode) {
+				if err := decodeYAMLValue(valueNode, reflect.Indirect(elem), name+" "+strconv.Quote(keyNode.Value)); err != nil {
+					return err
+				}
+			}
+			target.SetMapIndex(reflect.ValueOf(keyNode.Value), elem)
+		}
+		return nil
+	case reflect.Slice:
+		var values []*yaml.Node
+		switch node.Kind {
+		case yaml.ScalarNode:
+			values = []*yaml.Node{node}
+		case yaml.SequenceNode:
+			values = node.Content
+		default:
+			return errYAMLWrongShape(node.Line, name, "a value or a list of values")
+		}
+		slice := reflect.MakeSlice(target.Type(), len(values), len(values))
+		for i, v := range values {
+			if err := decodeYAMLValue(resolveYAMLAlias(v), slice.Index(i), name); err != nil {
+				return err
+			}
+		}
+		target.Set(slice)
+		return nil
+	}
+
+	if node.Kind != yaml.ScalarNode {
+		return errYAMLWrongShape(node.Line, name, "a single value")
+	}
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(node.Value)
+	case reflect.Bool:
+		b, err := types.ParseBool(node.Value)
+		if err != nil {
+			return errYAMLBadValue(node.Line, name, err)
+		}
+		target.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(node.Value, 10, 64)
+		if err != nil {
+			return errYAMLBadValue(node.Line, name, err)
+		}
+		target.SetInt(n)
+	default:
+		return errYAMLWrongShape(node.Line, name, "a supported type") // COVERAGE: Config has no other field types
+	}
+	return nil
+}
+
+// decodeYAMLText decodes a value into a type that implements encoding.TextUnmarshaler, as gcfg does. For
+// an OptStringList, a list is passed to UnmarshalText one item at a time, as if the property appeared
+// multiple times in a gcfg file.
+func decodeYAMLText(node *yaml.Node, target reflect.Value, name string) error {
+	var values []*yaml.Node
+	switch {
+	case node.Kind == yaml.ScalarNode:
+		values = []*yaml.Node{node}
+	case node.Kind == yaml.SequenceNode && target.Type() == optStringListType:
+		values = node.Content
+	case target.Type() == optStringListType:
+		return errYAMLWrongShape(node.Line, name, "a value or a list of values")
+	default:
+		return errYAMLWrongShape(node.Line, name, "a single value")
+	}
+	target.Set(reflect.Zero(target.Type()))
+	for _, v := range values {
+		v = resolveYAMLAlias(v)
+		if v.Kind != yaml.ScalarNode {
+			return errYAMLWrongShape(v.Line, name, "a value or a list of values")
+		}
+		if err := target.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(v.Value)); err != nil {
+			return errYAMLBadValue(v.Line, name, err)
+		}
+	}
+	return nil
+}
+
+// findFieldIgnoringCase finds an exported field by name, including fields of embedded structs such as
+// MetricsConfig.
+func findFieldIgnoringCase(target reflect.Value, name string) (reflect.Value, bool) {
+	for _, f := range reflect.VisibleFields(target.Type()) {
+		if f.IsExported() && !f.Anonymous && strings.EqualFold(f.Name, name) {
+			return target.FieldByIndex(f.Index), true
+		}
+	}
+	return reflect.Value{}, false
+}
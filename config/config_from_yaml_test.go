@@ -0,0 +1,222 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ct "github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+)
+
+func makeYAMLEquivalentConfig(c *Config) {
+	c.Main.Port = mustOptIntGreaterThanZero(8333)
+	c.Main.ExitOnError = true
+	c.Main.HeartbeatInterval = ct.NewOptDuration(90 * time.Second)
+	c.Main.LogLevel = NewOptLogLevel(ldlog.Warn)
+	c.Environment = map[string]*EnvConfig{
+		"earth": {
+			SDKKey:        SDKKey("earth-sdk"),
+			EnvID:         EnvironmentID("earth-env"),
+			AllowedOrigin: ct.NewOptStringList([]string{"http://first", "http://second"}),
+			TTL:           ct.NewOptDuration(5 * time.Minute),
+		},
+		"krypton": {
+			SDKKey:        SDKKey("krypton-sdk"),
+			AllowedOrigin: ct.NewOptStringList([]string{"http://only"}),
+		},
+	}
+	c.Datadog = DatadogConfig{Enabled: true, Tag: []string{"tag1:a", "tag2:b"}}
+}
+
+const yamlEquivalentConfig = `
+main:
+  port: 8333
+  exitOnError: true
+  heartbeatInterval: 90s
+  logLevel: warn
+
+environment:
+  earth:
+    sdkKey: earth-sdk
+    envId: earth-env
+    allowedOrigin:
+      - http://first
+      - http://second
+    ttl: 5m
+  krypton:
+    SDKKey: krypton-sdk
+    AllowedOrigin: http://only
+
+datadog:
+  enabled: true
+  tag: [ "tag1:a", "tag2:b" ]
+`
+
+const jsonEquivalentConfig = `{
+  "main": {
+    "port": 8333,
+    "exitOnError": true,
+    "heartbeatInterval": "90s",
+    "logLevel": "warn"
+  },
+  "environment": {
+    "earth": {
+      "sdkKey": "earth-sdk",
+      "envId": "earth-env",
+      "allowedOrigin": ["http://first", "http://second"],
+      "ttl": "5m"
+    },
+    "krypton": {
+      "sdkKey": "krypton-sdk",
+      "allowedOrigin": "http://only"
+    }
+  },
+  "datadog": {
+    "enabled": true,
+    "tag": ["tag1:a", "tag2:b"]
+  }
+}`
+
+func testFileWithNameAndValidConfig(t *testing.T, fileName string, tdc testDataValidConfig) {
+	path := filepath.Join(t.TempDir(), fileName)
+	require.NoError(t, os.WriteFile(path, []byte(tdc.fileContent), 0600))
+
+	var c Config
+	mockLog := ldlogtest.NewMockLog()
+	require.NoError(t, LoadConfigFile(&c, path, mockLog.Loggers))
+	tdc.assertResult(t, c, mockLog)
+}
+
+func testFileWithNameAndInvalidConfig(t *testing.T, fileName string, fileContent string, errMessages ...string) {
+	path := filepath.Join(t.TempDir(), fileName)
+	require.NoError(t, os.WriteFile(path, []byte(fileContent), 0600))
+
+	var c Config
+	err := LoadConfigFile(&c, path, ldlog.NewDisabledLoggers())
+	require.Error(t, err)
+	for _, m := range errMessages {
+		assert.Contains(t, err.Error(), m)
+	}
+}
+
+func TestConfigFromYAMLFile(t *testing.T) {
+	for _, fileName := range []string{"relay.yaml", "relay.yml", "RELAY.YAML"} {
+		t.Run(fileName, func(t *testing.T) {
+			testFileWithNameAndValidConfig(t, fileName, testDataValidConfig{
+				makeConfig:  makeYAMLEquivalentConfig,
+				fileContent: yamlEquivalentConfig,
+			})
+		})
+	}
+
+	t.Run("empty file", func(t *testing.T) {
+		testFileWithNameAndValidConfig(t, "relay.yaml", testDataValidConfig{
+			makeConfig:  func(c *Config) {},
+			fileContent: "",
+		})
+	})
+
+	t.Run("null values are ignored", func(t *testing.T) {
+		testFileWithNameAndValidConfig(t, "relay.yaml", testDataValidConfig{
+			makeConfig:  func(c *Config) { c.Environment = map[string]*EnvConfig{"earth": {SDKKey: "earth-sdk"}} },
+			fileContent: "main:\nredis: ~\nenvironment:\n  earth:\n    sdkKey: earth-sdk\n    ttl: null\n",
+		})
+	})
+
+	t.Run("same boolean values as other formats", func(t *testing.T) {
+		for _, value := range []string{"true", "yes", "on", "1"} {
+			testFileWithNameAndValidConfig(t, "relay.yaml", testDataValidConfig{
+				makeConfig:  func(c *Config) { c.Main.ExitOnError = true },
+				fileContent: "main:\n  exitOnError: " + value + "\n",
+			})
+		}
+		for _, value := range []string{"false", "no", "off", "0"} {
+			testFileWithNameAndValidConfig(t, "relay.yaml", testDataValidConfig{
+				makeConfig:  func(c *Config) {},
+				fileContent: "main:\n  exitOnError: " + value + "\n",
+			})
+		}
+	})
+
+	t.Run("same validation as other formats", func(t *testing.T) {
+		testFileWithNameAndInvalidConfig(t, "relay.yaml", "environment:\n  earth:\n    mobileKey: mob-xxx\n",
+			`SDK key is required for environment "earth"`)
+	})
+
+	t.Run("unknown section", func(t *testing.T) {
+		testFileWithNameAndInvalidConfig(t, "relay.yaml", "main:\n  port: 8000\nunknown:\n  x: y\n",
+			"failed to read configuration file", "relay.yaml", `line 3: unsupported or misspelled section "unknown"`)
+	})
+
+	t.Run("unknown property", func(t *testing.T) {
+		testFileWithNameAndInvalidConfig(t, "relay.yaml", "main:\n  port: 8000\n  unknown: x\n",
+			`line 3: unsupported or misspelled section "main", variable "unknown"`)
+	})
+
+	t.Run("unknown environment property", func(t *testing.T) {
+		testFileWithNameAndInvalidConfig(t, "relay.yaml", "environment:\n  earth:\n    sdkKey: x\n    unknown: y\n",
+			`line 4: unsupported or misspelled section "environment \"earth\"", variable "unknown"`)
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		testFileWithNameAndInvalidConfig(t, "relay.yaml", "main:\n  port: x\n",
+			`line 2: invalid value for "main.port"`, "not a valid integer")
+		testFileWithNameAndInvalidConfig(t, "relay.yaml", "main:\n  port: 0\n",
+			`line 2`, "value must be greater than zero")
+		testFileWithNameAndInvalidConfig(t, "relay.yaml", "main:\n\n  exitOnError: maybe\n",
+			`line 3: invalid value for "main.exitOnError"`)
+		testFileWithNameAndInvalidConfig(t, "relay.yaml", "main:\n  streamUri: not/absolute\n",
+			`line 2`, "must be an absolute URL/URI")
+		testFileWithNameAndInvalidConfig(t, "relay.yaml", "main:\n  heartbeatInterval: x\n",
+			`line 2`, "not a valid duration")
+	})
+
+	t.Run("wrong shape", func(t *testing.T) {
+		testFileWithNameAndInvalidConfig(t, "relay.yaml", "- a\n- b\n",
+			"top level of file must be a map")
+		testFileWithNameAndInvalidConfig(t, "relay.yaml", "main: x\n",
+			`line 1: "main" must be a map`)
+		testFileWithNameAndInvalidConfig(t, "relay.yaml", "main:\n  port: [1, 2]\n",
+			`line 2: "main.port" must be a single value`)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		testFileWithNameAndInvalidConfig(t, "relay.yaml", "main:\n  port: 8000\n bad indentation\n",
+			"failed to read configuration file", "relay.yaml", "line 2")
+	})
+}
+
+func TestConfigFromJSONFile(t *testing.T) {
+	testFileWithNameAndValidConfig(t, "relay.json", testDataValidConfig{
+		makeConfig:  makeYAMLEquivalentConfig,
+		fileContent: jsonEquivalentConfig,
+	})
+
+	t.Run("unknown property", func(t *testing.T) {
+		testFileWithNameAndInvalidConfig(t, "relay.json", "{\n  \"main\": {\n    \"unknown\": 1\n  }\n}",
+			`line 3: unsupported or misspelled section "main", variable "unknown"`)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		testFileWithNameAndInvalidConfig(t, "relay.json", "{\n  \"main\": {\n    \"port\": 8000,,\n  }\n}",
+			"failed to read configuration file", "relay.json", "line 2")
+	})
+}
+
+func TestConfigFromYAMLFileCanBeOverriddenByEnvironment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "relay.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("main:\n  port: 8333\nenvironment:\n  earth:\n    sdkKey: earth-sdk\n"), 0600))
+	t.Setenv("PORT", "9999")
+
+	var c Config
+	require.NoError(t, LoadConfigFile(&c, path, ldlog.NewDisabledLoggers()))
+	require.NoError(t, LoadConfigFromEnvironment(&c, ldlog.NewDisabledLoggers()))
+	assert.Equal(t, mustOptIntGreaterThanZero(9999), c.Main.Port)
+	assert.Equal(t, SDKKey("earth-sdk"), c.Environment["earth"].SDKKey)
+}
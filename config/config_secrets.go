@@ -0,0 +1,223 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	ct "github.com/launchdarkly/go-configtypes"
+)
+
+const (
+	// SecretFilePrefix is the prefix of a credential property value that means the actual value should
+	// be read from a file, such as "file:/run/secrets/sdk-key".
+	SecretFilePrefix = "file:"
+
+	// SecretEnvPrefix is the prefix of a credential property value that means the actual value should
+	// be read from another environment variable, such as "env:MY_SDK_KEY".
+	SecretEnvPrefix = "env:"
+)
+
+// SecretRef describes a credential property whose value was given as a reference to a file or to an
+// environment variable, rather than as a literal value. ValidateConfig replaces the reference in the
+// Config with the value that it refers to; Config.GetSecretRefs returns the original references, so that
+// Relay can detect when a referenced file has changed.
+type SecretRef struct {
+	// EnvName is the name of the environment, if this is a property of an environment; otherwise it is
+	// empty.
+	EnvName string
+	// Field is the name of the property, such as "SDKKey" or "Redis.Password".
+	Field string
+	// Ref is the original reference, such as "file:/run/secrets/sdk-key".
+	Ref string
+}
+
+// secretRefState is a SecretRef plus the value it had when it was resolved. Keeping the value allows a
+// later call to ValidateConfig to tell whether the property has been overridden since then.
+type secretRefState struct {
+	SecretRef
+	value string
+}
+
+type secretField struct {
+	envName string
+	name    string
+	value   reflect.Value // a settable string field
+}
+
+var errSecretFileEmpty = errors.New("file is empty")
+
+func errSecretEnvVarNotSet(name string) error {
+	return fmt.Errorf("environment variable %q is not set", name)
+}
+
+func errResolvingSecret(ref SecretRef, err error) error {
+	return fmt.Errorf("could not resolve %s (%s): %w", ref.describe(), ref.Ref, err)
+}
+
+func (r SecretRef) describe() string {
+	if r.EnvName != "" {
+		return fmt.Sprintf("%s for environment %q", r.Field, r.EnvName)
+	}
+	return r.Field
+}
+
+// IsSecretRef returns true if a credential property value is a reference to a file or an environment
+// variable.
+func IsSecretRef(value string) bool {
+	return strings.HasPrefix(value, SecretFilePrefix) || strings.HasPrefix(value, SecretEnvPrefix)
+}
+
+// FilePath returns the referenced file path, or an empty string if this is not a file reference.
+func (r SecretRef) FilePath() string {
+	if strings.HasPrefix(r.Ref, SecretFilePrefix) {
+		return strings.TrimPrefix(r.Ref, SecretFilePrefix)
+	}
+	return ""
+}
+
+// Resolve reads the current value that the reference points to. Leading and trailing whitespace, such
+// as the final newline of a file, is removed. It is an error for the file to be missing or empty, or for
+// the environment variable not to be set.
+func (r SecretRef) Resolve() (string, error) {
+	if path := r.FilePath(); path != "" {
+		data, err := os.ReadFile(path) //nolint:gosec // the path comes from the configuration
+		if err != nil {
+			return "", err
+		}
+		value := strings.TrimSpace(string(data))
+		if value == "" {
+			return "", errSecretFileEmpty
+		}
+		return value, nil
+	}
+	name := strings.TrimPrefix(r.Ref, SecretEnvPrefix)
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", errSecretEnvVarNotSet(name)
+	}
+	return strings.TrimSpace(value), nil
+}
+
+// GetSecretRefs returns the credential properties that were given as references to files or
+// environment variables, as of the last call to ValidateConfig.
+func (c Config) GetSecretRefs() []SecretRef {
+	ret := make([]SecretRef, 0, len(c.secretRefs))
+	for _, s := range c.secretRefs {
+		ret = append(ret, s.SecretRef)
+	}
+	return ret
+}
+
+// credentialTypes are the property types that always contain a credential. A property with some other
+// type, such as string, contains a credential if its field has the tag `credential:"true"`.
+var credentialTypes = map[reflect.Type]bool{ //nolint:gochecknoglobals
+	reflect.TypeOf(SDKKey("")):        true,
+	reflect.TypeOf(MobileKey("")):     true,
+	reflect.TypeOf(AutoConfigKey("")): true,
+}
+
+func isCredentialField(f reflect.StructField) bool {
+	return credentialTypes[f.Type] || f.Tag.Get("credential") == "true"
+}
+
+// IsCredentialProperty returns true if a configuration property contains a credential, such as an SDK
+// key or a password, that should not be displayed. The section and property names are as described for
+// VisitProperties.
+func IsCredentialProperty(section, property string) bool {
+	if i := strings.Index(section, " "); i >= 0 {
+		section = section[:i] // `Environment "name"` and other named sections
+	}
+	sectionField, ok := reflect.TypeOf(Config{}).FieldByName(section)
+	if !ok {
+		return false
+	}
+	sectionType := sectionField.Type
+	if sectionType.Kind() == reflect.Map {
+		sectionType = sectionType.Elem().Elem()
+	}
+	if sectionType.Kind() != reflect.Struct {
+		return false
+	}
+	f, ok := sectionType.FieldByName(property)
+	return ok && isCredentialField(f)
+}
+
+// getSecretFields returns every credential property that is a string, and so could be a reference to a
+// file or an environment variable. For an environment, the name is just the property name, such as
+// "SDKKey"; for other named sections, it includes the section, such as `EventDestination "name".SDKKey`.
+func getSecretFields(c *Config) []secretField {
+	var fields []secretField
+	configValue := reflect.ValueOf(c).Elem()
+	addFields := func(envName, prefix string, section reflect.Value) {
+		for i := 0; i < section.NumField(); i++ {
+			f := section.Type().Field(i)
+			if f.IsExported() && f.Type.Kind() == reflect.String && isCredentialField(f) {
+				fields = append(fields, secretField{envName: envName, name: prefix + f.Name, value: section.Field(i)})
+			}
+		}
+	}
+	for _, f := range reflect.VisibleFields(configValue.Type()) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		section := configValue.FieldByIndex(f.Index)
+		switch section.Kind() {
+		case reflect.Struct:
+			addFields("", f.Name+".", section)
+		case reflect.Map:
+			for _, key := range section.MapKeys() {
+				item := section.MapIndex(key)
+				if item.IsNil() {
+					continue
+				}
+				if f.Name == "Environment" {
+					addFields(key.String(), "", item.Elem())
+				} else {
+					addFields("", fmt.Sprintf("%s %q.", f.Name, key.String()), item.Elem())
+				}
+			}
+		}
+	}
+	return fields
+}
+
+// validateConfigSecrets replaces every credential property that is a file or environment variable
+// reference with the value it refers to. This runs before the other validation steps, so that they see
+// the actual values.
+//
+// Since ValidateConfig can be called more than once for the same Config, a property that no longer looks
+// like a reference might have been resolved by an earlier call; in that case we keep the earlier
+// reference, unless the value has since been changed (for instance, by an environment variable that
+// overrides a configuration file setting).
+func validateConfigSecrets(result *ct.ValidationResult, c *Config) {
+	var refs []secretRefState
+	for _, f := range getSecretFields(c) {
+		if !IsSecretRef(f.value.String()) {
+			for _, old := range c.secretRefs {
+				if old.EnvName == f.envName && old.Field == f.name && old.value == f.value.String() {
+					refs = append(refs, old)
+				}
+			}
+			continue
+		}
+		ref := SecretRef{EnvName: f.envName, Field: f.name, Ref: f.value.String()}
+		value, err := ref.Resolve()
+		if err != nil {
+			result.AddError(nil, errResolvingSecret(ref, err))
+			continue
+		}
+		f.value.SetString(value)
+		refs = append(refs, secretRefState{SecretRef: ref, value: value})
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].EnvName != refs[j].EnvName {
+			return refs[i].EnvName < refs[j].EnvName
+		}
+		return refs[i].Field < refs[j].Field
+	})
+	c.secretRefs = refs
+}
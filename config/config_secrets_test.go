@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+)
+
+func writeSecretFile(t *testing.T, value string) string {
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte(value), 0600))
+	return path
+}
+
+func TestSecretRefsAreResolved(t *testing.T) {
+	sdkKeyPath := writeSecretFile(t, "earth-sdk\n")
+	passwordPath := writeSecretFile(t, "  redis-pass  ")
+	t.Setenv("MY_MOBILE_KEY", "earth-mob")
+
+	c := Config{
+		Redis: RedisConfig{Host: "localhost", Password: "file:" + passwordPath},
+		Environment: map[string]*EnvConfig{
+			"earth": {SDKKey: SDKKey("file:" + sdkKeyPath), MobileKey: "env:MY_MOBILE_KEY", Prefix: "p"},
+		},
+	}
+	require.NoError(t, ValidateConfig(&c, ldlog.NewDisabledLoggers()))
+
+	assert.Equal(t, SDKKey("earth-sdk"), c.Environment["earth"].SDKKey)
+	assert.Equal(t, MobileKey("earth-mob"), c.Environment["earth"].MobileKey)
+	assert.Equal(t, "redis-pass", c.Redis.Password)
+	assert.Equal(t, []SecretRef{
+		{Field: "Redis.Password", Ref: "file:" + passwordPath},
+		{EnvName: "earth", Field: "MobileKey", Ref: "env:MY_MOBILE_KEY"},
+		{EnvName: "earth", Field: "SDKKey", Ref: "file:" + sdkKeyPath},
+	}, c.GetSecretRefs())
+	assert.Equal(t, sdkKeyPath, c.GetSecretRefs()[2].FilePath())
+	assert.Equal(t, "", c.GetSecretRefs()[1].FilePath())
+}
+
+func TestSecretRefsAreResolvedForEventDestinations(t *testing.T) {
+	t.Setenv("BACKUP_SDK_KEY", "backup-sdk")
+	c := Config{
+		Environment:      map[string]*EnvConfig{"earth": {SDKKey: "earth-sdk"}},
+		EventDestination: map[string]*EventDestinationConfig{"backup": {Environment: "earth", SDKKey: "env:BACKUP_SDK_KEY"}},
+	}
+	require.NoError(t, ValidateConfig(&c, ldlog.NewDisabledLoggers()))
+
+	assert.Equal(t, SDKKey("backup-sdk"), c.EventDestination["backup"].SDKKey)
+	assert.Equal(t, []SecretRef{
+		{Field: `EventDestination "backup".SDKKey`, Ref: "env:BACKUP_SDK_KEY"},
+	}, c.GetSecretRefs())
+}
+
+func TestSecretRefsAreKeptWhenConfigIsValidatedAgain(t *testing.T) {
+	path := writeSecretFile(t, "earth-sdk")
+	c := Config{Environment: map[string]*EnvConfig{"earth": {SDKKey: SDKKey("file:" + path)}}}
+	require.NoError(t, ValidateConfig(&c, ldlog.NewDisabledLoggers()))
+	require.NoError(t, ValidateConfig(&c, ldlog.NewDisabledLoggers()))
+	assert.Len(t, c.GetSecretRefs(), 1)
+
+	c.Environment["earth"].SDKKey = "overridden-sdk"
+	require.NoError(t, ValidateConfig(&c, ldlog.NewDisabledLoggers()))
+	assert.Len(t, c.GetSecretRefs(), 0)
+}
+
+func TestSecretRefFromConfigFileAndEnvironment(t *testing.T) {
+	path := writeSecretFile(t, "earth-sdk\n")
+	t.Setenv("LD_ENV_krypton", "env:KRYPTON_KEY")
+	t.Setenv("KRYPTON_KEY", "krypton-sdk")
+	configPath := filepath.Join(t.TempDir(), "relay.conf")
+	require.NoError(t, os.WriteFile(configPath, []byte("[Environment \"earth\"]\nsdkKey = file:"+path+"\n"), 0600))
+
+	var c Config
+	require.NoError(t, LoadConfigFile(&c, configPath, ldlog.NewDisabledLoggers()))
+	require.NoError(t, LoadConfigFromEnvironment(&c, ldlog.NewDisabledLoggers()))
+	assert.Equal(t, SDKKey("earth-sdk"), c.Environment["earth"].SDKKey)
+	assert.Equal(t, SDKKey("krypton-sdk"), c.Environment["krypton"].SDKKey)
+	assert.Len(t, c.GetSecretRefs(), 2)
+}
+
+func TestSecretRefErrors(t *testing.T) {
+	missingPath := filepath.Join(t.TempDir(), "missing")
+	emptyPath := writeSecretFile(t, "\n")
+
+	for _, p := range []struct {
+		name    string
+		config  Config
+		message string
+	}{
+		{"missing file", Config{Proxy: ProxyConfig{Password: "file:" + missingPath}},
+			"could not resolve Proxy.Password (file:" + missingPath + ")"},
+		{"empty file", Config{AutoConfig: AutoConfigConfig{Key: AutoConfigKey("file:" + emptyPath)}},
+			"could not resolve AutoConfig.Key (file:" + emptyPath + "): file is empty"},
+		{"unset variable", Config{Environment: map[string]*EnvConfig{"earth": {SDKKey: "env:NOT_A_REAL_VARIABLE"}}},
+			`could not resolve SDKKey for environment "earth" (env:NOT_A_REAL_VARIABLE): environment variable "NOT_A_REAL_VARIABLE" is not set`},
+	} {
+		t.Run(p.name, func(t *testing.T) {
+			c := p.config
+			err := ValidateConfig(&c, ldlog.NewDisabledLoggers())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), p.message)
+		})
+	}
+}
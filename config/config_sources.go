@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// PropertySource describes where the value of a configuration property came from.
+type PropertySource string
+
+const (
+	// PropertySourceDefault means that the property was not set, so it has its default value.
+	PropertySourceDefault PropertySource = "default"
+	// PropertySourceFile means that the property was set in the configuration file.
+	PropertySourceFile PropertySource = "file"
+	// PropertySourceEnvironment means that the property was set by an environment variable.
+	PropertySourceEnvironment PropertySource = "environment"
+)
+
+// TrackPropertySource calls load, which should update the Config from a single source (for instance,
+// by calling LoadConfigFile), and records every property that it changed as having come from that
+// source. Config.GetPropertySource can then be used to find out where the current value of a property
+// came from.
+//
+// A property that a source sets to the same value it already had is not recorded as coming from that
+// source. Default values that ValidateConfig fills in are not attributed to any source.
+func TrackPropertySource(c *Config, source PropertySource, load func() error) error {
+	before := *c
+	validateConfigDefaultURLs(&before)
+	beforeValues := make(map[string]interface{})
+	VisitProperties(before, func(section, property string, value reflect.Value) {
+		beforeValues[section+"."+property] = value.Interface()
+	})
+
+	err := load()
+
+	sources := make(map[string]PropertySource, len(c.sources))
+	for k, v := range c.sources {
+		sources[k] = v
+	}
+	VisitProperties(*c, func(section, property string, value reflect.Value) {
+		key := section + "." + property
+		if oldValue, ok := beforeValues[key]; !ok || !reflect.DeepEqual(oldValue, value.Interface()) {
+			sources[key] = source
+		}
+	})
+	c.sources = sources
+	return err
+}
+
+// GetPropertySource returns the source of a property's value, as recorded by TrackPropertySource. The
+// section and property names are as described for VisitProperties. If TrackPropertySource was never
+// called for this Config, as when Relay is configured programmatically, it returns an empty string.
+func (c Config) GetPropertySource(section, property string) PropertySource {
+	if c.sources == nil {
+		return ""
+	}
+	if s, ok := c.sources[section+"."+property]; ok {
+		return s
+	}
+	return PropertySourceDefault
+}
+
+// VisitProperties calls fn for every property of every section in the Config, in the order that they
+// are declared; environments are visited in order of name. The section name is the name of the Config
+// field, such as "Main" or "Datadog", except for environments, where it is in the same form as a
+// configuration file section header, such as `Environment "my-env"`. The property name is the name of
+// the field within the section.
+func VisitProperties(c Config, fn func(section, property string, value reflect.Value)) {
+	configValue := reflect.ValueOf(c)
+	for _, f := range reflect.VisibleFields(configValue.Type()) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		section := configValue.FieldByIndex(f.Index)
+		switch section.Kind() {
+		case reflect.Struct:
+			visitSectionProperties(f.Name, section, fn)
+		case reflect.Map: // Environment
+			names := make([]string, 0, section.Len())
+			for _, key := range section.MapKeys() {
+				names = append(names, key.String())
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if envValue := section.MapIndex(reflect.ValueOf(name)); !envValue.IsNil() {
+					visitSectionProperties(fmt.Sprintf("%s %q", f.Name, name), envValue.Elem(), fn)
+				}
+			}
+		}
+	}
+}
+
+func visitSectionProperties(sectionName string, section reflect.Value, fn func(section, property string, value reflect.Value)) {
+	for i := 0; i < section.NumField(); i++ {
+		if field := section.Type().Field(i); field.IsExported() {
+			fn(sectionName, field.Name, section.Field(i))
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+)
+
+func TestTrackPropertySource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "relay.conf")
+	require.NoError(t, os.WriteFile(path, []byte(`
+[Main]
+port = 8000
+exitOnError = true
+
+[Environment "earth"]
+sdkKey = earth-sdk
+prefix = earth
+`), 0600))
+	t.Setenv("PORT", "9000")
+	t.Setenv("LD_ENV_earth", "earth-sdk") // same value as the file, so not considered a change
+	t.Setenv("LD_MOBILE_KEY_earth", "earth-mob")
+
+	var c Config
+	assert.Equal(t, PropertySource(""), c.GetPropertySource("Main", "Port"))
+
+	require.NoError(t, TrackPropertySource(&c, PropertySourceFile, func() error {
+		return LoadConfigFile(&c, path, ldlog.NewDisabledLoggers())
+	}))
+	require.NoError(t, TrackPropertySource(&c, PropertySourceEnvironment, func() error {
+		return LoadConfigFromEnvironment(&c, ldlog.NewDisabledLoggers())
+	}))
+
+	assert.Equal(t, PropertySourceEnvironment, c.GetPropertySource("Main", "Port"))
+	assert.Equal(t, PropertySourceFile, c.GetPropertySource("Main", "ExitOnError"))
+	assert.Equal(t, PropertySourceDefault, c.GetPropertySource("Main", "BaseURI"))
+	assert.Equal(t, PropertySourceDefault, c.GetPropertySource("Main", "LogLevel"))
+	assert.Equal(t, PropertySourceFile, c.GetPropertySource(`Environment "earth"`, "SDKKey"))
+	assert.Equal(t, PropertySourceFile, c.GetPropertySource(`Environment "earth"`, "Prefix"))
+	assert.Equal(t, PropertySourceEnvironment, c.GetPropertySource(`Environment "earth"`, "MobileKey"))
+}
+
+func TestTrackPropertySourceReturnsError(t *testing.T) {
+	var c Config
+	err := TrackPropertySource(&c, PropertySourceFile, func() error {
+		return LoadConfigFile(&c, filepath.Join(t.TempDir(), "missing.conf"), ldlog.NewDisabledLoggers())
+	})
+	assert.Error(t, err)
+}
+
+func TestVisitProperties(t *testing.T) {
+	c := Config{
+		Environment: map[string]*EnvConfig{"b": {SDKKey: "b-sdk"}, "a": {SDKKey: "a-sdk"}},
+	}
+	c.Datadog.Prefix = "x"
+	var sections []string
+	values := make(map[string]interface{})
+	VisitProperties(c, func(section, property string, value reflect.Value) {
+		if len(sections) == 0 || sections[len(sections)-1] != section {
+			sections = append(sections, section)
+		}
+		values[section+"."+property] = value.Interface()
+	})
+	assert.Equal(t, []string{"Main", "AutoConfig", "OfflineMode", "Downstream", "Webhooks", "AuditLog", "Events",
+		"Redis", "Consul", "DynamoDB", `Environment "a"`, `Environment "b"`, "Proxy", "Datadog", "Stackdriver",
+		"Prometheus"}, sections)
+	assert.Equal(t, SDKKey("a-sdk"), values[`Environment "a".SDKKey`])
+	assert.Equal(t, "x", values["Datadog.Prefix"])
+}
+
+func TestIsCredentialProperty(t *testing.T) {
+	assert.True(t, IsCredentialProperty("Redis", "Password"))
+	assert.True(t, IsCredentialProperty(`Environment "earth"`, "SDKKey"))
+	assert.True(t, IsCredentialProperty("Main", "AdminKey"))
+	assert.False(t, IsCredentialProperty("Redis", "Username"))
+	assert.False(t, IsCredentialProperty(`Environment "earth"`, "EnvID"))
+	assert.True(t, IsCredentialProperty("AutoConfig", "Key"))
+	assert.True(t, IsCredentialProperty("Proxy", "Headers"))
+	assert.True(t, IsCredentialProperty(`EventDestination "backup"`, "SDKKey"))
+	assert.True(t, IsCredentialProperty(`EventDestination "backup"`, "MobileKey"))
+	assert.False(t, IsCredentialProperty(`EventDestination "backup"`, "EnvID"))
+	assert.False(t, IsCredentialProperty("Unknown", "Password"))
+	assert.False(t, IsCredentialProperty("Main", "Unknown"))
+}
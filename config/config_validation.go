@@ -3,14 +3,22 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"sort"
 	"strings"
 
 	ct "github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldattr"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"golang.org/x/net/http/httpguts"
 )
 
 var (
 	errTLSEnabledWithoutCertOrKey      = errors.New("TLS cert and key are required if TLS is enabled")
+	errH2CWithTLS                      = errors.New("h2c cannot be enabled if TLS is enabled")
+	errSocketPathWithPort              = errors.New("please specify port or socket path, but not both")
+	errSocketModeWithoutPath           = errors.New("socket mode cannot be specified without a socket path")
+	errProxyClientCertOrKeyMissing     = errors.New("proxy client cert and key must be specified together")
 	errAutoConfPropertiesWithNoKey     = errors.New("must specify auto-configuration key if other auto-configuration properties are set")
 	errAutoConfWithEnvironments        = errors.New("cannot configure specific environments if auto-configuration is enabled")
 	errFileDataWithAutoConf            = errors.New("cannot specify both auto-configuration key and file data source")
@@ -18,13 +26,109 @@ var (
 	errOfflineModeWithEnvironments     = errors.New("cannot configure specific environments if offline mode is enabled")
 	errAutoConfWithoutDBDisambig       = errors.New(`when using auto-configuration with database storage, database prefix (or,` +
 		` if using DynamoDB, table name) must be specified and must contain "` + AutoConfigEnvironmentIDPlaceholder + `"`)
-	errRedisURLWithHostAndPort = errors.New("please specify Redis URL or host/port, but not both")
-	errRedisBadHostname        = errors.New("invalid Redis hostname")
-	errConsulTokenAndTokenFile = errors.New("Consul token must be specified as either an inline value or a file, but not both") //nolint:stylecheck
+	errRedisURLWithHostAndPort     = errors.New("please specify Redis URL or host/port, but not both")
+	errRedisBadHostname            = errors.New("invalid Redis hostname")
+	errWebhookWithNoSecret         = errors.New("webhook secret is required if webhook URL is set")
+	errEventsMaxRetryDelayTooShort = errors.New("events max retry delay cannot be less than retry delay")
+	errAuditLogFileAndStdout       = errors.New("audit log cannot be written to both a file and standard output")
+	errConsulTokenAndTokenFile     = errors.New("Consul token must be specified as either an inline value or a file, but not both") //nolint:stylecheck
 )
 
+func errProxyBadHeader(header string) error {
+	return fmt.Errorf("invalid proxy header %q; must be in the format \"Name: value\"", header)
+}
+
+func errListenerWithNoPort(name string) error {
+	return fmt.Errorf("port is required for listener %q", name)
+}
+
+func errListenerPortInUse(name string, port int) error {
+	return fmt.Errorf("listener %q cannot use port %d because it is already used by another listener", name, port)
+}
+
+func errListenerTLSWithoutCertOrKey(name string) error {
+	return fmt.Errorf("TLS cert and key are required if TLS is enabled for listener %q", name)
+}
+
+func errListenerH2CWithTLS(name string) error {
+	return fmt.Errorf("h2c cannot be enabled if TLS is enabled for listener %q", name)
+}
+
+func errListenerWithNoRoutes(name string) error {
+	return fmt.Errorf("routes must be specified for listener %q", name)
+}
+
+func errListenerUnknownRoutes(name, routes string) error {
+	return fmt.Errorf("unknown routes value %q for listener %q; allowed values are: %s",
+		routes, name, strings.Join(AllRouteGroups(), ", "))
+}
+
+func errMainUnknownRoutes(routes string) error {
+	return fmt.Errorf("unknown routes value %q in [Main]; allowed values are: %s",
+		routes, strings.Join(AllRouteGroups(), ", "))
+}
+
+func errEventSinkUnknownType(name, sinkType string) error {
+	return fmt.Errorf("unknown type %q for event sink %q; allowed values are: %s",
+		sinkType, name, strings.Join(AllEventSinkTypes(), ", "))
+}
+
+func errEventSinkWithNoFile(name string) error {
+	return fmt.Errorf("file is required for event sink %q", name)
+}
+
+func errEventSinkKafkaWithNoBrokersOrTopic(name string) error {
+	return fmt.Errorf("brokers and topic are required for Kafka event sink %q", name)
+}
+
+func errEventSinkUnknownKinds(name, kinds string) error {
+	return fmt.Errorf("unknown kinds value %q for event sink %q; allowed values are: %s",
+		kinds, name, strings.Join(AllEventSinkKinds(), ", "))
+}
+
+func errEventDestinationWithNoEnvironment(name string) error {
+	return fmt.Errorf("environment is required for event destination %q", name)
+}
+
+func errEventDestinationUnknownEnvironment(name, envName string) error {
+	return fmt.Errorf("event destination %q refers to environment %q, which is not configured", name, envName)
+}
+
+func errEventDestinationWithNoCredentials(name string) error {
+	return fmt.Errorf("at least one of SDK key, mobile key, or client-side ID is required for event destination %q", name)
+}
+
+func errEnvironmentWithNoCredentials(envName string) error {
+	return fmt.Errorf("at least one of SDK key, mobile key, or client-side ID is required for environment %q", envName)
+}
+
 func errEnvironmentWithNoSDKKey(envName string) error {
-	return fmt.Errorf("SDK key is required for environment %q", envName)
+	return fmt.Errorf("SDK key is required for environment %q, unless it is only used for forwarding events", envName)
+}
+
+func errEnvironmentWebhookWithNoSecret(envName string) error {
+	return fmt.Errorf("webhook secret is required if webhook URL is set for environment %q", envName)
+}
+
+func errEnvironmentBadEventAttribute(envName, attr string, err error) error {
+	if err == nil {
+		return fmt.Errorf("attribute %q cannot be redacted or hashed in events for environment %q", attr, envName)
+	}
+	return fmt.Errorf("invalid attribute %q to redact or hash in events for environment %q: %s", attr, envName, err)
+}
+
+func errEnvironmentUnknownDropEventKind(envName, kind string) error {
+	return fmt.Errorf("unknown event kind %q to drop for environment %q; allowed values are: %s",
+		kind, envName, strings.Join(AllEventKinds(), ", "))
+}
+
+func errEnvironmentBadEventSampleRate(envName, value string) error {
+	return fmt.Errorf("invalid event sample rate %q for environment %q; must be in the format \"name=rate\""+
+		" where rate is a number from 0 to 1", value, envName)
+}
+
+func errEnvironmentSummaryEventSampling(envName string) error {
+	return fmt.Errorf("summary events cannot be sampled for environment %q", envName)
 }
 
 func errMultipleDatabases(databases []string) error {
@@ -57,8 +161,14 @@ func warnEnvWithoutDBDisambiguation(envName string, canUseTableName bool) string
 func ValidateConfig(c *Config, loggers ldlog.Loggers) error {
 	var result ct.ValidationResult
 
+	validateConfigSecrets(&result, c)
 	validateConfigDefaultURLs(c)
 	validateConfigTLS(&result, c)
+	validateConfigListeners(&result, c)
+	validateConfigEventSinks(&result, c)
+	validateConfigEventDestinations(&result, c)
+	validateConfigProxy(&result, c)
+	validateConfigEvents(&result, c)
 	validateConfigEnvironments(&result, c)
 	validateConfigDatabases(&result, c, loggers)
 
@@ -94,6 +204,214 @@ func validateConfigTLS(result *ct.ValidationResult, c *Config) {
 	if c.Main.TLSEnabled && (c.Main.TLSCert == "" || c.Main.TLSKey == "") {
 		result.AddError(nil, errTLSEnabledWithoutCertOrKey)
 	}
+	if c.Main.TLSEnabled && c.Main.H2CEnabled {
+		result.AddError(nil, errH2CWithTLS)
+	}
+}
+
+func validateConfigProxy(result *ct.ValidationResult, c *Config) {
+	if err := ValidateProxyClientCert(c.Proxy); err != nil {
+		result.AddError(nil, err)
+	}
+	for _, header := range c.Proxy.Headers.Values() {
+		if _, _, err := ParseProxyHeader(header); err != nil {
+			result.AddError(nil, err)
+		}
+	}
+}
+
+// ValidateProxyClientCert returns an error if only one of ProxyConfig.ClientCertFile and
+// ProxyConfig.ClientKeyFile is set. It does not check whether the files exist.
+func ValidateProxyClientCert(c ProxyConfig) error {
+	if (c.ClientCertFile == "") != (c.ClientKeyFile == "") {
+		return errProxyClientCertOrKeyMissing
+	}
+	return nil
+}
+
+// ParseProxyHeader splits one of the values of ProxyConfig.Headers into a canonicalized header name and
+// a value. It returns an error if the value is not in the format "Name: value".
+func ParseProxyHeader(header string) (name, value string, err error) {
+	name, value, ok := strings.Cut(header, ":")
+	name = strings.TrimSpace(name)
+	if !ok || !httpguts.ValidHeaderFieldName(name) {
+		return "", "", errProxyBadHeader(header)
+	}
+	return http.CanonicalHeaderKey(name), strings.TrimSpace(value), nil
+}
+
+func validateConfigEvents(result *ct.ValidationResult, c *Config) {
+	retryDelay := c.Events.RetryDelay.GetOrElse(DefaultEventsRetryDelay)
+	if c.Events.MaxRetryDelay.GetOrElse(DefaultEventsMaxRetryDelay) < retryDelay {
+		result.AddError(nil, errEventsMaxRetryDelayTooShort)
+	}
+}
+
+func validateConfigListeners(result *ct.ValidationResult, c *Config) {
+	for _, route := range c.Main.Routes.Values() {
+		if !isRouteGroup(route) {
+			result.AddError(nil, errMainUnknownRoutes(route))
+		}
+	}
+	usedPorts := make(map[int]bool)
+	if c.Main.SocketPath != "" {
+		if c.Main.Port.IsDefined() {
+			result.AddError(nil, errSocketPathWithPort)
+		}
+	} else {
+		if c.Main.SocketMode.IsDefined() {
+			result.AddError(nil, errSocketModeWithoutPath)
+		}
+		usedPorts[c.Main.Port.GetOrElse(DefaultPort)] = true
+	}
+	names := make([]string, 0, len(c.Listener))
+	for name := range c.Listener {
+		names = append(names, name)
+	}
+	sort.Strings(names) // so that it's predictable which listener gets the error for a duplicate port
+	for _, name := range names {
+		lc := c.Listener[name]
+		if lc == nil { // Relay ignores these, as it does when starting the listeners
+			continue
+		}
+		if !lc.Port.IsDefined() {
+			result.AddError(nil, errListenerWithNoPort(name))
+		} else if port := lc.Port.GetOrElse(0); usedPorts[port] {
+			result.AddError(nil, errListenerPortInUse(name, port))
+		} else {
+			usedPorts[port] = true
+		}
+		if lc.TLSEnabled && (lc.TLSCert == "" || lc.TLSKey == "") {
+			result.AddError(nil, errListenerTLSWithoutCertOrKey(name))
+		}
+		if lc.TLSEnabled && lc.H2CEnabled {
+			result.AddError(nil, errListenerH2CWithTLS(name))
+		}
+		if len(lc.Routes.Values()) == 0 {
+			result.AddError(nil, errListenerWithNoRoutes(name))
+		}
+		for _, route := range lc.Routes.Values() {
+			if !isRouteGroup(route) {
+				result.AddError(nil, errListenerUnknownRoutes(name, route))
+			}
+		}
+	}
+}
+
+func isRouteGroup(name string) bool {
+	for _, g := range AllRouteGroups() {
+		if name == g {
+			return true
+		}
+	}
+	return false
+}
+
+func isEventKind(kind string) bool {
+	for _, k := range AllEventKinds() {
+		if kind == k {
+			return true
+		}
+	}
+	return false
+}
+
+func validateConfigEventSinks(result *ct.ValidationResult, c *Config) {
+	names := make([]string, 0, len(c.EventSink))
+	for name := range c.EventSink {
+		names = append(names, name)
+	}
+	sort.Strings(names) // so that errors are reported in a predictable order
+	for _, name := range names {
+		sc := c.EventSink[name]
+		if sc == nil { // Relay ignores these, as it does when creating the sinks
+			continue
+		}
+		switch sc.Type {
+		case EventSinkTypeFile:
+			if sc.File == "" {
+				result.AddError(nil, errEventSinkWithNoFile(name))
+			}
+		case EventSinkTypeStdout:
+		case EventSinkTypeKafka:
+			if len(sc.Brokers.Values()) == 0 || sc.Topic == "" {
+				result.AddError(nil, errEventSinkKafkaWithNoBrokersOrTopic(name))
+			}
+		default:
+			result.AddError(nil, errEventSinkUnknownType(name, sc.Type))
+		}
+		for _, kind := range sc.Kinds.Values() {
+			if !isEventSinkKind(kind) {
+				result.AddError(nil, errEventSinkUnknownKinds(name, kind))
+			}
+		}
+	}
+}
+
+func validateConfigEventDestinations(result *ct.ValidationResult, c *Config) {
+	names := make([]string, 0, len(c.EventDestination))
+	for name := range c.EventDestination {
+		names = append(names, name)
+	}
+	sort.Strings(names) // so that errors are reported in a predictable order
+	for _, name := range names {
+		dc := c.EventDestination[name]
+		if dc == nil { // Relay ignores these, as it does when setting up each environment's destinations
+			continue
+		}
+		switch {
+		case dc.Environment == "":
+			result.AddError(nil, errEventDestinationWithNoEnvironment(name))
+		case c.AutoConfig.Key == "" && c.Environment[dc.Environment] == nil:
+			// With auto-configuration, environments are not known until Relay connects to LaunchDarkly,
+			// so this can only be checked if the environments are configured explicitly.
+			result.AddError(nil, errEventDestinationUnknownEnvironment(name, dc.Environment))
+		}
+		if dc.SDKKey == "" && dc.MobileKey == "" && dc.EnvID == "" {
+			result.AddError(nil, errEventDestinationWithNoCredentials(name))
+		}
+	}
+}
+
+func isEventSinkKind(name string) bool {
+	for _, k := range AllEventSinkKinds() {
+		if name == k {
+			return true
+		}
+	}
+	return false
+}
+
+// validateEventAttributes checks that each attribute to be redacted or hashed is a valid attribute
+// reference, and is not one of the attributes that every context must have.
+func validateEventAttributes(result *ct.ValidationResult, envName string, attrs []string) {
+	for _, a := range attrs {
+		ref := ldattr.NewRef(a)
+		if ref.Err() != nil {
+			result.AddError(nil, errEnvironmentBadEventAttribute(envName, a, ref.Err()))
+			continue
+		}
+		switch ref.Component(0) {
+		case ldattr.KindAttr, ldattr.KeyAttr, "_meta":
+			result.AddError(nil, errEnvironmentBadEventAttribute(envName, a, nil))
+		}
+	}
+}
+
+func validateEventSampleRates(result *ct.ValidationResult, envName string, envConfig *EnvConfig) {
+	for _, v := range envConfig.EventSampleRates.Values() {
+		kind, _, ok := ParseEventSampleRate(v)
+		if !ok {
+			result.AddError(nil, errEnvironmentBadEventSampleRate(envName, v))
+		} else if kind == "summary" {
+			result.AddError(nil, errEnvironmentSummaryEventSampling(envName))
+		}
+	}
+	for _, v := range envConfig.CustomEventSampleRates.Values() {
+		if _, _, ok := ParseEventSampleRate(v); !ok {
+			result.AddError(nil, errEnvironmentBadEventSampleRate(envName, v))
+		}
+	}
 }
 
 func validateConfigEnvironments(result *ct.ValidationResult, c *Config) {
@@ -121,8 +439,32 @@ func validateConfigEnvironments(result *ct.ValidationResult, c *Config) {
 
 	for envName, envConfig := range c.Environment {
 		if envConfig.SDKKey == "" {
-			result.AddError(nil, errEnvironmentWithNoSDKKey(envName))
+			// Without an SDK key, Relay cannot get flag data for the environment, but it can still forward
+			// events from mobile and client-side SDKs using the mobile key or client-side ID.
+			switch {
+			case envConfig.MobileKey == "" && envConfig.EnvID == "":
+				result.AddError(nil, errEnvironmentWithNoCredentials(envName))
+			case !c.Events.SendEvents && len(c.EventSink) == 0:
+				result.AddError(nil, errEnvironmentWithNoSDKKey(envName))
+			}
 		}
+		if envConfig.WebhookURL.IsDefined() && envConfig.WebhookSecret == "" {
+			result.AddError(nil, errEnvironmentWebhookWithNoSecret(envName))
+		}
+		validateEventAttributes(result, envName, envConfig.RedactAttributes.Values())
+		validateEventAttributes(result, envName, envConfig.HashAttributes.Values())
+		validateEventSampleRates(result, envName, envConfig)
+		for _, kind := range envConfig.DropEventKinds.Values() {
+			if !isEventKind(kind) {
+				result.AddError(nil, errEnvironmentUnknownDropEventKind(envName, kind))
+			}
+		}
+	}
+	if c.Webhooks.URL.IsDefined() && c.Webhooks.Secret == "" {
+		result.AddError(nil, errWebhookWithNoSecret)
+	}
+	if c.AuditLog.File != "" && c.AuditLog.Stdout {
+		result.AddError(nil, errAuditLogFileAndStdout)
 	}
 }
 
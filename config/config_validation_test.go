@@ -0,0 +1,33 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+)
+
+func TestValidateConfigIgnoresNilListeners(t *testing.T) {
+	c := Config{
+		Environment: map[string]*EnvConfig{"earth": {SDKKey: "earth-sdk"}},
+		Listener:    map[string]*ListenerConfig{"internal": nil},
+	}
+	assert.NoError(t, ValidateConfig(&c, ldlog.NewDisabledLoggers()))
+}
+
+func TestValidateConfigIgnoresNilEventSinks(t *testing.T) {
+	c := Config{
+		Environment: map[string]*EnvConfig{"earth": {SDKKey: "earth-sdk"}},
+		EventSink:   map[string]*EventSinkConfig{"archive": nil},
+	}
+	assert.NoError(t, ValidateConfig(&c, ldlog.NewDisabledLoggers()))
+}
+
+func TestValidateConfigIgnoresNilEventDestinations(t *testing.T) {
+	c := Config{
+		Environment:      map[string]*EnvConfig{"earth": {SDKKey: "earth-sdk"}},
+		EventDestination: map[string]*EventDestinationConfig{"new-account": nil},
+	}
+	assert.NoError(t, ValidateConfig(&c, ldlog.NewDisabledLoggers()))
+}
@@ -11,10 +11,15 @@ type testDataInvalidConfig struct {
 func makeInvalidConfigs() []testDataInvalidConfig {
 	return []testDataInvalidConfig{
 		makeInvalidConfigMissingSDKKey(),
+		makeInvalidConfigEnvironmentWithNoCredentials(),
 		makeInvalidConfigTLSWithNoCertOrKey(),
 		makeInvalidConfigTLSWithNoCert(),
 		makeInvalidConfigTLSWithNoKey(),
 		makeInvalidConfigTLSVersion(),
+		makeInvalidConfigH2CWithTLS(),
+		makeInvalidConfigSocketPathWithPort(),
+		makeInvalidConfigSocketModeWithoutPath(),
+		makeInvalidConfigSocketMode(),
 		makeInvalidConfigAutoConfKeyWithEnvironments(),
 		makeInvalidConfigAutoConfAllowedOriginWithNoKey(),
 		makeInvalidConfigAutoConfAllowedHeaderWithNoKey(),
@@ -37,6 +42,31 @@ func makeInvalidConfigs() []testDataInvalidConfig {
 		makeInvalidConfigDynamoDBNoPrefixOrTableName(),
 		makeInvalidConfigDynamoDBAutoConfNoPrefixOrTableName(),
 		makeInvalidConfigMultipleDatabases(),
+		makeInvalidConfigWebhookWithNoSecret(),
+		makeInvalidConfigEnvWebhookWithNoSecret(),
+		makeInvalidConfigEnvRedactInvalidAttribute(),
+		makeInvalidConfigEnvHashKeyAttribute(),
+		makeInvalidConfigEnvBadEventSampleRate(),
+		makeInvalidConfigEnvSummaryEventSampleRate(),
+		makeInvalidConfigEnvUnknownDropEventKind(),
+		makeInvalidConfigAuditLogFileAndStdout(),
+		makeInvalidConfigProxyClientCertWithNoKey(),
+		makeInvalidConfigEventsMaxRetryDelayTooShort(),
+		makeInvalidConfigProxyBadHeader(),
+		makeInvalidConfigListenerWithNoRoutes(),
+		makeInvalidConfigListenerUnknownRoutes(),
+		makeInvalidConfigMainUnknownRoutes(),
+		makeInvalidConfigListenerSamePortAsMain(),
+		makeInvalidConfigListenerTLSWithNoCert(),
+		makeInvalidConfigListenerH2CWithTLS(),
+		makeInvalidConfigListenerWithNoPort(),
+		makeInvalidConfigEventSinkUnknownType(),
+		makeInvalidConfigEventSinkWithNoFile(),
+		makeInvalidConfigEventSinkKafkaWithNoTopic(),
+		makeInvalidConfigEventSinkUnknownKinds(),
+		makeInvalidConfigEventDestinationWithNoEnvironment(),
+		makeInvalidConfigEventDestinationUnknownEnvironment(),
+		makeInvalidConfigEventDestinationWithNoCredentials(),
 	}
 }
 
@@ -46,7 +76,20 @@ func makeInvalidConfigMissingSDKKey() testDataInvalidConfig {
 [Environment "envname"]
 MobileKey = mob-xxx
 `
-	c.fileError = `SDK key is required for environment "envname"`
+	c.fileError = `SDK key is required for environment "envname", unless it is only used for forwarding events`
+	return c
+}
+
+func makeInvalidConfigEnvironmentWithNoCredentials() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "environment without any credentials"}
+	c.fileContent = `
+[Events]
+SendEvents = 1
+
+[Environment "envname"]
+SecureMode = true
+`
+	c.fileError = `at least one of SDK key, mobile key, or client-side ID is required for environment "envname"`
 	return c
 }
 
@@ -97,6 +140,55 @@ TLSMinVersion = x
 	return c
 }
 
+func makeInvalidConfigH2CWithTLS() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "h2c with TLS"}
+	c.envVarsError = errH2CWithTLS.Error()
+	c.envVars = map[string]string{"TLS_ENABLED": "1", "TLS_CERT": "cert", "TLS_KEY": "key", "H2C_ENABLED": "1"}
+	c.fileContent = `
+[Main]
+TLSEnabled = true
+TLSCert = certfile
+TLSKey = keyfile
+H2CEnabled = true
+`
+	return c
+}
+
+func makeInvalidConfigSocketPathWithPort() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "socket path with port"}
+	c.envVarsError = errSocketPathWithPort.Error()
+	c.envVars = map[string]string{"SOCKET_PATH": "/tmp/relay.sock", "PORT": "8030"}
+	c.fileContent = `
+[Main]
+SocketPath = /tmp/relay.sock
+Port = 8030
+`
+	return c
+}
+
+func makeInvalidConfigSocketModeWithoutPath() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "socket mode without socket path"}
+	c.envVarsError = errSocketModeWithoutPath.Error()
+	c.envVars = map[string]string{"SOCKET_MODE": "0660"}
+	c.fileContent = `
+[Main]
+SocketMode = 0660
+`
+	return c
+}
+
+func makeInvalidConfigSocketMode() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "bad socket mode"}
+	c.envVarsError = "not a valid file mode"
+	c.envVars = map[string]string{"SOCKET_PATH": "/tmp/relay.sock", "SOCKET_MODE": "rw"}
+	c.fileContent = `
+[Main]
+SocketPath = /tmp/relay.sock
+SocketMode = 1777
+`
+	return c
+}
+
 func makeInvalidConfigAutoConfKeyWithEnvironments() testDataInvalidConfig {
 	c := testDataInvalidConfig{name: "auto-conf key with environments"}
 	c.envVarsError = errAutoConfWithEnvironments.Error()
@@ -439,3 +531,447 @@ Enabled = true
 `
 	return c
 }
+
+func makeInvalidConfigWebhookWithNoSecret() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "webhook URL without secret"}
+	c.envVarsError = "webhook secret is required if webhook URL is set"
+	c.envVars = map[string]string{
+		"LD_ENV_envname": "sdk-xxx",
+		"WEBHOOK_URL":    "http://hooks",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+
+[Webhooks]
+URL = http://hooks
+`
+	return c
+}
+
+func makeInvalidConfigEnvWebhookWithNoSecret() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "environment webhook URL without secret"}
+	c.envVarsError = `webhook secret is required if webhook URL is set for environment "envname"`
+	c.envVars = map[string]string{
+		"LD_ENV_envname":         "sdk-xxx",
+		"LD_WEBHOOK_URL_envname": "http://hooks",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+WebhookURL = http://hooks
+`
+	return c
+}
+
+func makeInvalidConfigEnvRedactInvalidAttribute() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "environment redacted attribute is not a valid reference"}
+	c.envVarsError = `invalid attribute "/address/" to redact or hash in events for environment "envname"`
+	c.envVars = map[string]string{
+		"LD_ENV_envname":               "sdk-xxx",
+		"LD_REDACT_ATTRIBUTES_envname": "/address/",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+RedactAttributes = /address/
+`
+	return c
+}
+
+func makeInvalidConfigEnvHashKeyAttribute() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "environment hashed attribute is the context key"}
+	c.envVarsError = `attribute "key" cannot be redacted or hashed in events for environment "envname"`
+	c.envVars = map[string]string{
+		"LD_ENV_envname":             "sdk-xxx",
+		"LD_HASH_ATTRIBUTES_envname": "key",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+HashAttributes = key
+`
+	return c
+}
+
+func makeInvalidConfigEnvBadEventSampleRate() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "environment custom event sample rate out of range"}
+	c.envVarsError = `invalid event sample rate "checkout=2" for environment "envname"`
+	c.envVars = map[string]string{
+		"LD_ENV_envname":                       "sdk-xxx",
+		"LD_CUSTOM_EVENT_SAMPLE_RATES_envname": "checkout=2",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+CustomEventSampleRates = checkout=2
+`
+	return c
+}
+
+func makeInvalidConfigEnvSummaryEventSampleRate() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "environment sample rate for summary events"}
+	c.envVarsError = `summary events cannot be sampled for environment "envname"`
+	c.envVars = map[string]string{
+		"LD_ENV_envname":                "sdk-xxx",
+		"LD_EVENT_SAMPLE_RATES_envname": "summary=0.5",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+EventSampleRates = summary=0.5
+`
+	return c
+}
+
+func makeInvalidConfigEnvUnknownDropEventKind() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "environment with unknown event kind to drop"}
+	c.envVarsError = errEnvironmentUnknownDropEventKind("envname", "evaluation").Error()
+	c.envVars = map[string]string{
+		"LD_ENV_envname":              "sdk-xxx",
+		"LD_DROP_EVENT_KINDS_envname": "custom,evaluation",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+DropEventKinds = custom
+DropEventKinds = evaluation
+`
+	return c
+}
+
+func makeInvalidConfigEventsMaxRetryDelayTooShort() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "events max retry delay less than retry delay"}
+	c.envVarsError = "events max retry delay cannot be less than retry delay"
+	c.envVars = map[string]string{
+		"LD_ENV_envname":         "sdk-xxx",
+		"EVENTS_RETRY_DELAY":     "10s",
+		"EVENTS_MAX_RETRY_DELAY": "5s",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+
+[Events]
+RetryDelay = 10s
+MaxRetryDelay = 5s
+`
+	return c
+}
+
+func makeInvalidConfigAuditLogFileAndStdout() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "audit log with both file and stdout"}
+	c.envVarsError = "audit log cannot be written to both a file and standard output"
+	c.envVars = map[string]string{
+		"LD_ENV_envname":   "sdk-xxx",
+		"AUDIT_LOG_FILE":   "/var/log/relay-audit.log",
+		"AUDIT_LOG_STDOUT": "true",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+
+[AuditLog]
+File = /var/log/relay-audit.log
+Stdout = true
+`
+	return c
+}
+
+func makeInvalidConfigProxyClientCertWithNoKey() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "proxy client cert without key"}
+	c.envVarsError = errProxyClientCertOrKeyMissing.Error()
+	c.envVars = map[string]string{
+		"LD_ENV_envname":         "sdk-xxx",
+		"PROXY_CLIENT_CERT_FILE": "client-cert",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+
+[Proxy]
+ClientCertFile = client-cert
+`
+	return c
+}
+
+func makeInvalidConfigProxyBadHeader() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "proxy header without value"}
+	c.envVarsError = errProxyBadHeader("X-Egress-Tenant").Error()
+	c.envVars = map[string]string{
+		"LD_ENV_envname": "sdk-xxx",
+		"PROXY_HEADERS":  "X-Egress-Tenant",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+
+[Proxy]
+Headers = X-Egress-Tenant
+`
+	return c
+}
+
+func makeInvalidConfigListenerWithNoRoutes() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "listener without routes"}
+	c.envVarsError = `routes must be specified for listener "extra"`
+	c.envVars = map[string]string{
+		"LD_ENV_envname":      "sdk-xxx",
+		"LISTENER_PORT_extra": "8040",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+
+[Listener "extra"]
+Port = 8040
+`
+	return c
+}
+
+func makeInvalidConfigListenerUnknownRoutes() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "listener with unknown routes"}
+	c.envVarsError = `unknown routes value "metrics" for listener "extra"`
+	c.envVars = map[string]string{
+		"LD_ENV_envname":        "sdk-xxx",
+		"LISTENER_PORT_extra":   "8040",
+		"LISTENER_ROUTES_extra": "status,metrics",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+
+[Listener "extra"]
+Port = 8040
+Routes = status
+Routes = metrics
+`
+	return c
+}
+
+func makeInvalidConfigMainUnknownRoutes() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "main with unknown routes"}
+	c.envVarsError = errMainUnknownRoutes("metrics").Error()
+	c.envVars = map[string]string{
+		"LD_ENV_envname": "sdk-xxx",
+		"ROUTES":         "status,metrics",
+	}
+	c.fileContent = `
+[Main]
+Routes = status
+Routes = metrics
+
+[Environment "envname"]
+SDKKey = sdk-xxx
+`
+	return c
+}
+
+func makeInvalidConfigListenerSamePortAsMain() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "listener with same port as main"}
+	c.envVarsError = `listener "extra" cannot use port 8030 because it is already used by another listener`
+	c.envVars = map[string]string{
+		"LD_ENV_envname":        "sdk-xxx",
+		"LISTENER_PORT_extra":   "8030",
+		"LISTENER_ROUTES_extra": "status",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+
+[Listener "extra"]
+Port = 8030
+Routes = status
+`
+	return c
+}
+
+func makeInvalidConfigListenerTLSWithNoCert() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "listener with TLS but no cert"}
+	c.envVarsError = `TLS cert and key are required if TLS is enabled for listener "extra"`
+	c.envVars = map[string]string{
+		"LD_ENV_envname":             "sdk-xxx",
+		"LISTENER_PORT_extra":        "8040",
+		"LISTENER_ROUTES_extra":      "status",
+		"LISTENER_TLS_ENABLED_extra": "true",
+		"LISTENER_TLS_KEY_extra":     "key",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+
+[Listener "extra"]
+Port = 8040
+Routes = status
+TLSEnabled = true
+TLSKey = key
+`
+	return c
+}
+
+func makeInvalidConfigListenerH2CWithTLS() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "listener with h2c and TLS"}
+	c.envVarsError = errListenerH2CWithTLS("extra").Error()
+	c.envVars = map[string]string{
+		"LD_ENV_envname":             "sdk-xxx",
+		"LISTENER_PORT_extra":        "8040",
+		"LISTENER_ROUTES_extra":      "status",
+		"LISTENER_TLS_ENABLED_extra": "true",
+		"LISTENER_TLS_CERT_extra":    "cert",
+		"LISTENER_TLS_KEY_extra":     "key",
+		"LISTENER_H2C_ENABLED_extra": "true",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+
+[Listener "extra"]
+Port = 8040
+Routes = status
+TLSEnabled = true
+TLSCert = cert
+TLSKey = key
+H2CEnabled = true
+`
+	return c
+}
+
+func makeInvalidConfigListenerWithNoPort() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "listener without port"}
+	c.fileError = `port is required for listener "extra"`
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+
+[Listener "extra"]
+Routes = status
+`
+	return c
+}
+
+func makeInvalidConfigEventSinkUnknownType() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "event sink with unknown type"}
+	c.envVarsError = `unknown type "syslog" for event sink "extra"`
+	c.envVars = map[string]string{
+		"LD_ENV_envname":        "sdk-xxx",
+		"EVENT_SINK_TYPE_extra": "syslog",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+
+[EventSink "extra"]
+Type = syslog
+`
+	return c
+}
+
+func makeInvalidConfigEventSinkWithNoFile() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "file event sink without file"}
+	c.envVarsError = errEventSinkWithNoFile("extra").Error()
+	c.envVars = map[string]string{
+		"LD_ENV_envname":        "sdk-xxx",
+		"EVENT_SINK_TYPE_extra": "file",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+
+[EventSink "extra"]
+Type = file
+`
+	return c
+}
+
+func makeInvalidConfigEventSinkKafkaWithNoTopic() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "Kafka event sink without topic"}
+	c.envVarsError = errEventSinkKafkaWithNoBrokersOrTopic("extra").Error()
+	c.envVars = map[string]string{
+		"LD_ENV_envname":           "sdk-xxx",
+		"EVENT_SINK_TYPE_extra":    "kafka",
+		"EVENT_SINK_BROKERS_extra": "localhost:9092",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+
+[EventSink "extra"]
+Type = kafka
+Brokers = localhost:9092
+`
+	return c
+}
+
+func makeInvalidConfigEventSinkUnknownKinds() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "event sink with unknown kinds"}
+	c.envVarsError = `unknown kinds value "summary" for event sink "extra"`
+	c.envVars = map[string]string{
+		"LD_ENV_envname":         "sdk-xxx",
+		"EVENT_SINK_TYPE_extra":  "stdout",
+		"EVENT_SINK_KINDS_extra": "analytics,summary",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+
+[EventSink "extra"]
+Type = stdout
+Kinds = analytics
+Kinds = summary
+`
+	return c
+}
+
+func makeInvalidConfigEventDestinationWithNoEnvironment() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "event destination without environment"}
+	c.envVarsError = errEventDestinationWithNoEnvironment("extra").Error()
+	c.envVars = map[string]string{
+		"LD_ENV_envname":                  "sdk-xxx",
+		"EVENT_DESTINATION_ENV_extra":     "",
+		"EVENT_DESTINATION_SDK_KEY_extra": "sdk-yyy",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+
+[EventDestination "extra"]
+SDKKey = sdk-yyy
+`
+	return c
+}
+
+func makeInvalidConfigEventDestinationUnknownEnvironment() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "event destination with unknown environment"}
+	c.envVarsError = errEventDestinationUnknownEnvironment("extra", "mars").Error()
+	c.envVars = map[string]string{
+		"LD_ENV_envname":                  "sdk-xxx",
+		"EVENT_DESTINATION_ENV_extra":     "mars",
+		"EVENT_DESTINATION_SDK_KEY_extra": "sdk-yyy",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+
+[EventDestination "extra"]
+Environment = mars
+SDKKey = sdk-yyy
+`
+	return c
+}
+
+func makeInvalidConfigEventDestinationWithNoCredentials() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "event destination without credentials"}
+	c.envVarsError = errEventDestinationWithNoCredentials("extra").Error()
+	c.envVars = map[string]string{
+		"LD_ENV_envname":              "sdk-xxx",
+		"EVENT_DESTINATION_ENV_extra": "envname",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+
+[EventDestination "extra"]
+Environment = envname
+`
+	return c
+}
@@ -66,6 +66,15 @@ func makeValidConfigs() []testDataValidConfig {
 		makeValidConfigAutoConfig(),
 		makeValidConfigAutoConfigWithDatabase(),
 		makeValidConfigFileData(),
+		makeValidConfigDownstream(),
+		makeValidConfigWebhooks(),
+		makeValidConfigEventFilters(),
+		makeValidConfigAuditLog(),
+		makeValidConfigListeners(),
+		makeValidConfigSocket(),
+		makeValidConfigEventSinks(),
+		makeValidConfigEventDestinations(),
+		makeValidConfigEventsOnlyEnvironments(),
 		makeValidConfigRedisMinimal(),
 		makeValidConfigRedisAll(),
 		makeValidConfigRedisURL(),
@@ -88,6 +97,8 @@ func makeValidConfigs() []testDataValidConfig {
 		makeValidConfigPrometheusMinimal(),
 		makeValidConfigPrometheusAll(),
 		makeValidConfigProxy(),
+		makeValidConfigProxyClientOptions(),
+		makeValidConfigProxyDestinations(),
 	}
 }
 
@@ -95,31 +106,54 @@ func makeValidConfigAllBaseProperties() testDataValidConfig {
 	c := testDataValidConfig{name: "all base properties"}
 	c.makeConfig = func(c *Config) {
 		c.Main = MainConfig{
-			Port:                        mustOptIntGreaterThanZero(8333),
-			BaseURI:                     newOptURLAbsoluteMustBeValid("http://base"),
-			ClientSideBaseURI:           newOptURLAbsoluteMustBeValid("http://clientbase"),
-			StreamURI:                   newOptURLAbsoluteMustBeValid("http://stream"),
-			ExitOnError:                 true,
-			ExitAlways:                  true,
-			IgnoreConnectionErrors:      true,
-			HeartbeatInterval:           ct.NewOptDuration(90 * time.Second),
-			MaxClientConnectionTime:     ct.NewOptDuration(30 * time.Minute),
-			DisconnectedStatusTime:      ct.NewOptDuration(3 * time.Minute),
-			DisableInternalUsageMetrics: true,
-			TLSEnabled:                  true,
-			TLSCert:                     "cert",
-			TLSKey:                      "key",
-			TLSMinVersion:               NewOptTLSVersion(tls.VersionTLS12),
-			LogLevel:                    NewOptLogLevel(ldlog.Warn),
-			BigSegmentsStaleAsDegraded:  true,
-			BigSegmentsStaleThreshold:   ct.NewOptDuration(10 * time.Minute),
+			Port:                              mustOptIntGreaterThanZero(8333),
+			BaseURI:                           newOptURLAbsoluteMustBeValid("http://base"),
+			ClientSideBaseURI:                 newOptURLAbsoluteMustBeValid("http://clientbase"),
+			StreamURI:                         newOptURLAbsoluteMustBeValid("http://stream"),
+			ExitOnError:                       true,
+			ExitAlways:                        true,
+			IgnoreConnectionErrors:            true,
+			HeartbeatInterval:                 ct.NewOptDuration(90 * time.Second),
+			ServerSideHeartbeatInterval:       ct.NewOptDuration(2 * time.Minute),
+			MobileHeartbeatInterval:           ct.NewOptDuration(20 * time.Second),
+			JSClientHeartbeatInterval:         ct.NewOptDuration(60 * time.Second),
+			MaxClientConnectionTime:           ct.NewOptDuration(30 * time.Minute),
+			ServerSideMaxClientConnectionTime: ct.NewOptDuration(25 * time.Minute),
+			MobileMaxClientConnectionTime:     ct.NewOptDuration(2 * time.Hour),
+			JSClientMaxClientConnectionTime:   ct.NewOptDuration(1 * time.Hour),
+			MaxClientConnectionTimeJitter:     ct.NewOptDuration(5 * time.Minute),
+			MaxStreamBufferSize:               mustOptIntGreaterThanZero(1000000),
+			DisconnectedStatusTime:            ct.NewOptDuration(3 * time.Minute),
+			DisableInternalUsageMetrics:       true,
+			TLSEnabled:                        true,
+			TLSCert:                           "cert",
+			TLSKey:                            "key",
+			TLSMinVersion:                     NewOptTLSVersion(tls.VersionTLS12),
+			LogLevel:                          NewOptLogLevel(ldlog.Warn),
+			BigSegmentsStaleAsDegraded:        true,
+			BigSegmentsStaleThreshold:         ct.NewOptDuration(10 * time.Minute),
+			ReadHeaderTimeout:                 ct.NewOptDuration(5 * time.Second),
+			ReadTimeout:                       ct.NewOptDuration(30 * time.Second),
+			WriteTimeout:                      ct.NewOptDuration(45 * time.Second),
+			IdleTimeout:                       ct.NewOptDuration(2 * time.Minute),
+			MaxHeaderBytes:                    mustOptIntGreaterThanZero(65536),
+			MaxRequestBodySize:                mustOptIntGreaterThanZero(2000000),
 		}
 		c.Events = EventsConfig{
-			SendEvents:    true,
-			EventsURI:     newOptURLAbsoluteMustBeValid("http://events"),
-			FlushInterval: ct.NewOptDuration(120 * time.Second),
-			Capacity:      mustOptIntGreaterThanZero(500),
-			InlineUsers:   true,
+			SendEvents:               true,
+			EventsURI:                newOptURLAbsoluteMustBeValid("http://events"),
+			FlushInterval:            ct.NewOptDuration(120 * time.Second),
+			Capacity:                 mustOptIntGreaterThanZero(500),
+			InlineUsers:              true,
+			EnableCompression:        true,
+			MaxAttempts:              mustOptIntGreaterThanZero(4),
+			RetryDelay:               ct.NewOptDuration(2 * time.Second),
+			MaxRetryDelay:            ct.NewOptDuration(time.Minute),
+			DiagnosticQueueSize:      mustOptIntGreaterThanZero(20),
+			EnableAggregation:        true,
+			ContextKeysCapacity:      mustOptIntGreaterThanZero(5000),
+			ContextKeysFlushInterval: ct.NewOptDuration(10 * time.Minute),
+			EnableValidation:         true,
 		}
 		c.Environment = map[string]*EnvConfig{
 			"earth": {
@@ -131,57 +165,84 @@ func makeValidConfigAllBaseProperties() testDataValidConfig {
 				LogLevel:  NewOptLogLevel(ldlog.Debug),
 			},
 			"krypton": {
-				SDKKey:        "krypton-sdk",
-				MobileKey:     "krypton-mob",
-				EnvID:         "krypton-env",
-				SecureMode:    true,
-				Prefix:        "krypton-",
-				TableName:     "krypton-table",
-				AllowedOrigin: ct.NewOptStringList([]string{"https://oa", "https://rann"}),
-				AllowedHeader: ct.NewOptStringList([]string{"Timestamp-Valid", "Random-Id-Valid"}),
-				TTL:           ct.NewOptDuration(5 * time.Minute),
+				SDKKey:                  "krypton-sdk",
+				MobileKey:               "krypton-mob",
+				EnvID:                   "krypton-env",
+				SecureMode:              true,
+				Prefix:                  "krypton-",
+				TableName:               "krypton-table",
+				AllowedOrigin:           ct.NewOptStringList([]string{"https://oa", "https://rann"}),
+				AllowedHeader:           ct.NewOptStringList([]string{"Timestamp-Valid", "Random-Id-Valid"}),
+				TTL:                     ct.NewOptDuration(5 * time.Minute),
+				HeartbeatInterval:       ct.NewOptDuration(15 * time.Second),
+				MaxClientConnectionTime: ct.NewOptDuration(10 * time.Minute),
 			},
 		}
 	}
 	c.envVars = map[string]string{
-		"PORT":                           "8333",
-		"BASE_URI":                       "http://base",
-		"CLIENT_SIDE_BASE_URI":           "http://clientbase",
-		"STREAM_URI":                     "http://stream",
-		"EXIT_ON_ERROR":                  "1",
-		"EXIT_ALWAYS":                    "1",
-		"IGNORE_CONNECTION_ERRORS":       "1",
-		"HEARTBEAT_INTERVAL":             "90s",
-		"MAX_CLIENT_CONNECTION_TIME":     "30m",
-		"DISCONNECTED_STATUS_TIME":       "3m",
-		"DISABLE_INTERNAL_USAGE_METRICS": "1",
-		"TLS_ENABLED":                    "1",
-		"TLS_CERT":                       "cert",
-		"TLS_KEY":                        "key",
-		"TLS_MIN_VERSION":                "1.2",
-		"LOG_LEVEL":                      "warn",
-		"BIG_SEGMENTS_STALE_AS_DEGRADED": "true",
-		"BIG_SEGMENTS_STALE_THRESHOLD":   "10m",
-		"USE_EVENTS":                     "1",
-		"EVENTS_HOST":                    "http://events",
-		"EVENTS_FLUSH_INTERVAL":          "120s",
-		"EVENTS_CAPACITY":                "500",
-		"EVENTS_INLINE_USERS":            "1",
-		"LD_ENV_earth":                   "earth-sdk",
-		"LD_MOBILE_KEY_earth":            "earth-mob",
-		"LD_CLIENT_SIDE_ID_earth":        "earth-env",
-		"LD_PREFIX_earth":                "earth-",
-		"LD_TABLE_NAME_earth":            "earth-table",
-		"LD_LOG_LEVEL_earth":             "debug",
-		"LD_ENV_krypton":                 "krypton-sdk",
-		"LD_MOBILE_KEY_krypton":          "krypton-mob",
-		"LD_CLIENT_SIDE_ID_krypton":      "krypton-env",
-		"LD_SECURE_MODE_krypton":         "1",
-		"LD_PREFIX_krypton":              "krypton-",
-		"LD_TABLE_NAME_krypton":          "krypton-table",
-		"LD_ALLOWED_ORIGIN_krypton":      "https://oa,https://rann",
-		"LD_ALLOWED_HEADER_krypton":      "Timestamp-Valid,Random-Id-Valid",
-		"LD_TTL_krypton":                 "5m",
+		"PORT":                                   "8333",
+		"BASE_URI":                               "http://base",
+		"CLIENT_SIDE_BASE_URI":                   "http://clientbase",
+		"STREAM_URI":                             "http://stream",
+		"EXIT_ON_ERROR":                          "1",
+		"EXIT_ALWAYS":                            "1",
+		"IGNORE_CONNECTION_ERRORS":               "1",
+		"HEARTBEAT_INTERVAL":                     "90s",
+		"SERVER_SIDE_HEARTBEAT_INTERVAL":         "2m",
+		"MOBILE_HEARTBEAT_INTERVAL":              "20s",
+		"JS_CLIENT_HEARTBEAT_INTERVAL":           "60s",
+		"MAX_CLIENT_CONNECTION_TIME":             "30m",
+		"SERVER_SIDE_MAX_CLIENT_CONNECTION_TIME": "25m",
+		"MOBILE_MAX_CLIENT_CONNECTION_TIME":      "2h",
+		"JS_CLIENT_MAX_CLIENT_CONNECTION_TIME":   "1h",
+		"MAX_CLIENT_CONNECTION_TIME_JITTER":      "5m",
+		"MAX_STREAM_BUFFER_SIZE":                 "1000000",
+		"DISCONNECTED_STATUS_TIME":               "3m",
+		"DISABLE_INTERNAL_USAGE_METRICS":         "1",
+		"TLS_ENABLED":                            "1",
+		"TLS_CERT":                               "cert",
+		"TLS_KEY":                                "key",
+		"TLS_MIN_VERSION":                        "1.2",
+		"LOG_LEVEL":                              "warn",
+		"BIG_SEGMENTS_STALE_AS_DEGRADED":         "true",
+		"BIG_SEGMENTS_STALE_THRESHOLD":           "10m",
+		"READ_HEADER_TIMEOUT":                    "5s",
+		"READ_TIMEOUT":                           "30s",
+		"WRITE_TIMEOUT":                          "45s",
+		"IDLE_TIMEOUT":                           "2m",
+		"MAX_HEADER_BYTES":                       "65536",
+		"MAX_REQUEST_BODY_SIZE":                  "2000000",
+		"USE_EVENTS":                             "1",
+		"EVENTS_HOST":                            "http://events",
+		"EVENTS_FLUSH_INTERVAL":                  "120s",
+		"EVENTS_CAPACITY":                        "500",
+		"EVENTS_INLINE_USERS":                    "1",
+		"EVENTS_ENABLE_COMPRESSION":              "1",
+		"EVENTS_MAX_ATTEMPTS":                    "4",
+		"EVENTS_RETRY_DELAY":                     "2s",
+		"EVENTS_MAX_RETRY_DELAY":                 "1m",
+		"EVENTS_DIAGNOSTIC_QUEUE_SIZE":           "20",
+		"EVENTS_ENABLE_AGGREGATION":              "1",
+		"EVENTS_CONTEXT_KEYS_CAPACITY":           "5000",
+		"EVENTS_CONTEXT_KEYS_FLUSH_INTERVAL":     "10m",
+		"EVENTS_ENABLE_VALIDATION":               "1",
+		"LD_ENV_earth":                           "earth-sdk",
+		"LD_MOBILE_KEY_earth":                    "earth-mob",
+		"LD_CLIENT_SIDE_ID_earth":                "earth-env",
+		"LD_PREFIX_earth":                        "earth-",
+		"LD_TABLE_NAME_earth":                    "earth-table",
+		"LD_LOG_LEVEL_earth":                     "debug",
+		"LD_ENV_krypton":                         "krypton-sdk",
+		"LD_MOBILE_KEY_krypton":                  "krypton-mob",
+		"LD_CLIENT_SIDE_ID_krypton":              "krypton-env",
+		"LD_SECURE_MODE_krypton":                 "1",
+		"LD_PREFIX_krypton":                      "krypton-",
+		"LD_TABLE_NAME_krypton":                  "krypton-table",
+		"LD_ALLOWED_ORIGIN_krypton":              "https://oa,https://rann",
+		"LD_ALLOWED_HEADER_krypton":              "Timestamp-Valid,Random-Id-Valid",
+		"LD_TTL_krypton":                         "5m",
+		"LD_HEARTBEAT_INTERVAL_krypton":          "15s",
+		"LD_MAX_CLIENT_CONNECTION_TIME_krypton":  "10m",
 	}
 	c.fileContent = `
 [Main]
@@ -193,7 +254,15 @@ ExitOnError = 1
 ExitAlways = 1
 IgnoreConnectionErrors = 1
 HeartbeatInterval = 90s
+ServerSideHeartbeatInterval = 2m
+MobileHeartbeatInterval = 20s
+JSClientHeartbeatInterval = 60s
 MaxClientConnectionTime = 30m
+ServerSideMaxClientConnectionTime = 25m
+MobileMaxClientConnectionTime = 2h
+JSClientMaxClientConnectionTime = 1h
+MaxClientConnectionTimeJitter = 5m
+MaxStreamBufferSize = 1000000
 DisconnectedStatusTime = 3m
 DisableInternalUsageMetrics = 1
 TLSEnabled = 1
@@ -203,6 +272,12 @@ TLSMinVersion = "1.2"
 LogLevel = "warn"
 BigSegmentsStaleAsDegraded = 1
 BigSegmentsStaleThreshold = 10m
+ReadHeaderTimeout = 5s
+ReadTimeout = 30s
+WriteTimeout = 45s
+IdleTimeout = 2m
+MaxHeaderBytes = 65536
+MaxRequestBodySize = 2000000
 
 [Events]
 SendEvents = 1
@@ -210,6 +285,15 @@ EventsUri = "http://events"
 FlushInterval = 120s
 Capacity = 500
 InlineUsers = 1
+EnableCompression = 1
+MaxAttempts = 4
+RetryDelay = 2s
+MaxRetryDelay = 1m
+DiagnosticQueueSize = 20
+EnableAggregation = 1
+ContextKeysCapacity = 5000
+ContextKeysFlushInterval = 10m
+EnableValidation = 1
 
 [Environment "earth"]
 SdkKey = "earth-sdk"
@@ -231,6 +315,8 @@ AllowedOrigin = "https://rann"
 AllowedHeader = "Timestamp-Valid"
 AllowedHeader = "Random-Id-Valid"
 TTL = 5m
+HeartbeatInterval = 15s
+MaxClientConnectionTime = 10m
 `
 	return c
 }
@@ -353,6 +439,142 @@ FileDataSource = my-file-path
 	return c
 }
 
+func makeValidConfigDownstream() testDataValidConfig {
+	c := testDataValidConfig{name: "downstream properties"}
+	c.makeConfig = func(c *Config) {
+		c.Environment = map[string]*EnvConfig{
+			"earth": {SDKKey: SDKKey("earth-sdk")},
+		}
+		c.Downstream.AutoConfigKey = AutoConfigKey("downstream-key")
+	}
+	c.envVars = map[string]string{
+		"LD_ENV_earth":               "earth-sdk",
+		"DOWNSTREAM_AUTO_CONFIG_KEY": "downstream-key",
+	}
+	c.fileContent = `
+[Environment "earth"]
+SDKKey = earth-sdk
+
+[Downstream]
+AutoConfigKey = downstream-key
+`
+	return c
+}
+
+func makeValidConfigWebhooks() testDataValidConfig {
+	c := testDataValidConfig{name: "webhooks properties"}
+	c.makeConfig = func(c *Config) {
+		c.Environment = map[string]*EnvConfig{
+			"earth": {
+				SDKKey:        SDKKey("earth-sdk"),
+				WebhookURL:    newOptURLAbsoluteMustBeValid("http://earth-hooks"),
+				WebhookSecret: "earth-secret",
+			},
+		}
+		c.Webhooks = WebhooksConfig{
+			URL:         newOptURLAbsoluteMustBeValid("http://hooks"),
+			Secret:      "secret",
+			QueueSize:   mustOptIntGreaterThanZero(50),
+			MaxAttempts: mustOptIntGreaterThanZero(5),
+			RetryDelay:  ct.NewOptDuration(3 * time.Second),
+		}
+	}
+	c.envVars = map[string]string{
+		"LD_ENV_earth":            "earth-sdk",
+		"LD_WEBHOOK_URL_earth":    "http://earth-hooks",
+		"LD_WEBHOOK_SECRET_earth": "earth-secret",
+		"WEBHOOK_URL":             "http://hooks",
+		"WEBHOOK_SECRET":          "secret",
+		"WEBHOOK_QUEUE_SIZE":      "50",
+		"WEBHOOK_MAX_ATTEMPTS":    "5",
+		"WEBHOOK_RETRY_DELAY":     "3s",
+	}
+	c.fileContent = `
+[Environment "earth"]
+SDKKey = earth-sdk
+WebhookURL = http://earth-hooks
+WebhookSecret = earth-secret
+
+[Webhooks]
+URL = http://hooks
+Secret = secret
+QueueSize = 50
+MaxAttempts = 5
+RetryDelay = 3s
+`
+	return c
+}
+
+func makeValidConfigEventFilters() testDataValidConfig {
+	c := testDataValidConfig{name: "event filter properties"}
+	c.makeConfig = func(c *Config) {
+		c.Environment = map[string]*EnvConfig{
+			"earth": {
+				SDKKey:                 SDKKey("earth-sdk"),
+				RedactAttributes:       ct.NewOptStringList([]string{"email", "/address/street"}),
+				HashAttributes:         ct.NewOptStringList([]string{"name"}),
+				DropEventKinds:         ct.NewOptStringList([]string{"custom"}),
+				DropFlagKeys:           ct.NewOptStringList([]string{"flag1", "flag2"}),
+				EventSampleRates:       ct.NewOptStringList([]string{"custom=0.1", "identify=0.5"}),
+				CustomEventSampleRates: ct.NewOptStringList([]string{"checkout=0.01"}),
+			},
+		}
+	}
+	c.envVars = map[string]string{
+		"LD_ENV_earth":                       "earth-sdk",
+		"LD_REDACT_ATTRIBUTES_earth":         "email,/address/street",
+		"LD_HASH_ATTRIBUTES_earth":           "name",
+		"LD_DROP_EVENT_KINDS_earth":          "custom",
+		"LD_DROP_FLAG_KEYS_earth":            "flag1,flag2",
+		"LD_EVENT_SAMPLE_RATES_earth":        "custom=0.1,identify=0.5",
+		"LD_CUSTOM_EVENT_SAMPLE_RATES_earth": "checkout=0.01",
+	}
+	c.fileContent = `
+[Environment "earth"]
+SDKKey = earth-sdk
+RedactAttributes = email
+RedactAttributes = /address/street
+HashAttributes = name
+DropEventKinds = custom
+DropFlagKeys = flag1
+DropFlagKeys = flag2
+EventSampleRates = custom=0.1
+EventSampleRates = identify=0.5
+CustomEventSampleRates = checkout=0.01
+`
+	return c
+}
+
+func makeValidConfigAuditLog() testDataValidConfig {
+	c := testDataValidConfig{name: "audit log properties"}
+	c.makeConfig = func(c *Config) {
+		c.Environment = map[string]*EnvConfig{
+			"earth": {SDKKey: SDKKey("earth-sdk")},
+		}
+		c.AuditLog = AuditLogConfig{
+			File:        "/var/log/relay-audit.log",
+			MaxFileSize: mustOptIntGreaterThanZero(1000000),
+			MaxBackups:  mustOptIntGreaterThanZero(2),
+		}
+	}
+	c.envVars = map[string]string{
+		"LD_ENV_earth":            "earth-sdk",
+		"AUDIT_LOG_FILE":          "/var/log/relay-audit.log",
+		"AUDIT_LOG_MAX_FILE_SIZE": "1000000",
+		"AUDIT_LOG_MAX_BACKUPS":   "2",
+	}
+	c.fileContent = `
+[Environment "earth"]
+SDKKey = earth-sdk
+
+[AuditLog]
+File = /var/log/relay-audit.log
+MaxFileSize = 1000000
+MaxBackups = 2
+`
+	return c
+}
+
 func makeValidConfigRedisMinimal() testDataValidConfig {
 	c := testDataValidConfig{name: "Redis - minimal parameters"}
 	c.makeConfig = func(c *Config) {
@@ -844,3 +1066,311 @@ CaCertFiles = "cert"
 `
 	return c
 }
+
+func makeValidConfigProxyClientOptions() testDataValidConfig {
+	c := testDataValidConfig{name: "proxy client options"}
+	c.makeConfig = func(c *Config) {
+		c.Proxy = ProxyConfig{
+			ConnectTimeout: ct.NewOptDuration(5 * time.Second),
+			ReadTimeout:    ct.NewOptDuration(20 * time.Second),
+			ClientCertFile: "client-cert",
+			ClientKeyFile:  "client-key",
+			Headers:        ct.NewOptStringList([]string{"X-Egress-Tenant: relay", "X-Other:value"}),
+		}
+	}
+	c.envVars = map[string]string{
+		"PROXY_CONNECT_TIMEOUT":  "5s",
+		"PROXY_READ_TIMEOUT":     "20s",
+		"PROXY_CLIENT_CERT_FILE": "client-cert",
+		"PROXY_CLIENT_KEY_FILE":  "client-key",
+		"PROXY_HEADERS":          "X-Egress-Tenant: relay,X-Other:value",
+	}
+	c.fileContent = `
+[Proxy]
+ConnectTimeout = 5s
+ReadTimeout = 20s
+ClientCertFile = "client-cert"
+ClientKeyFile = "client-key"
+Headers = "X-Egress-Tenant: relay"
+Headers = "X-Other:value"
+`
+	return c
+}
+
+func makeValidConfigProxyDestinations() testDataValidConfig {
+	c := testDataValidConfig{name: "proxy destinations"}
+	c.makeConfig = func(c *Config) {
+		c.Proxy = ProxyConfig{
+			URL:            newOptURLAbsoluteMustBeValid("http://proxy"),
+			NoProxy:        ct.NewOptStringList([]string{"redis.internal", ".corp.example"}),
+			StreamURL:      newOptURLAbsoluteMustBeValid("http://stream-proxy"),
+			BaseURL:        newOptURLAbsoluteMustBeValid("http://base-proxy"),
+			EventsURL:      newOptURLAbsoluteMustBeValid("http://events-proxy"),
+			BigSegmentsURL: newOptURLAbsoluteMustBeValid("http://big-segments-proxy"),
+			AutoConfigURL:  newOptURLAbsoluteMustBeValid("http://auto-config-proxy"),
+		}
+	}
+	c.envVars = map[string]string{
+		"PROXY_URL":              "http://proxy",
+		"PROXY_NO_PROXY":         "redis.internal,.corp.example",
+		"PROXY_STREAM_URL":       "http://stream-proxy",
+		"PROXY_BASE_URL":         "http://base-proxy",
+		"PROXY_EVENTS_URL":       "http://events-proxy",
+		"PROXY_BIG_SEGMENTS_URL": "http://big-segments-proxy",
+		"PROXY_AUTO_CONFIG_URL":  "http://auto-config-proxy",
+	}
+	c.fileContent = `
+[Proxy]
+URL = "http://proxy"
+NoProxy = "redis.internal"
+NoProxy = ".corp.example"
+StreamURL = "http://stream-proxy"
+BaseURL = "http://base-proxy"
+EventsURL = "http://events-proxy"
+BigSegmentsURL = "http://big-segments-proxy"
+AutoConfigURL = "http://auto-config-proxy"
+`
+	return c
+}
+
+func makeValidConfigListeners() testDataValidConfig {
+	c := testDataValidConfig{name: "listeners"}
+	c.makeConfig = func(c *Config) {
+		c.Main.Routes = ct.NewOptStringList([]string{RouteGroupServerSide, RouteGroupEvents})
+		c.Environment = map[string]*EnvConfig{"earth": {SDKKey: SDKKey("earth-sdk")}}
+		c.Listener = map[string]*ListenerConfig{
+			"internal": {
+				Port:       mustOptIntGreaterThanZero(8040),
+				H2CEnabled: true,
+				Routes:     ct.NewOptStringList([]string{RouteGroupStatus, RouteGroupAdmin}),
+			},
+			"sdks": {
+				Port:          mustOptIntGreaterThanZero(8443),
+				TLSEnabled:    true,
+				TLSCert:       "cert",
+				TLSKey:        "key",
+				TLSMinVersion: NewOptTLSVersion(tls.VersionTLS12),
+				Routes:        ct.NewOptStringList([]string{RouteGroupServerSide, RouteGroupMobile, RouteGroupClientSide}),
+			},
+		}
+	}
+	c.envVars = map[string]string{
+		"ROUTES":                        "serverSide,events",
+		"LD_ENV_earth":                  "earth-sdk",
+		"LISTENER_PORT_internal":        "8040",
+		"LISTENER_ROUTES_internal":      "status,admin",
+		"LISTENER_H2C_ENABLED_internal": "true",
+		"LISTENER_PORT_sdks":            "8443",
+		"LISTENER_TLS_ENABLED_sdks":     "true",
+		"LISTENER_TLS_CERT_sdks":        "cert",
+		"LISTENER_TLS_KEY_sdks":         "key",
+		"LISTENER_TLS_MIN_VERSION_sdks": "1.2",
+		"LISTENER_ROUTES_sdks":          "serverSide,mobile,clientSide",
+	}
+	c.fileContent = `
+[Main]
+Routes = serverSide
+Routes = events
+
+[Environment "earth"]
+SDKKey = earth-sdk
+
+[Listener "internal"]
+Port = 8040
+H2CEnabled = true
+Routes = status
+Routes = admin
+
+[Listener "sdks"]
+Port = 8443
+TLSEnabled = true
+TLSCert = cert
+TLSKey = key
+TLSMinVersion = 1.2
+Routes = serverSide
+Routes = mobile
+Routes = clientSide
+`
+	return c
+}
+
+func makeValidConfigEventSinks() testDataValidConfig {
+	c := testDataValidConfig{name: "event sinks"}
+	c.makeConfig = func(c *Config) {
+		c.Environment = map[string]*EnvConfig{"earth": {SDKKey: SDKKey("earth-sdk")}}
+		c.EventSink = map[string]*EventSinkConfig{
+			"archive": {
+				Type:           EventSinkTypeFile,
+				File:           "/var/log/relay/events.json",
+				MaxFileSize:    mustOptIntGreaterThanZero(1000000),
+				MaxBackups:     mustOptIntGreaterThanZero(10),
+				RotateInterval: ct.NewOptDuration(time.Hour),
+				Kinds:          ct.NewOptStringList([]string{EventSinkKindAnalytics, EventSinkKindDiagnostic}),
+				Environments:   ct.NewOptStringList([]string{"earth"}),
+			},
+			"console": {
+				Type: EventSinkTypeStdout,
+			},
+			"stream": {
+				Type:          EventSinkTypeKafka,
+				Brokers:       ct.NewOptStringList([]string{"kafka1:9092", "kafka2:9092"}),
+				Topic:         "ld-events",
+				TLSEnabled:    true,
+				FlushInterval: ct.NewOptDuration(time.Second),
+				Capacity:      mustOptIntGreaterThanZero(5000),
+			},
+		}
+	}
+	c.envVars = map[string]string{
+		"LD_ENV_earth":                       "earth-sdk",
+		"EVENT_SINK_TYPE_archive":            "file",
+		"EVENT_SINK_FILE_archive":            "/var/log/relay/events.json",
+		"EVENT_SINK_MAX_FILE_SIZE_archive":   "1000000",
+		"EVENT_SINK_MAX_BACKUPS_archive":     "10",
+		"EVENT_SINK_ROTATE_INTERVAL_archive": "1h",
+		"EVENT_SINK_KINDS_archive":           "analytics,diagnostic",
+		"EVENT_SINK_ENVIRONMENTS_archive":    "earth",
+		"EVENT_SINK_TYPE_console":            "stdout",
+		"EVENT_SINK_TYPE_stream":             "kafka",
+		"EVENT_SINK_BROKERS_stream":          "kafka1:9092,kafka2:9092",
+		"EVENT_SINK_TOPIC_stream":            "ld-events",
+		"EVENT_SINK_TLS_ENABLED_stream":      "true",
+		"EVENT_SINK_FLUSH_INTERVAL_stream":   "1s",
+		"EVENT_SINK_CAPACITY_stream":         "5000",
+	}
+	c.fileContent = `
+[Environment "earth"]
+SDKKey = earth-sdk
+
+[EventSink "archive"]
+Type = file
+File = /var/log/relay/events.json
+MaxFileSize = 1000000
+MaxBackups = 10
+RotateInterval = 1h
+Kinds = analytics
+Kinds = diagnostic
+Environments = earth
+
+[EventSink "console"]
+Type = stdout
+
+[EventSink "stream"]
+Type = kafka
+Brokers = kafka1:9092
+Brokers = kafka2:9092
+Topic = ld-events
+TLSEnabled = true
+FlushInterval = 1s
+Capacity = 5000
+`
+	return c
+}
+
+func makeValidConfigEventDestinations() testDataValidConfig {
+	c := testDataValidConfig{name: "event destinations"}
+	c.makeConfig = func(c *Config) {
+		c.Environment = map[string]*EnvConfig{"earth": {SDKKey: SDKKey("earth-sdk")}}
+		c.EventDestination = map[string]*EventDestinationConfig{
+			"new-account": {
+				Environment: "earth",
+				EventsURI:   newOptURLAbsoluteMustBeValid("http://other-events"),
+				SDKKey:      SDKKey("other-sdk"),
+				MobileKey:   MobileKey("other-mob"),
+				EnvID:       EnvironmentID("other-env"),
+			},
+			"server-only": {
+				Environment: "earth",
+				SDKKey:      SDKKey("third-sdk"),
+			},
+		}
+	}
+	c.envVars = map[string]string{
+		"LD_ENV_earth":                                 "earth-sdk",
+		"EVENT_DESTINATION_ENV_new-account":            "earth",
+		"EVENT_DESTINATION_URI_new-account":            "http://other-events",
+		"EVENT_DESTINATION_SDK_KEY_new-account":        "other-sdk",
+		"EVENT_DESTINATION_MOBILE_KEY_new-account":     "other-mob",
+		"EVENT_DESTINATION_CLIENT_SIDE_ID_new-account": "other-env",
+		"EVENT_DESTINATION_ENV_server-only":            "earth",
+		"EVENT_DESTINATION_SDK_KEY_server-only":        "third-sdk",
+	}
+	c.fileContent = `
+[Environment "earth"]
+SDKKey = earth-sdk
+
+[EventDestination "new-account"]
+Environment = earth
+EventsURI = http://other-events
+SDKKey = other-sdk
+MobileKey = other-mob
+EnvID = other-env
+
+[EventDestination "server-only"]
+Environment = earth
+SDKKey = third-sdk
+`
+	return c
+}
+
+func makeValidConfigEventsOnlyEnvironments() testDataValidConfig {
+	c := testDataValidConfig{name: "environments with no SDK key, for forwarding events"}
+	c.makeConfig = func(c *Config) {
+		c.Events.SendEvents = true
+		c.Environment = map[string]*EnvConfig{
+			"mobile":  {MobileKey: MobileKey("mob-xxx")},
+			"browser": {EnvID: EnvironmentID("env-xxx")},
+		}
+	}
+	c.envVars = map[string]string{
+		"USE_EVENTS":                "1",
+		"LD_MOBILE_KEY_mobile":      "mob-xxx",
+		"LD_CLIENT_SIDE_ID_browser": "env-xxx",
+	}
+	c.fileContent = `
+[Events]
+SendEvents = 1
+
+[Environment "mobile"]
+MobileKey = mob-xxx
+
+[Environment "browser"]
+EnvID = env-xxx
+`
+	return c
+}
+
+func makeValidConfigSocket() testDataValidConfig {
+	c := testDataValidConfig{name: "Unix socket"}
+	c.makeConfig = func(c *Config) {
+		c.Main.SocketPath = "/run/ld-relay/relay.sock"
+		c.Main.SocketMode = NewOptFileMode(0660)
+		c.Environment = map[string]*EnvConfig{"earth": {SDKKey: SDKKey("earth-sdk")}}
+		c.Listener = map[string]*ListenerConfig{
+			"internal": {
+				Port:   mustOptIntGreaterThanZero(DefaultPort),
+				Routes: ct.NewOptStringList([]string{RouteGroupStatus}),
+			},
+		}
+	}
+	c.envVars = map[string]string{
+		"SOCKET_PATH":              "/run/ld-relay/relay.sock",
+		"SOCKET_MODE":              "0660",
+		"LD_ENV_earth":             "earth-sdk",
+		"LISTENER_PORT_internal":   "8030",
+		"LISTENER_ROUTES_internal": "status",
+	}
+	c.fileContent = `
+[Main]
+SocketPath = /run/ld-relay/relay.sock
+SocketMode = 0660
+
+[Environment "earth"]
+SDKKey = earth-sdk
+
+[Listener "internal"]
+Port = 8030
+Routes = status
+`
+	return c
+}
@@ -0,0 +1,63 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/configdump"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+)
+
+// This is the same rule that the Relay constructor enforces, but since it depends on how Relay is being
+// used rather than just on the Config, it isn't part of config.ValidateConfig.
+var errNoEnvironments = errors.New("you must specify at least one environment in your configuration")
+
+// CheckConfig loads the configuration in the same way that Relay does at startup, and validates it,
+// without creating a Relay instance or making any network connections. If the configuration is valid,
+// the effective configuration is written to out, with credentials obscured and with the source of each
+// value. Any warnings and errors are written to errOut. The return value is false if the configuration
+// is invalid.
+func CheckConfig(opts Options, out, errOut io.Writer) bool {
+	var c config.Config
+
+	// Validation is done again at the end, after all the sources have been read, so we don't log
+	// warnings about the partial configurations.
+	quietLoggers := ldlog.NewDisabledLoggers()
+	if opts.ConfigFile != "" {
+		err := config.TrackPropertySource(&c, config.PropertySourceFile, func() error {
+			return config.LoadConfigFile(&c, opts.ConfigFile, quietLoggers)
+		})
+		if err != nil {
+			_, _ = fmt.Fprintf(errOut, "Error loading config file: %s\n", err)
+			return false
+		}
+	}
+	if opts.UseEnvironment {
+		err := config.TrackPropertySource(&c, config.PropertySourceEnvironment, func() error {
+			return config.LoadConfigFromEnvironment(&c, quietLoggers)
+		})
+		if err != nil {
+			_, _ = fmt.Fprintf(errOut, "Configuration error: %s\n", err)
+			return false
+		}
+	}
+
+	loggers := ldlog.NewDefaultLoggers()
+	loggers.SetBaseLogger(log.New(errOut, "", 0))
+	loggers.SetMinLevel(ldlog.Warn)
+	if err := config.ValidateConfig(&c, loggers); err != nil {
+		_, _ = fmt.Fprintf(errOut, "Configuration error: %s\n", err)
+		return false
+	}
+	if c.AutoConfig.Key == "" && c.OfflineMode.FileDataSource == "" && len(c.Environment) == 0 {
+		_, _ = fmt.Fprintf(errOut, "Configuration error: %s\n", errNoEnvironments)
+		return false
+	}
+
+	configdump.Write(c, out)
+	return true
+}
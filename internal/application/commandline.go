@@ -8,8 +8,23 @@ import (
 	"strings"
 )
 
-// DefaultConfigPath is the default configuration file path.
-const DefaultConfigPath = "/etc/ld-relay.conf"
+const (
+	// DefaultConfigPath is the default configuration file path.
+	DefaultConfigPath = "/etc/ld-relay.conf"
+
+	// CheckConfigCommand is the subcommand for validating the configuration without starting Relay.
+	CheckConfigCommand = "check-config"
+
+	// ReplayEventsCommand is the subcommand for sending a captured set of event payloads again without
+	// starting Relay.
+	ReplayEventsCommand = "replay-events"
+
+	// These are the default credentials for ReplayEventsCommand. They are placeholders, since the events are
+	// meant to be sent to a test endpoint rather than to LaunchDarkly.
+	defaultReplaySDKKey    = "sdk-replay"
+	defaultReplayMobileKey = "mob-replay"
+	defaultReplayEnvID     = "replay"
+)
 
 // Options represents all options that can be set from the command line.
 type Options struct {
@@ -17,12 +32,26 @@ type Options struct {
 	AllowMissingFile bool
 	UseEnvironment   bool
 	PrintVersion     bool
+	CheckConfig      bool
+	PrintConfig      bool
+
+	// These options are only used with ReplayEventsCommand.
+	ReplayEvents    bool
+	ReplayFile      string
+	ReplayEventsURI string
+	ReplaySDKKey    string
+	ReplayMobileKey string
+	ReplayEnvID     string
 }
 
 func errConfigFileNotFound(filename string) error {
 	return fmt.Errorf("configuration file %q does not exist", filename)
 }
 
+func errReplayOptionRequired(name string) error {
+	return fmt.Errorf("the %s command requires the --%s option", ReplayEventsCommand, name)
+}
+
 // DescribeConfigSource returns a human-readable phrase describing whether the configuration comes from a
 // file, from variables, or both.
 func (o Options) DescribeConfigSource() string {
@@ -50,16 +79,31 @@ func (o Options) DescribeConfigSource() string {
 // 3. If you specify --from-env, it creates a configuration from environment variables as described in README.
 // 4. If you specify both, the file is loaded first, then it applies changes from variables if any.
 // 5. Omitting all options is equivalent to explicitly specifying --config /etc/ld-relay.conf.
+//
+// If the first argument is "check-config", the same options apply, but Relay only validates the
+// configuration (see CheckConfig) instead of starting.
+//
+// If the first argument is "replay-events", the options are different: see readReplayEventsOptions.
 func ReadOptions(osArgs []string, errorOutput io.Writer) (Options, error) {
 	var o Options
 
+	args := osArgs[1:]
+	if len(args) > 0 && args[0] == CheckConfigCommand {
+		o.CheckConfig = true
+		args = args[1:]
+	}
+	if len(args) > 0 && args[0] == ReplayEventsCommand {
+		return readReplayEventsOptions(args[1:], errorOutput)
+	}
+
 	fs := flag.NewFlagSet("", flag.ContinueOnError)
 	fs.SetOutput(errorOutput)
 	fs.StringVar(&o.ConfigFile, "config", "", "configuration file location")
 	fs.BoolVar(&o.AllowMissingFile, "allow-missing-file", false, "suppress error if config file is not found")
 	fs.BoolVar(&o.UseEnvironment, "from-env", false, "read configuration from environment variables")
 	fs.BoolVar(&o.PrintVersion, "version", false, "print relay's version")
-	err := fs.Parse(osArgs[1:])
+	fs.BoolVar(&o.PrintConfig, "print-config", false, "print the effective configuration at startup")
+	err := fs.Parse(args)
 	if err != nil {
 		return o, err
 	}
@@ -86,6 +130,33 @@ func ReadOptions(osArgs []string, errorOutput io.Writer) (Options, error) {
 	return o, nil
 }
 
+// readReplayEventsOptions reads the options for the replay-events command, which does not use a Relay
+// configuration. The --file and --events-uri options are required; there is no default events URI, so that
+// captured events are not sent to LaunchDarkly by accident. Any credentials that are not specified are
+// placeholders.
+func readReplayEventsOptions(args []string, errorOutput io.Writer) (Options, error) {
+	o := Options{ReplayEvents: true}
+
+	fs := flag.NewFlagSet(ReplayEventsCommand, flag.ContinueOnError)
+	fs.SetOutput(errorOutput)
+	fs.StringVar(&o.ReplayFile, "file", "", "file containing event payloads from the event capture endpoint")
+	fs.StringVar(&o.ReplayEventsURI, "events-uri", "", "base URI to send the events to")
+	fs.StringVar(&o.ReplaySDKKey, "sdk-key", defaultReplaySDKKey, "SDK key for events from server-side SDKs")
+	fs.StringVar(&o.ReplayMobileKey, "mobile-key", defaultReplayMobileKey, "mobile key for events from mobile SDKs")
+	fs.StringVar(&o.ReplayEnvID, "env-id", defaultReplayEnvID, "environment ID for events from client-side SDKs")
+	if err := fs.Parse(args); err != nil {
+		return o, err
+	}
+
+	if o.ReplayFile == "" {
+		return o, errReplayOptionRequired("file")
+	}
+	if o.ReplayEventsURI == "" {
+		return o, errReplayOptionRequired("events-uri")
+	}
+	return o, nil
+}
+
 // DescribeRelayVersion returns the same version string unless it is a prerelease build, in
 // which case it is reformatted to change "+xxx" into "(build xxx)".
 func DescribeRelayVersion(version string) string {
@@ -59,6 +59,45 @@ func TestReadOptions(t *testing.T) {
 		assert.True(t, opts.PrintVersion)
 	})
 
+	t.Run("check-config command", func(t *testing.T) {
+		helpers.WithTempFile(func(filename string) {
+			opts, err := ReadOptions([]string{appName, "check-config", "--config", filename, "--from-env"}, io.Discard)
+			require.NoError(t, err)
+			assert.True(t, opts.CheckConfig)
+			assert.Equal(t, filename, opts.ConfigFile)
+			assert.True(t, opts.UseEnvironment)
+		})
+
+		opts, err := ReadOptions([]string{appName, "--from-env"}, io.Discard)
+		require.NoError(t, err)
+		assert.False(t, opts.CheckConfig)
+	})
+
+	t.Run("replay-events command", func(t *testing.T) {
+		opts, err := ReadOptions([]string{appName, "replay-events", "--file", "capture.json", "--events-uri", "http://localhost:9999",
+			"--mobile-key", "mob-xyz"}, io.Discard)
+		require.NoError(t, err)
+		assert.True(t, opts.ReplayEvents)
+		assert.Equal(t, "capture.json", opts.ReplayFile)
+		assert.Equal(t, "http://localhost:9999", opts.ReplayEventsURI)
+		assert.Equal(t, defaultReplaySDKKey, opts.ReplaySDKKey)
+		assert.Equal(t, "mob-xyz", opts.ReplayMobileKey)
+		assert.Equal(t, defaultReplayEnvID, opts.ReplayEnvID)
+		assert.Equal(t, "", opts.ConfigFile)
+
+		_, err = ReadOptions([]string{appName, "replay-events", "--events-uri", "http://localhost:9999"}, io.Discard)
+		assert.Equal(t, errReplayOptionRequired("file"), err)
+
+		_, err = ReadOptions([]string{appName, "replay-events", "--file", "capture.json"}, io.Discard)
+		assert.Equal(t, errReplayOptionRequired("events-uri"), err)
+	})
+
+	t.Run("print-config option", func(t *testing.T) {
+		opts, err := ReadOptions([]string{appName, "--from-env", "--print-config"}, io.Discard)
+		require.NoError(t, err)
+		assert.True(t, opts.PrintConfig)
+	})
+
 	t.Run("invalid options", func(t *testing.T) {
 		_, err := ReadOptions([]string{appName, "--unknown"}, io.Discard)
 		assert.Error(t, err)
@@ -0,0 +1,183 @@
+package application
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+)
+
+const (
+	// These environment variables are defined by the systemd socket activation protocol; see
+	// https://www.freedesktop.org/software/systemd/man/sd_listen_fds.html
+	systemdListenPIDVar     = "LISTEN_PID"
+	systemdListenFDsVar     = "LISTEN_FDS"
+	systemdListenFDNamesVar = "LISTEN_FDNAMES"
+
+	// systemdListenFDsStart is the first file descriptor that systemd passes to the process.
+	systemdListenFDsStart = 3
+
+	// SystemdMainSocketName is the FileDescriptorName of a socket from systemd that is used for the main
+	// server; see MatchSystemdListeners.
+	SystemdMainSocketName = "main"
+)
+
+func errSocketPathNotSocket(path string) error {
+	return fmt.Errorf("%s already exists and is not a socket", path)
+}
+
+func errBadSystemdVar(name, value string) error {
+	return fmt.Errorf("invalid value %q for %s", value, name)
+}
+
+func errSystemdSocketUnmatched(name string) error {
+	return fmt.Errorf("socket from systemd has name %q, which is neither %q nor the name of a [Listener] section;"+
+		" set FileDescriptorName in the socket unit", name, SystemdMainSocketName)
+}
+
+func errSystemdSocketAmbiguous(name string) error {
+	return fmt.Errorf("socket from systemd has name %q, which could mean either the main server or [Listener %q]",
+		name, name)
+}
+
+// ListenUnixSocket creates a Unix domain socket listener at the specified path, and sets the socket's
+// file permissions to mode. If a socket already exists at that path, it is assumed to have been left
+// behind by an earlier Relay process and is removed; if some other kind of file exists there, it is an
+// error. The socket file is removed when the listener is closed.
+func ListenUnixSocket(path string, mode os.FileMode) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, errSocketPathNotSocket(path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// SystemdListeners returns the listening sockets that were passed to Relay by systemd socket activation,
+// grouped by the name that was set with FileDescriptorName in the socket unit (if no name was set, systemd
+// uses the name of the socket unit). If Relay was not started by socket activation, it returns an empty
+// map.
+//
+// The socket activation environment variables are removed afterward, so that they will not be inherited
+// by any child processes.
+func SystemdListeners() (map[string][]net.Listener, error) {
+	defer func() {
+		_ = os.Unsetenv(systemdListenPIDVar)
+		_ = os.Unsetenv(systemdListenFDsVar)
+		_ = os.Unsetenv(systemdListenFDNamesVar)
+	}()
+	return systemdListeners(os.Getenv, os.Getpid(), systemdListenFDsStart)
+}
+
+func systemdListeners(getenv func(string) string, pid int, firstFD int) (map[string][]net.Listener, error) {
+	ret := make(map[string][]net.Listener)
+	pidValue, fdsValue := getenv(systemdListenPIDVar), getenv(systemdListenFDsVar)
+	if pidValue == "" || fdsValue == "" {
+		return ret, nil
+	}
+	if listenPID, err := strconv.Atoi(pidValue); err != nil {
+		return nil, errBadSystemdVar(systemdListenPIDVar, pidValue)
+	} else if listenPID != pid {
+		return ret, nil // these variables were meant for some other process
+	}
+	count, err := strconv.Atoi(fdsValue)
+	if err != nil || count < 0 {
+		return nil, errBadSystemdVar(systemdListenFDsVar, fdsValue)
+	}
+	var names []string
+	if namesValue := getenv(systemdListenFDNamesVar); namesValue != "" {
+		names = strings.Split(namesValue, ":")
+	}
+	for i := 0; i < count; i++ {
+		name := ""
+		if i < len(names) {
+			name = names[i]
+		}
+		fd := firstFD + i
+		file := os.NewFile(uintptr(fd), name)
+		listener, err := net.FileListener(file) // this duplicates the file descriptor
+		_ = file.Close()
+		if err != nil {
+			for _, ls := range ret {
+				for _, l := range ls {
+					_ = l.Close()
+				}
+			}
+			return nil, fmt.Errorf("file descriptor %d from systemd is not a listening socket: %w", fd, err)
+		}
+		ret[name] = append(ret[name], listener)
+	}
+	return ret, nil
+}
+
+// MatchSystemdListeners decides which of the sockets returned by SystemdListeners are used for the main
+// server and for each [Listener] section, and logs each replacement. A socket whose FileDescriptorName is
+// SystemdMainSocketName is used for the main server instead of MainConfig.Port or MainConfig.SocketPath,
+// and one whose name is the name of a [Listener] section is used for that listener instead of its port.
+//
+// It is an error for a socket to have any other name. That includes a socket with no FileDescriptorName,
+// since systemd then uses the name of the socket unit, and we do not want to guess what that means. It
+// is also an error if there is a [Listener] section whose name is SystemdMainSocketName and a socket has
+// that name.
+func MatchSystemdListeners(
+	activated map[string][]net.Listener,
+	c config.Config,
+	loggers ldlog.Loggers,
+) (main []net.Listener, listeners map[string][]net.Listener, err error) {
+	names := make([]string, 0, len(activated))
+	for name := range activated {
+		names = append(names, name)
+	}
+	sort.Strings(names) // so that the log output and errors are predictable
+	listeners = make(map[string][]net.Listener)
+	for _, name := range names {
+		listenerConfig := c.Listener[name]
+		switch {
+		case name == SystemdMainSocketName && listenerConfig != nil:
+			return nil, nil, errSystemdSocketAmbiguous(name)
+		case name == SystemdMainSocketName:
+			replaced := fmt.Sprintf("port %d", c.Main.Port.GetOrElse(config.DefaultPort))
+			if c.Main.SocketPath != "" {
+				replaced = "Unix socket " + c.Main.SocketPath
+			}
+			for _, l := range activated[name] {
+				loggers.Infof("Using %s from systemd for the main server, instead of %s", describeListener(l), replaced)
+			}
+			main = append(main, activated[name]...)
+		case listenerConfig != nil:
+			for _, l := range activated[name] {
+				loggers.Infof("Using %s from systemd for listener %q, instead of port %d", describeListener(l), name,
+					listenerConfig.Port.GetOrElse(0))
+			}
+			listeners[name] = append(listeners[name], activated[name]...)
+		default:
+			return nil, nil, errSystemdSocketUnmatched(name)
+		}
+	}
+	return main, listeners, nil
+}
+
+func describeListener(listener net.Listener) string {
+	addr := listener.Addr()
+	if addr.Network() == "unix" {
+		return "Unix socket " + addr.String()
+	}
+	return addr.Network() + " " + addr.String()
+}
@@ -0,0 +1,146 @@
+package application
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+
+	ct "github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeSystemdEnv(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "relay.sock")
+
+	listener, err := ListenUnixSocket(path, 0640)
+	require.NoError(t, err)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	server, _ := StartHTTPServerOnListener(listener, httphelpers.HandlerWithStatus(http.StatusOK),
+		false, "", "", 0, HTTPServerOptions{}, ldlog.NewDisabledLoggers())
+	defer server.Close()
+
+	client := http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://relay/")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestListenUnixSocketReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "relay.sock")
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	listener, err := ListenUnixSocket(path, 0660)
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+}
+
+func TestListenUnixSocketDoesNotReplaceOtherFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "relay.sock")
+	require.NoError(t, os.WriteFile(path, []byte("x"), 0600))
+
+	_, err := ListenUnixSocket(path, 0660)
+	assert.Equal(t, errSocketPathNotSocket(path), err)
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+}
+
+func TestSystemdListenersNotActivated(t *testing.T) {
+	for _, vars := range []map[string]string{
+		{},
+		{systemdListenFDsVar: "1"},
+		{systemdListenPIDVar: "1", systemdListenFDsVar: "1"}, // meant for another process
+	} {
+		listeners, err := systemdListeners(makeSystemdEnv(vars), 2, systemdListenFDsStart)
+		require.NoError(t, err)
+		assert.Len(t, listeners, 0)
+	}
+}
+
+func TestSystemdListenersWithBadVariables(t *testing.T) {
+	for _, vars := range []map[string]string{
+		{systemdListenPIDVar: "x", systemdListenFDsVar: "1"},
+		{systemdListenPIDVar: "2", systemdListenFDsVar: "x"},
+		{systemdListenPIDVar: "2", systemdListenFDsVar: "-1"},
+	} {
+		_, err := systemdListeners(makeSystemdEnv(vars), 2, systemdListenFDsStart)
+		assert.Error(t, err)
+	}
+}
+
+func makeTestTCPListener(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	return listener
+}
+
+func TestMatchSystemdListeners(t *testing.T) {
+	var c config.Config
+	c.Main.Port, _ = ct.NewOptIntGreaterThanZero(8030)
+	internalPort, _ := ct.NewOptIntGreaterThanZero(8040)
+	c.Listener = map[string]*config.ListenerConfig{"internal": {Port: internalPort}}
+
+	t.Run("sockets are matched by name and replacements are logged", func(t *testing.T) {
+		mainListener, internalListener := makeTestTCPListener(t), makeTestTCPListener(t)
+		mockLog := ldlogtest.NewMockLog()
+		main, listeners, err := MatchSystemdListeners(map[string][]net.Listener{
+			SystemdMainSocketName: {mainListener},
+			"internal":            {internalListener},
+		}, c, mockLog.Loggers)
+		require.NoError(t, err)
+		assert.Equal(t, []net.Listener{mainListener}, main)
+		assert.Equal(t, map[string][]net.Listener{"internal": {internalListener}}, listeners)
+		mockLog.AssertMessageMatch(t, true, ldlog.Info,
+			"Using tcp "+mainListener.Addr().String()+" from systemd for the main server, instead of port 8030")
+		mockLog.AssertMessageMatch(t, true, ldlog.Info,
+			"Using tcp "+internalListener.Addr().String()+` from systemd for listener "internal", instead of port 8040`)
+	})
+
+	t.Run("no sockets", func(t *testing.T) {
+		main, listeners, err := MatchSystemdListeners(map[string][]net.Listener{}, c, ldlog.NewDisabledLoggers())
+		require.NoError(t, err)
+		assert.Len(t, main, 0)
+		assert.Len(t, listeners, 0)
+	})
+
+	t.Run("socket with no name or the name of the unit is an error", func(t *testing.T) {
+		for _, name := range []string{"", "ld-relay.socket"} {
+			_, _, err := MatchSystemdListeners(map[string][]net.Listener{name: {makeTestTCPListener(t)}}, c,
+				ldlog.NewDisabledLoggers())
+			assert.Equal(t, errSystemdSocketUnmatched(name), err)
+		}
+	})
+
+	t.Run("main socket with a listener named main is an error", func(t *testing.T) {
+		c1 := c
+		c1.Listener = map[string]*config.ListenerConfig{SystemdMainSocketName: {Port: internalPort}}
+		_, _, err := MatchSystemdListeners(map[string][]net.Listener{SystemdMainSocketName: {makeTestTCPListener(t)}},
+			c1, ldlog.NewDisabledLoggers())
+		assert.Equal(t, errSystemdSocketAmbiguous(SystemdMainSocketName), err)
+	})
+}
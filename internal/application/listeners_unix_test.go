@@ -0,0 +1,82 @@
+//go:build !windows
+
+package application
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dupFD returns a new file descriptor for the same file, which the caller (or systemdListeners) owns.
+// We can't control which descriptor numbers a test gets, so instead of starting at 3 as systemd does,
+// the tests pass the descriptor number explicitly.
+func dupFD(t *testing.T, f *os.File) int {
+	fd, err := syscall.Dup(int(f.Fd()))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	return fd
+}
+
+func TestSystemdListenersActivated(t *testing.T) {
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	f, err := tcpListener.(*net.TCPListener).File()
+	require.NoError(t, err)
+	require.NoError(t, tcpListener.Close())
+	fd := dupFD(t, f)
+
+	vars := map[string]string{
+		systemdListenPIDVar:     strconv.Itoa(os.Getpid()),
+		systemdListenFDsVar:     "1",
+		systemdListenFDNamesVar: "sdks",
+	}
+	listeners, err := systemdListeners(makeSystemdEnv(vars), os.Getpid(), fd)
+	require.NoError(t, err)
+	require.Len(t, listeners["sdks"], 1)
+
+	server, _ := StartHTTPServerOnListener(listeners["sdks"][0], httphelpers.HandlerWithStatus(http.StatusOK),
+		false, "", "", 0, HTTPServerOptions{}, ldlog.NewDisabledLoggers())
+	defer server.Close()
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/", listeners["sdks"][0].Addr()))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestSystemdListenersWithoutNames(t *testing.T) {
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	f, err := tcpListener.(*net.TCPListener).File()
+	require.NoError(t, err)
+	require.NoError(t, tcpListener.Close())
+	fd := dupFD(t, f)
+
+	vars := map[string]string{systemdListenPIDVar: "2", systemdListenFDsVar: "1"}
+	listeners, err := systemdListeners(makeSystemdEnv(vars), 2, fd)
+	require.NoError(t, err)
+	require.Len(t, listeners[""], 1)
+	assert.NoError(t, listeners[""][0].Close())
+}
+
+func TestSystemdListenersWithNonSocketDescriptor(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "not-a-socket"))
+	require.NoError(t, err)
+	fd := dupFD(t, f)
+
+	vars := map[string]string{systemdListenPIDVar: "2", systemdListenFDsVar: "1"}
+	_, err = systemdListeners(makeSystemdEnv(vars), 2, fd)
+	assert.Error(t, err)
+}
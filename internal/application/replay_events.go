@@ -0,0 +1,96 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/events"
+	"github.com/launchdarkly/ld-relay/v7/internal/httpconfig"
+	"github.com/launchdarkly/ld-relay/v7/relay/version"
+
+	ct "github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+)
+
+// replayFlushTimeout is how long ReplayEvents waits for the replayed events to be delivered.
+const replayFlushTimeout = 30 * time.Second
+
+// ReplayEvents reads a file containing event payloads that were retrieved from Relay's event capture
+// endpoint, and sends the analytics events again to the events URI in opts, going through the same
+// processing that Relay uses when it forwards events. A summary is written to out, and any errors are
+// written to errOut. The return value is false if the file could not be read, or if any events could not
+// be delivered.
+func ReplayEvents(opts Options, out, errOut io.Writer) bool {
+	data, err := os.ReadFile(opts.ReplayFile)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "Error reading event capture file: %s\n", err)
+		return false
+	}
+	var bundle events.EventCaptureBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		_, _ = fmt.Fprintf(errOut, "Error parsing event capture file: %s\n", err)
+		return false
+	}
+	eventsURI, err := ct.NewOptURLAbsoluteFromString(opts.ReplayEventsURI)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "Invalid events URI: %s\n", err)
+		return false
+	}
+
+	loggers := ldlog.NewDefaultLoggers()
+	loggers.SetBaseLogger(log.New(errOut, "", 0))
+	loggers.SetMinLevel(ldlog.Warn)
+	httpConfig, err := httpconfig.NewHTTPConfig(config.ProxyConfig{}, nil, "LDRelay/"+version.Version, loggers)
+	if err != nil { // COVERAGE: can't happen with an empty ProxyConfig
+		_, _ = fmt.Fprintf(errOut, "Error creating HTTP configuration: %s\n", err)
+		return false
+	}
+
+	var lock sync.Mutex
+	delivered, failed := 0, 0
+	dispatcher := events.NewEventDispatcher(
+		config.SDKKey(opts.ReplaySDKKey),
+		config.MobileKey(opts.ReplayMobileKey),
+		config.EnvironmentID(opts.ReplayEnvID),
+		loggers,
+		config.EventsConfig{EventsURI: eventsURI},
+		httpConfig,
+		nil,
+		events.EventDispatcherOptions{
+			OnEventsDelivered: func(destination string, eventCount int, success bool) {
+				lock.Lock()
+				defer lock.Unlock()
+				if success {
+					delivered += eventCount
+				} else {
+					failed += eventCount
+				}
+			},
+		},
+		0,
+	)
+	result := events.ReplayEventCapture(dispatcher, bundle, replayFlushTimeout)
+	dispatcher.Close()
+
+	lock.Lock()
+	defer lock.Unlock()
+	_, _ = fmt.Fprintf(out, "Replayed %d event payloads to %s: %d events delivered, %d events failed\n",
+		result.Replayed, opts.ReplayEventsURI, delivered, failed)
+	if result.Skipped > 0 {
+		_, _ = fmt.Fprintf(out, "Skipped %d diagnostic event payloads, payloads from SDKs with no credential, or payloads with an old event schema\n", result.Skipped)
+	}
+	if result.Rejected > 0 {
+		_, _ = fmt.Fprintf(out, "Rejected %d event payloads that were empty or not valid\n", result.Rejected)
+	}
+	if !result.Flushed {
+		_, _ = fmt.Fprintf(errOut, "Timed out waiting for events to be delivered\n")
+		return false
+	}
+	return failed == 0
+}
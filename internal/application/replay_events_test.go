@@ -0,0 +1,103 @@
+package application
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	helpers "github.com/launchdarkly/go-test-helpers/v3"
+	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
+	m "github.com/launchdarkly/go-test-helpers/v3/matchers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testEventCapture = `{
+	"environment": "earth",
+	"payloads": [
+		{
+			"sdkKind": "mobile",
+			"eventsKind": "analytics",
+			"headers": {"X-Launchdarkly-Event-Schema": ["4"], "X-Launchdarkly-Tags": ["application-id/app"]},
+			"body": "[{\"kind\":\"custom\",\"key\":\"a\",\"creationDate\":1000}]"
+		},
+		{
+			"sdkKind": "mobile",
+			"eventsKind": "diagnostic",
+			"body": "{\"kind\":\"diagnostic\"}"
+		}
+	]
+}`
+
+func replayEventsFile(t *testing.T, content string, eventsURI string) (bool, string, string) {
+	path := filepath.Join(t.TempDir(), "capture.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	opts := Options{
+		ReplayEvents:    true,
+		ReplayFile:      path,
+		ReplayEventsURI: eventsURI,
+		ReplaySDKKey:    defaultReplaySDKKey,
+		ReplayMobileKey: "mob-xyz",
+		ReplayEnvID:     defaultReplayEnvID,
+	}
+	var out, errOut bytes.Buffer
+	ok := ReplayEvents(opts, &out, &errOut)
+	return ok, out.String(), errOut.String()
+}
+
+func TestReplayEventsSendsCapturedEvents(t *testing.T) {
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(202))
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		ok, out, errOut := replayEventsFile(t, testEventCapture, server.URL)
+		require.True(t, ok, errOut)
+		assert.Contains(t, out, "Replayed 1 event payloads to "+server.URL+": 1 events delivered, 0 events failed")
+		assert.Contains(t, out, "Skipped 1 diagnostic event payloads")
+
+		r := helpers.RequireValue(t, requestsCh, time.Second)
+		assert.Equal(t, "/mobile", r.Request.URL.Path)
+		assert.Equal(t, "mob-xyz", r.Request.Header.Get("Authorization"))
+		assert.Equal(t, "application-id/app", r.Request.Header.Get("X-LaunchDarkly-Tags"))
+		m.In(t).Assert(r.Body, m.JSONStrEqual(`[{"kind":"custom","key":"a","creationDate":1000}]`))
+	})
+}
+
+func TestReplayEventsSkipsPayloadsWithOldEventSchema(t *testing.T) {
+	capture := `{
+	"environment": "earth",
+	"payloads": [
+		{
+			"sdkKind": "server",
+			"eventsKind": "analytics",
+			"headers": {"X-Launchdarkly-Event-Schema": ["2"]},
+			"body": "[{\"kind\":\"feature\",\"key\":\"flag\",\"user\":{\"key\":\"u\"},\"value\":true,\"creationDate\":1000}]"
+		}
+	]
+}`
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(202))
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		ok, out, errOut := replayEventsFile(t, capture, server.URL)
+		require.True(t, ok, errOut)
+		assert.Contains(t, out, "Replayed 0 event payloads to "+server.URL+": 0 events delivered, 0 events failed")
+		assert.Contains(t, out, "Skipped 1 ")
+		assert.Empty(t, errOut)
+		helpers.AssertNoMoreValues(t, requestsCh, time.Millisecond*100)
+	})
+}
+
+func TestReplayEventsReportsFailedDelivery(t *testing.T) {
+	httphelpers.WithServer(httphelpers.HandlerWithStatus(400), func(server *httptest.Server) {
+		ok, out, _ := replayEventsFile(t, testEventCapture, server.URL)
+		assert.False(t, ok)
+		assert.Contains(t, out, "0 events delivered, 1 events failed")
+	})
+}
+
+func TestReplayEventsReportsInvalidFile(t *testing.T) {
+	ok, _, errOut := replayEventsFile(t, "not JSON", "http://localhost:9999")
+	assert.False(t, ok)
+	assert.Contains(t, errOut, "Error parsing event capture file")
+}
@@ -3,14 +3,44 @@ package application
 import (
 	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
+	"runtime"
+	"strings"
 	"time"
 
 	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/util"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
+// HTTPServerOptions contains settings for StartHTTPServer and StartHTTPServerOnListener that apply to
+// the server as a whole. A zero value for any field means that the default is used.
+type HTTPServerOptions struct {
+	// ReadHeaderTimeout is the maximum time for reading the request headers. The default is
+	// config.DefaultReadHeaderTimeout.
+	ReadHeaderTimeout time.Duration
+	// IdleTimeout is the maximum time that a keep-alive connection can wait for the next request. The
+	// default is no limit.
+	IdleTimeout time.Duration
+	// MaxHeaderBytes is the maximum total size of the request headers. The default is
+	// http.DefaultMaxHeaderBytes.
+	MaxHeaderBytes int
+}
+
+// MakeHTTPServerOptions returns the HTTPServerOptions that correspond to the configuration.
+func MakeHTTPServerOptions(c config.MainConfig) HTTPServerOptions {
+	return HTTPServerOptions{
+		ReadHeaderTimeout: c.ReadHeaderTimeout.GetOrElse(0),
+		IdleTimeout:       c.IdleTimeout.GetOrElse(0),
+		MaxHeaderBytes:    c.MaxHeaderBytes.GetOrElse(0),
+	}
+}
+
 // StartHTTPServer starts the server, with or without TLS. It returns immediately, starting the server
 // on a separate goroutine; if the server fails to start up, it sends an error to the error channel.
 func StartHTTPServer(
@@ -19,12 +49,95 @@ func StartHTTPServer(
 	tlsEnabled bool,
 	tlsCertFile, tlsKeyFile string,
 	tlsMinVersion uint16,
+	options HTTPServerOptions,
+	loggers ldlog.Loggers,
+) (*http.Server, <-chan error) {
+	srv := makeHTTPServer(fmt.Sprintf(":%d", port), handler, tlsEnabled, tlsMinVersion, options)
+	errCh := serveHTTP(srv, nil, fmt.Sprintf("port %d", port), tlsEnabled, tlsCertFile, tlsKeyFile, tlsMinVersion, loggers)
+	return srv, errCh
+}
+
+// StartHTTPServerOnListener is the same as StartHTTPServer, except that it uses a listener that has
+// already been created, such as a Unix domain socket from ListenUnixSocket or a socket that was passed
+// to Relay by systemd (see SystemdListeners), instead of listening on a TCP port.
+func StartHTTPServerOnListener(
+	listener net.Listener,
+	handler http.Handler,
+	tlsEnabled bool,
+	tlsCertFile, tlsKeyFile string,
+	tlsMinVersion uint16,
+	options HTTPServerOptions,
 	loggers ldlog.Loggers,
 ) (*http.Server, <-chan error) {
+	srv := makeHTTPServer(listener.Addr().String(), handler, tlsEnabled, tlsMinVersion, options)
+	errCh := serveHTTP(srv, listener, describeListener(listener), tlsEnabled, tlsCertFile, tlsKeyFile, tlsMinVersion, loggers)
+	return srv, errCh
+}
+
+// H2CHandler wraps a handler so that the server will also accept HTTP/2 connections without TLS ("h2c"),
+// either with prior knowledge or by upgrading from HTTP/1.1. This allows a proxy that has already
+// terminated TLS, such as a service mesh sidecar, to multiplex many stream connections over a few TCP
+// connections. Requests that use HTTP/1.x are passed through unchanged.
+//
+// Since an h2c connection is shared by many requests, the request that upgrades it is not allowed to
+// set deadlines for it (see middleware.RequestLimits); the HTTP/1.1 request that asks for the upgrade
+// is served as the first HTTP/2 request, so it would otherwise look like it owned the connection. Any
+// deadlines that are already set are cleared before the connection is taken over.
+//
+// The HTTP/2 server applies options.IdleTimeout to these connections, since it does not use the settings
+// of the http.Server. A connection has no open streams until the client has sent all of a request's
+// headers, so the idle limit also limits how long a client can take to send them; if options.IdleTimeout
+// is zero, the read header timeout (by default config.DefaultReadHeaderTimeout) is used instead. The read
+// and write timeouts of middleware.RequestLimits apply to each HTTP/2 request's own stream.
+func H2CHandler(handler http.Handler, options HTTPServerOptions) http.Handler {
+	idleTimeout := options.IdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = options.ReadHeaderTimeout
+		if idleTimeout <= 0 {
+			idleTimeout = config.DefaultReadHeaderTimeout
+		}
+	}
+	h2cHandler := h2c.NewHandler(handler, &http2.Server{IdleTimeout: idleTimeout})
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == "PRI" || strings.EqualFold(req.Header.Get("Upgrade"), "h2c") {
+			util.ClearConnDeadlines(req)
+			req = req.WithContext(util.WithoutConn(req.Context()))
+		}
+		if strings.EqualFold(req.Header.Get("Upgrade"), "h2c") {
+			// If a client upgrades a connection but then never sends the HTTP/2 connection preface, the
+			// HTTP/2 server dereferences its idle timer before creating it when it closes the connection. The
+			// connection has already been hijacked and closed by then, so there is nothing left to clean up.
+			defer func() {
+				if r := recover(); r != nil {
+					if _, ok := r.(runtime.Error); !ok {
+						panic(r)
+					}
+				}
+			}()
+		}
+		h2cHandler.ServeHTTP(w, req)
+	})
+}
+
+func makeHTTPServer(
+	addr string,
+	handler http.Handler,
+	tlsEnabled bool,
+	tlsMinVersion uint16,
+	options HTTPServerOptions,
+) *http.Server {
+	readHeaderTimeout := options.ReadHeaderTimeout
+	if readHeaderTimeout <= 0 {
+		readHeaderTimeout = config.DefaultReadHeaderTimeout
+	}
 	srv := &http.Server{
-		Addr:              fmt.Sprintf(":%d", port),
+		Addr:              addr,
 		Handler:           handler,
-		ReadHeaderTimeout: 10 * time.Second,
+		ReadHeaderTimeout: readHeaderTimeout,
+		IdleTimeout:       options.IdleTimeout,
+		MaxHeaderBytes:    options.MaxHeaderBytes,
+		// This allows handlers to set deadlines for the connection; see middleware.RequestLimits.
+		ConnContext: util.WithConn,
 	}
 
 	if tlsEnabled && tlsMinVersion != 0 {
@@ -32,26 +145,47 @@ func StartHTTPServer(
 			MinVersion: tlsMinVersion,
 		}
 	}
+	return srv
+}
 
+// serveHTTP runs the server on a separate goroutine, either on the specified listener or, if that is
+// nil, on the server's address.
+func serveHTTP(
+	srv *http.Server,
+	listener net.Listener,
+	description string,
+	tlsEnabled bool,
+	tlsCertFile, tlsKeyFile string,
+	tlsMinVersion uint16,
+	loggers ldlog.Loggers,
+) <-chan error {
 	errCh := make(chan error)
 
 	go func() {
 		var err error
-		loggers.Infof("Starting server listening on port %d\n", port)
+		loggers.Infof("Starting server listening on %s\n", description)
 		if tlsEnabled {
 			message := "TLS enabled for server"
 			if tlsMinVersion != 0 {
 				message += fmt.Sprintf(" (minimum TLS version: %s)", config.NewOptTLSVersion(tlsMinVersion).String())
 			}
 			loggers.Info(message)
-			err = srv.ListenAndServeTLS(tlsCertFile, tlsKeyFile)
+			if listener == nil {
+				err = srv.ListenAndServeTLS(tlsCertFile, tlsKeyFile)
+			} else {
+				err = srv.ServeTLS(listener, tlsCertFile, tlsKeyFile)
+			}
 		} else {
-			err = srv.ListenAndServe()
+			if listener == nil {
+				err = srv.ListenAndServe()
+			} else {
+				err = srv.Serve(listener)
+			}
 		}
 		if err != nil {
 			errCh <- err
 		}
 	}()
 
-	return srv, errCh
+	return errCh
 }
@@ -1,17 +1,24 @@
 package application
 
 import (
+	"bufio"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	st "github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
+	"github.com/launchdarkly/ld-relay/v7/internal/util"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
@@ -20,6 +27,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
 )
 
 func withSelfSignedCert(t *testing.T, action func(certFilePath, keyFilePath string, certPool *x509.CertPool)) {
@@ -43,7 +51,7 @@ func withSelfSignedCert(t *testing.T, action func(certFilePath, keyFilePath stri
 func TestStartHTTPServerInsecure(t *testing.T) {
 	port := st.GetAvailablePort(t)
 	mockLog := ldlogtest.NewMockLog()
-	server, errCh := StartHTTPServer(port, httphelpers.HandlerWithStatus(http.StatusOK), false, "", "", 0, mockLog.Loggers)
+	server, errCh := StartHTTPServer(port, httphelpers.HandlerWithStatus(http.StatusOK), false, "", "", 0, HTTPServerOptions{}, mockLog.Loggers)
 	require.NotNil(t, server)
 	require.NotNil(t, errCh)
 	require.Eventually(t, func() bool {
@@ -61,7 +69,7 @@ func TestStartHTTPServerSecure(t *testing.T) {
 
 	withSelfSignedCert(t, func(certFilePath, keyFilePath string, certPool *x509.CertPool) {
 		server, errCh := StartHTTPServer(port, httphelpers.HandlerWithStatus(http.StatusOK),
-			true, certFilePath, keyFilePath, 0, mockLog.Loggers)
+			true, certFilePath, keyFilePath, 0, HTTPServerOptions{}, mockLog.Loggers)
 		require.NotNil(t, server)
 		require.NotNil(t, errCh)
 
@@ -87,7 +95,7 @@ func TestStartHTTPServerSecureWithMinTLSVersion(t *testing.T) {
 
 	withSelfSignedCert(t, func(certFilePath, keyFilePath string, certPool *x509.CertPool) {
 		server, errCh := StartHTTPServer(port, httphelpers.HandlerWithStatus(http.StatusOK),
-			true, certFilePath, keyFilePath, tls.VersionTLS12, mockLog.Loggers)
+			true, certFilePath, keyFilePath, tls.VersionTLS12, HTTPServerOptions{}, mockLog.Loggers)
 		require.NotNil(t, server)
 		require.NotNil(t, errCh)
 
@@ -112,9 +120,133 @@ func TestStartHTTPServerSecureWithMinTLSVersion(t *testing.T) {
 
 func TestStartHTTPServerPortAlreadyUsed(t *testing.T) {
 	st.WithListenerForAnyPort(t, func(l net.Listener, port int) {
-		_, errCh := StartHTTPServer(port, httphelpers.HandlerWithStatus(200), false, "", "", 0, ldlog.NewDisabledLoggers())
+		_, errCh := StartHTTPServer(port, httphelpers.HandlerWithStatus(200), false, "", "", 0, HTTPServerOptions{}, ldlog.NewDisabledLoggers())
 		require.NotNil(t, errCh)
 		err := helpers.RequireValue(t, errCh, time.Second, "timed out waiting for error")
 		assert.NotNil(t, err)
 	})
 }
+
+func TestH2CHandler(t *testing.T) {
+	handler := H2CHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "%s", r.Proto)
+	}), HTTPServerOptions{})
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		t.Run("HTTP/2 with prior knowledge", func(t *testing.T) {
+			resp, err := st.MakeH2CClient().Get(server.URL)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, 2, resp.ProtoMajor)
+			assert.Equal(t, "HTTP/2.0", string(body))
+		})
+
+		t.Run("HTTP/1.1", func(t *testing.T) {
+			resp, err := http.Get(server.URL)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, 1, resp.ProtoMajor)
+			assert.Equal(t, "HTTP/1.1", string(body))
+		})
+	})
+}
+
+func TestH2CHandlerDoesNotLetUpgradeRequestSetDeadlines(t *testing.T) {
+	connCh := make(chan net.Conn, 1)
+	handler := H2CHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connCh <- util.GetRequestConn(r)
+	}), HTTPServerOptions{})
+	var errorLog strings.Builder
+	var errorLogLock sync.Mutex
+	server := httptest.NewUnstartedServer(handler)
+	server.Config.ConnContext = util.WithConn
+	server.Config.ErrorLog = log.New(writerFunc(func(p []byte) (int, error) {
+		errorLogLock.Lock()
+		defer errorLogLock.Unlock()
+		return errorLog.Write(p)
+	}), "", 0)
+	server.Start()
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	_, err = io.WriteString(conn, "GET / HTTP/1.1\r\nHost: localhost\r\n"+
+		"Connection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: \r\n\r\n")
+	require.NoError(t, err)
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	// The upgrade request is served as HTTP/1.1, but on what is now a shared HTTP/2 connection
+	assert.Nil(t, helpers.RequireValue(t, connCh, time.Second, "timed out waiting for upgrade request"))
+
+	// Closing the connection without ever sending the HTTP/2 preface must not cause a panic in the server
+	require.NoError(t, conn.Close())
+	time.Sleep(time.Millisecond * 100)
+	errorLogLock.Lock()
+	defer errorLogLock.Unlock()
+	assert.Empty(t, errorLog.String())
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+func TestH2CHandlerClosesIdleConnection(t *testing.T) {
+	handler := H2CHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		HTTPServerOptions{IdleTimeout: time.Millisecond * 100})
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		conn, err := net.Dial("tcp", server.Listener.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, err = io.WriteString(conn, http2.ClientPreface)
+		require.NoError(t, err)
+		framer := http2.NewFramer(conn, conn)
+		require.NoError(t, framer.WriteSettings())
+
+		// Read whatever the server sends (settings, and a GOAWAY when it gives up on the idle connection)
+		// until it closes the connection; the read deadline is only there so the test can't hang.
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second*5)))
+		for {
+			if _, err = framer.ReadFrame(); err != nil {
+				break
+			}
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			assert.Fail(t, "server did not close idle HTTP/2 connection")
+		}
+	})
+}
+
+func TestH2CHandlerFlushesStreamedResponse(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	handler := H2CHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: put\ndata: {}\n\n")
+		w.(http.Flusher).Flush()
+		select { // keep the stream open, so the client can only see the event if it was flushed
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}), HTTPServerOptions{})
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		resp, err := st.MakeH2CClient().Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, 2, resp.ProtoMajor)
+
+		lines := make(chan string, 10)
+		go func() {
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				lines <- scanner.Text()
+			}
+		}()
+		assert.Equal(t, "event: put", helpers.RequireValue(t, lines, time.Second, "timed out waiting for flushed event"))
+	})
+}
@@ -0,0 +1,199 @@
+package auditlog
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/util"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+)
+
+// Action is the type of change described by an Entry.
+type Action string
+
+// Source describes where Relay got the information described by an Entry.
+type Source string
+
+const (
+	// ActionPut means that Relay received a full set of data for an environment. There is one Entry for
+	// each flag or segment in the data.
+	ActionPut Action = "put"
+	// ActionPatch means that Relay received an updated version of a single flag or segment.
+	ActionPatch Action = "patch"
+	// ActionDelete means that Relay received a deletion of a single flag or segment.
+	ActionDelete Action = "delete"
+	// ActionAddEnvironment means that an environment was added.
+	ActionAddEnvironment Action = "addEnvironment"
+	// ActionUpdateEnvironment means that an environment's properties were changed.
+	ActionUpdateEnvironment Action = "updateEnvironment"
+	// ActionDeleteEnvironment means that an environment was removed.
+	ActionDeleteEnvironment Action = "deleteEnvironment"
+	// ActionAddCredential means that a new SDK key or mobile key was added to an environment.
+	ActionAddCredential Action = "addCredential"
+	// ActionDeprecateCredential means that an SDK key was replaced but will still be accepted until
+	// it expires.
+	ActionDeprecateCredential Action = "deprecateCredential"
+	// ActionRemoveCredential means that an SDK key or mobile key is no longer accepted.
+	ActionRemoveCredential Action = "removeCredential"
+
+	// SourceStream means that the data came from a streaming connection to LaunchDarkly (or to another
+	// Relay instance that Relay is configured to use instead).
+	SourceStream Source = "stream"
+	// SourceFileData means that the data came from an offline mode data archive file.
+	SourceFileData Source = "fileData"
+	// SourceAutoConfig means that the change came from the auto-configuration stream.
+	SourceAutoConfig Source = "autoConfig"
+	// SourceSecretFile means that the change came from a file that a credential in the configuration
+	// refers to.
+	SourceSecretFile Source = "secretFile"
+)
+
+// Environment identifies the environment in an Entry.
+type Environment struct {
+	Name string
+	ID   config.EnvironmentID
+}
+
+// Entry is a single record in the audit log. It is written as one line of JSON.
+type Entry struct {
+	Timestamp  time.Time            `json:"timestamp"`
+	Action     Action               `json:"action"`
+	Source     Source               `json:"source"`
+	EnvName    string               `json:"envName,omitempty"`
+	EnvID      config.EnvironmentID `json:"envId,omitempty"`
+	Kind       string               `json:"kind,omitempty"`
+	Key        string               `json:"key,omitempty"`
+	Version    int                  `json:"version,omitempty"`
+	Deleted    bool                 `json:"deleted,omitempty"`
+	Credential string               `json:"credential,omitempty"`
+}
+
+// Log writes audit log entries to a file or to standard output. It is safe for concurrent use.
+type Log struct {
+	writer  io.WriteCloser
+	loggers ldlog.Loggers
+	lock    sync.Mutex
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// NewLog creates a Log based on the configuration. It returns nil if the audit log is not enabled.
+func NewLog(c config.AuditLogConfig, loggers ldlog.Loggers) (*Log, error) {
+	switch {
+	case c.File != "":
+		w, err := util.NewRotatingFile(
+			c.File,
+			int64(c.MaxFileSize.GetOrElse(config.DefaultAuditLogMaxFileSize)),
+			c.MaxBackups.GetOrElse(config.DefaultAuditLogMaxBackups),
+			0,
+		)
+		if err != nil {
+			return nil, err
+		}
+		loggers.Infof("Writing audit log to %s", c.File)
+		return newLog(w, loggers), nil
+	case c.Stdout:
+		loggers.Info("Writing audit log to standard output")
+		return newLog(nopCloser{os.Stdout}, loggers), nil
+	default:
+		return nil, nil
+	}
+}
+
+func newLog(w io.WriteCloser, loggers ldlog.Loggers) *Log {
+	return &Log{writer: w, loggers: loggers}
+}
+
+// DataReceived records a full set of flag and segment data for an environment.
+func (l *Log) DataReceived(env Environment, source Source, allData []ldstoretypes.Collection) {
+	now := time.Now()
+	entries := make([]Entry, 0)
+	for _, coll := range allData {
+		for _, item := range coll.Items {
+			entries = append(entries, Entry{
+				Timestamp: now,
+				Action:    ActionPut,
+				Source:    source,
+				EnvName:   env.Name,
+				EnvID:     env.ID,
+				Kind:      coll.Kind.GetName(),
+				Key:       item.Key,
+				Version:   item.Item.Version,
+				Deleted:   item.Item.Item == nil,
+			})
+		}
+	}
+	l.write(entries...)
+}
+
+// ItemReceived records an update or deletion of a single flag or segment.
+func (l *Log) ItemReceived(
+	env Environment,
+	source Source,
+	kind ldstoretypes.DataKind,
+	key string,
+	item ldstoretypes.ItemDescriptor,
+) {
+	action := ActionPatch
+	if item.Item == nil {
+		action = ActionDelete
+	}
+	l.write(Entry{
+		Timestamp: time.Now(),
+		Action:    action,
+		Source:    source,
+		EnvName:   env.Name,
+		EnvID:     env.ID,
+		Kind:      kind.GetName(),
+		Key:       key,
+		Version:   item.Version,
+		Deleted:   item.Item == nil,
+	})
+}
+
+// EnvironmentChanged records a change to an environment's configuration. The credential parameter is
+// only used for credential actions, and should already be obscured by the caller.
+func (l *Log) EnvironmentChanged(action Action, env Environment, source Source, credential string) {
+	l.write(Entry{
+		Timestamp:  time.Now(),
+		Action:     action,
+		Source:     source,
+		EnvName:    env.Name,
+		EnvID:      env.ID,
+		Credential: credential,
+	})
+}
+
+// Close closes the underlying file, if any.
+func (l *Log) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.writer.Close()
+}
+
+func (l *Log) write(entries ...Entry) {
+	if len(entries) == 0 {
+		return
+	}
+	var buf []byte
+	for _, e := range entries {
+		data, _ := json.Marshal(e)
+		buf = append(buf, data...)
+		buf = append(buf, '\n')
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if _, err := l.writer.Write(buf); err != nil {
+		l.loggers.Errorf("Error writing to audit log: %s", err)
+	}
+}
@@ -0,0 +1,118 @@
+package auditlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testEnv = Environment{Name: "my-env", ID: config.EnvironmentID("env-id")} //nolint:gochecknoglobals
+
+type bufferCloser struct {
+	bytes.Buffer
+}
+
+func (b *bufferCloser) Close() error { return nil }
+
+func parseEntries(t *testing.T, data string) []Entry {
+	var ret []Entry
+	for _, line := range strings.Split(strings.TrimSuffix(data, "\n"), "\n") {
+		var e Entry
+		require.NoError(t, json.Unmarshal([]byte(line), &e))
+		assert.False(t, e.Timestamp.IsZero())
+		ret = append(ret, e)
+	}
+	return ret
+}
+
+func TestNewLogReturnsNilIfNotEnabled(t *testing.T) {
+	log, err := NewLog(config.AuditLogConfig{}, ldlog.NewDisabledLoggers())
+	require.NoError(t, err)
+	assert.Nil(t, log)
+}
+
+func TestDataReceived(t *testing.T) {
+	buf := &bufferCloser{}
+	log := newLog(buf, ldlog.NewDisabledLoggers())
+	flag := ldbuilders.NewFlagBuilder("flag1").Version(42).Build()
+	segment := ldbuilders.NewSegmentBuilder("segment1").Version(3).Build()
+	log.DataReceived(testEnv, SourceStream, []ldstoretypes.Collection{
+		{Kind: ldstoreimpl.Features(), Items: []ldstoretypes.KeyedItemDescriptor{
+			{Key: flag.Key, Item: sharedtest.FlagDesc(flag)},
+			{Key: "flag2", Item: sharedtest.DeletedItem(7)},
+		}},
+		{Kind: ldstoreimpl.Segments(), Items: []ldstoretypes.KeyedItemDescriptor{
+			{Key: segment.Key, Item: sharedtest.SegmentDesc(segment)},
+		}},
+	})
+
+	entries := parseEntries(t, buf.String())
+	require.Len(t, entries, 3)
+	expected := []Entry{
+		{Action: ActionPut, Source: SourceStream, EnvName: "my-env", EnvID: "env-id", Kind: "features", Key: "flag1", Version: 42},
+		{Action: ActionPut, Source: SourceStream, EnvName: "my-env", EnvID: "env-id", Kind: "features", Key: "flag2", Version: 7, Deleted: true},
+		{Action: ActionPut, Source: SourceStream, EnvName: "my-env", EnvID: "env-id", Kind: "segments", Key: "segment1", Version: 3},
+	}
+	for i := range entries {
+		expected[i].Timestamp = entries[i].Timestamp
+	}
+	assert.Equal(t, expected, entries)
+}
+
+func TestItemReceived(t *testing.T) {
+	buf := &bufferCloser{}
+	log := newLog(buf, ldlog.NewDisabledLoggers())
+	flag := ldbuilders.NewFlagBuilder("flag1").Version(42).Build()
+	log.ItemReceived(testEnv, SourceFileData, ldstoreimpl.Features(), flag.Key, sharedtest.FlagDesc(flag))
+	log.ItemReceived(testEnv, SourceFileData, ldstoreimpl.Segments(), "segment1", sharedtest.DeletedItem(4))
+
+	entries := parseEntries(t, buf.String())
+	require.Len(t, entries, 2)
+	expected := []Entry{
+		{Action: ActionPatch, Source: SourceFileData, EnvName: "my-env", EnvID: "env-id", Kind: "features", Key: "flag1", Version: 42},
+		{Action: ActionDelete, Source: SourceFileData, EnvName: "my-env", EnvID: "env-id", Kind: "segments", Key: "segment1", Version: 4, Deleted: true},
+	}
+	for i := range entries {
+		expected[i].Timestamp = entries[i].Timestamp
+	}
+	assert.Equal(t, expected, entries)
+}
+
+func TestEnvironmentChanged(t *testing.T) {
+	buf := &bufferCloser{}
+	log := newLog(buf, ldlog.NewDisabledLoggers())
+	log.EnvironmentChanged(ActionRemoveCredential, testEnv, SourceAutoConfig, "sdk-****1234")
+
+	entries := parseEntries(t, buf.String())
+	require.Len(t, entries, 1)
+	assert.Equal(t, Entry{Timestamp: entries[0].Timestamp, Action: ActionRemoveCredential, Source: SourceAutoConfig,
+		EnvName: "my-env", EnvID: "env-id", Credential: "sdk-****1234"}, entries[0])
+}
+
+func TestLogToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	log, err := NewLog(config.AuditLogConfig{File: path}, ldlog.NewDisabledLoggers())
+	require.NoError(t, err)
+	log.EnvironmentChanged(ActionAddEnvironment, testEnv, SourceAutoConfig, "")
+	require.NoError(t, log.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	entries := parseEntries(t, string(data))
+	require.Len(t, entries, 1)
+	assert.Equal(t, ActionAddEnvironment, entries[0].Action)
+}
@@ -0,0 +1,3 @@
+// Package auditlog contains the internal implementation of Relay's audit log, which records the data
+// changes and environment configuration changes that Relay has received.
+package auditlog
@@ -0,0 +1,100 @@
+package configdump
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/sdks"
+
+	ct "github.com/launchdarkly/go-configtypes"
+)
+
+const obscuredSecret = "********"
+
+// Write writes a Config in the configuration file format, omitting properties that are not set.
+// Credentials are obscured, as are passwords in URLs.
+//
+// If the Config records where its properties came from (see config.TrackPropertySource), each line has
+// a comment saying whether the value came from the file, an environment variable, or the defaults.
+func Write(c config.Config, out io.Writer) {
+	var lines []string
+	currentSection := ""
+	wroteSection := false
+	flush := func() {
+		if len(lines) == 0 {
+			return
+		}
+		if wroteSection {
+			_, _ = fmt.Fprintln(out)
+		}
+		wroteSection = true
+		_, _ = fmt.Fprintf(out, "[%s]\n", currentSection)
+		for _, line := range lines {
+			_, _ = fmt.Fprintln(out, line)
+		}
+		lines = nil
+	}
+	config.VisitProperties(c, func(section, property string, value reflect.Value) {
+		if section != currentSection {
+			flush()
+			currentSection = section
+		}
+		if value.IsZero() {
+			return
+		}
+		source := c.GetPropertySource(section, property)
+		for _, s := range formatValue(value) {
+			if config.IsCredentialProperty(section, property) {
+				s = obscureCredential(value, s)
+			}
+			line := fmt.Sprintf("%s = %s", property, s)
+			if source != "" {
+				line += " ; " + string(source)
+			}
+			lines = append(lines, line)
+		}
+	})
+	flush()
+}
+
+// formatValue returns the string representation of a property. It returns more than one string for a
+// property that has multiple values, which is written as repeated lines in the configuration file format.
+func formatValue(value reflect.Value) []string {
+	switch v := value.Interface().(type) {
+	case ct.OptStringList:
+		return v.Values()
+	case []string:
+		return v
+	case ct.OptURLAbsolute:
+		return []string{redactURL(v.Get())}
+	case fmt.Stringer:
+		return []string{v.String()}
+	}
+	return []string{fmt.Sprint(value.Interface())}
+}
+
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.Redacted()
+}
+
+// obscureCredential uses sdks.ObscureKey for LaunchDarkly keys, which have a known format. For a list of
+// headers in the format "Name: value", such as Proxy.Headers, it hides only the value. Otherwise it hides
+// the whole value.
+func obscureCredential(value reflect.Value, s string) string {
+	switch value.Interface().(type) {
+	case config.SDKKey, config.MobileKey, config.AutoConfigKey:
+		return sdks.ObscureKey(s)
+	case ct.OptStringList:
+		if name, _, ok := strings.Cut(s, ":"); ok {
+			return name + ": " + obscuredSecret
+		}
+	}
+	return obscuredSecret
+}
@@ -0,0 +1,95 @@
+package configdump
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+
+	ct "github.com/launchdarkly/go-configtypes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteConfigWithoutSources(t *testing.T) {
+	redisURL, err := ct.NewOptURLAbsoluteFromString("redis://:pass@localhost:6379")
+	require.NoError(t, err)
+	c := config.Config{
+		Main:        config.MainConfig{ExitOnError: true, AdminKey: "admin-secret"},
+		AutoConfig:  config.AutoConfigConfig{Key: "rel-12345678-abcd-ef01-2345-6789abcdef01"},
+		Redis:       config.RedisConfig{URL: redisURL, Password: "pass"},
+		Webhooks:    config.WebhooksConfig{Secret: "webhook-secret"},
+		Environment: map[string]*config.EnvConfig{"earth": {EnvID: "env-id", WebhookSecret: "env-webhook-secret"}},
+	}
+	c.Datadog.Tag = []string{"a:1", "b:2"}
+
+	var buf bytes.Buffer
+	Write(c, &buf)
+	assert.Equal(t, `[Main]
+ExitOnError = true
+AdminKey = ********
+
+[AutoConfig]
+Key = rel-********-****-****-****-*******def01
+
+[Webhooks]
+Secret = ********
+
+[Redis]
+URL = redis://:xxxxx@localhost:6379
+Password = ********
+
+[Environment "earth"]
+EnvID = env-id
+WebhookSecret = ********
+
+[Datadog]
+Tag = a:1
+Tag = b:2
+`, buf.String())
+}
+
+func TestWriteConfigObscuresEventDestinationKeys(t *testing.T) {
+	c := config.Config{
+		EventDestination: map[string]*config.EventDestinationConfig{
+			"backup": {
+				Environment: "earth",
+				SDKKey:      "sdk-99999999-abcd-ef01-2345-6789abcdef01",
+				MobileKey:   "mob-99999999-abcd-ef01-2345-6789abcdef01",
+				EnvID:       "env-id",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	Write(c, &buf)
+	assert.NotContains(t, buf.String(), "99999999")
+	assert.Equal(t, `[EventDestination "backup"]
+Environment = earth
+SDKKey = sdk-********-****-****-****-*******def01
+MobileKey = mob-********-****-****-****-*******def01
+EnvID = env-id
+`, buf.String())
+}
+
+func TestWriteConfigObscuresProxyHeaderValues(t *testing.T) {
+	c := config.Config{
+		Proxy: config.ProxyConfig{
+			Headers: ct.NewOptStringList([]string{"Authorization: Bearer supersecrettoken", "X-Egress-Tenant: tenant1"}),
+		},
+	}
+
+	var buf bytes.Buffer
+	Write(c, &buf)
+	assert.Equal(t, `[Proxy]
+Headers = Authorization: ********
+Headers = X-Egress-Tenant: ********
+`, buf.String())
+}
+
+func TestWriteEmptyConfig(t *testing.T) {
+	var buf bytes.Buffer
+	Write(config.Config{}, &buf)
+	assert.Equal(t, "", buf.String())
+}
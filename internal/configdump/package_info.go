@@ -0,0 +1,3 @@
+// Package configdump contains the internal implementation of displaying Relay's effective
+// configuration, for the check-config command, the --print-config option, and the admin endpoint.
+package configdump
@@ -2,6 +2,7 @@ package events
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"reflect"
@@ -20,16 +21,73 @@ import (
 )
 
 type eventVerbatimRelay struct {
-	config    c.EventsConfig
-	publisher EventPublisher
+	config     c.EventsConfig
+	publisher  EventPublisher
+	aggregator *eventAggregator
 }
 
 const defaultEventQueueCleanupInterval = time.Hour
 
+// PrimaryEventDestinationName is the destination name that EventDispatcherOptions.OnEventsDelivered
+// receives for events that are forwarded with the environment's own credentials.
+const PrimaryEventDestinationName = "primary"
+
+// EventDestination is an additional place that analytics events are forwarded to, with its own
+// credentials, besides the environment's own credentials and events URI. Each destination has its own
+// queues and retries, so a destination that is slow or unavailable does not hold up the others.
+//
+// Events from each kind of SDK are forwarded only if the destination has the corresponding credential:
+// for instance, events from mobile SDKs are forwarded only if MobileKey is set.
+type EventDestination struct {
+	Name      string
+	EventsURI string // if empty, the events URI from the EventsConfig is used
+	SDKKey    c.SDKKey
+	MobileKey c.MobileKey
+	EnvID     c.EnvironmentID
+	Loggers   ldlog.Loggers
+}
+
+// EventDispatcherOptions contains optional parameters for NewEventDispatcher.
+type EventDispatcherOptions struct {
+	// DisableForwarding prevents events from being forwarded to LaunchDarkly, so that they are only
+	// delivered to Sinks. This is used in offline mode, or if event forwarding is turned off.
+	DisableForwarding bool
+
+	// Sinks are additional destinations for events. Any sinks that do not accept SinkEnvironment are
+	// ignored.
+	Sinks []EventSink
+
+	// SinkEnvironment identifies the environment to the sinks.
+	SinkEnvironment EventSinkEnvironment
+
+	// Filter, if not nil, is applied to all analytics events before they are delivered to either
+	// LaunchDarkly or the sinks.
+	Filter *EventFilter
+
+	// Sampler, if not nil, is applied to analytics events before they are forwarded to LaunchDarkly. It
+	// does not affect the events that are delivered to the sinks.
+	Sampler *EventSampler
+
+	// OnEventsSampledOut, if not nil, is called with the number of events of each kind that Sampler
+	// discarded from a request.
+	OnEventsSampledOut func(req *http.Request, sdkKind basictypes.SDKKind, countsByKind map[string]int)
+
+	// Destinations are additional places that analytics events are forwarded to, unless
+	// DisableForwarding is set. Filter and Sampler apply to these the same as to LaunchDarkly.
+	Destinations []EventDestination
+
+	// OnEventsDelivered, if not nil, is called after each payload of analytics events has either been
+	// delivered to a destination or failed. For the environment's own credentials, the destination name is
+	// PrimaryEventDestinationName.
+	OnEventsDelivered func(destination string, eventCount int, success bool)
+}
+
 // EventDispatcher relays events to LaunchDarkly for an environment
 type EventDispatcher struct {
 	analyticsEndpoints  map[basictypes.SDKKind]*analyticsEventEndpointDispatcher
 	diagnosticEndpoints map[basictypes.SDKKind]*diagnosticEventEndpointDispatcher
+	rejectedEvents      *rejectedEventLog
+	capture             *eventCapture
 }
 
 type analyticsEventEndpointDispatcher struct {
@@ -38,20 +96,49 @@ type analyticsEventEndpointDispatcher struct {
 	httpConfig                httpconfig.HTTPConfig
 	authKey                   c.SDKCredential
 	remotePath                string
+	sendPolicy                EventSendPolicy
 	verbatimRelay             *eventVerbatimRelay
 	summarizingRelay          *eventSummarizingRelay
 	storeAdapter              *store.SSERelayDataStoreAdapter
 	eventQueueCleanupInterval time.Duration
+	forward                   bool
+	sinkPublishers            []EventPublisher
+	filter                    *EventFilter
+	sampler                   *EventSampler
+	onSampledOut              func(req *http.Request, countsByKind map[string]int)
+	destinations              []*analyticsEventEndpointDispatcher
+	sdkKind                   basictypes.SDKKind
+	rejectedEvents            *rejectedEventLog // nil if validation is not enabled
+	capture                   *eventCapture
 	loggers                   ldlog.Loggers
 	mu                        sync.Mutex
 }
 
+// diagnosticEventEndpointDispatcher forwards diagnostic events on a background goroutine, so that a slow
+// response from LaunchDarkly does not hold up the SDK's request. Payloads wait in a bounded queue; if it
+// is full, new payloads are dropped, since diagnostic events are only informational.
 type diagnosticEventEndpointDispatcher struct {
-	httpClient *http.Client
-	httpConfig httpconfig.HTTPConfig
-	baseURI    string
-	uriPath    string
-	loggers    ldlog.Loggers
+	httpClient     *http.Client
+	httpConfig     httpconfig.HTTPConfig
+	baseURI        string
+	uriPath        string
+	sendPolicy     EventSendPolicy
+	forward        bool
+	sinkPublishers []EventPublisher
+	sdkKind        basictypes.SDKKind
+	capture        *eventCapture
+	queue          chan diagnosticEventPayload
+	closer         chan struct{}
+	closeOnce      sync.Once
+	wg             sync.WaitGroup
+	overflowed     bool
+	overflowLock   sync.Mutex
+	loggers        ldlog.Loggers
+}
+
+type diagnosticEventPayload struct {
+	headers http.Header
+	body    []byte
 }
 
 // GetHandler returns the HTTP handler for an endpoint, or nil if none is defined
@@ -69,24 +156,109 @@ func (r *EventDispatcher) GetHandler(sdkKind basictypes.SDKKind, eventsKind ldev
 }
 
 func (r *analyticsEventEndpointDispatcher) dispatch(w http.ResponseWriter, req *http.Request) {
+	if r.rejectedEvents != nil {
+		r.dispatchWithValidation(w, req)
+		return
+	}
 	consumeEvents(w, req, r.loggers, func(body []byte) {
+		r.capture.record(r.sdkKind, ldevents.AnalyticsEventDataKind, req, body)
 		evts := make([]json.RawMessage, 0)
 		err := json.Unmarshal(body, &evts)
 		if err != nil {
 			r.loggers.Errorf("Error unmarshaling event post body: %+v", err)
 			return
 		}
+		r.processEvents(req, evts)
+	})
+}
+
+// dispatchWithValidation is used instead of consumeEvents if validation is enabled, so that the response
+// can say whether the events were accepted. A payload that is not a JSON array is rejected with a 400
+// error; otherwise, only the invalid events are rejected, and the response body has the number of events
+// that were accepted and rejected.
+func (r *analyticsEventEndpointDispatcher) dispatchWithValidation(w http.ResponseWriter, req *http.Request) {
+	body, ok := readEventsBody(w, req, r.loggers)
+	if !ok {
+		return
+	}
+	r.capture.record(r.sdkKind, ldevents.AnalyticsEventDataKind, req, body)
+	evts := make([]json.RawMessage, 0)
+	if err := json.Unmarshal(body, &evts); err != nil {
+		r.rejectedEvents.add(r.sdkKind, "payload is not a JSON array: "+err.Error(), body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write(util.ErrorJSONMsg("event payload must be a JSON array"))
+		return
+	}
+	valid := make([]json.RawMessage, 0, len(evts))
+	for _, e := range evts {
+		if err := validateEvent(e); err != nil {
+			r.rejectedEvents.add(r.sdkKind, err.Error(), e)
+			continue
+		}
+		valid = append(valid, e)
+	}
+	resp, _ := json.Marshal(eventValidationResponse{Accepted: len(valid), Rejected: len(evts) - len(valid)})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = w.Write(resp)
 
-		metadata := GetEventPayloadMetadata(req)
+	defer func() {
+		if err := recover(); err != nil { // COVERAGE: can't make this happen in unit tests
+			r.loggers.Errorf("Unexpected panic in event relay: %+v", err)
+		}
+	}()
+	if len(valid) > 0 {
+		r.processEvents(req, valid)
+	}
+}
 
-		r.loggers.Debugf("Received %d events (v%d) to be proxied to %s", len(evts), metadata.SchemaVersion, r.remotePath)
-		if metadata.SchemaVersion >= SummaryEventsSchemaVersion {
-			// New-style events that have already gone through summarization - deliver them as-is
-			r.getVerbatimRelay().enqueue(metadata, evts)
-		} else {
-			r.getSummarizingRelay().enqueue(metadata, evts)
+func (r *analyticsEventEndpointDispatcher) processEvents(req *http.Request, evts []json.RawMessage) {
+	metadata := GetEventPayloadMetadata(req)
+
+	if r.filter != nil {
+		received := len(evts)
+		evts = r.filter.apply(evts)
+		if len(evts) < received {
+			r.loggers.Debugf("Dropped %d of %d events due to event filtering rules", received-len(evts), received)
 		}
-	})
+		if len(evts) == 0 {
+			return
+		}
+	}
+
+	for _, p := range r.sinkPublishers {
+		p.Publish(metadata, evts...)
+	}
+	if !r.forward {
+		return
+	}
+
+	if r.sampler != nil {
+		var sampledOut map[string]int
+		evts, sampledOut = r.sampler.sample(evts, metadata.SchemaVersion < SummaryEventsSchemaVersion)
+		if len(sampledOut) != 0 && r.onSampledOut != nil {
+			r.onSampledOut(req, sampledOut)
+		}
+		if len(evts) == 0 {
+			return
+		}
+	}
+
+	r.forwardEvents(metadata, evts)
+	for _, d := range r.destinations {
+		d.forwardEvents(metadata, evts)
+	}
+}
+
+func (r *analyticsEventEndpointDispatcher) forwardEvents(metadata EventPayloadMetadata, evts []json.RawMessage) {
+	r.loggers.Debugf("Received %d events (v%d) to be proxied to %s", len(evts), metadata.SchemaVersion, r.remotePath)
+	if metadata.SchemaVersion >= SummaryEventsSchemaVersion {
+		// New-style events that have already gone through summarization - deliver them as-is
+		r.getVerbatimRelay().enqueue(metadata, evts)
+	} else {
+		r.getSummarizingRelay().enqueue(metadata, evts)
+	}
 }
 
 func (r *analyticsEventEndpointDispatcher) replaceCredential(newCredential c.SDKCredential) {
@@ -112,37 +284,91 @@ func (r *analyticsEventEndpointDispatcher) close() {
 	if r.verbatimRelay != nil {
 		r.verbatimRelay.close()
 	}
+	for _, p := range r.sinkPublishers {
+		p.Close()
+	}
+	for _, d := range r.destinations {
+		d.close()
+	}
 }
 
 func (d *diagnosticEventEndpointDispatcher) dispatch(w http.ResponseWriter, req *http.Request) {
 	consumeEvents(w, req, d.loggers, func(body []byte) {
+		d.capture.record(d.sdkKind, ldevents.DiagnosticEventDataKind, req, body)
+		if len(d.sinkPublishers) > 0 {
+			metadata := GetEventPayloadMetadata(req)
+			for _, p := range d.sinkPublishers {
+				p.Publish(metadata, json.RawMessage(body))
+			}
+		}
+		if !d.forward {
+			return
+		}
+
 		// We are just operating as a reverse proxy and passing the request on verbatim to LD; we do not
 		// need to parse the JSON.
 		d.loggers.Debugf("Received diagnostic event to be proxied to %s/%s", d.baseURI, d.uriPath)
+		d.enqueue(diagnosticEventPayload{headers: req.Header.Clone(), body: body})
+	})
+}
 
-		sendConfig := ldevents.EventSenderConfiguration{
-			Client:      d.httpClient,
-			BaseURI:     d.baseURI,
-			BaseHeaders: func() http.Header { return req.Header },
-			Loggers:     d.loggers,
+func (d *diagnosticEventEndpointDispatcher) start(queueSize int) {
+	d.queue = make(chan diagnosticEventPayload, queueSize)
+	d.closer = make(chan struct{})
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		for {
+			select {
+			case payload := <-d.queue:
+				d.send(payload)
+			case <-d.closer:
+				return
+			}
 		}
-		_ = ldevents.SendEventDataWithRetry(sendConfig, ldevents.DiagnosticEventDataKind, d.uriPath, body, 1)
-	})
+	}()
 }
 
-func consumeEvents(w http.ResponseWriter, req *http.Request, loggers ldlog.Loggers, thenExecute func([]byte)) {
-	body, bodyErr := io.ReadAll(req.Body)
+func (d *diagnosticEventEndpointDispatcher) enqueue(payload diagnosticEventPayload) {
+	d.overflowLock.Lock()
+	defer d.overflowLock.Unlock()
+	select {
+	case d.queue <- payload:
+		d.overflowed = false
+	default:
+		if !d.overflowed {
+			d.loggers.Warnf("Exceeded diagnostic event queue capacity of %d; diagnostic events will be dropped", cap(d.queue))
+			d.overflowed = true
+		}
+	}
+}
 
-	if bodyErr != nil { // COVERAGE: can't make this happen in unit tests
-		loggers.Errorf("Error reading event post body: %+v", bodyErr)
-		w.WriteHeader(http.StatusBadRequest)
-		_, _ = w.Write(util.ErrorJSONMsg("unable to read request body"))
-		return
+func (d *diagnosticEventEndpointDispatcher) send(payload diagnosticEventPayload) {
+	sendConfig := ldevents.EventSenderConfiguration{
+		Client:      d.httpClient,
+		BaseURI:     d.baseURI,
+		BaseHeaders: func() http.Header { return payload.headers },
+		Loggers:     d.loggers,
 	}
+	_ = sendEventData(sendConfig, d.sendPolicy, d.closer, ldevents.DiagnosticEventDataKind, d.uriPath, payload.body, 1)
+}
 
-	if len(body) == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		_, _ = w.Write(util.ErrorJSONMsg("body may not be empty"))
+// close stops the background goroutine, discarding any diagnostic events that have not been sent yet.
+func (d *diagnosticEventEndpointDispatcher) close() {
+	d.closeOnce.Do(func() {
+		if d.closer != nil {
+			close(d.closer)
+			d.wg.Wait()
+		}
+	})
+	for _, p := range d.sinkPublishers {
+		p.Close()
+	}
+}
+
+func consumeEvents(w http.ResponseWriter, req *http.Request, loggers ldlog.Loggers, thenExecute func([]byte)) {
+	body, ok := readEventsBody(w, req, loggers)
+	if !ok {
 		return
 	}
 
@@ -157,11 +383,37 @@ func consumeEvents(w http.ResponseWriter, req *http.Request, loggers ldlog.Logge
 	thenExecute(body)
 }
 
+// readEventsBody reads the body of an event post. If the body cannot be read or is empty, it writes an
+// error response and returns false.
+func readEventsBody(w http.ResponseWriter, req *http.Request, loggers ldlog.Loggers) ([]byte, bool) {
+	body, bodyErr := io.ReadAll(req.Body)
+
+	if errors.Is(bodyErr, util.ErrRequestBodyTooLarge) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		_, _ = w.Write(util.ErrorJSONMsg(bodyErr.Error()))
+		return nil, false
+	}
+
+	if bodyErr != nil { // COVERAGE: can't make this happen in unit tests
+		loggers.Errorf("Error reading event post body: %+v", bodyErr)
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write(util.ErrorJSONMsg("unable to read request body"))
+		return nil, false
+	}
+
+	if len(body) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write(util.ErrorJSONMsg("body may not be empty"))
+		return nil, false
+	}
+	return body, true
+}
+
 func (r *analyticsEventEndpointDispatcher) getVerbatimRelay() *eventVerbatimRelay {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	if r.verbatimRelay == nil {
-		r.verbatimRelay = newEventVerbatimRelay(r.authKey, r.config, r.httpConfig, r.loggers, r.remotePath)
+		r.verbatimRelay = newEventVerbatimRelay(r.authKey, r.config, r.httpConfig, r.sendPolicy, r.loggers, r.remotePath)
 	}
 	return r.verbatimRelay
 }
@@ -171,7 +423,7 @@ func (r *analyticsEventEndpointDispatcher) getSummarizingRelay() *eventSummarizi
 	defer r.mu.Unlock()
 	if r.summarizingRelay == nil {
 		r.summarizingRelay = newEventSummarizingRelay(r.config, r.httpConfig, r.authKey, r.storeAdapter,
-			r.loggers, r.remotePath, r.eventQueueCleanupInterval)
+			r.sendPolicy, r.loggers, r.remotePath, r.eventQueueCleanupInterval)
 	}
 	return r.summarizingRelay
 }
@@ -185,9 +437,44 @@ func (r *analyticsEventEndpointDispatcher) flush() { //nolint:unused // used onl
 	if r.summarizingRelay != nil {
 		r.summarizingRelay.flush()
 	}
+	for _, p := range r.sinkPublishers {
+		p.Flush()
+	}
+	for _, d := range r.destinations {
+		d.flush()
+	}
+}
+
+func (r *analyticsEventEndpointDispatcher) flushBlocking(deadline time.Time) bool {
+	r.mu.Lock()
+	verbatimRelay, summarizingRelay := r.verbatimRelay, r.summarizingRelay
+	r.mu.Unlock()
+	if verbatimRelay != nil {
+		if p, ok := verbatimRelay.publisher.(*HTTPEventPublisher); ok {
+			if !p.flushBlocking(deadline) {
+				return false
+			}
+		} else { // COVERAGE: the verbatim relay always uses HTTPEventPublisher
+			verbatimRelay.publisher.Flush()
+		}
+	}
+	if summarizingRelay != nil && !summarizingRelay.flushBlocking(deadline) {
+		return false
+	}
+	// Sinks only have a non-blocking Flush, since they are not necessarily sending over the network
+	for _, p := range r.sinkPublishers {
+		p.Flush()
+	}
+	for _, d := range r.destinations {
+		if !d.flushBlocking(deadline) {
+			return false
+		}
+	}
+	return true
 }
 
-// NewEventDispatcher creates a handler for relaying events to LaunchDarkly for an environment
+// NewEventDispatcher creates a handler for relaying events to LaunchDarkly for an environment, and to
+// any event sinks specified in the options.
 func NewEventDispatcher(
 	sdkKey c.SDKKey,
 	mobileKey c.MobileKey,
@@ -196,38 +483,143 @@ func NewEventDispatcher(
 	config c.EventsConfig,
 	httpConfig httpconfig.HTTPConfig,
 	storeAdapter *store.SSERelayDataStoreAdapter,
+	options EventDispatcherOptions,
 	eventQueueCleanupInterval time.Duration, // normally zero to use the default; overridden in tests
 ) *EventDispatcher {
 	ep := &EventDispatcher{
-		analyticsEndpoints: map[basictypes.SDKKind]*analyticsEventEndpointDispatcher{
-			basictypes.ServerSDK: newAnalyticsEventEndpointDispatcher(sdkKey,
-				config, httpConfig, storeAdapter, loggers, "/bulk", eventQueueCleanupInterval),
-		},
-		diagnosticEndpoints: map[basictypes.SDKKind]*diagnosticEventEndpointDispatcher{
-			basictypes.ServerSDK: newDiagnosticEventEndpointDispatcher(config, httpConfig, loggers, "/diagnostic"),
-		},
+		analyticsEndpoints: makeAnalyticsEventEndpointDispatchers(sdkKey, mobileKey, envID, config, httpConfig,
+			storeAdapter, loggers, eventQueueCleanupInterval),
+		diagnosticEndpoints: make(map[basictypes.SDKKind]*diagnosticEventEndpointDispatcher),
+		capture:             &eventCapture{},
+	}
+	// An environment that is only used for forwarding events from mobile and client-side SDKs may have no
+	// SDK key, so each kind of endpoint is only created if there is a credential for it.
+	if sdkKey != "" {
+		ep.diagnosticEndpoints[basictypes.ServerSDK] = newDiagnosticEventEndpointDispatcher(config, httpConfig, loggers, "/diagnostic")
 	}
 	if mobileKey != "" {
-		ep.analyticsEndpoints[basictypes.MobileSDK] = newAnalyticsEventEndpointDispatcher(mobileKey,
-			config, httpConfig, storeAdapter, loggers, "/mobile", eventQueueCleanupInterval)
 		ep.diagnosticEndpoints[basictypes.MobileSDK] = newDiagnosticEventEndpointDispatcher(config, httpConfig, loggers, "/mobile/events/diagnostic")
 	}
 	if envID != "" {
-		ep.analyticsEndpoints[basictypes.JSClientSDK] = newAnalyticsEventEndpointDispatcher(envID, config, httpConfig, storeAdapter, loggers,
-			"/events/bulk/"+string(envID), eventQueueCleanupInterval)
 		ep.diagnosticEndpoints[basictypes.JSClientSDK] = newDiagnosticEventEndpointDispatcher(config, httpConfig, loggers,
 			"/events/diagnostic/"+string(envID))
 	}
+	options.setSendResultHandler(PrimaryEventDestinationName, ep.analyticsEndpoints)
+	if !options.DisableForwarding {
+		for _, dest := range options.Destinations {
+			destConfig := config
+			if dest.EventsURI != "" {
+				destConfig.EventsURI, _ = configtypes.NewOptURLAbsoluteFromString(dest.EventsURI)
+			}
+			destEndpoints := makeAnalyticsEventEndpointDispatchers(dest.SDKKey, dest.MobileKey, dest.EnvID, destConfig,
+				httpConfig, storeAdapter, dest.Loggers, eventQueueCleanupInterval)
+			options.setSendResultHandler(dest.Name, destEndpoints)
+			for sdkKind, d := range destEndpoints {
+				// A destination can only receive the kinds of events that this environment receives
+				if e, ok := ep.analyticsEndpoints[sdkKind]; ok {
+					d.forward = true
+					e.destinations = append(e.destinations, d)
+				}
+			}
+		}
+	}
+	if config.EnableValidation {
+		ep.rejectedEvents = newRejectedEventLog(loggers)
+	}
+	for sdkKind, e := range ep.analyticsEndpoints {
+		e.sdkKind = sdkKind
+		e.rejectedEvents = ep.rejectedEvents
+		e.capture = ep.capture
+		e.forward = !options.DisableForwarding
+		e.sinkPublishers = options.makeSinkPublishers(sdkKind, ldevents.AnalyticsEventDataKind)
+		e.filter = options.Filter
+		e.sampler = options.Sampler
+		if options.OnEventsSampledOut != nil {
+			sdkKind := sdkKind
+			e.onSampledOut = func(req *http.Request, countsByKind map[string]int) {
+				options.OnEventsSampledOut(req, sdkKind, countsByKind)
+			}
+		}
+	}
+	for sdkKind, e := range ep.diagnosticEndpoints {
+		e.sdkKind = sdkKind
+		e.capture = ep.capture
+		e.forward = !options.DisableForwarding
+		e.sinkPublishers = options.makeSinkPublishers(sdkKind, ldevents.DiagnosticEventDataKind)
+		if e.forward {
+			e.start(config.DiagnosticQueueSize.GetOrElse(c.DefaultDiagnosticEventQueueSize))
+		}
+	}
 	return ep
 }
 
+func (o EventDispatcherOptions) setSendResultHandler(
+	destination string,
+	endpoints map[basictypes.SDKKind]*analyticsEventEndpointDispatcher,
+) {
+	if o.OnEventsDelivered == nil {
+		return
+	}
+	for _, e := range endpoints {
+		e.sendPolicy.OnResult = func(eventCount int, success bool) {
+			o.OnEventsDelivered(destination, eventCount, success)
+		}
+	}
+}
+
+func (o EventDispatcherOptions) makeSinkPublishers(
+	sdkKind basictypes.SDKKind,
+	dataKind ldevents.EventDataKind,
+) []EventPublisher {
+	var ret []EventPublisher
+	for _, s := range o.Sinks {
+		if !s.AcceptsEnvironment(o.SinkEnvironment) {
+			continue
+		}
+		if p := s.NewPublisher(o.SinkEnvironment, sdkKind, dataKind); p != nil {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
+
 // Close shuts down any goroutines/channels being used by the EventDispatcher.
 func (r *EventDispatcher) Close() {
 	for _, e := range r.analyticsEndpoints {
 		e.close()
 	}
-	// diagnosticEventEndpointDispatcher doesn't currently need to be closed, because it doesn't maintain any
-	// goroutines or channels
+	for _, e := range r.diagnosticEndpoints {
+		e.close()
+	}
+}
+
+// GetRecentRejectedEvents returns the most recent analytics events or payloads from SDKs that were rejected
+// because they were not valid, oldest first. This is always empty if EventsConfig.EnableValidation is not
+// set.
+func (r *EventDispatcher) GetRecentRejectedEvents() []RejectedEvent {
+	if r.rejectedEvents == nil {
+		return nil
+	}
+	return r.rejectedEvents.recent()
+}
+
+// StartCapture begins recording the event payloads that SDKs send to this environment, so that they can
+// be retrieved with GetCapture. Any previously captured payloads are discarded. Recording stops after the
+// specified duration, and only the most recent payloads whose bodies add up to no more than maxBytes are
+// kept.
+func (r *EventDispatcher) StartCapture(duration time.Duration, maxBytes int) {
+	r.capture.begin(duration, maxBytes)
+}
+
+// StopCapture stops recording event payloads, if a capture is active, and discards any captured payloads.
+func (r *EventDispatcher) StopCapture() {
+	r.capture.discard()
+}
+
+// GetCapture returns the event payloads that were recorded since StartCapture was called. This is empty
+// if StartCapture has not been called.
+func (r *EventDispatcher) GetCapture() EventCaptureBundle {
+	return r.capture.bundle()
 }
 
 func (r *EventDispatcher) flush() { //nolint:unused // used only in tests
@@ -236,6 +628,17 @@ func (r *EventDispatcher) flush() { //nolint:unused // used only in tests
 	}
 }
 
+// flushBlocking is like flush, but waits until all of the queued analytics events have been delivered or
+// have failed. It returns false if that did not happen before the deadline.
+func (r *EventDispatcher) flushBlocking(deadline time.Time) bool {
+	for _, e := range r.analyticsEndpoints {
+		if !e.flushBlocking(deadline) {
+			return false
+		}
+	}
+	return true
+}
+
 // ReplaceCredential changes the authorization credentail that is used when forwarding events to any
 // endpoints that use that type of credential. For instance, if newCredential is a MobileKey, this
 // affects only endpoints that use a mobile key.
@@ -259,10 +662,39 @@ func newDiagnosticEventEndpointDispatcher(
 		httpConfig: httpConfig,
 		baseURI:    eventsURI,
 		uriPath:    remotePath,
+		sendPolicy: MakeEventSendPolicy(config),
 		loggers:    loggers,
 	}
 }
 
+// makeAnalyticsEventEndpointDispatchers creates an analyticsEventEndpointDispatcher for each kind of SDK
+// that there is a credential for.
+func makeAnalyticsEventEndpointDispatchers(
+	sdkKey c.SDKKey,
+	mobileKey c.MobileKey,
+	envID c.EnvironmentID,
+	config c.EventsConfig,
+	httpConfig httpconfig.HTTPConfig,
+	storeAdapter *store.SSERelayDataStoreAdapter,
+	loggers ldlog.Loggers,
+	eventQueueCleanupInterval time.Duration,
+) map[basictypes.SDKKind]*analyticsEventEndpointDispatcher {
+	ret := make(map[basictypes.SDKKind]*analyticsEventEndpointDispatcher)
+	if sdkKey != "" {
+		ret[basictypes.ServerSDK] = newAnalyticsEventEndpointDispatcher(sdkKey,
+			config, httpConfig, storeAdapter, loggers, "/bulk", eventQueueCleanupInterval)
+	}
+	if mobileKey != "" {
+		ret[basictypes.MobileSDK] = newAnalyticsEventEndpointDispatcher(mobileKey,
+			config, httpConfig, storeAdapter, loggers, "/mobile", eventQueueCleanupInterval)
+	}
+	if envID != "" {
+		ret[basictypes.JSClientSDK] = newAnalyticsEventEndpointDispatcher(envID, config, httpConfig, storeAdapter, loggers,
+			"/events/bulk/"+string(envID), eventQueueCleanupInterval)
+	}
+	return ret
+}
+
 func newAnalyticsEventEndpointDispatcher(
 	authKey c.SDKCredential,
 	config c.EventsConfig,
@@ -280,6 +712,7 @@ func newAnalyticsEventEndpointDispatcher(
 		storeAdapter:              storeAdapter,
 		loggers:                   loggers,
 		remotePath:                remotePath,
+		sendPolicy:                MakeEventSendPolicy(config),
 		eventQueueCleanupInterval: eventQueueCleanupInterval,
 	}
 }
@@ -288,6 +721,7 @@ func newEventVerbatimRelay(
 	authKey c.SDKCredential,
 	config c.EventsConfig,
 	httpConfig httpconfig.HTTPConfig,
+	sendPolicy EventSendPolicy,
 	loggers ldlog.Loggers,
 	remotePath string,
 ) *eventVerbatimRelay {
@@ -296,21 +730,40 @@ func newEventVerbatimRelay(
 		OptionCapacity(config.Capacity.GetOrElse(c.DefaultEventCapacity)),
 		OptionBaseURI(eventsURI),
 		OptionURIPath(remotePath),
+		OptionSendPolicy(sendPolicy),
 	}
 
 	opts = append(opts, OptionFlushInterval(config.FlushInterval.GetOrElse(c.DefaultEventsFlushInterval)))
 
+	var aggregator *eventAggregator
+	if config.EnableAggregation {
+		aggregator = newEventAggregator(config)
+		// The merged summaries are added to the publisher's queues just before each flush, so they are
+		// delivered along with the other events from the same flush interval.
+		opts = append(opts, OptionBeforeFlush(func(publish func(EventPayloadMetadata, ...json.RawMessage)) {
+			for metadata, summary := range aggregator.takeSummaries() {
+				publish(metadata, summary)
+			}
+		}))
+	}
+
 	publisher, _ := NewHTTPEventPublisher(authKey, httpConfig, loggers, opts...)
 
 	res := &eventVerbatimRelay{
-		config:    config,
-		publisher: publisher,
+		config:     config,
+		publisher:  publisher,
+		aggregator: aggregator,
 	}
 
 	return res
 }
 
 func (er *eventVerbatimRelay) enqueue(metadata EventPayloadMetadata, evts []json.RawMessage) {
+	if er.aggregator != nil {
+		if evts = er.aggregator.add(metadata, evts); len(evts) == 0 {
+			return
+		}
+	}
 	er.publisher.Publish(metadata, evts...)
 }
 
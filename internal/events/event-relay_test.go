@@ -1,6 +1,7 @@
 package events
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"sort"
@@ -64,6 +65,7 @@ var testJSClientEndpointInfo = testEndpointInfo{
 var allTestEndpoints = []testEndpointInfo{testServerEndpointInfo, testMobileEndpointInfo, testJSClientEndpointInfo}
 
 type eventRelayTestOptions struct {
+	dispatcherOptions         EventDispatcherOptions
 	eventQueueCleanupInterval time.Duration
 }
 
@@ -115,6 +117,7 @@ func eventRelayTestWithOptions(
 			eventsConfig,
 			httpConfig,
 			makeStoreAdapterWithExistingStore(store),
+			opts.dispatcherOptions,
 			opts.eventQueueCleanupInterval,
 		)
 		defer dispatcher.Close()
@@ -274,6 +277,132 @@ func TestDiagnosticEventForwarding(t *testing.T) {
 	}
 }
 
+func TestEventSinksReceiveEvents(t *testing.T) {
+	for _, forwarding := range []bool{true, false} {
+		t.Run(fmt.Sprintf("forwarding: %t", forwarding), func(t *testing.T) {
+			sink, buf := makeTestEventSink(t, config.EventSinkConfig{
+				Kinds: configtypes.NewOptStringList([]string{config.EventSinkKindAnalytics, config.EventSinkKindDiagnostic}),
+			})
+			opts := eventRelayTestOptions{dispatcherOptions: EventDispatcherOptions{
+				DisableForwarding: !forwarding,
+				Sinks:             []EventSink{sink},
+				SinkEnvironment:   testSinkEnv,
+			}}
+			eventRelayTestWithOptions(t, st.EnvMain, config.EventsConfig{}, opts, func(p eventRelayTestParams) {
+				for _, kind := range []ldevents.EventDataKind{ldevents.AnalyticsEventDataKind, ldevents.DiagnosticEventDataKind} {
+					req := st.BuildRequest("POST", "/", []byte(eventPayloadForVerbatimOnly),
+						headersWithEventSchema(CurrentEventsSchemaVersion))
+					handler := p.dispatcher.GetHandler(basictypes.ServerSDK, kind)
+					require.NotNil(t, handler)
+					w := httptest.NewRecorder()
+					handler(w, req)
+					assert.Equal(t, http.StatusAccepted, w.Result().StatusCode)
+				}
+				p.dispatcher.flush()
+
+				records := parseEventSinkRecords(t, buf.String())
+				require.Len(t, records, 4)
+				for i, r := range records[:3] {
+					assert.Equal(t, ldevents.AnalyticsEventDataKind, r.DataKind)
+					assert.Equal(t, fmt.Sprintf(`"fake-event-%d"`, i+1), string(r.Event))
+				}
+				assert.Equal(t, ldevents.DiagnosticEventDataKind, records[3].DataKind)
+				assert.Equal(t, eventPayloadForVerbatimOnly, string(records[3].Event))
+
+				if forwarding {
+					helpers.RequireValue(t, p.requestsCh, time.Second)
+					helpers.RequireValue(t, p.requestsCh, time.Second)
+				} else {
+					helpers.AssertNoMoreValues(t, p.requestsCh, time.Millisecond*50)
+				}
+			})
+		})
+	}
+}
+
+func TestEventSinksIgnoredIfEnvironmentNotAccepted(t *testing.T) {
+	sink, buf := makeTestEventSink(t, config.EventSinkConfig{
+		Environments: configtypes.NewOptStringList([]string{"mars"}),
+	})
+	opts := eventRelayTestOptions{dispatcherOptions: EventDispatcherOptions{
+		Sinks:           []EventSink{sink},
+		SinkEnvironment: testSinkEnv,
+	}}
+	eventRelayTestWithOptions(t, st.EnvMain, config.EventsConfig{}, opts, func(p eventRelayTestParams) {
+		req := st.BuildRequest("POST", "/", []byte(eventPayloadForVerbatimOnly),
+			headersWithEventSchema(CurrentEventsSchemaVersion))
+		handler := p.dispatcher.GetHandler(basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		assert.Equal(t, http.StatusAccepted, w.Result().StatusCode)
+		assert.Equal(t, "", buf.String())
+	})
+}
+
+func TestEventsAreForwardedToAdditionalDestinations(t *testing.T) {
+	type deliveryResult struct {
+		destination string
+		eventCount  int
+		success     bool
+	}
+	deliveriesCh := make(chan deliveryResult, 10)
+	release := make(chan struct{})
+	slowHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-time.After(time.Second * 5): // so the dispatcher can still be closed if the test fails
+		}
+		w.WriteHeader(202)
+	})
+	destHandler, destRequestsCh := httphelpers.RecordingHandler(slowHandler)
+	httphelpers.WithServer(destHandler, func(destServer *httptest.Server) {
+		mockLog := ldlogtest.NewMockLog()
+		opts := eventRelayTestOptions{dispatcherOptions: EventDispatcherOptions{
+			Destinations: []EventDestination{
+				{Name: "new-account", EventsURI: destServer.URL, SDKKey: "other-sdk-key", Loggers: mockLog.Loggers},
+			},
+			OnEventsDelivered: func(destination string, eventCount int, success bool) {
+				deliveriesCh <- deliveryResult{destination, eventCount, success}
+			},
+		}}
+		eventRelayTestWithOptions(t, st.EnvWithAllCredentials, config.EventsConfig{}, opts, func(p eventRelayTestParams) {
+			for _, e := range allTestEndpoints {
+				req := st.BuildRequest("POST", "/", []byte(eventPayloadForVerbatimOnly),
+					headersWithEventSchema(CurrentEventsSchemaVersion))
+				handler := p.dispatcher.GetHandler(e.sdkKind, ldevents.AnalyticsEventDataKind)
+				w := httptest.NewRecorder()
+				handler(w, req)
+				assert.Equal(t, http.StatusAccepted, w.Result().StatusCode)
+			}
+
+			p.dispatcher.flush()
+
+			// The destination only has an SDK key, so it only receives events from server-side SDKs
+			r := helpers.RequireValue(t, destRequestsCh, time.Second)
+			assert.Equal(t, "/bulk", r.Request.URL.Path)
+			assert.Equal(t, "other-sdk-key", r.Request.Header.Get("Authorization"))
+			assert.Equal(t, eventPayloadForVerbatimOnly, string(r.Body))
+			helpers.AssertNoMoreValues(t, destRequestsCh, time.Millisecond*50)
+
+			// The destination's slow response does not hold up delivery to LaunchDarkly
+			var paths []string
+			for range allTestEndpoints {
+				r := helpers.RequireValue(t, p.requestsCh, time.Second)
+				paths = append(paths, r.Request.URL.Path)
+			}
+			assert.ElementsMatch(t, []string{testServerEndpointInfo.analyticsPath, testMobileEndpointInfo.analyticsPath,
+				testJSClientEndpointInfo.analyticsPath}, paths)
+			for range allTestEndpoints {
+				assert.Equal(t, deliveryResult{PrimaryEventDestinationName, 3, true},
+					helpers.RequireValue(t, deliveriesCh, time.Second))
+			}
+
+			close(release)
+			assert.Equal(t, deliveryResult{"new-account", 3, true}, helpers.RequireValue(t, deliveriesCh, time.Second))
+		})
+	})
+}
+
 func TestEventDispatcherReplaceCredential(t *testing.T) {
 	summarizeEventsParams := makeBasicSummarizeEventsParams()
 
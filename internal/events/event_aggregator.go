@@ -0,0 +1,228 @@
+package events
+
+import (
+	"container/list"
+	"encoding/json"
+	"sync"
+	"time"
+
+	c "github.com/launchdarkly/ld-relay/v7/config"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+)
+
+// eventAggregator reduces the number of events that Relay forwards from SDKs that have already summarized
+// their own events, when many SDK instances are sending events for the same environment. It is used only if
+// EventsConfig.EnableAggregation is set.
+//
+// Summary events are not forwarded as they are received; instead, all of the summaries received during a
+// flush interval are merged into one summary per payload metadata value, adding together the counters for
+// each flag, so the evaluation counts are the same as if the summaries had been forwarded separately.
+//
+// Index and identify events are dropped if an event for the same context key has already been forwarded
+// recently. The context keys are kept in an LRU cache of limited size, which is cleared periodically so
+// that LaunchDarkly still receives up-to-date context attributes. Other kinds of events are not affected.
+type eventAggregator struct {
+	contextKeys       *contextKeyCache
+	keysFlushInterval time.Duration
+	lastKeysFlush     time.Time
+	summaries         map[EventPayloadMetadata]*summaryAggregate
+	lock              sync.Mutex
+}
+
+// summaryAggregate is the merged content of one or more summary events.
+type summaryAggregate struct {
+	startDate float64
+	endDate   float64
+	features  map[string]*flagSummaryAggregate
+}
+
+type flagSummaryAggregate struct {
+	base     ldvalue.Value // the first summary received for the flag, for any properties that are not merged
+	counters map[summaryCounterKey]*summaryCounter
+	order    []summaryCounterKey
+}
+
+type summaryCounterKey struct {
+	variation ldvalue.OptionalInt
+	version   ldvalue.OptionalInt
+	unknown   bool
+}
+
+type summaryCounter struct {
+	value ldvalue.Value
+	count int
+}
+
+func newEventAggregator(config c.EventsConfig) *eventAggregator {
+	return &eventAggregator{
+		contextKeys:       newContextKeyCache(config.ContextKeysCapacity.GetOrElse(c.DefaultEventsContextKeysCapacity)),
+		keysFlushInterval: config.ContextKeysFlushInterval.GetOrElse(c.DefaultEventsContextKeysFlushInterval),
+		lastKeysFlush:     time.Now(),
+		summaries:         make(map[EventPayloadMetadata]*summaryAggregate),
+	}
+}
+
+// add returns the events that should be forwarded right away. Summary events are retained to be merged,
+// and duplicate index and identify events are discarded.
+func (a *eventAggregator) add(metadata EventPayloadMetadata, events []json.RawMessage) []json.RawMessage {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if now := time.Now(); now.Sub(a.lastKeysFlush) >= a.keysFlushInterval {
+		a.contextKeys.clear()
+		a.lastKeysFlush = now
+	}
+	ret := make([]json.RawMessage, 0, len(events))
+	for _, e := range events {
+		event := ldvalue.Parse(e)
+		switch event.GetByKey("kind").StringValue() {
+		case "summary":
+			if a.addSummary(metadata, event) {
+				continue
+			}
+		case "index", "identify":
+			if key := getEventContextKey(e); key != "" && a.contextKeys.add(key) {
+				continue
+			}
+		}
+		ret = append(ret, e)
+	}
+	return ret
+}
+
+func (a *eventAggregator) addSummary(metadata EventPayloadMetadata, event ldvalue.Value) bool {
+	features := event.GetByKey("features")
+	if features.Type() != ldvalue.ObjectType {
+		return false
+	}
+	s := a.summaries[metadata]
+	if s == nil {
+		s = &summaryAggregate{features: make(map[string]*flagSummaryAggregate)}
+		a.summaries[metadata] = s
+	}
+	if start := event.GetByKey("startDate"); start.IsNumber() && (s.startDate == 0 || start.Float64Value() < s.startDate) {
+		s.startDate = start.Float64Value()
+	}
+	if end := event.GetByKey("endDate"); end.IsNumber() && end.Float64Value() > s.endDate {
+		s.endDate = end.Float64Value()
+	}
+	for _, flagKey := range features.Keys(nil) {
+		flag := features.GetByKey(flagKey)
+		f := s.features[flagKey]
+		if f == nil {
+			f = &flagSummaryAggregate{base: flag, counters: make(map[summaryCounterKey]*summaryCounter)}
+			s.features[flagKey] = f
+		} else if kinds := flag.GetByKey("contextKinds"); kinds.Type() == ldvalue.ArrayType {
+			var names []string
+			for _, k := range kinds.AsValueArray().AsSlice() {
+				names = append(names, k.StringValue())
+			}
+			f.base = withProperty(f.base, "contextKinds", appendStrings(f.base.GetByKey("contextKinds"), names))
+		}
+		for _, counter := range flag.GetByKey("counters").AsValueArray().AsSlice() {
+			key := summaryCounterKey{
+				variation: optionalInt(counter.GetByKey("variation")),
+				version:   optionalInt(counter.GetByKey("version")),
+				unknown:   counter.GetByKey("unknown").BoolValue(),
+			}
+			if existing := f.counters[key]; existing != nil {
+				existing.count += counter.GetByKey("count").IntValue()
+			} else {
+				f.counters[key] = &summaryCounter{value: counter.GetByKey("value"), count: counter.GetByKey("count").IntValue()}
+				f.order = append(f.order, key)
+			}
+		}
+	}
+	return true
+}
+
+// takeSummaries returns the merged summary event for each payload metadata value, and resets the state so
+// that new summaries will be merged separately.
+func (a *eventAggregator) takeSummaries() map[EventPayloadMetadata]json.RawMessage {
+	a.lock.Lock()
+	summaries := a.summaries
+	a.summaries = make(map[EventPayloadMetadata]*summaryAggregate)
+	a.lock.Unlock()
+
+	if len(summaries) == 0 {
+		return nil
+	}
+	ret := make(map[EventPayloadMetadata]json.RawMessage, len(summaries))
+	for metadata, s := range summaries {
+		ret[metadata] = json.RawMessage(s.toValue().JSONString())
+	}
+	return ret
+}
+
+func (s *summaryAggregate) toValue() ldvalue.Value {
+	features := ldvalue.ObjectBuildWithCapacity(len(s.features))
+	for flagKey, f := range s.features {
+		counters := ldvalue.ArrayBuildWithCapacity(len(f.order))
+		for _, key := range f.order {
+			counter := f.counters[key]
+			b := ldvalue.ObjectBuild()
+			if key.variation.IsDefined() {
+				b.Set("variation", key.variation.AsValue())
+			}
+			if key.version.IsDefined() {
+				b.Set("version", key.version.AsValue())
+			}
+			if key.unknown {
+				b.Set("unknown", ldvalue.Bool(true))
+			}
+			b.Set("value", counter.value)
+			b.Set("count", ldvalue.Int(counter.count))
+			counters.Add(b.Build())
+		}
+		features.Set(flagKey, withProperty(f.base, "counters", counters.Build()))
+	}
+	return ldvalue.ObjectBuild().
+		Set("kind", ldvalue.String("summary")).
+		Set("startDate", ldvalue.Float64(s.startDate)).
+		Set("endDate", ldvalue.Float64(s.endDate)).
+		Set("features", features.Build()).
+		Build()
+}
+
+func optionalInt(value ldvalue.Value) ldvalue.OptionalInt {
+	if value.IsInt() {
+		return ldvalue.NewOptionalInt(value.IntValue())
+	}
+	return ldvalue.OptionalInt{}
+}
+
+// contextKeyCache is a set of context keys with a maximum size; when it is full, adding a key discards
+// the key that was least recently added or seen.
+type contextKeyCache struct {
+	capacity int
+	items    map[string]*list.Element
+	lru      *list.List
+}
+
+func newContextKeyCache(capacity int) *contextKeyCache {
+	return &contextKeyCache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// add stores a key in the cache, and returns true if it was already there.
+func (c *contextKeyCache) add(key string) bool {
+	if e, ok := c.items[key]; ok {
+		c.lru.MoveToFront(e)
+		return true
+	}
+	c.items[key] = c.lru.PushFront(key)
+	for c.lru.Len() > c.capacity {
+		oldest := c.lru.Back()
+		delete(c.items, oldest.Value.(string)) //nolint:forcetypeassert // we only ever store strings here
+		c.lru.Remove(oldest)
+	}
+	return false
+}
+
+func (c *contextKeyCache) clear() {
+	c.items = make(map[string]*list.Element)
+	c.lru.Init()
+}
@@ -0,0 +1,151 @@
+package events
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
+	st "github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
+
+	"github.com/launchdarkly/go-configtypes"
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+	helpers "github.com/launchdarkly/go-test-helpers/v3"
+	m "github.com/launchdarkly/go-test-helpers/v3/matchers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addAggregatedEvents(a *eventAggregator, metadata EventPayloadMetadata, events ...string) []string {
+	raw := make([]json.RawMessage, 0, len(events))
+	for _, e := range events {
+		raw = append(raw, json.RawMessage(e))
+	}
+	var ret []string
+	for _, e := range a.add(metadata, raw) {
+		ret = append(ret, string(e))
+	}
+	return ret
+}
+
+func TestEventAggregatorMergesSummaryEvents(t *testing.T) {
+	a := newEventAggregator(config.EventsConfig{})
+	metadata := EventPayloadMetadata{SchemaVersion: CurrentEventsSchemaVersion}
+
+	assert.Nil(t, addAggregatedEvents(a, metadata,
+		`{"kind":"summary","startDate":1000,"endDate":2000,"features":{
+			"flag1":{"default":"x","contextKinds":["user"],"counters":[
+				{"variation":0,"version":1,"value":"a","count":2},
+				{"unknown":true,"value":"x","count":1}]}}}`))
+	assert.Nil(t, addAggregatedEvents(a, metadata,
+		`{"kind":"summary","startDate":500,"endDate":1500,"features":{
+			"flag1":{"default":"x","contextKinds":["org"],"counters":[
+				{"variation":0,"version":1,"value":"a","count":3},
+				{"variation":1,"version":1,"value":"b","count":1}]},
+			"flag2":{"default":true,"contextKinds":["user"],"counters":[
+				{"variation":0,"version":5,"value":true,"count":4}]}}}`))
+
+	summaries := a.takeSummaries()
+	require.Len(t, summaries, 1)
+	m.In(t).Assert(summaries[metadata], m.JSONStrEqual(`{"kind":"summary","startDate":500,"endDate":2000,"features":{
+		"flag1":{"default":"x","contextKinds":["user","org"],"counters":[
+			{"variation":0,"version":1,"value":"a","count":5},
+			{"unknown":true,"value":"x","count":1},
+			{"variation":1,"version":1,"value":"b","count":1}]},
+		"flag2":{"default":true,"contextKinds":["user"],"counters":[
+			{"variation":0,"version":5,"value":true,"count":4}]}}}`))
+
+	assert.Nil(t, a.takeSummaries())
+}
+
+func TestEventAggregatorKeepsSummariesWithDifferentMetadataSeparate(t *testing.T) {
+	a := newEventAggregator(config.EventsConfig{})
+	metadata1 := EventPayloadMetadata{SchemaVersion: CurrentEventsSchemaVersion, Tags: "application-id=a"}
+	metadata2 := EventPayloadMetadata{SchemaVersion: CurrentEventsSchemaVersion, Tags: "application-id=b"}
+	summary := `{"kind":"summary","startDate":1000,"endDate":2000,"features":{"flag1":{"counters":[{"value":1,"count":1}]}}}`
+
+	addAggregatedEvents(a, metadata1, summary)
+	addAggregatedEvents(a, metadata2, summary)
+
+	summaries := a.takeSummaries()
+	require.Len(t, summaries, 2)
+	m.In(t).Assert(summaries[metadata1], m.JSONStrEqual(summary))
+	m.In(t).Assert(summaries[metadata2], m.JSONStrEqual(summary))
+}
+
+func TestEventAggregatorDeduplicatesIndexAndIdentifyEvents(t *testing.T) {
+	a := newEventAggregator(config.EventsConfig{})
+	metadata := EventPayloadMetadata{SchemaVersion: CurrentEventsSchemaVersion}
+
+	kept := addAggregatedEvents(a, metadata,
+		`{"kind":"index","context":{"kind":"user","key":"a"}}`,
+		`{"kind":"identify","context":{"kind":"user","key":"a"}}`,
+		`{"kind":"index","context":{"kind":"org","key":"a"}}`,
+		`{"kind":"custom","key":"e","contextKeys":{"user":"a"}}`,
+		`{"kind":"index","context":{"kind":"multi","user":{"key":"a"},"org":{"key":"b"}}}`,
+		`{"kind":"index","context":{"kind":"multi","org":{"key":"b"},"user":{"key":"a"}}}`)
+	assert.Equal(t, []string{
+		`{"kind":"index","context":{"kind":"user","key":"a"}}`,
+		`{"kind":"index","context":{"kind":"org","key":"a"}}`,
+		`{"kind":"custom","key":"e","contextKeys":{"user":"a"}}`,
+		`{"kind":"index","context":{"kind":"multi","user":{"key":"a"},"org":{"key":"b"}}}`,
+	}, kept)
+
+	// The context keys are remembered across payloads
+	kept = addAggregatedEvents(a, metadata, `{"kind":"index","context":{"kind":"user","key":"a"}}`)
+	assert.Nil(t, kept)
+}
+
+func TestEventAggregatorForgetsLeastRecentlyUsedContextKeys(t *testing.T) {
+	capacity, _ := configtypes.NewOptIntGreaterThanZero(2)
+	a := newEventAggregator(config.EventsConfig{ContextKeysCapacity: capacity})
+	metadata := EventPayloadMetadata{SchemaVersion: CurrentEventsSchemaVersion}
+	index := func(key string) string { return `{"kind":"index","context":{"key":"` + key + `"}}` }
+
+	assert.Len(t, addAggregatedEvents(a, metadata, index("a"), index("b")), 2)
+	assert.Len(t, addAggregatedEvents(a, metadata, index("a")), 0) // "a" is now the most recently used
+	assert.Len(t, addAggregatedEvents(a, metadata, index("c")), 1) // this evicts "b"
+	assert.Len(t, addAggregatedEvents(a, metadata, index("a")), 0)
+	assert.Len(t, addAggregatedEvents(a, metadata, index("b")), 1)
+}
+
+func TestEventAggregatorClearsContextKeysAfterFlushInterval(t *testing.T) {
+	a := newEventAggregator(config.EventsConfig{ContextKeysFlushInterval: configtypes.NewOptDuration(time.Millisecond)})
+	metadata := EventPayloadMetadata{SchemaVersion: CurrentEventsSchemaVersion}
+	index := `{"kind":"index","context":{"key":"a"}}`
+
+	assert.Len(t, addAggregatedEvents(a, metadata, index), 1)
+	time.Sleep(time.Millisecond * 10)
+	assert.Len(t, addAggregatedEvents(a, metadata, index), 1)
+}
+
+func TestEventAggregationIsAppliedToVerbatimEvents(t *testing.T) {
+	eventsConfig := config.EventsConfig{EnableAggregation: true}
+	eventRelayTest(t, st.EnvMain, eventsConfig, func(p eventRelayTestParams) {
+		handler := p.dispatcher.GetHandler(basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+		for i := 0; i < 2; i++ {
+			body := `[
+				{"kind":"index","creationDate":1000,"context":{"kind":"user","key":"a"}},
+				{"kind":"summary","startDate":1000,"endDate":2000,"features":{
+					"flag1":{"default":"x","contextKinds":["user"],"counters":[{"variation":0,"version":1,"value":"a","count":1}]}}}
+			]`
+			req := st.BuildRequest("POST", "/", []byte(body), headersWithEventSchema(CurrentEventsSchemaVersion))
+			w := httptest.NewRecorder()
+			handler(w, req)
+			assert.Equal(t, http.StatusAccepted, w.Result().StatusCode)
+		}
+
+		p.dispatcher.flush()
+
+		r := helpers.RequireValue(t, p.requestsCh, time.Second)
+		m.In(t).Assert(r.Body, m.JSONStrEqual(`[
+			{"kind":"index","creationDate":1000,"context":{"kind":"user","key":"a"}},
+			{"kind":"summary","startDate":1000,"endDate":2000,"features":{
+				"flag1":{"default":"x","contextKinds":["user"],"counters":[{"variation":0,"version":1,"value":"a","count":2}]}}}
+		]`))
+	})
+}
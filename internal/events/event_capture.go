@@ -0,0 +1,229 @@
+package events
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
+
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+)
+
+const (
+	// DefaultEventCaptureDuration is how long EventDispatcher.StartCapture records payloads if no
+	// duration is specified.
+	DefaultEventCaptureDuration = 5 * time.Minute
+
+	// MaxEventCaptureDuration is the longest time that EventDispatcher.StartCapture will record payloads.
+	MaxEventCaptureDuration = time.Hour
+
+	// DefaultEventCaptureMaxBytes is the total size of payload bodies that EventDispatcher.StartCapture
+	// keeps if no limit is specified.
+	DefaultEventCaptureMaxBytes = 1024 * 1024
+
+	// MaxEventCaptureMaxBytes is the largest total size of payload bodies that EventDispatcher.StartCapture
+	// will keep.
+	MaxEventCaptureMaxBytes = 10 * 1024 * 1024
+)
+
+// capturedHeadersToRemove are request headers that are never recorded in a capture, since they contain
+// credentials.
+var capturedHeadersToRemove = []string{"Authorization", "Cookie"} //nolint:gochecknoglobals
+
+// CapturedEventPayload is an event payload that an SDK sent to Relay, as recorded by
+// EventDispatcher.StartCapture. The Authorization and Cookie headers are not recorded.
+type CapturedEventPayload struct {
+	Time       time.Time              `json:"time"`
+	SDKKind    basictypes.SDKKind     `json:"sdkKind"`
+	EventsKind ldevents.EventDataKind `json:"eventsKind"`
+	Headers    http.Header            `json:"headers"`
+	Metadata   EventPayloadMetadata   `json:"metadata"`
+	Body       string                 `json:"body"`
+}
+
+// EventCaptureBundle is the result of EventDispatcher.GetCapture. It can be passed to ReplayEventCapture
+// to send the same payloads again.
+//
+// If the total size of the payload bodies would have exceeded the limit for the capture, the oldest
+// payloads were discarded; DroppedPayloads is the number of payloads that were discarded.
+type EventCaptureBundle struct {
+	Environment     string                 `json:"environment,omitempty"`
+	Start           time.Time              `json:"start"`
+	End             time.Time              `json:"end"`
+	Active          bool                   `json:"active"`
+	DroppedPayloads int                    `json:"droppedPayloads"`
+	Payloads        []CapturedEventPayload `json:"payloads"`
+}
+
+// eventCapture records incoming event payloads for an environment while a capture is active. The payloads
+// are kept in a ring buffer that is limited by the total size of their bodies.
+type eventCapture struct {
+	start    time.Time
+	end      time.Time
+	maxBytes int
+	size     int
+	dropped  int
+	payloads []CapturedEventPayload
+	lock     sync.Mutex
+}
+
+func (c *eventCapture) begin(duration time.Duration, maxBytes int) {
+	now := time.Now()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.start = now
+	c.end = now.Add(duration)
+	c.maxBytes = maxBytes
+	c.size = 0
+	c.dropped = 0
+	c.payloads = nil
+}
+
+func (c *eventCapture) discard() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.start = time.Time{}
+	c.end = time.Time{}
+	c.size = 0
+	c.dropped = 0
+	c.payloads = nil
+}
+
+func (c *eventCapture) isActive(now time.Time) bool {
+	return !c.start.IsZero() && now.Before(c.end)
+}
+
+func (c *eventCapture) record(
+	sdkKind basictypes.SDKKind,
+	eventsKind ldevents.EventDataKind,
+	req *http.Request,
+	body []byte,
+) {
+	now := time.Now()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.isActive(now) {
+		return
+	}
+	if len(body) > c.maxBytes {
+		c.dropped++
+		return
+	}
+	for len(c.payloads) > 0 && c.size+len(body) > c.maxBytes {
+		c.size -= len(c.payloads[0].Body)
+		c.payloads = c.payloads[1:]
+		c.dropped++
+	}
+	headers := req.Header.Clone()
+	for _, h := range capturedHeadersToRemove {
+		headers.Del(h)
+	}
+	c.payloads = append(c.payloads, CapturedEventPayload{
+		Time:       now,
+		SDKKind:    sdkKind,
+		EventsKind: eventsKind,
+		Headers:    headers,
+		Metadata:   GetEventPayloadMetadata(req),
+		Body:       string(body),
+	})
+	c.size += len(body)
+}
+
+func (c *eventCapture) bundle() EventCaptureBundle {
+	now := time.Now()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ret := EventCaptureBundle{
+		Start:           c.start,
+		End:             c.end,
+		Active:          c.isActive(now),
+		DroppedPayloads: c.dropped,
+		Payloads:        make([]CapturedEventPayload, len(c.payloads)),
+	}
+	if ret.Active {
+		ret.End = now
+	}
+	copy(ret.Payloads, c.payloads)
+	return ret
+}
+
+// EventReplayResult describes what happened when ReplayEventCapture sent a capture again.
+type EventReplayResult struct {
+	// Replayed is the number of analytics event payloads that were accepted by the EventDispatcher.
+	Replayed int
+
+	// Rejected is the number of payloads that the EventDispatcher did not accept, for instance because
+	// validation is enabled and the payload was not a JSON array.
+	Rejected int
+
+	// Skipped is the number of payloads that were not replayed: diagnostic event payloads, payloads from a
+	// kind of SDK that the EventDispatcher has no credential for, and payloads from SDKs that use an event
+	// schema older than SummaryEventsSchemaVersion.
+	Skipped int
+
+	// Flushed is true if all of the replayed events were delivered, or failed, before the timeout.
+	Flushed bool
+}
+
+// ReplayEventCapture submits each analytics event payload in a capture to an EventDispatcher, in the
+// same way as if an SDK had posted it with the captured headers, and then waits for the events to be
+// forwarded. The events are forwarded with the EventDispatcher's own credentials.
+//
+// Diagnostic event payloads are not replayed, since they only describe the SDK that sent them. Neither are
+// payloads that use an event schema older than SummaryEventsSchemaVersion: Relay summarizes those events
+// itself using the flag data it has, and a replay has no flag data.
+func ReplayEventCapture(d *EventDispatcher, bundle EventCaptureBundle, timeout time.Duration) EventReplayResult {
+	var result EventReplayResult
+	for _, p := range bundle.Payloads {
+		if p.EventsKind != ldevents.AnalyticsEventDataKind {
+			result.Skipped++
+			continue
+		}
+		handler := d.GetHandler(p.SDKKind, ldevents.AnalyticsEventDataKind)
+		if handler == nil {
+			result.Skipped++
+			continue
+		}
+		req, _ := http.NewRequest("POST", "/", bytes.NewReader([]byte(p.Body)))
+		if p.Headers != nil {
+			req.Header = p.Headers.Clone()
+		}
+		if GetEventPayloadMetadata(req).SchemaVersion < SummaryEventsSchemaVersion {
+			result.Skipped++
+			continue
+		}
+		w := &replayResponseWriter{header: make(http.Header)}
+		handler(w, req)
+		if w.status == http.StatusAccepted {
+			result.Replayed++
+		} else {
+			result.Rejected++
+		}
+	}
+	result.Flushed = d.flushBlocking(time.Now().Add(timeout))
+	return result
+}
+
+// replayResponseWriter is the minimal http.ResponseWriter that ReplayEventCapture needs, since it only
+// looks at the status.
+type replayResponseWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *replayResponseWriter) Header() http.Header { return w.header }
+
+func (w *replayResponseWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return len(data), nil
+}
+
+func (w *replayResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
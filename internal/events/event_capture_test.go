@@ -0,0 +1,168 @@
+package events
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
+	st "github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
+
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+	helpers "github.com/launchdarkly/go-test-helpers/v3"
+	m "github.com/launchdarkly/go-test-helpers/v3/matchers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func postEventsForCapture(d *EventDispatcher, sdkKind basictypes.SDKKind, eventsKind ldevents.EventDataKind, body string) {
+	headers := headersWithEventSchema(CurrentEventsSchemaVersion)
+	headers.Set("Authorization", "fake-key")
+	headers.Set(TagsHeader, "application-id/app")
+	req := st.BuildRequest("POST", "/", []byte(body), headers)
+	d.GetHandler(sdkKind, eventsKind)(httptest.NewRecorder(), req)
+}
+
+func TestEventPayloadsAreNotCapturedByDefault(t *testing.T) {
+	eventRelayTest(t, st.EnvMain, config.EventsConfig{}, func(p eventRelayTestParams) {
+		postEventsForCapture(p.dispatcher, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind, `[{"kind":"custom"}]`)
+
+		bundle := p.dispatcher.GetCapture()
+		assert.False(t, bundle.Active)
+		assert.Len(t, bundle.Payloads, 0)
+	})
+}
+
+func TestEventPayloadsAreCapturedWhileCaptureIsActive(t *testing.T) {
+	eventRelayTest(t, st.EnvMain, config.EventsConfig{}, func(p eventRelayTestParams) {
+		p.dispatcher.StartCapture(time.Minute, DefaultEventCaptureMaxBytes)
+		postEventsForCapture(p.dispatcher, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind, `[{"kind":"custom"}]`)
+		postEventsForCapture(p.dispatcher, basictypes.ServerSDK, ldevents.DiagnosticEventDataKind, `{"kind":"diagnostic"}`)
+
+		bundle := p.dispatcher.GetCapture()
+		assert.True(t, bundle.Active)
+		require.Len(t, bundle.Payloads, 2)
+
+		analytics := bundle.Payloads[0]
+		assert.Equal(t, basictypes.ServerSDK, analytics.SDKKind)
+		assert.Equal(t, ldevents.AnalyticsEventDataKind, analytics.EventsKind)
+		assert.Equal(t, `[{"kind":"custom"}]`, analytics.Body)
+		assert.Equal(t, EventPayloadMetadata{SchemaVersion: CurrentEventsSchemaVersion, Tags: "application-id/app"},
+			analytics.Metadata)
+		assert.Equal(t, "application-id/app", analytics.Headers.Get(TagsHeader))
+		assert.Equal(t, "", analytics.Headers.Get("Authorization"))
+
+		assert.Equal(t, ldevents.DiagnosticEventDataKind, bundle.Payloads[1].EventsKind)
+
+		// The events are still forwarded as usual
+		p.dispatcher.flush()
+		for i := 0; i < 2; i++ {
+			r := helpers.RequireValue(t, p.requestsCh, time.Second)
+			if r.Request.URL.Path == "/bulk" {
+				m.In(t).Assert(r.Body, m.JSONStrEqual(`[{"kind":"custom"}]`))
+			} else {
+				m.In(t).Assert(r.Body, m.JSONStrEqual(`{"kind":"diagnostic"}`))
+			}
+		}
+	})
+}
+
+func TestEventCaptureStopsAfterDuration(t *testing.T) {
+	eventRelayTest(t, st.EnvMain, config.EventsConfig{}, func(p eventRelayTestParams) {
+		p.dispatcher.StartCapture(time.Millisecond*10, DefaultEventCaptureMaxBytes)
+		postEventsForCapture(p.dispatcher, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind, `["a"]`)
+		time.Sleep(time.Millisecond * 20)
+		postEventsForCapture(p.dispatcher, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind, `["b"]`)
+
+		bundle := p.dispatcher.GetCapture()
+		assert.False(t, bundle.Active)
+		require.Len(t, bundle.Payloads, 1)
+		assert.Equal(t, `["a"]`, bundle.Payloads[0].Body)
+	})
+}
+
+func TestEventCaptureDiscardsOldestPayloadsWhenFull(t *testing.T) {
+	eventRelayTest(t, st.EnvMain, config.EventsConfig{}, func(p eventRelayTestParams) {
+		p.dispatcher.StartCapture(time.Minute, 12)
+		for _, body := range []string{`["a"]`, `["b"]`, `["c"]`, `["` + strings.Repeat("x", 20) + `"]`} {
+			postEventsForCapture(p.dispatcher, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind, body)
+		}
+
+		bundle := p.dispatcher.GetCapture()
+		require.Len(t, bundle.Payloads, 2)
+		assert.Equal(t, `["b"]`, bundle.Payloads[0].Body)
+		assert.Equal(t, `["c"]`, bundle.Payloads[1].Body)
+		assert.Equal(t, 2, bundle.DroppedPayloads) // "a" was pushed out, and the last one was too big
+	})
+}
+
+func TestStopCaptureDiscardsPayloads(t *testing.T) {
+	eventRelayTest(t, st.EnvMain, config.EventsConfig{}, func(p eventRelayTestParams) {
+		p.dispatcher.StartCapture(time.Minute, DefaultEventCaptureMaxBytes)
+		postEventsForCapture(p.dispatcher, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind, `["a"]`)
+		p.dispatcher.StopCapture()
+		postEventsForCapture(p.dispatcher, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind, `["b"]`)
+
+		bundle := p.dispatcher.GetCapture()
+		assert.False(t, bundle.Active)
+		assert.Len(t, bundle.Payloads, 0)
+	})
+}
+
+func TestReplayEventCapture(t *testing.T) {
+	bundle := EventCaptureBundle{
+		Payloads: []CapturedEventPayload{
+			{
+				SDKKind:    basictypes.MobileSDK,
+				EventsKind: ldevents.AnalyticsEventDataKind,
+				Headers:    headersWithEventSchema(CurrentEventsSchemaVersion),
+				Body:       `[{"kind":"custom","key":"a","creationDate":1000}]`,
+			},
+			{
+				SDKKind:    basictypes.MobileSDK,
+				EventsKind: ldevents.DiagnosticEventDataKind,
+				Body:       `{"kind":"diagnostic"}`,
+			},
+			{
+				SDKKind:    basictypes.JSClientSDK, // EnvMobile has no environment ID
+				EventsKind: ldevents.AnalyticsEventDataKind,
+				Body:       `[{"kind":"custom","key":"b","creationDate":1000}]`,
+			},
+		},
+	}
+	bundle.Payloads[0].Headers.Set(TagsHeader, "application-id/app")
+
+	eventRelayTest(t, st.EnvMobile, config.EventsConfig{}, func(p eventRelayTestParams) {
+		result := ReplayEventCapture(p.dispatcher, bundle, time.Second)
+		assert.Equal(t, EventReplayResult{Replayed: 1, Skipped: 2, Flushed: true}, result)
+
+		// ReplayEventCapture flushes the events itself, so we do not need to call p.dispatcher.flush()
+		r := helpers.RequireValue(t, p.requestsCh, time.Second)
+		assert.Equal(t, "/mobile", r.Request.URL.Path)
+		assert.Equal(t, string(st.EnvMobile.Config.MobileKey), r.Request.Header.Get("Authorization"))
+		assert.Equal(t, "application-id/app", r.Request.Header.Get(TagsHeader))
+		m.In(t).Assert(r.Body, m.JSONStrEqual(bundle.Payloads[0].Body))
+		helpers.AssertNoMoreValues(t, p.requestsCh, time.Millisecond*50)
+	})
+}
+
+func TestReplayEventCaptureCountsRejectedPayloads(t *testing.T) {
+	bundle := EventCaptureBundle{
+		Payloads: []CapturedEventPayload{
+			{
+				SDKKind:    basictypes.ServerSDK,
+				EventsKind: ldevents.AnalyticsEventDataKind,
+				Headers:    headersWithEventSchema(CurrentEventsSchemaVersion),
+				Body:       `{"kind":"custom"}`,
+			},
+		},
+	}
+	eventRelayTest(t, st.EnvMain, config.EventsConfig{EnableValidation: true}, func(p eventRelayTestParams) {
+		result := ReplayEventCapture(p.dispatcher, bundle, time.Second)
+		assert.Equal(t, EventReplayResult{Rejected: 1, Flushed: true}, result)
+		helpers.AssertNoMoreValues(t, p.requestsCh, time.Millisecond*50)
+	})
+}
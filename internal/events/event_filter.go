@@ -0,0 +1,291 @@
+package events
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	c "github.com/launchdarkly/ld-relay/v7/config"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldattr"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+)
+
+const (
+	redactedAttributesProperty  = "redactedAttributes"
+	oldUserPrivateAttrsProperty = "privateAttrs"
+)
+
+// oldUserBuiltInAttributes are the attributes that are top-level properties in the old user JSON format;
+// any other attribute of an old-style user is a property of "custom".
+var oldUserBuiltInAttributes = map[string]bool{ //nolint:gochecknoglobals
+	"key": true, "secondary": true, "ip": true, "country": true, "email": true, "firstName": true,
+	"lastName": true, "avatar": true, "name": true, "anonymous": true,
+}
+
+// EventFilter applies an environment's filtering rules to analytics events before they are delivered
+// anywhere: it can redact or hash context attributes, and drop events of some kinds or for some flags.
+//
+// A redacted attribute is removed from the context; a hashed attribute is replaced with the hex-encoded
+// SHA-256 hash of its value. In either case, the attribute is added to the context's
+// _meta.redactedAttributes so that LaunchDarkly knows that the original value is not present. For a user
+// in the old JSON format, only redacted attributes are added to privateAttrs, since that means that the
+// attribute is absent and a hashed attribute is still there.
+//
+// Since this operates on the raw event JSON, it works the same for events that are forwarded verbatim and
+// for events from older SDKs that go through the summarizing path, which keeps the context JSON as-is.
+type EventFilter struct {
+	redact    []ldattr.Ref
+	hash      []ldattr.Ref
+	dropKinds map[string]bool
+	dropFlags map[string]bool
+}
+
+// NewEventFilter creates an EventFilter from the filtering properties of an environment's configuration.
+// It returns nil if the environment does not have any filtering rules.
+func NewEventFilter(config c.EnvConfig) *EventFilter {
+	f := &EventFilter{
+		dropKinds: makeStringSet(config.DropEventKinds.Values()),
+		dropFlags: makeStringSet(config.DropFlagKeys.Values()),
+	}
+	for _, a := range config.RedactAttributes.Values() {
+		f.redact = append(f.redact, ldattr.NewRef(a))
+	}
+	for _, a := range config.HashAttributes.Values() {
+		f.hash = append(f.hash, ldattr.NewRef(a))
+	}
+	if len(f.redact) == 0 && len(f.hash) == 0 && len(f.dropKinds) == 0 && len(f.dropFlags) == 0 {
+		return nil
+	}
+	return f
+}
+
+func makeStringSet(values []string) map[string]bool {
+	ret := make(map[string]bool, len(values))
+	for _, v := range values {
+		ret[v] = true
+	}
+	return ret
+}
+
+// apply returns the events that remain after filtering, with any attributes redacted or hashed. Events
+// that are not changed are returned exactly as they were, and anything that is not a JSON object is
+// passed through.
+func (f *EventFilter) apply(events []json.RawMessage) []json.RawMessage {
+	ret := make([]json.RawMessage, 0, len(events))
+	for _, e := range events {
+		event := ldvalue.Parse(e)
+		if event.Type() != ldvalue.ObjectType {
+			ret = append(ret, e)
+			continue
+		}
+		filtered, keep, changed := f.filterEvent(event)
+		switch {
+		case !keep:
+			continue
+		case changed:
+			ret = append(ret, json.RawMessage(filtered.JSONString()))
+		default:
+			ret = append(ret, e)
+		}
+	}
+	return ret
+}
+
+func (f *EventFilter) filterEvent(event ldvalue.Value) (result ldvalue.Value, keep bool, changed bool) {
+	kind := event.GetByKey("kind").StringValue()
+	if f.dropKinds[kind] {
+		return event, false, false
+	}
+	switch kind {
+	case "feature", "debug":
+		if f.dropFlags[event.GetByKey("key").StringValue()] {
+			return event, false, false
+		}
+	case "summary":
+		if len(f.dropFlags) != 0 {
+			features := event.GetByKey("features")
+			var featuresChanged bool
+			features = features.Transform(func(_ int, key string, value ldvalue.Value) (ldvalue.Value, bool) {
+				if f.dropFlags[key] {
+					featuresChanged = true
+					return value, false
+				}
+				return value, true
+			})
+			if featuresChanged {
+				if features.Count() == 0 {
+					return event, false, false
+				}
+				event, changed = withProperty(event, "features", features), true
+			}
+		}
+	}
+	if len(f.redact) != 0 || len(f.hash) != 0 {
+		// Current SDKs send the context as "context"; older ones send a user as "user".
+		for _, prop := range []string{"context", "user"} {
+			if context, ok := event.TryGetByKey(prop); ok && context.Type() == ldvalue.ObjectType {
+				if filteredContext, contextChanged := f.filterContext(context); contextChanged {
+					event, changed = withProperty(event, prop, filteredContext), true
+				}
+			}
+		}
+	}
+	return event, true, changed
+}
+
+func (f *EventFilter) filterContext(context ldvalue.Value) (ldvalue.Value, bool) {
+	kind, hasKind := context.TryGetByKey(ldattr.KindAttr)
+	switch {
+	case !hasKind:
+		return f.filterOldUser(context)
+	case kind.StringValue() == "multi":
+		var changed bool
+		result := context.Transform(func(_ int, key string, value ldvalue.Value) (ldvalue.Value, bool) {
+			if key == ldattr.KindAttr || value.Type() != ldvalue.ObjectType {
+				return value, true
+			}
+			if filtered, contextChanged := f.filterSingleKindContext(value); contextChanged {
+				changed = true
+				return filtered, true
+			}
+			return value, true
+		})
+		return result, changed
+	default:
+		return f.filterSingleKindContext(context)
+	}
+}
+
+// filterSingleKindContext applies the redact and hash rules to a context in the current JSON format,
+// where attributes are top-level properties and attribute references can refer to nested properties.
+func (f *EventFilter) filterSingleKindContext(context ldvalue.Value) (ldvalue.Value, bool) {
+	var redacted []string
+	for _, ref := range f.redact {
+		if updated, found := updateAttribute(context, ref, 0, redactValue); found {
+			context, redacted = updated, append(redacted, ref.String())
+		}
+	}
+	for _, ref := range f.hash {
+		if updated, found := updateAttribute(context, ref, 0, hashValue); found {
+			context, redacted = updated, append(redacted, ref.String())
+		}
+	}
+	if len(redacted) == 0 {
+		return context, false
+	}
+	meta := context.GetByKey("_meta")
+	if meta.Type() != ldvalue.ObjectType {
+		meta = ldvalue.ObjectBuild().Build()
+	}
+	meta = withProperty(meta, redactedAttributesProperty,
+		appendStrings(meta.GetByKey(redactedAttributesProperty), redacted))
+	return withProperty(context, "_meta", meta), true
+}
+
+// filterOldUser applies the redact and hash rules to a user in the old JSON format, where only the
+// built-in attributes are top-level properties and all others are properties of "custom".
+func (f *EventFilter) filterOldUser(user ldvalue.Value) (ldvalue.Value, bool) {
+	var redacted []string
+	var changed bool
+	update := func(ref ldattr.Ref, fn func(ldvalue.Value) (ldvalue.Value, bool)) bool {
+		if ref.Depth() == 1 && oldUserBuiltInAttributes[ref.Component(0)] {
+			if updated, found := updateAttribute(user, ref, 0, fn); found {
+				user, changed = updated, true
+				return true
+			}
+			return false
+		}
+		custom := user.GetByKey("custom")
+		if updated, found := updateAttribute(custom, ref, 0, fn); found {
+			user, changed = withProperty(user, "custom", updated), true
+			return true
+		}
+		return false
+	}
+	for _, ref := range f.redact {
+		if update(ref, redactValue) {
+			redacted = append(redacted, ref.String())
+		}
+	}
+	for _, ref := range f.hash {
+		update(ref, hashValue)
+	}
+	if len(redacted) != 0 {
+		user = withProperty(user, oldUserPrivateAttrsProperty,
+			appendStrings(user.GetByKey(oldUserPrivateAttrsProperty), redacted))
+	}
+	return user, changed
+}
+
+// updateAttribute finds the property that an attribute reference refers to, starting with the component
+// at index, and replaces it with the result of fn; if fn returns false, the property is removed. It
+// returns the updated object and true, or the original object and false if there is no such property.
+func updateAttribute(
+	obj ldvalue.Value,
+	ref ldattr.Ref,
+	index int,
+	fn func(ldvalue.Value) (ldvalue.Value, bool),
+) (ldvalue.Value, bool) {
+	if obj.Type() != ldvalue.ObjectType {
+		return obj, false
+	}
+	name := ref.Component(index)
+	value, ok := obj.TryGetByKey(name)
+	if !ok {
+		return obj, false
+	}
+	if index < ref.Depth()-1 {
+		updated, found := updateAttribute(value, ref, index+1, fn)
+		if !found {
+			return obj, false
+		}
+		return withProperty(obj, name, updated), true
+	}
+	m := obj.AsValueMap().AsMap()
+	if updated, keep := fn(value); keep {
+		m[name] = updated
+	} else {
+		delete(m, name)
+	}
+	return ldvalue.CopyObject(m), true
+}
+
+func redactValue(ldvalue.Value) (ldvalue.Value, bool) {
+	return ldvalue.Null(), false
+}
+
+func hashValue(value ldvalue.Value) (ldvalue.Value, bool) {
+	s := value.StringValue()
+	if !value.IsString() {
+		s = value.JSONString()
+	}
+	sum := sha256.Sum256([]byte(s))
+	return ldvalue.String(hex.EncodeToString(sum[:])), true
+}
+
+func withProperty(obj ldvalue.Value, name string, value ldvalue.Value) ldvalue.Value {
+	m := obj.AsValueMap().AsMap()
+	if m == nil {
+		m = make(map[string]ldvalue.Value)
+	}
+	m[name] = value
+	return ldvalue.CopyObject(m)
+}
+
+// appendStrings adds each of the strings to a JSON array if it is not already there.
+func appendStrings(array ldvalue.Value, values []string) ldvalue.Value {
+	builder := ldvalue.ArrayBuild()
+	existing := make(map[string]bool)
+	for _, v := range array.AsValueArray().AsSlice() {
+		builder.Add(v)
+		existing[v.StringValue()] = true
+	}
+	for _, s := range values {
+		if !existing[s] {
+			builder.Add(ldvalue.String(s))
+			existing[s] = true
+		}
+	}
+	return builder.Build()
+}
@@ -0,0 +1,190 @@
+package events
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
+	st "github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
+
+	"github.com/launchdarkly/go-configtypes"
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+	helpers "github.com/launchdarkly/go-test-helpers/v3"
+	m "github.com/launchdarkly/go-test-helpers/v3/matchers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestEventFilter(t *testing.T, envConfig config.EnvConfig) *EventFilter {
+	f := NewEventFilter(envConfig)
+	require.NotNil(t, f)
+	return f
+}
+
+func applyEventFilter(f *EventFilter, events ...string) []string {
+	raw := make([]json.RawMessage, 0, len(events))
+	for _, e := range events {
+		raw = append(raw, json.RawMessage(e))
+	}
+	var ret []string
+	for _, e := range f.apply(raw) {
+		ret = append(ret, string(e))
+	}
+	return ret
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestNewEventFilterReturnsNilIfThereAreNoRules(t *testing.T) {
+	assert.Nil(t, NewEventFilter(config.EnvConfig{SDKKey: "sdk-key"}))
+}
+
+func TestEventFilterRedactsContextAttributes(t *testing.T) {
+	f := makeTestEventFilter(t, config.EnvConfig{
+		RedactAttributes: configtypes.NewOptStringList([]string{"email", "/address/street", "missing"}),
+	})
+	for _, p := range []struct {
+		name     string
+		event    string
+		expected string
+	}{
+		{
+			"single-kind context",
+			`{"kind":"identify","context":{"kind":"user","key":"a","email":"a@b","name":"x"}}`,
+			`{"kind":"identify","context":{"kind":"user","key":"a","name":"x","_meta":{"redactedAttributes":["email"]}}}`,
+		},
+		{
+			"nested attribute",
+			`{"kind":"identify","context":{"kind":"user","key":"a","address":{"street":"1 Main","city":"x"}}}`,
+			`{"kind":"identify","context":{"kind":"user","key":"a","address":{"city":"x"},` +
+				`"_meta":{"redactedAttributes":["/address/street"]}}}`,
+		},
+		{
+			"existing redacted attributes",
+			`{"kind":"index","context":{"kind":"user","key":"a","email":"a@b","_meta":{"redactedAttributes":["name"]}}}`,
+			`{"kind":"index","context":{"kind":"user","key":"a","_meta":{"redactedAttributes":["name","email"]}}}`,
+		},
+		{
+			"multi-kind context",
+			`{"kind":"identify","context":{"kind":"multi","user":{"key":"a","email":"a@b"},"org":{"key":"b"}}}`,
+			`{"kind":"identify","context":{"kind":"multi","user":{"key":"a","_meta":{"redactedAttributes":["email"]}},` +
+				`"org":{"key":"b"}}}`,
+		},
+		{
+			"old user with built-in and custom attributes",
+			`{"kind":"feature","key":"f","user":{"key":"a","email":"a@b","custom":{"address":{"street":"1 Main"}}}}`,
+			`{"kind":"feature","key":"f","user":{"key":"a","custom":{"address":{}},` +
+				`"privateAttrs":["email","/address/street"]}}`,
+		},
+		{
+			"no matching attributes",
+			`{"kind":"identify","context":{"kind":"user","key":"a"}}`,
+			`{"kind":"identify","context":{"kind":"user","key":"a"}}`,
+		},
+	} {
+		t.Run(p.name, func(t *testing.T) {
+			result := applyEventFilter(f, p.event)
+			require.Len(t, result, 1)
+			assert.JSONEq(t, p.expected, result[0])
+		})
+	}
+}
+
+func TestEventFilterHashesContextAttributes(t *testing.T) {
+	f := makeTestEventFilter(t, config.EnvConfig{
+		HashAttributes: configtypes.NewOptStringList([]string{"email", "age"}),
+	})
+	result := applyEventFilter(f,
+		`{"kind":"identify","context":{"kind":"user","key":"a","email":"a@b","age":30}}`,
+		`{"kind":"identify","user":{"key":"a","email":"a@b"}}`)
+	require.Len(t, result, 2)
+	assert.JSONEq(t, `{"kind":"identify","context":{"kind":"user","key":"a","email":"`+sha256Hex("a@b")+
+		`","age":"`+sha256Hex("30")+`","_meta":{"redactedAttributes":["email","age"]}}}`, result[0])
+	// privateAttrs would mean that the attribute is absent, so a hashed attribute is not added to it
+	assert.JSONEq(t, `{"kind":"identify","user":{"key":"a","email":"`+sha256Hex("a@b")+`"}}`, result[1])
+}
+
+func TestEventFilterDropsEventKinds(t *testing.T) {
+	f := makeTestEventFilter(t, config.EnvConfig{
+		DropEventKinds: configtypes.NewOptStringList([]string{"custom", "identify"}),
+	})
+	result := applyEventFilter(f,
+		`{"kind":"custom","key":"e"}`,
+		`{"kind":"feature","key":"f"}`,
+		`{"kind":"identify","context":{"kind":"user","key":"a"}}`)
+	assert.Equal(t, []string{`{"kind":"feature","key":"f"}`}, result)
+}
+
+func TestEventFilterDropsFlagKeys(t *testing.T) {
+	f := makeTestEventFilter(t, config.EnvConfig{
+		DropFlagKeys: configtypes.NewOptStringList([]string{"secret-flag"}),
+	})
+
+	t.Run("feature and debug events", func(t *testing.T) {
+		result := applyEventFilter(f,
+			`{"kind":"feature","key":"secret-flag"}`,
+			`{"kind":"debug","key":"secret-flag"}`,
+			`{"kind":"feature","key":"other-flag"}`,
+			`{"kind":"custom","key":"secret-flag"}`)
+		assert.Equal(t, []string{`{"kind":"feature","key":"other-flag"}`, `{"kind":"custom","key":"secret-flag"}`}, result)
+	})
+
+	t.Run("summary event", func(t *testing.T) {
+		result := applyEventFilter(f,
+			`{"kind":"summary","features":{"secret-flag":{"counters":[]},"other-flag":{"counters":[]}}}`)
+		require.Len(t, result, 1)
+		assert.JSONEq(t, `{"kind":"summary","features":{"other-flag":{"counters":[]}}}`, result[0])
+	})
+
+	t.Run("summary event with only dropped flags", func(t *testing.T) {
+		result := applyEventFilter(f, `{"kind":"summary","features":{"secret-flag":{"counters":[]}}}`)
+		assert.Len(t, result, 0)
+	})
+}
+
+func TestEventFilterPassesThroughUnchangedEventsVerbatim(t *testing.T) {
+	f := makeTestEventFilter(t, config.EnvConfig{
+		RedactAttributes: configtypes.NewOptStringList([]string{"email"}),
+	})
+	events := []string{`{ "kind": "custom",  "key": "e" }`, `"not an object"`}
+	assert.Equal(t, events, applyEventFilter(f, events...))
+}
+
+func TestEventFilterIsAppliedInSummarizingPath(t *testing.T) {
+	opts := eventRelayTestOptions{dispatcherOptions: EventDispatcherOptions{
+		Filter: makeTestEventFilter(t, config.EnvConfig{
+			RedactAttributes: configtypes.NewOptStringList([]string{"email"}),
+			DropFlagKeys:     configtypes.NewOptStringList([]string{"secret-flag"}),
+		}),
+	}}
+	eventRelayTestWithOptions(t, st.EnvMain, config.EventsConfig{}, opts, func(p eventRelayTestParams) {
+		body := `[
+			{"kind": "feature", "creationDate": 1000, "key": "secret-flag", "version": 1,
+				"user": {"key": "userkey"}, "variation": 0, "value": "a", "default": "a"},
+			{"kind": "identify", "creationDate": 1000, "user": {"key": "userkey", "email": "a@b"}}
+		]`
+		req := st.BuildRequest("POST", "/", []byte(body), headersWithEventSchema(2))
+		handler := p.dispatcher.GetHandler(basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		assert.Equal(t, http.StatusAccepted, w.Result().StatusCode)
+
+		p.dispatcher.flush()
+
+		r := helpers.RequireValue(t, p.requestsCh, time.Second)
+		m.In(t).Assert(r.Body, m.JSONStrEqual(`[
+			{"kind": "identify", "creationDate": 1000,
+				"context": {"key": "userkey", "privateAttrs": ["email"]}}
+		]`))
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/launchdarkly/ld-relay/v7/config"
 	"github.com/launchdarkly/ld-relay/v7/internal/httpconfig"
 
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	ldevents "github.com/launchdarkly/go-sdk-events/v2"
 )
@@ -62,9 +63,9 @@ type EventPublisher interface {
 type EventPayloadMetadata struct {
 	// SchemaVersion is the numeric value of the X-LaunchDarkly-Event-Schema header, or 1 if unknown
 	// (in version 1, this header was not used).
-	SchemaVersion int
+	SchemaVersion int `json:"schemaVersion"`
 	// Tags is the value of the X-LaunchDarkly-Tags header, or "" if none.
-	Tags string
+	Tags string `json:"tags,omitempty"`
 }
 
 // GetEventPayloadMetadata parses EventPayloadMetadata values from an HTTP request.
@@ -88,7 +89,9 @@ type HTTPEventPublisher struct {
 	client      *http.Client
 	authKey     config.SDKCredential
 	baseHeaders http.Header
-	closer      chan<- struct{}
+	sendPolicy  EventSendPolicy
+	beforeFlush func(publish func(EventPayloadMetadata, ...json.RawMessage))
+	closer      chan struct{}
 	closeOnce   sync.Once
 	wg          sync.WaitGroup
 	inputQueue  chan interface{}
@@ -106,6 +109,10 @@ type HTTPEventPublisher struct {
 	capacity   int
 	overflowed bool
 	lock       sync.RWMutex
+
+	// lastFlushDone is the channel returned by the most recent flush() that sent anything. It is only
+	// accessed by the event loop goroutine.
+	lastFlushDone <-chan struct{}
 }
 
 type eventBatch struct {
@@ -117,7 +124,11 @@ type publisherQueue struct {
 	events []json.RawMessage
 }
 
-type flush struct{}
+// flush is the message for delivering the queued events. If replyCh is not nil, it is closed once all of
+// the resulting payloads have been delivered or have failed.
+type flush struct {
+	replyCh chan struct{}
+}
 
 // OptionType defines optional parameters for NewHTTPEventPublisher.
 type OptionType interface {
@@ -158,6 +169,27 @@ func (o OptionCapacity) apply(p *HTTPEventPublisher) error {
 	return nil
 }
 
+// OptionSendPolicy specifies how event payloads are compressed and retried. If not specified, the
+// defaults from MakeEventSendPolicy are used.
+type OptionSendPolicy EventSendPolicy
+
+func (o OptionSendPolicy) apply(p *HTTPEventPublisher) error {
+	p.sendPolicy = EventSendPolicy(o)
+	return nil
+}
+
+// OptionBeforeFlush specifies a function that is called at the start of each flush, which can add more
+// events to the queues by calling the publish function that is passed to it. Those events do not count
+// toward the capacity, since they are derived from events that were already accepted; for instance, a
+// summary event that replaces the summaries in many payloads must not be dropped just because the queue
+// is full.
+type OptionBeforeFlush func(publish func(EventPayloadMetadata, ...json.RawMessage))
+
+func (o OptionBeforeFlush) apply(p *HTTPEventPublisher) error {
+	p.beforeFlush = o
+	return nil
+}
+
 // NewHTTPEventPublisher creates a new HTTPEventPublisher.
 func NewHTTPEventPublisher(authKey config.SDKCredential, httpConfig httpconfig.HTTPConfig, loggers ldlog.Loggers, options ...OptionType) (*HTTPEventPublisher, error) {
 	closer := make(chan struct{})
@@ -175,6 +207,7 @@ func NewHTTPEventPublisher(authKey config.SDKCredential, httpConfig httpconfig.H
 		client:       client,
 		eventsURI:    *defaultEventsBaseURI,
 		authKey:      authKey,
+		sendPolicy:   MakeEventSendPolicy(config.EventsConfig{}),
 		closer:       closer,
 		capacity:     defaultCapacity,
 		inputQueue:   inputQueue,
@@ -218,12 +251,21 @@ func NewHTTPEventPublisher(authKey config.SDKCredential, httpConfig httpconfig.H
 					p.disabled = true
 				case e := <-inputQueue:
 					if p.disabled {
+						if f, ok := e.(flush); ok && f.replyCh != nil {
+							close(f.replyCh)
+						}
 						continue
 					}
 
 					switch e := e.(type) {
 					case flush:
-						p.flush()
+						done := p.flush()
+						if e.replyCh != nil {
+							go func() {
+								<-done
+								close(e.replyCh)
+							}()
+						}
 					case eventBatch:
 						p.append(e)
 					}
@@ -243,11 +285,7 @@ func NewHTTPEventPublisher(authKey config.SDKCredential, httpConfig httpconfig.H
 }
 
 func (p *HTTPEventPublisher) append(batch eventBatch) {
-	queue := p.queues[batch.metadata]
-	if queue == nil {
-		queue = &publisherQueue{events: make([]json.RawMessage, 0, p.capacity)}
-		p.queues[batch.metadata] = queue
-	}
+	queue := p.getQueue(batch.metadata)
 	available := p.capacity - len(queue.events)
 	taken := len(batch.events)
 	if available < len(batch.events) {
@@ -262,6 +300,15 @@ func (p *HTTPEventPublisher) append(batch eventBatch) {
 	queue.events = append(queue.events, batch.events[:taken]...)
 }
 
+func (p *HTTPEventPublisher) getQueue(metadata EventPayloadMetadata) *publisherQueue {
+	queue := p.queues[metadata]
+	if queue == nil {
+		queue = &publisherQueue{events: make([]json.RawMessage, 0, p.capacity)}
+		p.queues[metadata] = queue
+	}
+	return queue
+}
+
 func (p *HTTPEventPublisher) ReplaceCredential(newCredential config.SDKCredential) { //nolint:golint // method is already documented in interface
 	p.lock.Lock()
 	if reflect.TypeOf(newCredential) == reflect.TypeOf(p.authKey) {
@@ -278,7 +325,31 @@ func (p *HTTPEventPublisher) Flush() { //nolint:golint // method is already docu
 	p.inputQueue <- flush{}
 }
 
-func (p *HTTPEventPublisher) flush() {
+// flushBlocking is like Flush, but waits until all of the queued events have been delivered or have failed.
+// It returns false if that did not happen before the deadline.
+func (p *HTTPEventPublisher) flushBlocking(deadline time.Time) bool {
+	replyCh := make(chan struct{})
+	p.inputQueue <- flush{replyCh: replyCh}
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-replyCh:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// flush starts delivering all of the queued events. It returns a channel that is closed once those
+// payloads, and the payloads from any earlier flush, have been delivered or have failed.
+func (p *HTTPEventPublisher) flush() <-chan struct{} {
+	if p.beforeFlush != nil {
+		p.beforeFlush(func(metadata EventPayloadMetadata, events ...json.RawMessage) {
+			queue := p.getQueue(metadata) // not subject to capacity; see OptionBeforeFlush
+			queue.events = append(queue.events, events...)
+		})
+	}
+
 	// Notes on implementation of this method:
 	// - We are creating a new ldevents.EventSender for each payload delivery, because potentially
 	// each one could have different headers (based on EventPayloadMetadata) and also because the
@@ -289,7 +360,7 @@ func (p *HTTPEventPublisher) flush() {
 	// that are never deallocated just because we received different metadata at some point. So in
 	// the multiple-queue case, we will discard any buffers that haven't been used since last flush.
 	if len(p.queues) == 0 {
-		return
+		return p.pendingFlush()
 	}
 	queues := p.queues
 	discardingUnusedBuffers := false
@@ -304,6 +375,7 @@ func (p *HTTPEventPublisher) flush() {
 	authKey := p.authKey
 	p.lock.RUnlock()
 
+	var sends []chan struct{}
 	for metadata, queue := range queues {
 		count := len(queue.events)
 		if count == 0 {
@@ -319,6 +391,8 @@ func (p *HTTPEventPublisher) flush() {
 			continue
 		}
 		p.wg.Add(1)
+		sent := make(chan struct{})
+		sends = append(sends, sent)
 
 		schemaVersion := metadata.SchemaVersion
 		tags := metadata.Tags
@@ -338,9 +412,8 @@ func (p *HTTPEventPublisher) flush() {
 		}
 
 		go func() {
-			// The EventSender created by ldevents.NewDefaultEventSender implements the standard retry behavior,
-			// and error logging, in its SendEventData method. Retries could cause this call to block for a while,
-			// so it's run on a separate goroutine.
+			// sendEventData implements the retry behavior and error logging. Retries could cause this call to
+			// block for a while, so it's run on a separate goroutine.
 			sendConfig := ldevents.EventSenderConfiguration{
 				Client:        p.client,
 				BaseURI:       p.baseURI,
@@ -348,13 +421,40 @@ func (p *HTTPEventPublisher) flush() {
 				SchemaVersion: schemaVersion,
 				Loggers:       p.loggers,
 			}
-			result := ldevents.SendEventDataWithRetry(sendConfig, ldevents.AnalyticsEventDataKind, p.uriPath, payload, count)
+			result := sendEventData(sendConfig, p.sendPolicy, p.closer, ldevents.AnalyticsEventDataKind, p.uriPath,
+				payload, count)
+			close(sent)
 			p.wg.Done()
 			if result.MustShutDown {
 				p.disableQueue <- struct{}{}
 			}
 		}()
 	}
+	if len(sends) == 0 {
+		return p.pendingFlush()
+	}
+	previous := p.pendingFlush()
+	done := make(chan struct{})
+	go func() {
+		<-previous
+		for _, sent := range sends {
+			<-sent
+		}
+		close(done)
+	}()
+	p.lastFlushDone = done
+	return done
+}
+
+// pendingFlush returns the channel from the most recent flush that sent anything, or a closed channel if
+// there was none.
+func (p *HTTPEventPublisher) pendingFlush() <-chan struct{} {
+	if p.lastFlushDone == nil {
+		done := make(chan struct{})
+		close(done)
+		p.lastFlushDone = done
+	}
+	return p.lastFlushDone
 }
 
 func (p *HTTPEventPublisher) Close() { //nolint:golint // method is already documented in interface
@@ -364,3 +464,39 @@ func (p *HTTPEventPublisher) Close() { //nolint:golint // method is already docu
 		close(p.disableQueue)
 	})
 }
+
+// eventContextKeys is the subset of event properties that can identify the evaluation context. Current
+// SDKs send either a full context or, in feature and debug events, only the context keys; older SDKs send
+// a user or a user key.
+type eventContextKeys struct {
+	Context     *ldcontext.Context `json:"context"`
+	ContextKeys map[string]string  `json:"contextKeys"`
+	User        *struct {
+		Key string `json:"key"`
+	} `json:"user"`
+	UserKey string `json:"userKey"`
+}
+
+// getEventContextKey returns the fully qualified key of the event's context, or "" if the event does not
+// have one (such as a summary event or a diagnostic event).
+func getEventContextKey(event json.RawMessage) string {
+	var ec eventContextKeys
+	if err := json.Unmarshal(event, &ec); err != nil {
+		return ""
+	}
+	switch {
+	case ec.Context != nil && ec.Context.IsDefined():
+		return ec.Context.FullyQualifiedKey()
+	case len(ec.ContextKeys) > 0:
+		builder := ldcontext.NewMultiBuilder()
+		for kind, key := range ec.ContextKeys {
+			builder.Add(ldcontext.NewWithKind(ldcontext.Kind(kind), key))
+		}
+		if context := builder.Build(); context.Err() == nil {
+			return context.FullyQualifiedKey()
+		}
+	case ec.User != nil:
+		return ec.User.Key
+	}
+	return ec.UserKey
+}
@@ -202,6 +202,28 @@ func TestHTTPEventPublisherErrorRetry(t *testing.T) {
 	})
 }
 
+func TestHTTPEventPublisherCloseDoesNotWaitForRetry(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(503))
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		policy := EventSendPolicy{MaxAttempts: 2, RetryDelay: time.Hour, MaxRetryDelay: time.Hour}
+		publisher, _ := NewHTTPEventPublisher(testSDKKey, defaultHTTPConfig(), mockLog.Loggers,
+			OptionBaseURI(server.URL), OptionSendPolicy(policy))
+		publisher.Publish(EventPayloadMetadata{}, json.RawMessage(`"hello"`))
+		publisher.Flush()
+		helpers.RequireValue(t, requestsCh, time.Second)
+
+		closedCh := make(chan struct{})
+		go func() {
+			publisher.Close()
+			close(closedCh)
+		}()
+		helpers.AssertChannelClosed(t, closedCh, time.Second)
+		helpers.AssertNoMoreValues(t, requestsCh, time.Millisecond*50)
+	})
+}
+
 func TestHTTPEventPublisherUnrecoverableError(t *testing.T) {
 	mockLog := ldlogtest.NewMockLog()
 	defer mockLog.DumpIfTestFailed(t)
@@ -268,3 +290,96 @@ func TestHTTPEventPublisherReplaceCredential(t *testing.T) {
 		assert.Equal(t, string(newSDKKey), r2.Request.Header.Get("Authorization"))
 	})
 }
+
+func TestHTTPEventPublisherFlushBlockingWaitsForDelivery(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+	release := make(chan struct{})
+	handler, requestsCh := httphelpers.RecordingHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		publisher, _ := NewHTTPEventPublisher(testSDKKey, defaultHTTPConfig(), mockLog.Loggers, OptionBaseURI(server.URL))
+		defer publisher.Close()
+		publisher.Publish(EventPayloadMetadata{}, json.RawMessage(`"hello"`))
+
+		assert.False(t, publisher.flushBlocking(time.Now().Add(time.Millisecond*50)))
+		close(release)
+		assert.True(t, publisher.flushBlocking(time.Now().Add(time.Second)))
+		r := helpers.RequireValue(t, requestsCh, time.Second)
+		m.In(t).Assert(r.Body, m.JSONStrEqual(`["hello"]`))
+	})
+}
+
+func TestHTTPEventPublisherFlushBlockingWaitsForEarlierFlush(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+	release, firstRequest := make(chan struct{}), make(chan struct{}, 1)
+	firstRequest <- struct{}{}
+	handler, requestsCh := httphelpers.RecordingHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-firstRequest: // only the first payload is held up
+			<-release
+		default:
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		publisher, _ := NewHTTPEventPublisher(testSDKKey, defaultHTTPConfig(), mockLog.Loggers, OptionBaseURI(server.URL))
+		defer publisher.Close()
+		publisher.Publish(EventPayloadMetadata{}, json.RawMessage(`"a"`))
+		publisher.Flush()
+		helpers.RequireValue(t, requestsCh, time.Second)
+		publisher.Publish(EventPayloadMetadata{}, json.RawMessage(`"b"`))
+
+		// "b" is delivered right away, but "a" from the earlier flush is still in flight
+		assert.False(t, publisher.flushBlocking(time.Now().Add(time.Millisecond*100)))
+		r := helpers.RequireValue(t, requestsCh, time.Second)
+		m.In(t).Assert(r.Body, m.JSONStrEqual(`["b"]`))
+		close(release)
+		assert.True(t, publisher.flushBlocking(time.Now().Add(time.Second)))
+	})
+}
+
+func TestHTTPEventPublisherEventsFromBeforeFlushAreNotLimitedByCapacity(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(http.StatusAccepted))
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		beforeFlush := OptionBeforeFlush(func(publish func(EventPayloadMetadata, ...json.RawMessage)) {
+			publish(EventPayloadMetadata{}, json.RawMessage(`"summary"`))
+		})
+		publisher, _ := NewHTTPEventPublisher(testSDKKey, defaultHTTPConfig(), mockLog.Loggers,
+			OptionBaseURI(server.URL), OptionCapacity(1), beforeFlush)
+		defer publisher.Close()
+		publisher.Publish(EventPayloadMetadata{}, json.RawMessage(`"a"`))
+		publisher.Flush()
+
+		r := helpers.RequireValue(t, requestsCh, time.Second)
+		m.In(t).Assert(r.Body, m.JSONStrEqual(`["a","summary"]`))
+	})
+}
+
+func TestGetEventContextKey(t *testing.T) {
+	for _, p := range []struct {
+		name     string
+		event    string
+		expected string
+	}{
+		{"user context", `{"kind":"identify","context":{"kind":"user","key":"a"}}`, "a"},
+		{"other context kind", `{"kind":"identify","context":{"kind":"org","key":"b"}}`, "org:b"},
+		{"multi-kind context", `{"kind":"identify","context":{"kind":"multi","user":{"key":"a"},"org":{"key":"b"}}}`,
+			"org:b:user:a"},
+		{"context keys", `{"kind":"feature","contextKeys":{"user":"a"}}`, "a"},
+		{"multiple context keys", `{"kind":"feature","contextKeys":{"user":"a","org":"b"}}`, "org:b:user:a"},
+		{"old user", `{"kind":"identify","user":{"key":"a"}}`, "a"},
+		{"old user key", `{"kind":"feature","userKey":"a"}`, "a"},
+		{"no context", `{"kind":"summary"}`, ""},
+		{"not an object", `"x"`, ""},
+	} {
+		t.Run(p.name, func(t *testing.T) {
+			assert.Equal(t, p.expected, getEventContextKey(json.RawMessage(p.event)))
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package events
+
+import (
+	"encoding/json"
+	"math/rand"
+
+	c "github.com/launchdarkly/ld-relay/v7/config"
+)
+
+// EventSampler discards a random fraction of analytics events before they are forwarded to LaunchDarkly,
+// according to an environment's sample rates for each kind of event and for each custom event key. A
+// rate for a custom event key takes precedence over the rate for custom events in general, and events
+// that do not have a rate are always kept.
+//
+// Summary events are never sampled, since they are already an aggregate of many evaluations. For the
+// same reason, feature events from older SDKs are never sampled if Relay is going to summarize them.
+type EventSampler struct {
+	kindRates   map[string]float64
+	customRates map[string]float64
+	random      func() float64
+}
+
+// sampledEvent is the subset of event properties that EventSampler uses.
+type sampledEvent struct {
+	Kind string `json:"kind"`
+	Key  string `json:"key"`
+}
+
+// NewEventSampler creates an EventSampler from the sampling properties of an environment's configuration.
+// It returns nil if the environment does not have any sample rates. Values that are not in the correct
+// format are ignored; config.ValidateConfig has already reported them.
+func NewEventSampler(config c.EnvConfig) *EventSampler {
+	s := &EventSampler{
+		kindRates:   makeSampleRates(config.EventSampleRates.Values()),
+		customRates: makeSampleRates(config.CustomEventSampleRates.Values()),
+		random:      rand.Float64, //nolint:gosec // doesn't need to be cryptographically secure
+	}
+	if len(s.kindRates) == 0 && len(s.customRates) == 0 {
+		return nil
+	}
+	return s
+}
+
+func makeSampleRates(values []string) map[string]float64 {
+	ret := make(map[string]float64, len(values))
+	for _, v := range values {
+		if name, rate, ok := c.ParseEventSampleRate(v); ok {
+			ret[name] = rate
+		}
+	}
+	return ret
+}
+
+// sample returns the events that were kept, and the number of events of each kind that were discarded.
+// The summarizing parameter is true if the events are from an older SDK and will be summarized by Relay.
+func (s *EventSampler) sample(events []json.RawMessage, summarizing bool) ([]json.RawMessage, map[string]int) {
+	var sampledOut map[string]int
+	ret := make([]json.RawMessage, 0, len(events))
+	for _, e := range events {
+		var se sampledEvent
+		if err := json.Unmarshal(e, &se); err == nil && !s.keep(se, summarizing) {
+			if sampledOut == nil {
+				sampledOut = make(map[string]int)
+			}
+			sampledOut[se.Kind]++
+			continue
+		}
+		ret = append(ret, e)
+	}
+	return ret, sampledOut
+}
+
+func (s *EventSampler) keep(e sampledEvent, summarizing bool) bool {
+	if e.Kind == "summary" || (summarizing && e.Kind == "feature") {
+		return true
+	}
+	rate, ok := s.customRates[e.Key]
+	if !ok || e.Kind != "custom" {
+		if rate, ok = s.kindRates[e.Kind]; !ok {
+			return true
+		}
+	}
+	return s.random() < rate
+}
@@ -0,0 +1,115 @@
+package events
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
+	st "github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
+
+	"github.com/launchdarkly/go-configtypes"
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+	helpers "github.com/launchdarkly/go-test-helpers/v3"
+	m "github.com/launchdarkly/go-test-helpers/v3/matchers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makeTestEventSampler creates an EventSampler whose random number is always 0.5, so that events with
+// a rate above 0.5 are always kept and events with a rate of 0.5 or less are always discarded.
+func makeTestEventSampler(t *testing.T, kindRates, customRates []string) *EventSampler {
+	s := NewEventSampler(config.EnvConfig{
+		EventSampleRates:       configtypes.NewOptStringList(kindRates),
+		CustomEventSampleRates: configtypes.NewOptStringList(customRates),
+	})
+	require.NotNil(t, s)
+	s.random = func() float64 { return 0.5 }
+	return s
+}
+
+func sampleEvents(s *EventSampler, summarizing bool, events ...string) ([]string, map[string]int) {
+	raw := make([]json.RawMessage, 0, len(events))
+	for _, e := range events {
+		raw = append(raw, json.RawMessage(e))
+	}
+	kept, sampledOut := s.sample(raw, summarizing)
+	var ret []string
+	for _, e := range kept {
+		ret = append(ret, string(e))
+	}
+	return ret, sampledOut
+}
+
+func TestNewEventSamplerReturnsNilIfThereAreNoRates(t *testing.T) {
+	assert.Nil(t, NewEventSampler(config.EnvConfig{SDKKey: "sdk-key"}))
+}
+
+func TestEventSamplerUsesRateForEventKind(t *testing.T) {
+	s := makeTestEventSampler(t, []string{"custom=0.1", "identify=0.9"}, nil)
+	kept, sampledOut := sampleEvents(s, false,
+		`{"kind":"custom","key":"a"}`,
+		`{"kind":"custom","key":"b"}`,
+		`{"kind":"identify"}`,
+		`{"kind":"index"}`)
+	assert.Equal(t, []string{`{"kind":"identify"}`, `{"kind":"index"}`}, kept)
+	assert.Equal(t, map[string]int{"custom": 2}, sampledOut)
+}
+
+func TestEventSamplerUsesRateForCustomEventKey(t *testing.T) {
+	s := makeTestEventSampler(t, []string{"custom=0.1"}, []string{"important=1", "hot-path=0"})
+	kept, sampledOut := sampleEvents(s, false,
+		`{"kind":"custom","key":"important"}`,
+		`{"kind":"custom","key":"hot-path"}`,
+		`{"kind":"custom","key":"other"}`,
+		`{"kind":"feature","key":"hot-path"}`)
+	assert.Equal(t, []string{`{"kind":"custom","key":"important"}`, `{"kind":"feature","key":"hot-path"}`}, kept)
+	assert.Equal(t, map[string]int{"custom": 2}, sampledOut)
+}
+
+func TestEventSamplerNeverSamplesSummaryEvents(t *testing.T) {
+	s := makeTestEventSampler(t, []string{"feature=0"}, nil)
+
+	kept, sampledOut := sampleEvents(s, false, `{"kind":"summary"}`, `{"kind":"feature"}`)
+	assert.Equal(t, []string{`{"kind":"summary"}`}, kept)
+	assert.Equal(t, map[string]int{"feature": 1}, sampledOut)
+
+	// Feature events that Relay is going to summarize are not sampled either
+	kept, sampledOut = sampleEvents(s, true, `{"kind":"feature"}`)
+	assert.Equal(t, []string{`{"kind":"feature"}`}, kept)
+	assert.Nil(t, sampledOut)
+}
+
+func TestEventSamplerIsAppliedOnlyToForwardedEvents(t *testing.T) {
+	sink, buf := makeTestEventSink(t, config.EventSinkConfig{})
+	var sampledOut map[string]int
+	var sampledOutSDKKind basictypes.SDKKind
+	opts := eventRelayTestOptions{dispatcherOptions: EventDispatcherOptions{
+		Sinks:           []EventSink{sink},
+		SinkEnvironment: testSinkEnv,
+		Sampler:         makeTestEventSampler(t, []string{"custom=0"}, nil),
+		OnEventsSampledOut: func(req *http.Request, sdkKind basictypes.SDKKind, countsByKind map[string]int) {
+			sampledOutSDKKind, sampledOut = sdkKind, countsByKind
+		},
+	}}
+	eventRelayTestWithOptions(t, st.EnvMain, config.EventsConfig{}, opts, func(p eventRelayTestParams) {
+		body := `[{"kind":"custom","key":"a"},{"kind":"identify"}]`
+		req := st.BuildRequest("POST", "/", []byte(body), headersWithEventSchema(CurrentEventsSchemaVersion))
+		handler := p.dispatcher.GetHandler(basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		assert.Equal(t, http.StatusAccepted, w.Result().StatusCode)
+
+		p.dispatcher.flush()
+
+		r := helpers.RequireValue(t, p.requestsCh, time.Second)
+		m.In(t).Assert(r.Body, m.JSONStrEqual(`[{"kind":"identify"}]`))
+		assert.Equal(t, basictypes.ServerSDK, sampledOutSDKKind)
+		assert.Equal(t, map[string]int{"custom": 1}, sampledOut)
+		assert.Len(t, parseEventSinkRecords(t, buf.String()), 2)
+	})
+}
@@ -0,0 +1,216 @@
+package events
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	c "github.com/launchdarkly/ld-relay/v7/config"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+	"github.com/pborman/uuid"
+)
+
+const (
+	payloadIDHeader = "X-LaunchDarkly-Payload-ID"
+)
+
+// EventSendPolicy describes how Relay delivers event payloads to LaunchDarkly: whether they are
+// compressed, and how failed deliveries are retried.
+//
+// After each failed attempt, the delay before the next one starts at RetryDelay and doubles each time,
+// up to MaxRetryDelay; a random jitter of up to half of that delay is added, so that many Relay instances
+// that failed at the same time do not all retry at the same time.
+type EventSendPolicy struct {
+	Compress      bool
+	MaxAttempts   int
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+
+	// OnResult, if not nil, is called after each payload of analytics events has either been delivered or
+	// failed after all retries, with the number of events in the payload.
+	OnResult func(eventCount int, success bool)
+}
+
+// MakeEventSendPolicy creates an EventSendPolicy from the configuration, using defaults for any
+// properties that are not set.
+func MakeEventSendPolicy(config c.EventsConfig) EventSendPolicy {
+	return EventSendPolicy{
+		Compress:      config.EnableCompression,
+		MaxAttempts:   config.MaxAttempts.GetOrElse(c.DefaultEventsMaxAttempts),
+		RetryDelay:    config.RetryDelay.GetOrElse(c.DefaultEventsRetryDelay),
+		MaxRetryDelay: config.MaxRetryDelay.GetOrElse(c.DefaultEventsMaxRetryDelay),
+	}
+}
+
+func (p EventSendPolicy) retryDelay(attempt int) time.Duration {
+	delay := p.RetryDelay
+	for i := 1; i < attempt && delay < p.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxRetryDelay {
+		delay = p.MaxRetryDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1)) //nolint:gosec // doesn't need to be secure
+}
+
+// sendEventData delivers an event payload to LaunchDarkly, and reports the result to the policy's OnResult
+// function if any. If the closer channel is closed while it is waiting to retry, it gives up instead of
+// retrying, so that the component that is shutting down does not have to wait out the retry delay.
+func sendEventData(
+	config ldevents.EventSenderConfiguration,
+	policy EventSendPolicy,
+	closer <-chan struct{},
+	kind ldevents.EventDataKind,
+	overridePath string,
+	data []byte,
+	eventCount int,
+) ldevents.EventSenderResult {
+	result := sendEventDataWithRetry(config, policy, closer, kind, overridePath, data, eventCount)
+	if policy.OnResult != nil && kind == ldevents.AnalyticsEventDataKind {
+		policy.OnResult(eventCount, result.Success)
+	}
+	return result
+}
+
+// sendEventDataWithRetry delivers an event payload to LaunchDarkly. It is equivalent to
+// ldevents.SendEventDataWithRetry, with the same headers, logging, and handling of unrecoverable errors,
+// except that it compresses the payload and retries failed deliveries according to the EventSendPolicy.
+// Retries are done synchronously, unless the closer channel is closed.
+func sendEventDataWithRetry(
+	config ldevents.EventSenderConfiguration,
+	policy EventSendPolicy,
+	closer <-chan struct{},
+	kind ldevents.EventDataKind,
+	overridePath string,
+	data []byte,
+	eventCount int,
+) ldevents.EventSenderResult {
+	headers := make(http.Header)
+	if config.BaseHeaders != nil {
+		for k, vv := range config.BaseHeaders() {
+			headers[k] = vv
+		}
+	}
+	headers.Set("Content-Type", "application/json")
+
+	var path, description string
+	switch kind {
+	case ldevents.AnalyticsEventDataKind:
+		path = defaultEventsURIPath
+		description = fmt.Sprintf("%d events", eventCount)
+		schemaVersion := config.SchemaVersion
+		if schemaVersion == 0 {
+			schemaVersion = CurrentEventsSchemaVersion
+		}
+		headers.Set(EventSchemaHeader, strconv.Itoa(schemaVersion))
+		// The payload ID is the same for every attempt, so LaunchDarkly can discard duplicates.
+		headers.Set(payloadIDHeader, uuid.New())
+	case ldevents.DiagnosticEventDataKind:
+		path = "/diagnostic"
+		description = "diagnostic event"
+	default:
+		return ldevents.EventSenderResult{}
+	}
+	if overridePath != "" {
+		path = "/" + strings.TrimLeft(overridePath, "/")
+	}
+	baseURI := strings.TrimRight(config.BaseURI, "/")
+	if baseURI == "" {
+		baseURI = c.DefaultEventsURI
+	}
+	uri := baseURI + path
+
+	config.Loggers.Debugf("Sending %s: %s", description, data)
+
+	body := data
+	if policy.Compress {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, _ = zw.Write(data)
+		_ = zw.Close()
+		body = buf.Bytes()
+		headers.Set("Content-Encoding", "gzip")
+	}
+
+	client := config.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			delay := policy.retryDelay(attempt - 1)
+			config.Loggers.Warnf("Will retry posting %s after %s", description, delay)
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-closer:
+				timer.Stop()
+				config.Loggers.Warnf("Shutting down, so %s will not be retried", description)
+				return ldevents.EventSenderResult{}
+			}
+		}
+		req, reqErr := http.NewRequest("POST", uri, bytes.NewReader(body))
+		if reqErr != nil { // COVERAGE: no way to simulate this condition in unit tests
+			config.Loggers.Errorf("Unexpected error while creating event request: %+v", reqErr)
+			return ldevents.EventSenderResult{}
+		}
+		req.Header = headers
+
+		resp, respErr := client.Do(req)
+		if resp != nil && resp.Body != nil {
+			_, _ = io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+		}
+		if respErr != nil {
+			config.Loggers.Warnf("Unexpected error while sending events: %+v", respErr)
+			continue
+		}
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			result := ldevents.EventSenderResult{Success: true}
+			if t, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+				result.TimeFromServer = ldtime.UnixMillisFromTime(t)
+			}
+			return result
+		}
+		if !isHTTPErrorRecoverable(resp.StatusCode) {
+			config.Loggers.Warnf("Received HTTP error %d for sending %s - giving up permanently", resp.StatusCode, description)
+			// A payload that is too large is a failure for this request only; later payloads may be smaller.
+			return ldevents.EventSenderResult{MustShutDown: resp.StatusCode != http.StatusRequestEntityTooLarge}
+		}
+		maybeRetry := "will retry"
+		if attempt == maxAttempts {
+			maybeRetry = "some events were dropped"
+		}
+		config.Loggers.Warnf("Received HTTP error %d for sending %s - %s", resp.StatusCode, description, maybeRetry)
+	}
+	return ldevents.EventSenderResult{}
+}
+
+// isHTTPErrorRecoverable returns true if an HTTP error status might be resolved by retrying, using the
+// same rules as the SDKs.
+func isHTTPErrorRecoverable(statusCode int) bool {
+	if statusCode >= 400 && statusCode < 500 {
+		switch statusCode {
+		case http.StatusBadRequest, http.StatusRequestTimeout, http.StatusTooManyRequests:
+			return true
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,177 @@
+package events
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
+	st "github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
+
+	"github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+	helpers "github.com/launchdarkly/go-test-helpers/v3"
+	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sendTestEventData(
+	t *testing.T,
+	policy EventSendPolicy,
+	handler http.Handler,
+	action func(ldevents.EventSenderResult, <-chan httphelpers.HTTPRequestInfo),
+) {
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+	recordingHandler, requestsCh := httphelpers.RecordingHandler(handler)
+	httphelpers.WithServer(recordingHandler, func(server *httptest.Server) {
+		sendConfig := ldevents.EventSenderConfiguration{BaseURI: server.URL, Loggers: mockLog.Loggers}
+		result := sendEventData(sendConfig, policy, nil, ldevents.AnalyticsEventDataKind, "", []byte(`["hello"]`), 1)
+		action(result, requestsCh)
+	})
+}
+
+func TestMakeEventSendPolicyDefaults(t *testing.T) {
+	assert.Equal(t, EventSendPolicy{
+		MaxAttempts:   config.DefaultEventsMaxAttempts,
+		RetryDelay:    config.DefaultEventsRetryDelay,
+		MaxRetryDelay: config.DefaultEventsMaxRetryDelay,
+	}, MakeEventSendPolicy(config.EventsConfig{}))
+}
+
+func TestEventSendPolicyRetryDelayIncreasesExponentiallyWithJitter(t *testing.T) {
+	policy := EventSendPolicy{RetryDelay: time.Second, MaxRetryDelay: time.Second * 5}
+	for i := 0; i < 20; i++ {
+		for attempt, base := range map[int]time.Duration{1: time.Second, 2: time.Second * 2, 3: time.Second * 4,
+			4: time.Second * 5, 10: time.Second * 5} {
+			delay := policy.retryDelay(attempt)
+			assert.GreaterOrEqual(t, delay, base)
+			assert.LessOrEqual(t, delay, base+base/2)
+		}
+	}
+}
+
+func TestSendEventDataCompressesPayload(t *testing.T) {
+	sendTestEventData(t, EventSendPolicy{Compress: true, MaxAttempts: 1}, httphelpers.HandlerWithStatus(202),
+		func(result ldevents.EventSenderResult, requestsCh <-chan httphelpers.HTTPRequestInfo) {
+			assert.True(t, result.Success)
+			r := helpers.RequireValue(t, requestsCh, time.Second)
+			assert.Equal(t, "gzip", r.Request.Header.Get("Content-Encoding"))
+			zr, err := gzip.NewReader(bytes.NewReader(r.Body))
+			require.NoError(t, err)
+			body, err := io.ReadAll(zr)
+			require.NoError(t, err)
+			assert.Equal(t, `["hello"]`, string(body))
+		})
+}
+
+func TestSendEventDataDoesNotCompressPayloadByDefault(t *testing.T) {
+	sendTestEventData(t, MakeEventSendPolicy(config.EventsConfig{}), httphelpers.HandlerWithStatus(202),
+		func(result ldevents.EventSenderResult, requestsCh <-chan httphelpers.HTTPRequestInfo) {
+			r := helpers.RequireValue(t, requestsCh, time.Second)
+			assert.Equal(t, "", r.Request.Header.Get("Content-Encoding"))
+			assert.Equal(t, `["hello"]`, string(r.Body))
+		})
+}
+
+func TestSendEventDataRetriesUpToMaxAttempts(t *testing.T) {
+	policy := EventSendPolicy{MaxAttempts: 3, RetryDelay: time.Millisecond, MaxRetryDelay: time.Millisecond * 10}
+	sendTestEventData(t, policy, httphelpers.HandlerWithStatus(503),
+		func(result ldevents.EventSenderResult, requestsCh <-chan httphelpers.HTTPRequestInfo) {
+			assert.False(t, result.Success)
+			assert.False(t, result.MustShutDown)
+			r1 := helpers.RequireValue(t, requestsCh, time.Second)
+			r2 := helpers.RequireValue(t, requestsCh, time.Second)
+			r3 := helpers.RequireValue(t, requestsCh, time.Second)
+			helpers.AssertNoMoreValues(t, requestsCh, time.Millisecond*50)
+
+			payloadID := r1.Request.Header.Get(payloadIDHeader)
+			assert.NotEqual(t, "", payloadID)
+			assert.Equal(t, payloadID, r2.Request.Header.Get(payloadIDHeader))
+			assert.Equal(t, payloadID, r3.Request.Header.Get(payloadIDHeader))
+		})
+}
+
+func TestSendEventDataStopsRetryingAfterSuccess(t *testing.T) {
+	policy := EventSendPolicy{MaxAttempts: 5, RetryDelay: time.Millisecond, MaxRetryDelay: time.Millisecond}
+	handler := httphelpers.SequentialHandler(httphelpers.HandlerWithStatus(503), httphelpers.HandlerWithStatus(202))
+	sendTestEventData(t, policy, handler,
+		func(result ldevents.EventSenderResult, requestsCh <-chan httphelpers.HTTPRequestInfo) {
+			assert.True(t, result.Success)
+			helpers.RequireValue(t, requestsCh, time.Second)
+			helpers.RequireValue(t, requestsCh, time.Second)
+			helpers.AssertNoMoreValues(t, requestsCh, time.Millisecond*50)
+		})
+}
+
+func TestSendEventDataDoesNotRetryUnrecoverableError(t *testing.T) {
+	policy := EventSendPolicy{MaxAttempts: 5, RetryDelay: time.Millisecond, MaxRetryDelay: time.Millisecond}
+	sendTestEventData(t, policy, httphelpers.HandlerWithStatus(401),
+		func(result ldevents.EventSenderResult, requestsCh <-chan httphelpers.HTTPRequestInfo) {
+			assert.True(t, result.MustShutDown)
+			helpers.RequireValue(t, requestsCh, time.Second)
+			helpers.AssertNoMoreValues(t, requestsCh, time.Millisecond*50)
+		})
+}
+
+func TestSendEventDataDoesNotRetryAfterCloserIsClosed(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+	policy := EventSendPolicy{MaxAttempts: 3, RetryDelay: time.Hour, MaxRetryDelay: time.Hour}
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(503))
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		sendConfig := ldevents.EventSenderConfiguration{BaseURI: server.URL, Loggers: mockLog.Loggers}
+		closer := make(chan struct{})
+		resultCh := make(chan ldevents.EventSenderResult, 1)
+		go func() {
+			resultCh <- sendEventData(sendConfig, policy, closer, ldevents.AnalyticsEventDataKind, "", []byte(`["hello"]`), 1)
+		}()
+		helpers.RequireValue(t, requestsCh, time.Second)
+		close(closer)
+		result := helpers.RequireValue(t, resultCh, time.Second)
+		assert.False(t, result.Success)
+		helpers.AssertNoMoreValues(t, requestsCh, time.Millisecond*50)
+	})
+}
+
+func TestDiagnosticEventsAreForwardedInBackground(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+	release := make(chan struct{})
+	slowHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(202)
+	})
+	handler, requestsCh := httphelpers.RecordingHandler(slowHandler)
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		eventsURI, _ := configtypes.NewOptURLAbsoluteFromString(server.URL)
+		queueSize, _ := configtypes.NewOptIntGreaterThanZero(1)
+		dispatcher := NewEventDispatcher(st.EnvMain.Config.SDKKey, "", "", mockLog.Loggers,
+			config.EventsConfig{EventsURI: eventsURI, DiagnosticQueueSize: queueSize},
+			defaultHTTPConfig(), nil, EventDispatcherOptions{}, 0)
+		defer dispatcher.Close()
+		defer close(release) // so the slow request can finish before the dispatcher is closed
+		handlerFn := dispatcher.GetHandler(basictypes.ServerSDK, ldevents.DiagnosticEventDataKind)
+
+		// The first payload is picked up by the background goroutine, which is then blocked by the slow
+		// response; the second one waits in the queue; the third one is dropped. None of these calls block.
+		for i := 0; i < 3; i++ {
+			if i == 1 {
+				helpers.RequireValue(t, requestsCh, time.Second)
+			}
+			w := httptest.NewRecorder()
+			handlerFn(w, st.BuildRequest("POST", "/", []byte(`{"kind":"diagnostic"}`), nil))
+			assert.Equal(t, http.StatusAccepted, w.Result().StatusCode)
+		}
+		mockLog.AssertMessageMatch(t, true, ldlog.Warn, "Exceeded diagnostic event queue capacity of 1")
+	})
+}
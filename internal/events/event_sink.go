@@ -0,0 +1,306 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	c "github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
+	"github.com/launchdarkly/ld-relay/v7/internal/util"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+)
+
+// EventSink is a destination other than LaunchDarkly for the events that Relay receives from SDKs. A
+// single EventSink can be shared by any number of environments.
+type EventSink interface {
+	// Name returns the name of the sink from the configuration.
+	Name() string
+
+	// AcceptsEnvironment returns true if the sink should receive events for the specified environment.
+	AcceptsEnvironment(env EventSinkEnvironment) bool
+
+	// NewPublisher returns an EventPublisher that delivers events of the specified kind, for the specified
+	// environment and kind of SDK, to the sink. It returns nil if the sink does not accept that kind of
+	// event.
+	NewPublisher(env EventSinkEnvironment, sdkKind basictypes.SDKKind, dataKind ldevents.EventDataKind) EventPublisher
+
+	// Close releases all resources used by the sink. It should be called only after all of the publishers
+	// that were created from it have been closed.
+	Close() error
+}
+
+// EventSinkEnvironment identifies the environment that events came from, for the purposes of EventSink.
+type EventSinkEnvironment struct {
+	Name string
+	ID   c.EnvironmentID
+
+	// OnEventsDropped, if set, is called with the number of events from this environment that a sink
+	// discarded without writing them.
+	OnEventsDropped func(sinkName string, eventCount int)
+}
+
+func (e EventSinkEnvironment) recordDropped(sinkName string, eventCount int) {
+	if e.OnEventsDropped != nil && eventCount > 0 {
+		e.OnEventsDropped(sinkName, eventCount)
+	}
+}
+
+// EventSinkRecord is the format of each line written by a file or stdout event sink. Each line contains
+// a single event, as it was received from the SDK, along with information about where it came from.
+type EventSinkRecord struct {
+	Timestamp     time.Time              `json:"timestamp"`
+	EnvName       string                 `json:"envName,omitempty"`
+	EnvID         c.EnvironmentID        `json:"envId,omitempty"`
+	SDKKind       basictypes.SDKKind     `json:"sdkKind"`
+	DataKind      ldevents.EventDataKind `json:"dataKind"`
+	SchemaVersion int                    `json:"schemaVersion"`
+	Tags          string                 `json:"tags,omitempty"`
+	Event         json.RawMessage        `json:"event"`
+}
+
+// eventSinkFilter implements the Kinds and Environments properties of EventSinkConfig, which are the same
+// for every type of sink.
+type eventSinkFilter struct {
+	kinds        map[ldevents.EventDataKind]bool
+	environments []string
+}
+
+// writerEventSink is the EventSink implementation for both file and stdout sinks. It writes each event as
+// one line of JSON.
+//
+// Publishing an event only adds its line to a queue, so that a slow disk or a blocked stdout does not
+// hold up the request handler that received the event; a separate goroutine writes the queue as soon as
+// there is anything in it. As with kafkaEventSink, the queue has a maximum capacity and any events that do
+// not fit are dropped.
+type writerEventSink struct {
+	name       string
+	writer     io.WriteCloser
+	filter     eventSinkFilter
+	capacity   int
+	queue      [][]byte
+	overflowed bool
+	loggers    ldlog.Loggers
+	lock       sync.Mutex
+	sendLock   sync.Mutex
+	notify     chan struct{}
+	closer     chan struct{}
+	closeOnce  sync.Once
+	wg         sync.WaitGroup
+}
+
+type writerEventSinkPublisher struct {
+	sink     *writerEventSink
+	env      EventSinkEnvironment
+	sdkKind  basictypes.SDKKind
+	dataKind ldevents.EventDataKind
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// NewEventSink creates an EventSink based on the configuration.
+func NewEventSink(name string, config c.EventSinkConfig, loggers ldlog.Loggers) (EventSink, error) {
+	var w io.WriteCloser
+	switch config.Type {
+	case c.EventSinkTypeFile:
+		f, err := util.NewRotatingFile(
+			config.File,
+			int64(config.MaxFileSize.GetOrElse(c.DefaultEventSinkMaxFileSize)),
+			config.MaxBackups.GetOrElse(c.DefaultEventSinkMaxBackups),
+			config.RotateInterval.GetOrElse(0),
+		)
+		if err != nil {
+			return nil, err
+		}
+		loggers.Infof("Writing events to %s for event sink %q", config.File, name)
+		w = f
+	case c.EventSinkTypeStdout:
+		loggers.Infof("Writing events to standard output for event sink %q", name)
+		w = nopCloser{os.Stdout}
+	case c.EventSinkTypeKafka:
+		return newKafkaEventSink(name, config, loggers)
+	default:
+		return nil, fmt.Errorf("unknown event sink type %q", config.Type)
+	}
+	return newWriterEventSink(name, w, config, loggers), nil
+}
+
+func makeEventSinkFilter(config c.EventSinkConfig) eventSinkFilter {
+	kinds := make(map[ldevents.EventDataKind]bool)
+	for _, k := range config.Kinds.Values() {
+		kinds[ldevents.EventDataKind(k)] = true
+	}
+	if len(kinds) == 0 {
+		kinds[ldevents.AnalyticsEventDataKind] = true
+	}
+	return eventSinkFilter{kinds: kinds, environments: config.Environments.Values()}
+}
+
+func (f eventSinkFilter) acceptsEnvironment(env EventSinkEnvironment) bool {
+	if len(f.environments) == 0 {
+		return true
+	}
+	for _, e := range f.environments {
+		if e == env.Name || (env.ID != "" && e == string(env.ID)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f eventSinkFilter) acceptsKind(dataKind ldevents.EventDataKind) bool {
+	return f.kinds[dataKind]
+}
+
+func newWriterEventSink(name string, w io.WriteCloser, config c.EventSinkConfig, loggers ldlog.Loggers) *writerEventSink {
+	s := &writerEventSink{
+		name:     name,
+		writer:   w,
+		filter:   makeEventSinkFilter(config),
+		capacity: config.Capacity.GetOrElse(c.DefaultEventCapacity),
+		loggers:  loggers,
+		notify:   make(chan struct{}, 1),
+		closer:   make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			select {
+			case <-s.notify:
+				s.flush()
+			case <-s.closer:
+				return
+			}
+		}
+	}()
+	return s
+}
+
+func (s *writerEventSink) Name() string {
+	return s.name
+}
+
+func (s *writerEventSink) AcceptsEnvironment(env EventSinkEnvironment) bool {
+	return s.filter.acceptsEnvironment(env)
+}
+
+func (s *writerEventSink) NewPublisher(
+	env EventSinkEnvironment,
+	sdkKind basictypes.SDKKind,
+	dataKind ldevents.EventDataKind,
+) EventPublisher {
+	if !s.filter.acceptsKind(dataKind) {
+		return nil
+	}
+	return &writerEventSinkPublisher{sink: s, env: env, sdkKind: sdkKind, dataKind: dataKind}
+}
+
+// Close writes any queued events and then closes the file, if this is a file sink.
+func (s *writerEventSink) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.closer)
+		s.wg.Wait()
+		s.flush()
+		s.sendLock.Lock()
+		defer s.sendLock.Unlock()
+		err = s.writer.Close()
+	})
+	return err
+}
+
+func (s *writerEventSink) enqueue(env EventSinkEnvironment, records []EventSinkRecord) {
+	if len(records) == 0 {
+		return
+	}
+	lines := make([][]byte, 0, len(records))
+	for _, r := range records {
+		data, err := json.Marshal(r)
+		if err != nil { // this can only happen if the event was not valid JSON
+			s.loggers.Warnf("Event sink %q could not write an invalid event: %s", s.name, err)
+			continue
+		}
+		lines = append(lines, append(data, '\n'))
+	}
+	s.lock.Lock()
+	available := s.capacity - len(s.queue)
+	dropped := 0
+	if available < len(lines) {
+		if !s.overflowed {
+			s.loggers.Warnf("Exceeded event queue capacity of %d for event sink %q. Increase capacity to avoid dropping events.",
+				s.capacity, s.name)
+			s.overflowed = true
+		}
+		dropped = len(lines) - available
+		lines = lines[:available]
+	} else {
+		s.overflowed = false
+	}
+	s.queue = append(s.queue, lines...)
+	s.lock.Unlock()
+	env.recordDropped(s.name, dropped)
+	select {
+	case s.notify <- struct{}{}:
+	default: // the writer goroutine has already been told that there is something to write
+	}
+}
+
+func (s *writerEventSink) flush() {
+	// sendLock ensures that batches are written in the order they were queued, and that Flush does not
+	// return while the writer goroutine is still writing a batch that it took from the queue.
+	s.sendLock.Lock()
+	defer s.sendLock.Unlock()
+	s.lock.Lock()
+	batch := s.queue
+	s.queue = nil
+	s.lock.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+	var buf []byte
+	for _, line := range batch {
+		buf = append(buf, line...)
+	}
+	if _, err := s.writer.Write(buf); err != nil {
+		s.loggers.Errorf("Error writing to event sink %q: %s", s.name, err)
+	}
+}
+
+func (p *writerEventSinkPublisher) Publish(metadata EventPayloadMetadata, events ...json.RawMessage) {
+	now := time.Now()
+	records := make([]EventSinkRecord, 0, len(events))
+	for _, e := range events {
+		records = append(records, EventSinkRecord{
+			Timestamp:     now,
+			EnvName:       p.env.Name,
+			EnvID:         p.env.ID,
+			SDKKind:       p.sdkKind,
+			DataKind:      p.dataKind,
+			SchemaVersion: metadata.SchemaVersion,
+			Tags:          metadata.Tags,
+			Event:         e,
+		})
+	}
+	p.sink.enqueue(p.env, records)
+}
+
+// Flush writes all queued events for the sink, not just the ones from this publisher.
+func (p *writerEventSinkPublisher) Flush() {
+	p.sink.flush()
+}
+
+// ReplaceCredential does nothing, because a sink does not use credentials.
+func (p *writerEventSinkPublisher) ReplaceCredential(c.SDKCredential) {}
+
+// Close does nothing, because the underlying file is owned by the sink.
+func (p *writerEventSinkPublisher) Close() {}
@@ -0,0 +1,220 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
+
+	ct "github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSinkEnv = EventSinkEnvironment{Name: "earth", ID: "earth-env-id"} //nolint:gochecknoglobals
+
+// bufferWriteCloser is safe to read while the sink's writer goroutine may be writing to it.
+type bufferWriteCloser struct {
+	buf    bytes.Buffer
+	closed bool
+	lock   sync.Mutex
+}
+
+func (b *bufferWriteCloser) Write(data []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(data)
+}
+
+func (b *bufferWriteCloser) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func (b *bufferWriteCloser) Close() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.closed = true
+	return nil
+}
+
+func (b *bufferWriteCloser) isClosed() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.closed
+}
+
+func makeTestEventSink(t *testing.T, sinkConfig config.EventSinkConfig) (*writerEventSink, *bufferWriteCloser) {
+	buf := &bufferWriteCloser{}
+	return newWriterEventSink("test", buf, sinkConfig, ldlogtest.NewMockLog().Loggers), buf
+}
+
+func parseEventSinkRecords(t *testing.T, data string) []EventSinkRecord {
+	var ret []EventSinkRecord
+	for _, line := range strings.Split(strings.TrimSuffix(data, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		var r EventSinkRecord
+		require.NoError(t, json.Unmarshal([]byte(line), &r))
+		ret = append(ret, r)
+	}
+	return ret
+}
+
+func TestEventSinkWritesOneLinePerEvent(t *testing.T) {
+	sink, buf := makeTestEventSink(t, config.EventSinkConfig{})
+	p := sink.NewPublisher(testSinkEnv, basictypes.MobileSDK, ldevents.AnalyticsEventDataKind)
+	require.NotNil(t, p)
+	p.Publish(EventPayloadMetadata{SchemaVersion: 4, Tags: "application-id=app"},
+		json.RawMessage(`{"kind":"identify"}`), json.RawMessage(`{"kind":"custom"}`))
+	p.Flush()
+
+	records := parseEventSinkRecords(t, buf.String())
+	require.Len(t, records, 2)
+	for _, r := range records {
+		assert.Equal(t, "earth", r.EnvName)
+		assert.Equal(t, config.EnvironmentID("earth-env-id"), r.EnvID)
+		assert.Equal(t, basictypes.MobileSDK, r.SDKKind)
+		assert.Equal(t, ldevents.AnalyticsEventDataKind, r.DataKind)
+		assert.Equal(t, 4, r.SchemaVersion)
+		assert.Equal(t, "application-id=app", r.Tags)
+		assert.False(t, r.Timestamp.IsZero())
+	}
+	assert.JSONEq(t, `{"kind":"identify"}`, string(records[0].Event))
+	assert.JSONEq(t, `{"kind":"custom"}`, string(records[1].Event))
+}
+
+func TestEventSinkSkipsInvalidEvent(t *testing.T) {
+	sink, buf := makeTestEventSink(t, config.EventSinkConfig{})
+	p := sink.NewPublisher(testSinkEnv, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+	p.Publish(EventPayloadMetadata{}, json.RawMessage(`{`), json.RawMessage(`{"kind":"custom"}`))
+	p.Flush()
+
+	records := parseEventSinkRecords(t, buf.String())
+	require.Len(t, records, 1)
+	assert.JSONEq(t, `{"kind":"custom"}`, string(records[0].Event))
+}
+
+func TestEventSinkWritesQueuedEventsWithoutFlush(t *testing.T) {
+	sink, buf := makeTestEventSink(t, config.EventSinkConfig{})
+	defer sink.Close()
+	p := sink.NewPublisher(testSinkEnv, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+	p.Publish(EventPayloadMetadata{}, json.RawMessage(`{"kind":"custom"}`))
+
+	require.Eventually(t, func() bool { return buf.String() != "" }, time.Second, time.Millisecond*10)
+	records := parseEventSinkRecords(t, buf.String())
+	require.Len(t, records, 1)
+	assert.JSONEq(t, `{"kind":"custom"}`, string(records[0].Event))
+}
+
+func TestEventSinkDropsEventsThatExceedCapacity(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	buf := &bufferWriteCloser{}
+	capacity, _ := ct.NewOptIntGreaterThanZero(2)
+	sink := newWriterEventSink("test", buf, config.EventSinkConfig{Capacity: capacity}, mockLog.Loggers)
+	env, getDropped := sinkEnvWithDropCounter()
+	p := sink.NewPublisher(env, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+	p.Publish(EventPayloadMetadata{}, json.RawMessage(`{"kind":"a"}`), json.RawMessage(`{"kind":"b"}`),
+		json.RawMessage(`{"kind":"c"}`))
+	require.NoError(t, sink.Close())
+
+	records := parseEventSinkRecords(t, buf.String())
+	require.Len(t, records, 2)
+	assert.JSONEq(t, `{"kind":"a"}`, string(records[0].Event))
+	assert.JSONEq(t, `{"kind":"b"}`, string(records[1].Event))
+	mockLog.AssertMessageMatch(t, true, ldlog.Warn, "Exceeded event queue capacity of 2")
+	assert.Equal(t, 1, getDropped())
+}
+
+func TestEventSinkKinds(t *testing.T) {
+	t.Run("defaults to analytics only", func(t *testing.T) {
+		sink, _ := makeTestEventSink(t, config.EventSinkConfig{})
+		assert.NotNil(t, sink.NewPublisher(testSinkEnv, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind))
+		assert.Nil(t, sink.NewPublisher(testSinkEnv, basictypes.ServerSDK, ldevents.DiagnosticEventDataKind))
+	})
+
+	t.Run("diagnostic only", func(t *testing.T) {
+		sink, _ := makeTestEventSink(t, config.EventSinkConfig{
+			Kinds: ct.NewOptStringList([]string{config.EventSinkKindDiagnostic}),
+		})
+		assert.Nil(t, sink.NewPublisher(testSinkEnv, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind))
+		assert.NotNil(t, sink.NewPublisher(testSinkEnv, basictypes.ServerSDK, ldevents.DiagnosticEventDataKind))
+	})
+}
+
+func TestEventSinkEnvironments(t *testing.T) {
+	t.Run("accepts all environments by default", func(t *testing.T) {
+		sink, _ := makeTestEventSink(t, config.EventSinkConfig{})
+		assert.True(t, sink.AcceptsEnvironment(testSinkEnv))
+	})
+
+	t.Run("matches environment name or ID", func(t *testing.T) {
+		sink, _ := makeTestEventSink(t, config.EventSinkConfig{
+			Environments: ct.NewOptStringList([]string{"earth", "mars-env-id"}),
+		})
+		assert.True(t, sink.AcceptsEnvironment(testSinkEnv))
+		assert.True(t, sink.AcceptsEnvironment(EventSinkEnvironment{Name: "mars", ID: "mars-env-id"}))
+		assert.False(t, sink.AcceptsEnvironment(EventSinkEnvironment{Name: "venus", ID: "venus-env-id"}))
+	})
+}
+
+func TestEventSinkCloseClosesWriter(t *testing.T) {
+	sink, buf := makeTestEventSink(t, config.EventSinkConfig{})
+	p := sink.NewPublisher(testSinkEnv, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+	p.Close()
+	assert.False(t, buf.isClosed())
+	require.NoError(t, sink.Close())
+	assert.True(t, buf.isClosed())
+}
+
+func TestNewEventSink(t *testing.T) {
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "events.json")
+		sink, err := NewEventSink("archive", config.EventSinkConfig{Type: config.EventSinkTypeFile, File: path},
+			ldlogtest.NewMockLog().Loggers)
+		require.NoError(t, err)
+		assert.Equal(t, "archive", sink.Name())
+		p := sink.NewPublisher(testSinkEnv, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+		p.Publish(EventPayloadMetadata{SchemaVersion: 4}, json.RawMessage(`{"kind":"custom"}`))
+		require.NoError(t, sink.Close())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		records := parseEventSinkRecords(t, string(data))
+		require.Len(t, records, 1)
+		assert.JSONEq(t, `{"kind":"custom"}`, string(records[0].Event))
+	})
+
+	t.Run("file cannot be opened", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "no-such-dir", "events.json")
+		_, err := NewEventSink("archive", config.EventSinkConfig{Type: config.EventSinkTypeFile, File: path},
+			ldlogtest.NewMockLog().Loggers)
+		assert.Error(t, err)
+	})
+
+	t.Run("stdout", func(t *testing.T) {
+		sink, err := NewEventSink("console", config.EventSinkConfig{Type: config.EventSinkTypeStdout},
+			ldlogtest.NewMockLog().Loggers)
+		require.NoError(t, err)
+		assert.Equal(t, "console", sink.Name())
+		assert.NoError(t, sink.Close())
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		_, err := NewEventSink("x", config.EventSinkConfig{Type: "syslog"}, ldlogtest.NewMockLog().Loggers)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,145 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+)
+
+const (
+	// maxRecentRejectedEvents is the number of rejected events that are kept for each environment, to be
+	// shown by EventDispatcher.GetRecentRejectedEvents.
+	maxRecentRejectedEvents = 50
+
+	// maxRejectedEventDataLength is the maximum length of the JSON data that is kept for a rejected event
+	// or payload; anything longer is truncated.
+	maxRejectedEventDataLength = 2000
+
+	// rejectedEventsLogInterval is the minimum time between warnings about rejected events, so that an SDK
+	// that keeps sending bad data does not flood the log. All rejections are logged at debug level.
+	rejectedEventsLogInterval = time.Minute
+)
+
+// RejectedEvent describes an analytics event, or an entire payload of events, that Relay did not accept
+// because it was not valid. These are only reported if EventsConfig.EnableValidation is set.
+type RejectedEvent struct {
+	Time    time.Time          `json:"time"`
+	SDKKind basictypes.SDKKind `json:"sdkKind"`
+	Reason  string             `json:"reason"`
+	Data    string             `json:"data"`
+}
+
+// eventValidationResponse is the response body for an analytics event post if validation is enabled.
+type eventValidationResponse struct {
+	Accepted int `json:"accepted"`
+	Rejected int `json:"rejected"`
+}
+
+// eventRequirements are the properties that each kind of event must have, other than "kind". The "click"
+// and "pageview" kinds are goal events from the JS client-side SDK.
+var eventRequirements = map[string][]eventPropertyRequirement{ //nolint:gochecknoglobals
+	"feature":      {{"key", ldvalue.StringType}, {"creationDate", ldvalue.NumberType}},
+	"debug":        {{"key", ldvalue.StringType}, {"creationDate", ldvalue.NumberType}},
+	"custom":       {{"key", ldvalue.StringType}, {"creationDate", ldvalue.NumberType}},
+	"identify":     {{"creationDate", ldvalue.NumberType}},
+	"index":        {{"creationDate", ldvalue.NumberType}},
+	"alias":        {{"key", ldvalue.StringType}, {"previousKey", ldvalue.StringType}, {"creationDate", ldvalue.NumberType}},
+	"summary":      {{"features", ldvalue.ObjectType}},
+	"migration_op": {{"operation", ldvalue.StringType}, {"creationDate", ldvalue.NumberType}},
+	"click":        {{"key", ldvalue.StringType}, {"url", ldvalue.StringType}, {"creationDate", ldvalue.NumberType}},
+	"pageview":     {{"key", ldvalue.StringType}, {"url", ldvalue.StringType}, {"creationDate", ldvalue.NumberType}},
+}
+
+type eventPropertyRequirement struct {
+	name      string
+	valueType ldvalue.ValueType
+}
+
+// validateEvent returns an error if an analytics event is not a JSON object, is not one of the known
+// kinds of events, or is missing a required property. This only checks the basic structure that
+// LaunchDarkly needs in order to process the event; it does not check every property.
+func validateEvent(data json.RawMessage) error {
+	event := ldvalue.Parse(data)
+	if event.Type() != ldvalue.ObjectType {
+		return fmt.Errorf("event is not a JSON object")
+	}
+	kind := event.GetByKey("kind")
+	if !kind.IsString() {
+		return fmt.Errorf("event has no kind")
+	}
+	requirements, ok := eventRequirements[kind.StringValue()]
+	if !ok {
+		return fmt.Errorf("unknown event kind %q", kind.StringValue())
+	}
+	for _, r := range requirements {
+		if event.GetByKey(r.name).Type() != r.valueType {
+			return fmt.Errorf("%s event must have a %s property of type %s", kind.StringValue(), r.name, r.valueType)
+		}
+	}
+	if kind.StringValue() == "identify" || kind.StringValue() == "index" {
+		if event.GetByKey("context").Type() != ldvalue.ObjectType && event.GetByKey("user").Type() != ldvalue.ObjectType {
+			return fmt.Errorf("%s event must have a context or user property of type object", kind.StringValue())
+		}
+	}
+	return nil
+}
+
+// rejectedEventLog keeps the most recent rejected events for an environment, and logs warnings about
+// them at a limited rate.
+type rejectedEventLog struct {
+	events      []RejectedEvent
+	next        int
+	lastWarning time.Time
+	sinceWarned int
+	loggers     ldlog.Loggers
+	lock        sync.Mutex
+}
+
+func newRejectedEventLog(loggers ldlog.Loggers) *rejectedEventLog {
+	return &rejectedEventLog{loggers: loggers}
+}
+
+func (l *rejectedEventLog) add(sdkKind basictypes.SDKKind, reason string, data []byte) {
+	s := string(data)
+	if len(s) > maxRejectedEventDataLength {
+		s = s[:maxRejectedEventDataLength] + "..."
+	}
+	e := RejectedEvent{Time: time.Now(), SDKKind: sdkKind, Reason: reason, Data: s}
+	l.loggers.Debugf("Rejected invalid event data from %s SDK (%s): %s", sdkKind, reason, s)
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if len(l.events) < maxRecentRejectedEvents {
+		l.events = append(l.events, e)
+	} else {
+		l.events[l.next] = e
+	}
+	l.next = (l.next + 1) % maxRecentRejectedEvents
+	l.sinceWarned++
+	if e.Time.Sub(l.lastWarning) >= rejectedEventsLogInterval {
+		l.loggers.Warnf("Rejected %d invalid events or payloads since the last warning; most recent was from %s SDK: %s",
+			l.sinceWarned, sdkKind, reason)
+		l.lastWarning = e.Time
+		l.sinceWarned = 0
+	}
+}
+
+// recent returns the rejected events that are being kept, oldest first.
+func (l *rejectedEventLog) recent() []RejectedEvent {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	ret := make([]RejectedEvent, 0, len(l.events))
+	if len(l.events) == maxRecentRejectedEvents {
+		ret = append(ret, l.events[l.next:]...)
+		ret = append(ret, l.events[:l.next]...)
+	} else {
+		ret = append(ret, l.events...)
+	}
+	return ret
+}
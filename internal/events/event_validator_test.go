@@ -0,0 +1,171 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
+	st "github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+	helpers "github.com/launchdarkly/go-test-helpers/v3"
+	m "github.com/launchdarkly/go-test-helpers/v3/matchers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateEvent(t *testing.T) {
+	for _, e := range []string{
+		`{"kind":"feature","key":"flag","creationDate":1000}`,
+		`{"kind":"debug","key":"flag","creationDate":1000}`,
+		`{"kind":"custom","key":"event","creationDate":1000}`,
+		`{"kind":"identify","creationDate":1000,"context":{"key":"a"}}`,
+		`{"kind":"identify","creationDate":1000,"user":{"key":"a"}}`,
+		`{"kind":"index","creationDate":1000,"context":{"key":"a"}}`,
+		`{"kind":"alias","key":"a","previousKey":"b","creationDate":1000}`,
+		`{"kind":"summary","startDate":1000,"endDate":2000,"features":{}}`,
+		`{"kind":"migration_op","operation":"read","creationDate":1000}`,
+		`{"kind":"click","key":"goal","url":"https://example.com","creationDate":1000,"selector":"a"}`,
+		`{"kind":"pageview","key":"goal","url":"https://example.com","creationDate":1000}`,
+	} {
+		t.Run(e, func(t *testing.T) {
+			assert.NoError(t, validateEvent(json.RawMessage(e)))
+		})
+	}
+
+	for e, reason := range map[string]string{
+		`"feature"`:                       "event is not a JSON object",
+		`{"key":"flag"}`:                  "event has no kind",
+		`{"kind":"unknown"}`:              `unknown event kind "unknown"`,
+		`{"kind":"feature","key":"flag"}`: "feature event must have a creationDate property of type number",
+		`{"kind":"custom","key":1,"creationDate":1000}`:        "custom event must have a key property of type string",
+		`{"kind":"summary","features":[]}`:                     "summary event must have a features property of type object",
+		`{"kind":"index","creationDate":1000}`:                 "index event must have a context or user property of type object",
+		`{"kind":"pageview","key":"goal","creationDate":1000}`: "pageview event must have a url property of type string",
+	} {
+		t.Run(e, func(t *testing.T) {
+			err := validateEvent(json.RawMessage(e))
+			require.Error(t, err)
+			assert.Equal(t, reason, err.Error())
+		})
+	}
+}
+
+func TestRejectedEventLogKeepsMostRecentEvents(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	l := newRejectedEventLog(mockLog.Loggers)
+	assert.Len(t, l.recent(), 0)
+
+	for i := 0; i < maxRecentRejectedEvents+5; i++ {
+		l.add(basictypes.ServerSDK, "bad", []byte(fmt.Sprintf(`{"n":%d}`, i)))
+	}
+	recent := l.recent()
+	require.Len(t, recent, maxRecentRejectedEvents)
+	assert.Equal(t, `{"n":5}`, recent[0].Data)
+	assert.Equal(t, fmt.Sprintf(`{"n":%d}`, maxRecentRejectedEvents+4), recent[maxRecentRejectedEvents-1].Data)
+
+	// Only the first rejection causes a warning, since they all happened within the log interval
+	assert.Len(t, mockLog.GetOutput(ldlog.Warn), 1)
+}
+
+func TestRejectedEventLogTruncatesLongData(t *testing.T) {
+	l := newRejectedEventLog(ldlog.NewDisabledLoggers())
+	l.add(basictypes.JSClientSDK, "bad", []byte(strings.Repeat("x", maxRejectedEventDataLength+1)))
+	recent := l.recent()
+	require.Len(t, recent, 1)
+	assert.Equal(t, strings.Repeat("x", maxRejectedEventDataLength)+"...", recent[0].Data)
+	assert.Equal(t, basictypes.JSClientSDK, recent[0].SDKKind)
+}
+
+func TestInvalidEventsAreRejectedIfValidationIsEnabled(t *testing.T) {
+	eventsConfig := config.EventsConfig{EnableValidation: true}
+	eventRelayTest(t, st.EnvMain, eventsConfig, func(p eventRelayTestParams) {
+		handler := p.dispatcher.GetHandler(basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+		body := `[
+			{"kind":"custom","key":"event","creationDate":1000,"context":{"key":"a"}},
+			{"kind":"custom","creationDate":1000},
+			{"kind":"unknown"}
+		]`
+		req := st.BuildRequest("POST", "/", []byte(body), headersWithEventSchema(CurrentEventsSchemaVersion))
+		w := httptest.NewRecorder()
+		handler(w, req)
+		assert.Equal(t, http.StatusAccepted, w.Result().StatusCode)
+		assert.Equal(t, "application/json", w.Result().Header.Get("Content-Type"))
+		assert.JSONEq(t, `{"accepted":1,"rejected":2}`, w.Body.String())
+
+		p.dispatcher.flush()
+		r := helpers.RequireValue(t, p.requestsCh, time.Second)
+		m.In(t).Assert(r.Body, m.JSONStrEqual(`[{"kind":"custom","key":"event","creationDate":1000,"context":{"key":"a"}}]`))
+
+		rejected := p.dispatcher.GetRecentRejectedEvents()
+		require.Len(t, rejected, 2)
+		assert.Equal(t, "custom event must have a key property of type string", rejected[0].Reason)
+		assert.Equal(t, `unknown event kind "unknown"`, rejected[1].Reason)
+		assert.Equal(t, basictypes.ServerSDK, rejected[1].SDKKind)
+	})
+}
+
+func TestJSClientGoalEventsAreAcceptedIfValidationIsEnabled(t *testing.T) {
+	eventsConfig := config.EventsConfig{EnableValidation: true}
+	eventRelayTest(t, st.EnvClientSide, eventsConfig, func(p eventRelayTestParams) {
+		handler := p.dispatcher.GetHandler(basictypes.JSClientSDK, ldevents.AnalyticsEventDataKind)
+		body := `[
+			{"kind":"click","key":"goal1","url":"https://example.com","creationDate":1000,"selector":"a","contextKeys":{"user":"a"}},
+			{"kind":"pageview","key":"goal2","url":"https://example.com","creationDate":1000,"contextKeys":{"user":"a"}}
+		]`
+		req := st.BuildRequest("POST", "/", []byte(body), headersWithEventSchema(CurrentEventsSchemaVersion))
+		w := httptest.NewRecorder()
+		handler(w, req)
+		assert.Equal(t, http.StatusAccepted, w.Result().StatusCode)
+		assert.JSONEq(t, `{"accepted":2,"rejected":0}`, w.Body.String())
+
+		p.dispatcher.flush()
+		r := helpers.RequireValue(t, p.requestsCh, time.Second)
+		assert.Equal(t, "/events/bulk/"+string(st.EnvClientSide.Config.EnvID), r.Request.URL.Path)
+		m.In(t).Assert(r.Body, m.JSONStrEqual(body))
+		assert.Len(t, p.dispatcher.GetRecentRejectedEvents(), 0)
+	})
+}
+
+func TestEventPayloadThatIsNotAnArrayIsRejectedIfValidationIsEnabled(t *testing.T) {
+	eventsConfig := config.EventsConfig{EnableValidation: true}
+	eventRelayTest(t, st.EnvMain, eventsConfig, func(p eventRelayTestParams) {
+		handler := p.dispatcher.GetHandler(basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+		req := st.BuildRequest("POST", "/", []byte(`{"kind":"custom"}`), headersWithEventSchema(CurrentEventsSchemaVersion))
+		w := httptest.NewRecorder()
+		handler(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+
+		p.dispatcher.flush()
+		helpers.AssertNoMoreValues(t, p.requestsCh, time.Millisecond*50)
+
+		rejected := p.dispatcher.GetRecentRejectedEvents()
+		require.Len(t, rejected, 1)
+		assert.Equal(t, `{"kind":"custom"}`, rejected[0].Data)
+	})
+}
+
+func TestEventsAreNotValidatedByDefault(t *testing.T) {
+	eventRelayTest(t, st.EnvMain, config.EventsConfig{}, func(p eventRelayTestParams) {
+		handler := p.dispatcher.GetHandler(basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+		req := st.BuildRequest("POST", "/", []byte(`[{"kind":"unknown"}]`), headersWithEventSchema(CurrentEventsSchemaVersion))
+		w := httptest.NewRecorder()
+		handler(w, req)
+		assert.Equal(t, http.StatusAccepted, w.Result().StatusCode)
+		assert.Equal(t, "", w.Body.String())
+
+		p.dispatcher.flush()
+		r := helpers.RequireValue(t, p.requestsCh, time.Second)
+		m.In(t).Assert(r.Body, m.JSONStrEqual(`[{"kind":"unknown"}]`))
+		assert.Nil(t, p.dispatcher.GetRecentRejectedEvents())
+	})
+}
@@ -0,0 +1,298 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	c "github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
+
+	"github.com/IBM/sarama"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+)
+
+const (
+	// KafkaEnvIDHeader is a Kafka message header containing the environment ID, if known.
+	KafkaEnvIDHeader = "X-LaunchDarkly-Env-Id"
+
+	// KafkaSDKKindHeader is a Kafka message header containing the kind of SDK that sent the event:
+	// "server", "mobile", or "js".
+	KafkaSDKKindHeader = "X-LaunchDarkly-SDK-Kind"
+
+	// KafkaDataKindHeader is a Kafka message header containing the kind of event data: "analytics" or
+	// "diagnostic".
+	KafkaDataKindHeader = "X-LaunchDarkly-Data-Kind"
+
+	kafkaClientID = "ld-relay"
+
+	// kafkaRetryDelay is how long kafkaEventSink waits before trying once more to send events that failed.
+	kafkaRetryDelay = time.Second
+)
+
+// kafkaProducer is the subset of sarama.SyncProducer that kafkaEventSink uses, so that it can be mocked.
+type kafkaProducer interface {
+	SendMessages(msgs []*sarama.ProducerMessage) error
+	Close() error
+}
+
+// kafkaEventSink is the EventSink implementation that produces each event as a separate message to a
+// Kafka topic. The value of each message is the event JSON, and its key is the environment ID (or name,
+// if the ID is not known) and the event's context key, so that all events for the same context in an
+// environment go to the same partition.
+//
+// Events from all of the sink's publishers go into a single queue, which is delivered to Kafka at each
+// flush interval. As with HTTPEventPublisher, the queue has a maximum capacity and any events that do not
+// fit are dropped, and events that could not be sent are retried once and then dropped. Dropped events are
+// reported with EventSinkEnvironment.OnEventsDropped.
+//
+// The sink does not connect to Kafka when it is created, since connecting can take a long time if the
+// brokers are unreachable; it makes the first attempt on its flush goroutine, so that creating the sink
+// never holds up Relay's startup. If that attempt fails, it tries to connect again at each flush.
+type kafkaEventSink struct {
+	name       string
+	topic      string
+	filter     eventSinkFilter
+	connect    func() (kafkaProducer, error)
+	producer   kafkaProducer
+	capacity   int
+	queue      []*sarama.ProducerMessage
+	overflowed bool
+	loggers    ldlog.Loggers
+	lock       sync.Mutex
+	sendLock   sync.Mutex
+	closer     chan struct{}
+	closeOnce  sync.Once
+	wg         sync.WaitGroup
+}
+
+type kafkaEventSinkPublisher struct {
+	sink     *kafkaEventSink
+	env      EventSinkEnvironment
+	sdkKind  basictypes.SDKKind
+	dataKind ldevents.EventDataKind
+}
+
+func newKafkaEventSink(name string, config c.EventSinkConfig, loggers ldlog.Loggers) (*kafkaEventSink, error) {
+	sc := sarama.NewConfig()
+	sc.ClientID = kafkaClientID
+	sc.Producer.Return.Successes = true // required by SyncProducer
+	sc.Producer.RequiredAcks = sarama.WaitForAll
+	sc.Net.TLS.Enable = config.TLSEnabled
+	connect := func() (kafkaProducer, error) {
+		return sarama.NewSyncProducer(config.Brokers.Values(), sc)
+	}
+	loggers.Infof("Writing events to Kafka topic %q for event sink %q", config.Topic, name)
+	return makeKafkaEventSink(name, config, connect, loggers), nil
+}
+
+func makeKafkaEventSink(
+	name string,
+	config c.EventSinkConfig,
+	connect func() (kafkaProducer, error),
+	loggers ldlog.Loggers,
+) *kafkaEventSink {
+	s := &kafkaEventSink{
+		name:     name,
+		topic:    config.Topic,
+		filter:   makeEventSinkFilter(config),
+		connect:  connect,
+		capacity: config.Capacity.GetOrElse(c.DefaultEventCapacity),
+		loggers:  loggers,
+		closer:   make(chan struct{}),
+	}
+	flushInterval := config.FlushInterval.GetOrElse(c.DefaultEventsFlushInterval)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.connectFirst()
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.flush()
+			case <-s.closer:
+				return
+			}
+		}
+	}()
+	return s
+}
+
+func (s *kafkaEventSink) Name() string {
+	return s.name
+}
+
+func (s *kafkaEventSink) AcceptsEnvironment(env EventSinkEnvironment) bool {
+	return s.filter.acceptsEnvironment(env)
+}
+
+func (s *kafkaEventSink) NewPublisher(
+	env EventSinkEnvironment,
+	sdkKind basictypes.SDKKind,
+	dataKind ldevents.EventDataKind,
+) EventPublisher {
+	if !s.filter.acceptsKind(dataKind) {
+		return nil
+	}
+	return &kafkaEventSinkPublisher{sink: s, env: env, sdkKind: sdkKind, dataKind: dataKind}
+}
+
+// Close delivers any queued events and then closes the connection to Kafka.
+func (s *kafkaEventSink) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.closer)
+		s.wg.Wait()
+		s.flush()
+		s.sendLock.Lock()
+		defer s.sendLock.Unlock()
+		if s.producer != nil {
+			err = s.producer.Close()
+		}
+	})
+	return err
+}
+
+// connectFirst makes the first attempt to connect to Kafka, unless a flush has already connected.
+func (s *kafkaEventSink) connectFirst() {
+	s.sendLock.Lock()
+	defer s.sendLock.Unlock()
+	if s.producer != nil {
+		return
+	}
+	if producer, err := s.connect(); err != nil {
+		s.loggers.Warnf("Could not connect to Kafka for event sink %q, will try again at the next flush: %s", s.name, err)
+	} else {
+		s.producer = producer
+	}
+}
+
+func (s *kafkaEventSink) enqueue(env EventSinkEnvironment, msgs []*sarama.ProducerMessage) {
+	s.lock.Lock()
+	available := s.capacity - len(s.queue)
+	dropped := 0
+	if available < len(msgs) {
+		if !s.overflowed {
+			s.loggers.Warnf("Exceeded event queue capacity of %d for event sink %q. Increase capacity to avoid dropping events.",
+				s.capacity, s.name)
+			s.overflowed = true
+		}
+		dropped = len(msgs) - available
+		msgs = msgs[:available]
+	} else {
+		s.overflowed = false
+	}
+	s.queue = append(s.queue, msgs...)
+	s.lock.Unlock()
+	env.recordDropped(s.name, dropped)
+}
+
+func (s *kafkaEventSink) flush() {
+	// sendLock ensures that batches are delivered in the order they were queued, even if a flush is
+	// requested while the previous one is still in progress.
+	s.sendLock.Lock()
+	defer s.sendLock.Unlock()
+	s.lock.Lock()
+	batch := s.queue
+	s.queue = nil
+	s.lock.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+	failed, err := s.send(batch)
+	if err == nil {
+		return
+	}
+	s.loggers.Warnf("Error sending %d events to Kafka for event sink %q, will retry: %s", len(failed), s.name, err)
+	retryTimer := time.NewTimer(kafkaRetryDelay)
+	select {
+	case <-retryTimer.C:
+	case <-s.closer: // if we're shutting down, retry right away rather than holding up Close
+		retryTimer.Stop()
+	}
+	if failed, err = s.send(failed); err != nil {
+		s.loggers.Errorf("Error sending %d events to Kafka for event sink %q: %s", len(failed), s.name, err)
+		for _, msg := range failed {
+			if env, ok := msg.Metadata.(EventSinkEnvironment); ok {
+				env.recordDropped(s.name, 1)
+			}
+		}
+	}
+}
+
+// send delivers a batch of messages, connecting to Kafka first if necessary. If that fails, it returns the
+// messages that were not delivered.
+func (s *kafkaEventSink) send(batch []*sarama.ProducerMessage) ([]*sarama.ProducerMessage, error) {
+	if s.producer == nil {
+		producer, err := s.connect()
+		if err != nil {
+			return batch, err
+		}
+		s.producer = producer
+	}
+	err := s.producer.SendMessages(batch)
+	if err == nil {
+		return nil, nil
+	}
+	var producerErrors sarama.ProducerErrors
+	if errors.As(err, &producerErrors) {
+		failed := make([]*sarama.ProducerMessage, 0, len(producerErrors))
+		for _, pe := range producerErrors {
+			failed = append(failed, pe.Msg)
+		}
+		return failed, err
+	}
+	return batch, err
+}
+
+func (p *kafkaEventSinkPublisher) Publish(metadata EventPayloadMetadata, events ...json.RawMessage) {
+	headers := []sarama.RecordHeader{
+		{Key: []byte(EventSchemaHeader), Value: []byte(strconv.Itoa(metadata.SchemaVersion))},
+		{Key: []byte(KafkaSDKKindHeader), Value: []byte(p.sdkKind)},
+		{Key: []byte(KafkaDataKindHeader), Value: []byte(p.dataKind)},
+	}
+	if p.env.ID != "" {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(KafkaEnvIDHeader), Value: []byte(p.env.ID)})
+	}
+	if metadata.Tags != "" {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(TagsHeader), Value: []byte(metadata.Tags)})
+	}
+	msgs := make([]*sarama.ProducerMessage, 0, len(events))
+	for _, e := range events {
+		msgs = append(msgs, &sarama.ProducerMessage{
+			Topic:    p.sink.topic,
+			Key:      sarama.StringEncoder(p.messageKey(e)),
+			Value:    sarama.ByteEncoder(e),
+			Headers:  headers,
+			Metadata: p.env, // so that the sink can report dropped events for the right environment
+		})
+	}
+	p.sink.enqueue(p.env, msgs)
+}
+
+func (p *kafkaEventSinkPublisher) messageKey(event json.RawMessage) string {
+	envKey := string(p.env.ID)
+	if envKey == "" {
+		envKey = p.env.Name
+	}
+	if contextKey := getEventContextKey(event); contextKey != "" {
+		return envKey + "/" + contextKey
+	}
+	return envKey
+}
+
+// Flush delivers all queued events for the sink, not just the ones from this publisher.
+func (p *kafkaEventSinkPublisher) Flush() {
+	p.sink.flush()
+}
+
+// ReplaceCredential does nothing, because a sink does not use LaunchDarkly credentials.
+func (p *kafkaEventSinkPublisher) ReplaceCredential(c.SDKCredential) {}
+
+// Close does nothing, because the queue and the connection to Kafka are owned by the sink.
+func (p *kafkaEventSinkPublisher) Close() {}
@@ -0,0 +1,280 @@
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	ct "github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+	helpers "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKafkaTopic = "ld-events"
+
+type capturedKafkaMessage struct {
+	key     string
+	value   string
+	headers map[string]string
+}
+
+func makeTestKafkaEventSink(
+	t *testing.T,
+	sinkConfig config.EventSinkConfig,
+	expectedMessages int,
+) (*kafkaEventSink, <-chan capturedKafkaMessage) {
+	producer := mocks.NewSyncProducer(t, nil)
+	messagesCh := make(chan capturedKafkaMessage, expectedMessages)
+	for i := 0; i < expectedMessages; i++ {
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+			assert.Equal(t, testKafkaTopic, msg.Topic)
+			key, _ := msg.Key.Encode()
+			value, _ := msg.Value.Encode()
+			headers := make(map[string]string)
+			for _, h := range msg.Headers {
+				headers[string(h.Key)] = string(h.Value)
+			}
+			messagesCh <- capturedKafkaMessage{key: string(key), value: string(value), headers: headers}
+			return nil
+		})
+	}
+	sinkConfig.Topic = testKafkaTopic
+	if !sinkConfig.FlushInterval.IsDefined() {
+		sinkConfig.FlushInterval = ct.NewOptDuration(time.Hour)
+	}
+	sink := makeKafkaEventSink("test", sinkConfig, func() (kafkaProducer, error) { return producer, nil },
+		ldlogtest.NewMockLog().Loggers)
+	t.Cleanup(func() { _ = sink.Close() })
+	return sink, messagesCh
+}
+
+// sinkEnvWithDropCounter returns a copy of testSinkEnv that counts the events reported as dropped.
+func sinkEnvWithDropCounter() (EventSinkEnvironment, func() int) {
+	var lock sync.Mutex
+	dropped := 0
+	env := testSinkEnv
+	env.OnEventsDropped = func(sinkName string, eventCount int) {
+		lock.Lock()
+		defer lock.Unlock()
+		dropped += eventCount
+	}
+	return env, func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return dropped
+	}
+}
+
+func TestKafkaEventSinkProducesOneMessagePerEvent(t *testing.T) {
+	sink, messagesCh := makeTestKafkaEventSink(t, config.EventSinkConfig{}, 2)
+	p := sink.NewPublisher(testSinkEnv, basictypes.MobileSDK, ldevents.AnalyticsEventDataKind)
+	require.NotNil(t, p)
+	p.Publish(EventPayloadMetadata{SchemaVersion: 4, Tags: "application-id=app"},
+		json.RawMessage(`{"kind":"identify","context":{"kind":"user","key":"a"}}`),
+		json.RawMessage(`{"kind":"custom","contextKeys":{"org":"b"}}`))
+	assert.Len(t, messagesCh, 0) // nothing is sent until the next flush
+	p.Flush()
+
+	expectedHeaders := map[string]string{
+		EventSchemaHeader:   "4",
+		TagsHeader:          "application-id=app",
+		KafkaEnvIDHeader:    "earth-env-id",
+		KafkaSDKKindHeader:  "mobile",
+		KafkaDataKindHeader: "analytics",
+	}
+	m1 := <-messagesCh
+	assert.Equal(t, "earth-env-id/a", m1.key)
+	assert.Equal(t, `{"kind":"identify","context":{"kind":"user","key":"a"}}`, m1.value)
+	assert.Equal(t, expectedHeaders, m1.headers)
+	m2 := <-messagesCh
+	assert.Equal(t, "earth-env-id/org:b", m2.key)
+	assert.Equal(t, `{"kind":"custom","contextKeys":{"org":"b"}}`, m2.value)
+	assert.Equal(t, expectedHeaders, m2.headers)
+}
+
+func TestKafkaEventSinkUsesEnvironmentNameIfThereIsNoID(t *testing.T) {
+	sink, messagesCh := makeTestKafkaEventSink(t, config.EventSinkConfig{}, 1)
+	p := sink.NewPublisher(EventSinkEnvironment{Name: "earth"}, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+	p.Publish(EventPayloadMetadata{SchemaVersion: 4}, json.RawMessage(`{"kind":"summary"}`))
+	p.Flush()
+
+	m := <-messagesCh
+	assert.Equal(t, "earth", m.key)
+	assert.NotContains(t, m.headers, KafkaEnvIDHeader)
+	assert.NotContains(t, m.headers, TagsHeader)
+}
+
+func TestKafkaEventSinkFlushesAtInterval(t *testing.T) {
+	sink, messagesCh := makeTestKafkaEventSink(t, config.EventSinkConfig{
+		FlushInterval: ct.NewOptDuration(time.Millisecond * 10),
+	}, 1)
+	p := sink.NewPublisher(testSinkEnv, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+	p.Publish(EventPayloadMetadata{SchemaVersion: 4}, json.RawMessage(`{"kind":"summary"}`))
+
+	select {
+	case m := <-messagesCh:
+		assert.Equal(t, `{"kind":"summary"}`, m.value)
+	case <-time.After(time.Second):
+		require.Fail(t, "timed out waiting for flush")
+	}
+}
+
+func TestKafkaEventSinkDropsEventsOverCapacity(t *testing.T) {
+	capacity, _ := ct.NewOptIntGreaterThanZero(2)
+	sink, messagesCh := makeTestKafkaEventSink(t, config.EventSinkConfig{Capacity: capacity}, 2)
+	env, getDropped := sinkEnvWithDropCounter()
+	p := sink.NewPublisher(env, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+	p.Publish(EventPayloadMetadata{SchemaVersion: 4},
+		json.RawMessage(`"e1"`), json.RawMessage(`"e2"`), json.RawMessage(`"e3"`))
+	p.Flush()
+
+	assert.Equal(t, `"e1"`, (<-messagesCh).value)
+	assert.Equal(t, `"e2"`, (<-messagesCh).value)
+	assert.Equal(t, 1, getDropped())
+}
+
+func TestKafkaEventSinkRetriesFailedSendOnce(t *testing.T) {
+	t.Run("retry succeeds", func(t *testing.T) {
+		mockLog := ldlogtest.NewMockLog()
+		producer := mocks.NewSyncProducer(t, nil)
+		producer.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+		producer.ExpectSendMessageAndSucceed()
+		sink := makeKafkaEventSink("test", config.EventSinkConfig{Topic: testKafkaTopic},
+			func() (kafkaProducer, error) { return producer, nil }, mockLog.Loggers)
+		env, getDropped := sinkEnvWithDropCounter()
+		p := sink.NewPublisher(env, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+		p.Publish(EventPayloadMetadata{SchemaVersion: 4}, json.RawMessage(`"e1"`))
+		require.NoError(t, sink.Close()) // the producer mock verifies that both expectations were used
+
+		assert.Equal(t, 0, getDropped())
+		mockLog.AssertMessageMatch(t, true, ldlog.Warn, "Error sending 1 events to Kafka .* will retry")
+		assert.Len(t, mockLog.GetOutput(ldlog.Error), 0)
+	})
+
+	t.Run("events are dropped if retry fails", func(t *testing.T) {
+		mockLog := ldlogtest.NewMockLog()
+		producer := mocks.NewSyncProducer(t, nil)
+		producer.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+		producer.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+		sink := makeKafkaEventSink("test", config.EventSinkConfig{Topic: testKafkaTopic},
+			func() (kafkaProducer, error) { return producer, nil }, mockLog.Loggers)
+		env, getDropped := sinkEnvWithDropCounter()
+		p := sink.NewPublisher(env, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+		p.Publish(EventPayloadMetadata{SchemaVersion: 4}, json.RawMessage(`"e1"`))
+		require.NoError(t, sink.Close())
+
+		assert.Equal(t, 1, getDropped())
+		mockLog.AssertMessageMatch(t, true, ldlog.Error, "Error sending 1 events to Kafka")
+	})
+}
+
+func TestKafkaEventSinkDoesNotWaitForConnectionWhenCreated(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageAndSucceed()
+	connectStarted, allowConnect := make(chan struct{}), make(chan struct{})
+	connect := func() (kafkaProducer, error) {
+		close(connectStarted)
+		<-allowConnect // as if the brokers were slow to respond
+		return producer, nil
+	}
+	sinkCh := make(chan *kafkaEventSink, 1)
+	go func() {
+		sinkCh <- makeKafkaEventSink("test", config.EventSinkConfig{Topic: testKafkaTopic, FlushInterval: ct.NewOptDuration(time.Hour)},
+			connect, ldlogtest.NewMockLog().Loggers)
+	}()
+	sink := helpers.RequireValue(t, sinkCh, time.Second, "timed out waiting for sink to be created")
+
+	helpers.AssertChannelClosed(t, connectStarted, time.Second, "timed out waiting for connection attempt")
+	p := sink.NewPublisher(testSinkEnv, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+	p.Publish(EventPayloadMetadata{SchemaVersion: 4}, json.RawMessage(`"e1"`))
+	close(allowConnect)
+	require.NoError(t, sink.Close()) // the producer mock verifies that the event was sent
+}
+
+func TestKafkaEventSinkConnectsLaterIfKafkaIsUnavailableAtStartup(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageAndSucceed()
+	var connectAttempts int32
+	connect := func() (kafkaProducer, error) {
+		if atomic.AddInt32(&connectAttempts, 1) == 1 {
+			return nil, sarama.ErrOutOfBrokers
+		}
+		return producer, nil
+	}
+	sink := makeKafkaEventSink("test", config.EventSinkConfig{Topic: testKafkaTopic, FlushInterval: ct.NewOptDuration(time.Hour)},
+		connect, mockLog.Loggers)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&connectAttempts) == 1 }, time.Second, time.Millisecond*10)
+
+	p := sink.NewPublisher(testSinkEnv, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+	p.Publish(EventPayloadMetadata{SchemaVersion: 4}, json.RawMessage(`"e1"`))
+	p.Flush()
+	require.NoError(t, sink.Close())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&connectAttempts))
+	mockLog.AssertMessageMatch(t, true, ldlog.Warn, "Could not connect to Kafka for event sink \"test\"")
+	assert.Len(t, mockLog.GetOutput(ldlog.Error), 0)
+}
+
+func TestKafkaEventSinkDeliversQueuedEventsOnClose(t *testing.T) {
+	sink, messagesCh := makeTestKafkaEventSink(t, config.EventSinkConfig{}, 1)
+	p := sink.NewPublisher(testSinkEnv, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+	p.Publish(EventPayloadMetadata{SchemaVersion: 4}, json.RawMessage(`"e1"`))
+	require.NoError(t, sink.Close())
+
+	assert.Len(t, messagesCh, 1)
+}
+
+func TestKafkaEventSinkKinds(t *testing.T) {
+	sink, _ := makeTestKafkaEventSink(t, config.EventSinkConfig{}, 0)
+	assert.NotNil(t, sink.NewPublisher(testSinkEnv, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind))
+	assert.Nil(t, sink.NewPublisher(testSinkEnv, basictypes.ServerSDK, ldevents.DiagnosticEventDataKind))
+}
+
+func TestKafkaEventSinkWithFakeBroker(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader(testKafkaTopic, 0, broker.BrokerID()),
+		"ProduceRequest": sarama.NewMockProduceResponse(t).
+			SetVersion(3). // the version that the producer uses for sarama's default Kafka version
+			SetError(testKafkaTopic, 0, sarama.ErrNoError),
+	})
+
+	sink, err := NewEventSink("kafka", config.EventSinkConfig{
+		Type:          config.EventSinkTypeKafka,
+		Brokers:       ct.NewOptStringList([]string{broker.Addr()}),
+		Topic:         testKafkaTopic,
+		FlushInterval: ct.NewOptDuration(time.Hour),
+	}, mockLog.Loggers)
+	require.NoError(t, err)
+
+	p := sink.NewPublisher(testSinkEnv, basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+	p.Publish(EventPayloadMetadata{SchemaVersion: 4}, json.RawMessage(`{"kind":"summary"}`))
+	p.Flush()
+	require.NoError(t, sink.Close())
+	assert.Len(t, mockLog.GetOutput(ldlog.Error), 0)
+
+	produceRequests := 0
+	for _, rr := range broker.History() {
+		if _, ok := rr.Request.(*sarama.ProduceRequest); ok {
+			produceRequests++
+		}
+	}
+	assert.Equal(t, 1, produceRequests)
+}
@@ -35,6 +35,7 @@ type eventSummarizingRelay struct {
 	baseHeaders  http.Header
 	storeAdapter *store.SSERelayDataStoreAdapter
 	eventsConfig ldevents.EventsConfiguration
+	sendPolicy   EventSendPolicy
 	baseURI      string
 	remotePath   string
 	loggers      ldlog.Loggers
@@ -60,6 +61,7 @@ func newEventSummarizingRelay(
 	httpConfig httpconfig.HTTPConfig,
 	credential c.SDKCredential,
 	storeAdapter *store.SSERelayDataStoreAdapter,
+	sendPolicy EventSendPolicy,
 	loggers ldlog.Loggers,
 	remotePath string,
 	eventQueueCleanupInterval time.Duration,
@@ -83,6 +85,7 @@ func newEventSummarizingRelay(
 		baseHeaders:  baseHeaders,
 		storeAdapter: storeAdapter,
 		eventsConfig: eventsConfig,
+		sendPolicy:   sendPolicy,
 		baseURI:      getEventsURI(config),
 		remotePath:   remotePath,
 		loggers:      loggers,
@@ -102,7 +105,8 @@ func (er *eventSummarizingRelay) enqueue(metadata EventPayloadMetadata, rawEvent
 	queue := er.queues[metadata]
 	if queue == nil {
 		sender := &delegatingEventSender{
-			wrapped: makeEventSender(er.httpClient, er.baseURI, er.remotePath, er.baseHeaders, er.authKey, metadata, er.loggers),
+			wrapped: makeEventSender(er.httpClient, er.baseURI, er.remotePath, er.baseHeaders, er.authKey, metadata,
+				er.sendPolicy, er.closer, er.loggers),
 		}
 		eventsConfig := er.eventsConfig
 		eventsConfig.EventSender = sender
@@ -138,13 +142,33 @@ func (er *eventSummarizingRelay) flush() { //nolint:unused // used only in tests
 	}
 }
 
+// flushBlocking is like flush, but waits until all of the queued events have been delivered or have failed.
+// It returns false if that did not happen before the deadline.
+func (er *eventSummarizingRelay) flushBlocking(deadline time.Time) bool {
+	processors := make([]ldevents.EventProcessor, 0, 10) // arbitrary initial capacity
+	er.lock.Lock()
+	for _, queue := range er.queues {
+		processors = append(processors, queue.eventProcessor)
+	}
+	er.lock.Unlock()
+	for _, p := range processors {
+		remaining := time.Until(deadline)
+		// FlushBlocking would wait indefinitely if the timeout were zero
+		if remaining <= 0 || !p.FlushBlocking(remaining) {
+			return false
+		}
+	}
+	return true
+}
+
 func (er *eventSummarizingRelay) replaceCredential(newCredential c.SDKCredential) {
 	er.lock.Lock()
 	if reflect.TypeOf(newCredential) == reflect.TypeOf(er.authKey) {
 		er.authKey = newCredential
 		for metadata, queue := range er.queues {
 			// See comment on makeEventSender() about why we create a new one in this situation.
-			sender := makeEventSender(er.httpClient, er.baseURI, er.remotePath, er.baseHeaders, newCredential, metadata, er.loggers)
+			sender := makeEventSender(er.httpClient, er.baseURI, er.remotePath, er.baseHeaders, newCredential, metadata,
+				er.sendPolicy, er.closer, er.loggers)
 			queue.eventSender.setWrapped(sender)
 		}
 	}
@@ -247,7 +271,7 @@ func (er *eventSummarizingRelay) runPeriodicCleanupTaskUntilClosed(cleanupInterv
 
 func (er *eventSummarizingRelay) close() {
 	er.closeOnce.Do(func() {
-		er.closer <- struct{}{}
+		close(er.closer)
 	})
 }
 
@@ -264,14 +288,15 @@ func (d *delegatingEventSender) setWrapped(newWrappedSender ldevents.EventSender
 	d.lock.Unlock()
 }
 
-// makeEventSender creates a new instance of the EventSender component that is provided by go-sdk-events,
-// configuring it to have the appropriate HTTP request headers.
+// makeEventSender creates a new instance of the EventSender component that the go-sdk-events
+// EventProcessor uses, configuring it to have the appropriate HTTP request headers.
 //
-// This component provides the standard behavior for error handling and retries on event posts. It does
-// not create its own goroutine or HTTP client or do any computations other than the minimum needed to
-// send each request, so there's not much overhead to creating and disposing of instances. And since the
-// current implementation doesn't allow the configured headers to be changed on a per-request basis, it's
-// simplest for us to just create a new instance if the relevant configuration may have changed.
+// This component provides Relay's behavior for compression, error handling, and retries on event posts,
+// as implemented by sendEventData; retries stop once the closer channel is closed. It does not create its
+// own goroutine or HTTP client or do any computations other than the minimum needed to send each request,
+// so there's not much overhead to creating and disposing of instances. And since the current
+// implementation doesn't allow the configured headers to be changed on a per-request basis, it's simplest
+// for us to just create a new instance if the relevant configuration may have changed.
 func makeEventSender(
 	httpClient *http.Client,
 	eventsURI string,
@@ -279,6 +304,8 @@ func makeEventSender(
 	baseHeaders http.Header,
 	authKey c.SDKCredential,
 	metadata EventPayloadMetadata,
+	sendPolicy EventSendPolicy,
+	closer <-chan struct{},
 	loggers ldlog.Loggers,
 ) ldevents.EventSender {
 	headers := make(http.Header)
@@ -298,15 +325,19 @@ func makeEventSender(
 			BaseHeaders: func() http.Header { return headers },
 			Loggers:     loggers,
 		},
+		sendPolicy: sendPolicy,
+		closer:     closer,
 		remotePath: remotePath,
 	}
 }
 
 type eventSenderWithOverridePath struct {
 	config     ldevents.EventSenderConfiguration
+	sendPolicy EventSendPolicy
+	closer     <-chan struct{}
 	remotePath string
 }
 
 func (e *eventSenderWithOverridePath) SendEventData(kind ldevents.EventDataKind, data []byte, eventCount int) ldevents.EventSenderResult {
-	return ldevents.SendEventDataWithRetry(e.config, kind, e.remotePath, data, eventCount)
+	return sendEventData(e.config, e.sendPolicy, e.closer, kind, e.remotePath, data, eventCount)
 }
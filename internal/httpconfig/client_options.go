@@ -0,0 +1,169 @@
+package httpconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+
+	ct "github.com/launchdarkly/go-configtypes"
+	"golang.org/x/net/http/httpproxy"
+)
+
+type customHeader struct {
+	name  string
+	value string
+}
+
+// clientOptions holds the properties from ProxyConfig that the SDK's HTTP configuration builder cannot
+// apply by itself.
+type clientOptions struct {
+	connectTimeout time.Duration
+	readTimeout    time.Duration
+	clientCert     *tls.Certificate
+	headers        []customHeader
+	proxyURL       *url.URL
+	noProxy        string
+}
+
+func makeClientOptions(
+	proxyConfig config.ProxyConfig,
+	proxyURL ct.OptURLAbsolute,
+	connectTimeout time.Duration,
+) (clientOptions, error) {
+	ret := clientOptions{
+		connectTimeout: connectTimeout,
+		readTimeout:    proxyConfig.ReadTimeout.GetOrElse(0),
+		proxyURL:       proxyURL.Get(),
+		noProxy:        strings.Join(proxyConfig.NoProxy.Values(), ","),
+	}
+	if err := config.ValidateProxyClientCert(proxyConfig); err != nil {
+		return ret, err
+	}
+	if proxyConfig.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(proxyConfig.ClientCertFile, proxyConfig.ClientKeyFile)
+		if err != nil {
+			return ret, fmt.Errorf("unable to load client certificate: %w", err)
+		}
+		ret.clientCert = &cert
+	}
+	for _, header := range proxyConfig.Headers.Values() {
+		name, value, err := config.ParseProxyHeader(header)
+		if err != nil {
+			return ret, err
+		}
+		ret.headers = append(ret.headers, customHeader{name: name, value: value})
+	}
+	return ret, nil
+}
+
+func (o clientOptions) isCustomized() bool {
+	return o.readTimeout > 0 || o.clientCert != nil || len(o.headers) > 0 || o.usesNoProxy()
+}
+
+func (o clientOptions) usesNoProxy() bool {
+	return o.proxyURL != nil && o.noProxy != ""
+}
+
+// noProxyFunc returns a function that returns the proxy URL for a request URL, or nil if the request
+// should not be proxied. It uses the same rules as the standard NO_PROXY environment variable.
+func (o clientOptions) noProxyFunc() func(*url.URL) (*url.URL, error) {
+	proxyConfig := httpproxy.Config{
+		HTTPProxy:  o.proxyURL.String(),
+		HTTPSProxy: o.proxyURL.String(),
+		NoProxy:    o.noProxy,
+	}
+	return proxyConfig.ProxyFunc()
+}
+
+// wrapNTLMFactory applies the options to every client created by the NTLM client factory, which creates
+// a new transport for each client. The NTLM transport always dials the proxy server, so to bypass the
+// proxy we have to replace its dial function.
+func (o clientOptions) wrapNTLMFactory(factory func() *http.Client) func() *http.Client {
+	var proxyForURL func(*url.URL) (*url.URL, error)
+	if o.usesNoProxy() {
+		proxyForURL = o.noProxyFunc()
+	}
+	return func() *http.Client {
+		client := factory()
+		if transport, ok := client.Transport.(*http.Transport); ok {
+			o.configureTransport(transport)
+			if proxyForURL != nil && transport.DialContext != nil {
+				proxyDial := transport.DialContext
+				directDialer := &net.Dialer{Timeout: o.connectTimeout, KeepAlive: time.Minute}
+				transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+					if proxy, _ := proxyForURL(&url.URL{Scheme: "http", Host: addr}); proxy == nil {
+						return directDialer.DialContext(ctx, network, addr)
+					}
+					return proxyDial(ctx, network, addr)
+				}
+			}
+		}
+		return o.configureClient(client)
+	}
+}
+
+// configureTransport sets the client certificate and the response header timeout. Since the transport
+// may be shared by many clients, this should be done only once for each transport.
+func (o clientOptions) configureTransport(transport *http.Transport) {
+	if o.clientCert != nil {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{} //nolint:gosec // not setting TLS.MinVersion
+		}
+		transport.TLSClientConfig.Certificates = []tls.Certificate{*o.clientCert}
+	}
+	if o.readTimeout > 0 {
+		transport.ResponseHeaderTimeout = o.readTimeout
+	}
+}
+
+// configureProxy sets the proxy for a transport that we created ourselves.
+func (o clientOptions) configureProxy(transport *http.Transport) {
+	switch {
+	case o.usesNoProxy():
+		proxyForURL := o.noProxyFunc()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyForURL(req.URL)
+		}
+	case o.proxyURL != nil:
+		transport.Proxy = http.ProxyURL(o.proxyURL)
+	}
+}
+
+// configureClient adds the custom headers to every request made by the client, and makes its overall
+// timeout long enough to allow for both the connect timeout and the read timeout. Components that use
+// the client for a stream set its Timeout to zero, so for them only the response header timeout on
+// the transport applies.
+func (o clientOptions) configureClient(client *http.Client) *http.Client {
+	if o.readTimeout > 0 {
+		client.Timeout = o.connectTimeout + o.readTimeout
+	}
+	if len(o.headers) > 0 {
+		client.Transport = headerTransport{base: client.Transport, headers: o.headers}
+	}
+	return client
+}
+
+// headerTransport is an http.RoundTripper that adds custom headers to every request.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers []customHeader
+}
+
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	req = req.Clone(req.Context()) // a RoundTripper must not modify the original request
+	for _, h := range t.headers {
+		req.Header.Set(h.name, h.value)
+	}
+	return base.RoundTrip(req)
+}
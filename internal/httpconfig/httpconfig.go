@@ -4,9 +4,11 @@ package httpconfig
 import (
 	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/launchdarkly/ld-relay/v7/config"
 
+	ct "github.com/launchdarkly/go-configtypes"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-server-sdk/v6/ldcomponents"
 	"github.com/launchdarkly/go-server-sdk/v6/ldhttp"
@@ -19,71 +21,205 @@ var (
 	errProxyAuthWithoutProxyURL        = errors.New("cannot specify proxy authentication without a proxy URL")
 )
 
-// HTTPConfig encapsulates ProxyConfig plus any other HTTP options we may support in the future (currently none).
+// Destination identifies a kind of outbound connection that can be configured to use a different proxy
+// server from the one in ProxyConfig.URL. See HTTPConfig.ForDestination.
+type Destination string
+
+const (
+	// DestinationStream is for the SDK stream connections to LaunchDarkly (ProxyConfig.StreamURL). The
+	// SDK uses a single HTTP configuration for everything it does, so this also applies to the diagnostic
+	// events that the SDK sends on its own, rather than DestinationEvents.
+	DestinationStream Destination = "stream"
+
+	// DestinationBase is for the requests to LaunchDarkly that Relay proxies for JavaScript client-side
+	// SDKs, such as for the goals endpoint (ProxyConfig.BaseURL). Relay does not otherwise poll
+	// LaunchDarkly.
+	DestinationBase Destination = "base"
+
+	// DestinationEvents is for analytics events and usage metrics (ProxyConfig.EventsURL).
+	DestinationEvents Destination = "events"
+
+	// DestinationBigSegments is for big segment synchronization (ProxyConfig.BigSegmentsURL).
+	DestinationBigSegments Destination = "bigSegments"
+
+	// DestinationAutoConfig is for the auto-configuration stream (ProxyConfig.AutoConfigURL).
+	DestinationAutoConfig Destination = "autoConfig"
+)
+
+func allDestinations() []Destination {
+	return []Destination{DestinationStream, DestinationBase, DestinationEvents, DestinationBigSegments,
+		DestinationAutoConfig}
+}
+
+func destinationProxyURL(proxyConfig config.ProxyConfig, dest Destination) ct.OptURLAbsolute {
+	switch dest {
+	case DestinationStream:
+		return proxyConfig.StreamURL
+	case DestinationBase:
+		return proxyConfig.BaseURL
+	case DestinationEvents:
+		return proxyConfig.EventsURL
+	case DestinationBigSegments:
+		return proxyConfig.BigSegmentsURL
+	case DestinationAutoConfig:
+		return proxyConfig.AutoConfigURL
+	default:
+		return ct.OptURLAbsolute{}
+	}
+}
+
+// HTTPConfig encapsulates ProxyConfig plus the SDK HTTP configuration that is derived from it.
 type HTTPConfig struct {
 	config.ProxyConfig
 	SDKHTTPConfigFactory *ldcomponents.HTTPConfigurationBuilder
 	SDKHTTPConfig        subsystems.HTTPConfiguration
+	destinations         map[Destination]HTTPConfig
 }
 
 // NewHTTPConfig validates all of the HTTP-related options and returns an HTTPConfig if successful.
+//
+// The connect timeout, read timeout, client certificate, custom headers, and NoProxy list in ProxyConfig
+// are applied to every HTTP client that is created from this configuration, including the ones used by
+// the SDK. The proxy URL is ProxyConfig.URL, unless a different one is configured for the destination
+// that is selected with ForDestination.
 func NewHTTPConfig(proxyConfig config.ProxyConfig, authKey config.SDKCredential, userAgent string, loggers ldlog.Loggers) (HTTPConfig, error) {
-	configBuilder := ldcomponents.HTTPConfiguration()
-	configBuilder.UserAgent(userAgent)
-
-	ret := HTTPConfig{ProxyConfig: proxyConfig}
-
 	authKeyStr := ""
 	if authKey != nil {
 		authKeyStr = authKey.GetAuthorizationHeaderValue()
 	}
 
-	if !proxyConfig.URL.IsDefined() && proxyConfig.NTLMAuth {
-		return ret, errProxyAuthWithoutProxyURL
+	if proxyConfig.NTLMAuth {
+		hasProxyURL := proxyConfig.URL.IsDefined()
+		for _, dest := range allDestinations() {
+			hasProxyURL = hasProxyURL || destinationProxyURL(proxyConfig, dest).IsDefined()
+		}
+		if !hasProxyURL {
+			return HTTPConfig{ProxyConfig: proxyConfig}, errProxyAuthWithoutProxyURL
+		}
+		if proxyConfig.User == "" || proxyConfig.Password == "" {
+			return HTTPConfig{ProxyConfig: proxyConfig}, errNTLMProxyAuthWithoutCredentials
+		}
 	}
+
 	if proxyConfig.URL.IsDefined() {
 		loggers.Infof("Using proxy server at %s", proxyConfig.URL)
 	}
+	ret, err := newHTTPConfigForProxyURL(proxyConfig, proxyConfig.URL, authKeyStr, userAgent)
+	if err != nil {
+		return ret, err
+	}
 
-	caCertFiles := proxyConfig.CACertFiles.Values()
+	for _, dest := range allDestinations() {
+		if proxyURL := destinationProxyURL(proxyConfig, dest); proxyURL.IsDefined() {
+			loggers.Infof("Using proxy server at %s for %s connections", proxyURL, dest)
+			destConfig, err := newHTTPConfigForProxyURL(proxyConfig, proxyURL, authKeyStr, userAgent)
+			if err != nil {
+				return ret, err
+			}
+			if ret.destinations == nil {
+				ret.destinations = make(map[Destination]HTTPConfig)
+			}
+			ret.destinations[dest] = destConfig
+		}
+	}
 
 	if proxyConfig.NTLMAuth {
-		if proxyConfig.User == "" || proxyConfig.Password == "" {
-			return ret, errNTLMProxyAuthWithoutCredentials
-		}
-		transportOpts := []ldhttp.TransportOption{
-			ldhttp.ConnectTimeoutOption(ldcomponents.DefaultConnectTimeout),
-		}
-		for _, filePath := range caCertFiles {
-			if filePath != "" {
-				transportOpts = append(transportOpts, ldhttp.CACertFileOption(filePath))
-			}
+		loggers.Info("NTLM proxy authentication enabled")
+	}
+	if noProxy := proxyConfig.NoProxy.Values(); len(noProxy) > 0 {
+		loggers.Infof("Not using proxy server for: %s", strings.Join(noProxy, ", "))
+	}
+	return ret, nil
+}
+
+func newHTTPConfigForProxyURL(
+	proxyConfig config.ProxyConfig,
+	proxyURL ct.OptURLAbsolute,
+	authKeyStr string,
+	userAgent string,
+) (HTTPConfig, error) {
+	configBuilder := ldcomponents.HTTPConfiguration()
+	configBuilder.UserAgent(userAgent)
+
+	ret := HTTPConfig{ProxyConfig: proxyConfig}
+	ret.URL = proxyURL
+
+	connectTimeout := proxyConfig.ConnectTimeout.GetOrElse(ldcomponents.DefaultConnectTimeout)
+	options, err := makeClientOptions(proxyConfig, proxyURL, connectTimeout)
+	if err != nil {
+		return ret, err
+	}
+
+	transportOpts := []ldhttp.TransportOption{
+		ldhttp.ConnectTimeoutOption(connectTimeout),
+	}
+	for _, filePath := range proxyConfig.CACertFiles.Values() {
+		if filePath != "" {
+			transportOpts = append(transportOpts, ldhttp.CACertFileOption(filePath))
 		}
-		factory, err := ldntlm.NewNTLMProxyHTTPClientFactory(proxyConfig.URL.String(),
+	}
+
+	if proxyConfig.NTLMAuth && proxyURL.IsDefined() {
+		factory, err := ldntlm.NewNTLMProxyHTTPClientFactory(proxyURL.String(),
 			proxyConfig.User, proxyConfig.Password, proxyConfig.Domain, transportOpts...)
 		if err != nil {
 			return ret, err
 		}
-		configBuilder.HTTPClientFactory(factory)
-		loggers.Info("NTLM proxy authentication enabled")
+		configBuilder.HTTPClientFactory(options.wrapNTLMFactory(factory))
+	} else if options.isCustomized() {
+		// The SDK's own HTTP configuration builder has no way to set a client certificate, a response
+		// timeout, or a NoProxy list, so in that case we create the transport ourselves, the same way
+		// the SDK would.
+		transport, _, err := ldhttp.NewHTTPTransport(transportOpts...)
+		if err != nil {
+			return ret, err
+		}
+		options.configureTransport(transport)
+		options.configureProxy(transport)
+		configBuilder.HTTPClientFactory(func() *http.Client {
+			return options.configureClient(&http.Client{Timeout: connectTimeout, Transport: transport})
+		})
 	} else {
-		if proxyConfig.URL.IsDefined() {
-			configBuilder.ProxyURL(proxyConfig.URL.String())
+		configBuilder.ConnectTimeout(connectTimeout)
+		if proxyURL.IsDefined() {
+			configBuilder.ProxyURL(proxyURL.String())
 		}
-		for _, filePath := range caCertFiles {
+		for _, filePath := range proxyConfig.CACertFiles.Values() {
 			if filePath != "" {
 				configBuilder.CACertFile(filePath)
 			}
 		}
 	}
 
-	var err error
+	for _, h := range options.headers {
+		configBuilder.Header(h.name, h.value)
+	}
+
 	ret.SDKHTTPConfigFactory = configBuilder
 	ret.SDKHTTPConfig, err = configBuilder.Build(subsystems.BasicClientContext{SDKKey: authKeyStr})
 	return ret, err
 }
 
+// ForDestination returns the configuration to use for the specified kind of outbound connection. This
+// is the same as the original configuration, unless a different proxy URL was configured for that
+// destination.
+func (c HTTPConfig) ForDestination(dest Destination) HTTPConfig {
+	if destConfig, ok := c.destinations[dest]; ok {
+		return destConfig
+	}
+	return c
+}
+
 // Client creates a new HTTP client instance that isn't for SDK use.
 func (c HTTPConfig) Client() *http.Client {
 	return c.SDKHTTPConfig.CreateHTTPClient()
 }
+
+// Transport returns an http.RoundTripper with the same configuration as Client, for components such
+// as httputil.ReverseProxy that take a transport rather than a client.
+func (c HTTPConfig) Transport() http.RoundTripper {
+	if transport := c.Client().Transport; transport != nil {
+		return transport
+	}
+	return http.DefaultTransport
+}
@@ -1,17 +1,21 @@
 package httpconfig
 
 import (
+	"crypto/tls"
 	"crypto/x509"
+	"encoding/pem"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/launchdarkly/ld-relay/v7/config"
 
 	"github.com/launchdarkly/go-configtypes"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-server-sdk/v6/ldcomponents"
 	helpers "github.com/launchdarkly/go-test-helpers/v3"
 	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
 
@@ -137,3 +141,229 @@ func TestNTLMProxyInvalidConfigs(t *testing.T) {
 		}
 	})
 }
+
+func TestCustomHeaders(t *testing.T) {
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(http.StatusOK))
+
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		proxyConfig := config.ProxyConfig{
+			Headers: configtypes.NewOptStringList([]string{"x-egress-tenant: tenant1", "X-Other:value"}),
+		}
+		hc, err := NewHTTPConfig(proxyConfig, config.SDKKey("key"), "", ldlog.NewDisabledLoggers())
+		require.NoError(t, err)
+
+		assert.Equal(t, "tenant1", hc.SDKHTTPConfig.DefaultHeaders.Get("X-Egress-Tenant"))
+
+		resp, err := hc.Client().Get(server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		req := <-requestsCh
+		assert.Equal(t, "tenant1", req.Request.Header.Get("X-Egress-Tenant"))
+		assert.Equal(t, "value", req.Request.Header.Get("X-Other"))
+
+		proxiedReq, _ := http.NewRequest("GET", server.URL, nil)
+		resp, err = hc.Transport().RoundTrip(proxiedReq)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		req = <-requestsCh
+		assert.Equal(t, "tenant1", req.Request.Header.Get("X-Egress-Tenant"))
+		assert.Equal(t, "", proxiedReq.Header.Get("X-Egress-Tenant"), "original request should not be modified")
+	})
+}
+
+func TestCustomHeaderInvalid(t *testing.T) {
+	proxyConfig := config.ProxyConfig{Headers: configtypes.NewOptStringList([]string{"X-Egress-Tenant"})}
+	_, err := NewHTTPConfig(proxyConfig, nil, "", ldlog.NewDisabledLoggers())
+	_, _, expectedErr := config.ParseProxyHeader("X-Egress-Tenant")
+	assert.Equal(t, expectedErr, err)
+}
+
+func TestReadTimeout(t *testing.T) {
+	unblockCh := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblockCh
+	})
+
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		defer close(unblockCh)
+		proxyConfig := config.ProxyConfig{ReadTimeout: configtypes.NewOptDuration(50 * time.Millisecond)}
+		hc, err := NewHTTPConfig(proxyConfig, nil, "", ldlog.NewDisabledLoggers())
+		require.NoError(t, err)
+
+		client := hc.Client()
+		assert.Equal(t, ldcomponents.DefaultConnectTimeout+50*time.Millisecond, client.Timeout)
+
+		// Stream clients clear the overall timeout, but the response header timeout still applies.
+		client.Timeout = 0
+		_, err = client.Get(server.URL)
+		assert.Error(t, err)
+	})
+}
+
+func TestConnectTimeout(t *testing.T) {
+	proxyConfig := config.ProxyConfig{ConnectTimeout: configtypes.NewOptDuration(7 * time.Second)}
+	hc, err := NewHTTPConfig(proxyConfig, nil, "", ldlog.NewDisabledLoggers())
+	require.NoError(t, err)
+	assert.Equal(t, 7*time.Second, hc.Client().Timeout)
+}
+
+func TestClientCertificate(t *testing.T) {
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(http.StatusOK))
+	server := httptest.NewUnstartedServer(handler)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert} //nolint:gosec // test server
+	server.StartTLS()
+	defer server.Close()
+
+	helpers.WithTempFile(func(caCertFilePath string) {
+		caCertData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		require.NoError(t, os.WriteFile(caCertFilePath, caCertData, 0600))
+		helpers.WithTempFile(func(certFilePath string) {
+			helpers.WithTempFile(func(keyFilePath string) {
+				require.NoError(t, httphelpers.MakeSelfSignedCert(certFilePath, keyFilePath))
+
+				proxyConfig := config.ProxyConfig{
+					CACertFiles: configtypes.NewOptStringList([]string{caCertFilePath}),
+				}
+				hc, err := NewHTTPConfig(proxyConfig, nil, "", ldlog.NewDisabledLoggers())
+				require.NoError(t, err)
+				_, err = hc.Client().Get(server.URL)
+				assert.Error(t, err, "server should have rejected a request without a client certificate")
+
+				proxyConfig.ClientCertFile = certFilePath
+				proxyConfig.ClientKeyFile = keyFilePath
+				hc, err = NewHTTPConfig(proxyConfig, nil, "", ldlog.NewDisabledLoggers())
+				require.NoError(t, err)
+				resp, err := hc.Client().Get(server.URL)
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				req := <-requestsCh
+				assert.Len(t, req.Request.TLS.PeerCertificates, 1)
+			})
+		})
+	})
+}
+
+func TestClientCertificateErrors(t *testing.T) {
+	_, err := NewHTTPConfig(config.ProxyConfig{ClientCertFile: "cert"}, nil, "", ldlog.NewDisabledLoggers())
+	require.Error(t, err)
+	assert.Equal(t, config.ValidateProxyClientCert(config.ProxyConfig{ClientCertFile: "cert"}), err)
+
+	helpers.WithTempFile(func(certFilePath string) {
+		proxyConfig := config.ProxyConfig{ClientCertFile: certFilePath, ClientKeyFile: certFilePath}
+		_, err := NewHTTPConfig(proxyConfig, nil, "", ldlog.NewDisabledLoggers())
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "unable to load client certificate")
+		}
+	})
+}
+
+func TestProxyForDestination(t *testing.T) {
+	fakeURL := "http://fake-url/"
+	handler1, requestsCh1 := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(http.StatusOK))
+	handler2, requestsCh2 := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(http.StatusOK))
+	mockLog := ldlogtest.NewMockLog()
+
+	httphelpers.WithServer(handler1, func(server1 *httptest.Server) {
+		httphelpers.WithServer(handler2, func(server2 *httptest.Server) {
+			proxyConfig := config.ProxyConfig{}
+			proxyConfig.URL, _ = configtypes.NewOptURLAbsoluteFromString(server1.URL)
+			proxyConfig.EventsURL, _ = configtypes.NewOptURLAbsoluteFromString(server2.URL)
+			hc, err := NewHTTPConfig(proxyConfig, nil, "", mockLog.Loggers)
+			require.NoError(t, err)
+
+			mockLog.AssertMessageMatch(t, true, ldlog.Info, "Using proxy server at "+server2.URL+" for events connections")
+
+			for _, dest := range []Destination{"", DestinationStream, DestinationEvents} {
+				t.Run(string(dest), func(t *testing.T) {
+					expectedCh, otherCh := requestsCh1, requestsCh2
+					if dest == DestinationEvents {
+						expectedCh, otherCh = requestsCh2, requestsCh1
+					}
+					resp, err := hc.ForDestination(dest).Client().Get(fakeURL)
+					require.NoError(t, err)
+					assert.Equal(t, http.StatusOK, resp.StatusCode)
+					req := <-expectedCh
+					assert.Equal(t, fakeURL, req.Request.URL.String())
+					assert.Len(t, otherCh, 0)
+				})
+			}
+		})
+	})
+}
+
+func TestProxyForDestinationOnly(t *testing.T) {
+	proxyConfig := config.ProxyConfig{}
+	proxyConfig.StreamURL, _ = configtypes.NewOptURLAbsoluteFromString("http://stream-proxy")
+	hc, err := NewHTTPConfig(proxyConfig, nil, "", ldlog.NewDisabledLoggers())
+	require.NoError(t, err)
+
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(http.StatusOK))
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		resp, err := hc.ForDestination(DestinationEvents).Client().Get(server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		<-requestsCh
+	})
+}
+
+func TestNoProxy(t *testing.T) {
+	proxyConfig := config.ProxyConfig{NoProxy: configtypes.NewOptStringList([]string{"redis.internal", ".corp.example"})}
+	proxyConfig.URL, _ = configtypes.NewOptURLAbsoluteFromString("http://proxy")
+	proxyConfig.EventsURL, _ = configtypes.NewOptURLAbsoluteFromString("http://events-proxy")
+	hc, err := NewHTTPConfig(proxyConfig, nil, "", ldlog.NewDisabledLoggers())
+	require.NoError(t, err)
+
+	for _, dest := range []Destination{"", DestinationEvents} {
+		t.Run(string(dest), func(t *testing.T) {
+			expectedProxy := "http://proxy"
+			if dest == DestinationEvents {
+				expectedProxy = "http://events-proxy"
+			}
+			transport, ok := hc.ForDestination(dest).Client().Transport.(*http.Transport)
+			require.True(t, ok)
+
+			for _, target := range []string{"http://redis.internal/", "https://host.corp.example/"} {
+				req, _ := http.NewRequest("GET", target, nil)
+				proxyURL, err := transport.Proxy(req)
+				require.NoError(t, err)
+				assert.Nil(t, proxyURL, target)
+			}
+
+			req, _ := http.NewRequest("GET", "https://stream.launchdarkly.com/", nil)
+			proxyURL, err := transport.Proxy(req)
+			require.NoError(t, err)
+			if assert.NotNil(t, proxyURL) {
+				assert.Equal(t, expectedProxy, proxyURL.String())
+			}
+		})
+	}
+}
+
+func TestNTLMProxyNoProxy(t *testing.T) {
+	// There is no NTLM proxy listening at this address, so the request can only succeed if it bypasses
+	// the proxy.
+	proxyConfig := config.ProxyConfig{
+		NTLMAuth: true,
+		User:     "user",
+		Password: "pass",
+		NoProxy:  configtypes.NewOptStringList([]string{"127.0.0.1"}),
+	}
+	proxyConfig.URL, _ = configtypes.NewOptURLAbsoluteFromString("http://127.0.0.1:1")
+	hc, err := NewHTTPConfig(proxyConfig, nil, "", ldlog.NewDisabledLoggers())
+	require.NoError(t, err)
+
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(http.StatusOK))
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		resp, err := hc.Client().Get(server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		<-requestsCh
+	})
+}
+
+func TestNTLMProxyWithDestinationURLOnly(t *testing.T) {
+	proxyConfig := config.ProxyConfig{NTLMAuth: true, User: "user", Password: "pass"}
+	proxyConfig.EventsURL, _ = configtypes.NewOptURLAbsoluteFromString("http://fake-proxy")
+	_, err := NewHTTPConfig(proxyConfig, nil, "", ldlog.NewDisabledLoggers())
+	assert.NoError(t, err)
+}
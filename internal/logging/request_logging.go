@@ -48,6 +48,12 @@ func (w *loggingHTTPResponseWriter) WriteHeader(statusCode int) {
 	w.writer.WriteHeader(statusCode)
 }
 
+// Unwrap allows http.ResponseController to reach the underlying ResponseWriter, so that stream
+// handlers can set write deadlines.
+func (w *loggingHTTPResponseWriter) Unwrap() http.ResponseWriter {
+	return w.writer
+}
+
 func (w *loggingHTTPResponseWriter) logRequest() {
 	authStr := "n/a"
 	if authHeader := w.request.Header.Get("Authorization"); authHeader != "" {
@@ -4,8 +4,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
@@ -50,6 +52,20 @@ func TestRequestLoggerMiddlewareStreaming(t *testing.T) {
 	mockLog.AssertMessageMatch(t, true, ldlog.Debug, "Stream closed: url=/url auth=n/a bytes=3")
 }
 
+func TestRequestLoggerMiddlewareAllowsResponseController(t *testing.T) {
+	var deadlineErr error
+	handler := RequestLoggerMiddleware(ldlog.NewDisabledLoggers())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadlineErr = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(time.Hour))
+	}))
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.NoError(t, deadlineErr)
+}
+
 func TestRequestLoggerMiddlewareAuth(t *testing.T) {
 	mockLog := ldlogtest.NewMockLog()
 	mockLog.Loggers.SetMinLevel(ldlog.Debug)
@@ -19,8 +19,21 @@ const (
 	newConnMeasureName        = "newconnections"
 	privateNewConnMeasureName = "internal_newconnections"
 
+	droppedConnMeasureName = "droppedconnections"
+
 	requestMeasureName = "requests"
 
+	webhookDeliveredMeasureName = "webhookdeliveries"
+	webhookFailedMeasureName    = "webhookfailures"
+	webhookDroppedMeasureName   = "webhookdrops"
+
+	eventsSampledOutMeasureName = "eventssampledout"
+
+	eventsDeliveredMeasureName = "eventsdelivered"
+	eventsFailedMeasureName    = "eventsfailed"
+
+	eventSinkDroppedMeasureName = "eventsinkdrops"
+
 	defaultFlushInterval = time.Minute
 )
 
@@ -31,6 +44,9 @@ var (
 	routeTagKey, _            = tag.NewKey("route")            //nolint:gochecknoglobals
 	methodTagKey, _           = tag.NewKey("method")           //nolint:gochecknoglobals
 	envNameTagKey, _          = tag.NewKey("env")              //nolint:gochecknoglobals
+	eventKindTagKey, _        = tag.NewKey("eventKind")        //nolint:gochecknoglobals
+	destinationTagKey, _      = tag.NewKey("destination")      //nolint:gochecknoglobals
+	sinkTagKey, _             = tag.NewKey("sink")             //nolint:gochecknoglobals
 
 	publicTags  = []tag.Key{platformCategoryTagKey, userAgentTagKey, envNameTagKey}                //nolint:gochecknoglobals
 	privateTags = []tag.Key{platformCategoryTagKey, userAgentTagKey, relayIDTagKey, envNameTagKey} //nolint:gochecknoglobals
@@ -19,9 +19,21 @@ var (
 	//
 	// To avoid having to put nolint:gochecknoglobals on everything here, that linter is excluded
 	// specifically for this file in .golangci-lint.yml.
-	connMeasure    = stats.Int64(connMeasureName, "current number of connections", stats.UnitDimensionless)
-	newConnMeasure = stats.Int64(newConnMeasureName, "total number of connections", stats.UnitDimensionless)
-	requestMeasure = stats.Int64(requestMeasureName, "Number of hits to a route", stats.UnitDimensionless)
+	connMeasure        = stats.Int64(connMeasureName, "current number of connections", stats.UnitDimensionless)
+	newConnMeasure     = stats.Int64(newConnMeasureName, "total number of connections", stats.UnitDimensionless)
+	droppedConnMeasure = stats.Int64(droppedConnMeasureName, "number of stream connections dropped for falling behind", stats.UnitDimensionless)
+	requestMeasure     = stats.Int64(requestMeasureName, "Number of hits to a route", stats.UnitDimensionless)
+
+	webhookDeliveredMeasure = stats.Int64(webhookDeliveredMeasureName, "number of webhook notifications delivered", stats.UnitDimensionless)
+	webhookFailedMeasure    = stats.Int64(webhookFailedMeasureName, "number of webhook notifications that could not be delivered", stats.UnitDimensionless)
+	webhookDroppedMeasure   = stats.Int64(webhookDroppedMeasureName, "number of webhook notifications discarded because the queue was full", stats.UnitDimensionless)
+
+	eventsSampledOutMeasure = stats.Int64(eventsSampledOutMeasureName, "number of analytics events discarded by event sampling", stats.UnitDimensionless)
+
+	eventsDeliveredMeasure = stats.Int64(eventsDeliveredMeasureName, "number of analytics events delivered to an event destination", stats.UnitDimensionless)
+	eventsFailedMeasure    = stats.Int64(eventsFailedMeasureName, "number of analytics events that could not be delivered to an event destination", stats.UnitDimensionless)
+
+	eventSinkDroppedMeasure = stats.Int64(eventSinkDroppedMeasureName, "number of events that an event sink discarded without writing them", stats.UnitDimensionless)
 
 	// For internal event exporter
 	privateConnMeasure    = stats.Int64(privateConnMeasureName, "current number of connections", stats.UnitDimensionless)
@@ -45,6 +57,54 @@ var (
 	// NewServerConns is a Measure representing the cumulative number of stream connections from server-side SDKs.
 	NewServerConns = Measure{measures: []*stats.Int64Measure{newConnMeasure, privateNewConnMeasure}, tags: makeServerTags()}
 
+	// DroppedBrowserConns is a Measure representing the cumulative number of stream connections from browsers
+	// that were dropped because the client was not reading events quickly enough.
+	DroppedBrowserConns = Measure{measures: []*stats.Int64Measure{droppedConnMeasure}, tags: makeBrowserTags()}
+
+	// DroppedMobileConns is a Measure representing the cumulative number of stream connections from mobile SDKs
+	// that were dropped because the client was not reading events quickly enough.
+	DroppedMobileConns = Measure{measures: []*stats.Int64Measure{droppedConnMeasure}, tags: makeMobileTags()}
+
+	// DroppedServerConns is a Measure representing the cumulative number of stream connections from server-side
+	// SDKs that were dropped because the client was not reading events quickly enough.
+	DroppedServerConns = Measure{measures: []*stats.Int64Measure{droppedConnMeasure}, tags: makeServerTags()}
+
+	// WebhookDeliveries is a Measure representing the cumulative number of flag change webhook notifications
+	// that were delivered successfully.
+	WebhookDeliveries = Measure{measures: []*stats.Int64Measure{webhookDeliveredMeasure}}
+
+	// WebhookFailures is a Measure representing the cumulative number of flag change webhook notifications
+	// that could not be delivered after all retries.
+	WebhookFailures = Measure{measures: []*stats.Int64Measure{webhookFailedMeasure}}
+
+	// WebhookDrops is a Measure representing the cumulative number of flag change webhook notifications
+	// that were discarded because the delivery queue was full.
+	WebhookDrops = Measure{measures: []*stats.Int64Measure{webhookDroppedMeasure}}
+
+	// SampledOutBrowserEvents is a Measure representing the cumulative number of analytics events from browsers
+	// that were not forwarded because of event sampling.
+	SampledOutBrowserEvents = Measure{measures: []*stats.Int64Measure{eventsSampledOutMeasure}, tags: makeBrowserTags()}
+
+	// SampledOutMobileEvents is a Measure representing the cumulative number of analytics events from mobile
+	// SDKs that were not forwarded because of event sampling.
+	SampledOutMobileEvents = Measure{measures: []*stats.Int64Measure{eventsSampledOutMeasure}, tags: makeMobileTags()}
+
+	// SampledOutServerEvents is a Measure representing the cumulative number of analytics events from
+	// server-side SDKs that were not forwarded because of event sampling.
+	SampledOutServerEvents = Measure{measures: []*stats.Int64Measure{eventsSampledOutMeasure}, tags: makeServerTags()}
+
+	// EventsDelivered is a Measure representing the cumulative number of analytics events that were
+	// delivered to an event destination.
+	EventsDelivered = Measure{measures: []*stats.Int64Measure{eventsDeliveredMeasure}}
+
+	// EventsFailed is a Measure representing the cumulative number of analytics events that could not be
+	// delivered to an event destination after all retries.
+	EventsFailed = Measure{measures: []*stats.Int64Measure{eventsFailedMeasure}}
+
+	// EventSinkDrops is a Measure representing the cumulative number of events that an event sink discarded,
+	// because its queue was full or because they could not be sent after a retry.
+	EventSinkDrops = Measure{measures: []*stats.Int64Measure{eventSinkDroppedMeasure}}
+
 	// BrowserRequests is a Measure representing the number of HTTP requests from browsers.
 	BrowserRequests = Measure{measures: []*stats.Int64Measure{requestMeasure}, tags: makeBrowserTags()}
 
@@ -91,16 +151,64 @@ func WithGauge(ctx context.Context, userAgent string, f func(), measure Measure)
 
 // WithCount runs a function and records a single-unit increment for the specified metric.
 func WithCount(ctx context.Context, userAgent string, f func(), measure Measure) {
+	IncrementForUserAgent(ctx, userAgent, measure)
+	f()
+}
+
+// IncrementForUserAgent records a single-unit increment for the specified metric, tagged with the user
+// agent of the SDK request that it relates to.
+func IncrementForUserAgent(ctx context.Context, userAgent string, measure Measure) {
 	ctx, err := tag.New(ctx, tag.Insert(userAgentTagKey, sanitizeTagValue(userAgent)))
 	if err != nil { // COVERAGE: can't make this happen in unit tests
 		logging.GetGlobalContextLoggers(ctx).Errorf(`Failed to create tag for user agent : %s`, err)
-	} else {
-		for _, m := range measure.measures {
-			ctx, _ := tag.New(ctx, measure.tags...)
-			stats.Record(ctx, m.M(1))
-		}
+		return
+	}
+	for _, m := range measure.measures {
+		ctx, _ := tag.New(ctx, measure.tags...)
+		stats.Record(ctx, m.M(1))
+	}
+}
+
+// Increment records a single-unit increment for the specified metric, for metrics that are not
+// associated with an SDK request.
+func Increment(ctx context.Context, measure Measure) {
+	for _, m := range measure.measures {
+		ctx, _ := tag.New(ctx, measure.tags...)
+		stats.Record(ctx, m.M(1))
+	}
+}
+
+// AddEventCount records an increment of the specified amount for one of the metrics that count
+// analytics events, such as SampledOutServerEvents, tagged with the kind of event.
+func AddEventCount(ctx context.Context, userAgent string, eventKind string, count int, measure Measure) {
+	addCountWithTags(ctx, count, measure, tag.Insert(userAgentTagKey, sanitizeTagValue(userAgent)),
+		tag.Insert(eventKindTagKey, sanitizeTagValue(eventKind)))
+}
+
+// AddEventDestinationCount records an increment of the specified amount for EventsDelivered or
+// EventsFailed, tagged with the name of the event destination.
+func AddEventDestinationCount(ctx context.Context, destination string, count int, measure Measure) {
+	addCountWithTags(ctx, count, measure, tag.Insert(destinationTagKey, sanitizeTagValue(destination)))
+}
+
+// AddEventSinkCount records an increment of the specified amount for EventSinkDrops, tagged with the name
+// of the event sink.
+func AddEventSinkCount(ctx context.Context, sink string, count int, measure Measure) {
+	addCountWithTags(ctx, count, measure, tag.Insert(sinkTagKey, sanitizeTagValue(sink)))
+}
+
+func addCountWithTags(ctx context.Context, count int, measure Measure, extra ...tag.Mutator) {
+	mutators := make([]tag.Mutator, 0, len(measure.tags)+len(extra))
+	mutators = append(mutators, measure.tags...)
+	mutators = append(mutators, extra...)
+	ctx, err := tag.New(ctx, mutators...)
+	if err != nil { // COVERAGE: can't make this happen in unit tests
+		logging.GetGlobalContextLoggers(ctx).Errorf(`Failed to create tags: %s`, err)
+		return
+	}
+	for _, m := range measure.measures {
+		stats.Record(ctx, m.M(int64(count)))
 	}
-	f()
 }
 
 // WithRouteCount records a route hit and starts a trace. For stream connections, the duration of the stream connection is recorded
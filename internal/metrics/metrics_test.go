@@ -166,6 +166,102 @@ func TestNewConnectionMetrics(t *testing.T) {
 	}
 }
 
+func TestDroppedConnectionMetrics(t *testing.T) {
+	specs := []measureAndPlatform{
+		{platform: browserTagValue, measure: DroppedBrowserConns},
+		{platform: mobileTagValue, measure: DroppedMobileConns},
+		{platform: serverTagValue, measure: DroppedServerConns},
+	}
+
+	for _, tt := range specs {
+		t.Run(tt.platform, func(*testing.T) {
+			testWithExporter(t, func(p testWithExporterParams) {
+				expectedTags := tt.getExpectedTagsMap("", p.envName, userAgentValue)
+
+				IncrementForUserAgent(p.env.GetOpenCensusContext(), userAgentValue, tt.measure)
+				IncrementForUserAgent(p.env.GetOpenCensusContext(), userAgentValue, tt.measure)
+
+				p.exporter.AwaitData(t, time.Second, p.mockLog.Loggers, func(d st.TestMetricsData) bool {
+					return d.HasRow(publicDroppedConnView.Name, st.TestMetricsRow{
+						Tags: expectedTags,
+						Sum:  2,
+					})
+				})
+			})
+		})
+	}
+}
+
+func TestAddEventCount(t *testing.T) {
+	specs := []measureAndPlatform{
+		{platform: browserTagValue, measure: SampledOutBrowserEvents},
+		{platform: mobileTagValue, measure: SampledOutMobileEvents},
+		{platform: serverTagValue, measure: SampledOutServerEvents},
+	}
+
+	for _, tt := range specs {
+		t.Run(tt.platform, func(*testing.T) {
+			testWithExporter(t, func(p testWithExporterParams) {
+				expectedTags := tt.getExpectedTagsMap("", p.envName, userAgentValue)
+				expectedTags[eventKindTagKey.Name()] = "custom"
+
+				AddEventCount(p.env.GetOpenCensusContext(), userAgentValue, "custom", 2, tt.measure)
+				AddEventCount(p.env.GetOpenCensusContext(), userAgentValue, "custom", 3, tt.measure)
+
+				p.exporter.AwaitData(t, time.Second, p.mockLog.Loggers, func(d st.TestMetricsData) bool {
+					return d.HasRow(eventsSampledOutView.Name, st.TestMetricsRow{
+						Tags: expectedTags,
+						Sum:  5,
+					})
+				})
+			})
+		})
+	}
+}
+
+func TestAddEventDestinationCount(t *testing.T) {
+	for _, measure := range []struct {
+		measure Measure
+		view    string
+	}{
+		{EventsDelivered, eventsDeliveredView.Name},
+		{EventsFailed, eventsFailedView.Name},
+	} {
+		t.Run(measure.view, func(t *testing.T) {
+			testWithExporter(t, func(p testWithExporterParams) {
+				expectedTags := map[string]string{
+					envNameTagKey.Name():     p.envName,
+					destinationTagKey.Name(): "new-account",
+				}
+
+				AddEventDestinationCount(p.env.GetOpenCensusContext(), "new-account", 2, measure.measure)
+				AddEventDestinationCount(p.env.GetOpenCensusContext(), "new-account", 3, measure.measure)
+
+				p.exporter.AwaitData(t, time.Second, p.mockLog.Loggers, func(d st.TestMetricsData) bool {
+					return d.HasRow(measure.view, st.TestMetricsRow{
+						Tags: expectedTags,
+						Sum:  5,
+					})
+				})
+			})
+		})
+	}
+}
+
+func TestAddEventSinkCount(t *testing.T) {
+	testWithExporter(t, func(p testWithExporterParams) {
+		AddEventSinkCount(p.env.GetOpenCensusContext(), "archive", 2, EventSinkDrops)
+		AddEventSinkCount(p.env.GetOpenCensusContext(), "archive", 3, EventSinkDrops)
+
+		p.exporter.AwaitData(t, time.Second, p.mockLog.Loggers, func(d st.TestMetricsData) bool {
+			return d.HasRow(eventSinkDroppedView.Name, st.TestMetricsRow{
+				Tags: map[string]string{envNameTagKey.Name(): p.envName, sinkTagKey.Name(): "archive"},
+				Sum:  5,
+			})
+		})
+	})
+}
+
 func TestWithRouteCount(t *testing.T) {
 	testWithExporter(t, func(p testWithExporterParams) {
 		WithRouteCount(p.env.GetOpenCensusContext(), userAgentValue, "someRoute", "GET", func() {
@@ -17,6 +17,46 @@ var (
 		Aggregation: view.Sum(),
 		TagKeys:     publicTags,
 	}
+	publicDroppedConnView *view.View = &view.View{ //nolint:gochecknoglobals
+		Measure:     droppedConnMeasure,
+		Aggregation: view.Sum(),
+		TagKeys:     publicTags,
+	}
+	webhookDeliveredView *view.View = &view.View{ //nolint:gochecknoglobals
+		Measure:     webhookDeliveredMeasure,
+		Aggregation: view.Sum(),
+		TagKeys:     publicTags,
+	}
+	webhookFailedView *view.View = &view.View{ //nolint:gochecknoglobals
+		Measure:     webhookFailedMeasure,
+		Aggregation: view.Sum(),
+		TagKeys:     publicTags,
+	}
+	webhookDroppedView *view.View = &view.View{ //nolint:gochecknoglobals
+		Measure:     webhookDroppedMeasure,
+		Aggregation: view.Sum(),
+		TagKeys:     publicTags,
+	}
+	eventsSampledOutView *view.View = &view.View{ //nolint:gochecknoglobals
+		Measure:     eventsSampledOutMeasure,
+		Aggregation: view.Sum(),
+		TagKeys:     append(publicTags, eventKindTagKey),
+	}
+	eventsDeliveredView *view.View = &view.View{ //nolint:gochecknoglobals
+		Measure:     eventsDeliveredMeasure,
+		Aggregation: view.Sum(),
+		TagKeys:     append(publicTags, destinationTagKey),
+	}
+	eventsFailedView *view.View = &view.View{ //nolint:gochecknoglobals
+		Measure:     eventsFailedMeasure,
+		Aggregation: view.Sum(),
+		TagKeys:     append(publicTags, destinationTagKey),
+	}
+	eventSinkDroppedView *view.View = &view.View{ //nolint:gochecknoglobals
+		Measure:     eventSinkDroppedMeasure,
+		Aggregation: view.Sum(),
+		TagKeys:     append(publicTags, sinkTagKey),
+	}
 	requestView *view.View = &view.View{ //nolint:gochecknoglobals
 		Measure:     requestMeasure,
 		Aggregation: view.Count(),
@@ -38,7 +78,9 @@ var (
 )
 
 func getPublicViews() []*view.View {
-	return []*view.View{publicConnView, publicNewConnView, requestView}
+	return []*view.View{publicConnView, publicNewConnView, publicDroppedConnView, requestView,
+		webhookDeliveredView, webhookFailedView, webhookDroppedView, eventsSampledOutView,
+		eventsDeliveredView, eventsFailedView, eventSinkDroppedView}
 }
 
 func getPrivateViews() []*view.View {
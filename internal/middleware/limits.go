@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/internal/util"
+
+	"github.com/gorilla/mux"
+)
+
+// RequestLimits is a middleware function that applies time limits to every request. A non-zero
+// readTimeout or writeTimeout is the maximum time from the start of the request until the request body
+// has been read, or until the response has been written. For HTTP/1.x these only take effect if the
+// server's ConnContext is util.WithConn; for HTTP/2 they are set on the request's own stream, since the
+// connection is shared with other requests. They work like the ReadTimeout and WriteTimeout of
+// http.Server, except that Streaming removes them for stream requests, which are meant to stay open
+// indefinitely.
+//
+// This should be applied outside of the router, rather than with Router.Use, so that it also applies
+// to requests that do not match any route.
+func RequestLimits(readTimeout, writeTimeout time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if conn := util.GetRequestConn(req); conn != nil {
+				// Since connections can be reused, we always set both deadlines, so that a deadline from a
+				// previous request can't affect this one.
+				now := time.Now()
+				var readDeadline, writeDeadline time.Time
+				if readTimeout > 0 {
+					readDeadline = now.Add(readTimeout)
+				}
+				if writeTimeout > 0 {
+					writeDeadline = now.Add(writeTimeout)
+				}
+				_ = conn.SetReadDeadline(readDeadline)
+				_ = conn.SetWriteDeadline(writeDeadline)
+			} else if req.ProtoMajor == 2 {
+				// Each HTTP/2 request is a new stream, so there are no previous deadlines to clear.
+				controller := http.NewResponseController(w)
+				now := time.Now()
+				if readTimeout > 0 {
+					_ = controller.SetReadDeadline(now.Add(readTimeout))
+				}
+				if writeTimeout > 0 {
+					_ = controller.SetWriteDeadline(now.Add(writeTimeout))
+				}
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// MaxRequestBodySize is a middleware function for endpoints that receive a request body, such as events
+// or REPORT requests. A non-zero maxBodySize is the maximum length of the body; a longer body causes a
+// 413 error if it has a Content-Length header, or causes reading the body to fail with
+// util.ErrRequestBodyTooLarge if it does not.
+func MaxRequestBodySize(maxBodySize int64) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if maxBodySize > 0 && req.Body != nil {
+				if req.ContentLength > maxBodySize {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+					_, _ = w.Write(util.ErrorJSONMsg(util.ErrRequestBodyTooLarge.Error()))
+					return
+				}
+				req.Body = util.LimitRequestBody(req.Body, maxBodySize)
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
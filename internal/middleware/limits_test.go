@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
+	"github.com/launchdarkly/ld-relay/v7/internal/util"
+
+	helpers "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func withRequestLimitsServer(handler http.Handler, action func(server *httptest.Server)) {
+	server := httptest.NewUnstartedServer(handler)
+	server.Config.ConnContext = util.WithConn
+	server.Start()
+	defer server.Close()
+	action(server)
+}
+
+func TestMaxRequestBodySizeRejectsBodyWithContentLengthOverLimit(t *testing.T) {
+	called := false
+	handler := MaxRequestBodySize(4)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		called = true
+	}))
+	req := httptest.NewRequest("POST", "/", strings.NewReader("abcde"))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, string(util.ErrorJSONMsg(util.ErrRequestBodyTooLarge.Error())), w.Body.String())
+	assert.False(t, called)
+}
+
+func TestMaxRequestBodySizeLimitsBodyWithoutContentLength(t *testing.T) {
+	var readErr error
+	handler := MaxRequestBodySize(4)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, readErr = io.ReadAll(req.Body)
+	}))
+	req := httptest.NewRequest("POST", "/", strings.NewReader("abcde"))
+	req.ContentLength = -1 // as if the request used chunked encoding
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, util.ErrRequestBodyTooLarge, readErr)
+}
+
+func TestMaxRequestBodySizeAllowsBodyWithinLimit(t *testing.T) {
+	var body []byte
+	handler := MaxRequestBodySize(4)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ = io.ReadAll(req.Body)
+	}))
+	req := httptest.NewRequest("POST", "/", strings.NewReader("abcd"))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "abcd", string(body))
+}
+
+func TestRequestLimitsWriteTimeout(t *testing.T) {
+	slowHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	t.Run("non-streaming request times out", func(t *testing.T) {
+		withRequestLimitsServer(RequestLimits(0, 50*time.Millisecond)(slowHandler), func(server *httptest.Server) {
+			_, err := http.Get(server.URL)
+			assert.Error(t, err)
+		})
+	})
+
+	t.Run("streaming request does not time out", func(t *testing.T) {
+		withRequestLimitsServer(RequestLimits(0, 50*time.Millisecond)(Streaming(slowHandler)), func(server *httptest.Server) {
+			resp, err := http.Get(server.URL)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, "ok", string(body))
+		})
+	})
+
+	t.Run("stream on a connection that was used for a non-streaming request does not time out", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, _ = w.Write([]byte("ok"))
+		}))
+		mux.Handle("/stream", Streaming(slowHandler))
+		withRequestLimitsServer(RequestLimits(0, 50*time.Millisecond)(mux), func(server *httptest.Server) {
+			client := server.Client() // reuses connections
+			for _, path := range []string{"/", "/stream"} {
+				resp, err := client.Get(server.URL + path)
+				require.NoError(t, err, path)
+				body, _ := io.ReadAll(resp.Body)
+				_ = resp.Body.Close()
+				assert.Equal(t, "ok", string(body), path)
+			}
+		})
+	})
+}
+
+func TestRequestLimitsReadTimeout(t *testing.T) {
+	readErrCh := make(chan error, 1)
+	handler := RequestLimits(50*time.Millisecond, 0)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, err := io.ReadAll(req.Body)
+		readErrCh <- err
+	}))
+	withRequestLimitsServer(handler, func(server *httptest.Server) {
+		bodyReader, bodyWriter := io.Pipe()
+		go func() {
+			_, _ = bodyWriter.Write([]byte("a"))
+			time.Sleep(200 * time.Millisecond) // a slow client
+			_ = bodyWriter.Close()
+		}()
+		resp, err := http.Post(server.URL, "text/plain", bodyReader)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		assert.Error(t, helpers.RequireValue(t, readErrCh, time.Second, "timed out waiting for handler"))
+	})
+}
+
+func TestRequestLimitsWriteTimeoutForHTTP2(t *testing.T) {
+	slowHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		_, _ = w.Write([]byte("ok"))
+	})
+	withH2CServer := func(handler http.Handler, action func(server *httptest.Server)) {
+		withRequestLimitsServer(h2c.NewHandler(handler, &http2.Server{}), action)
+	}
+
+	t.Run("non-streaming request times out", func(t *testing.T) {
+		withH2CServer(RequestLimits(0, 50*time.Millisecond)(slowHandler), func(server *httptest.Server) {
+			resp, err := sharedtest.MakeH2CClient().Get(server.URL)
+			if err == nil {
+				defer resp.Body.Close()
+				_, err = io.ReadAll(resp.Body)
+			}
+			assert.Error(t, err)
+		})
+	})
+
+	t.Run("streaming request does not time out", func(t *testing.T) {
+		withH2CServer(RequestLimits(0, 50*time.Millisecond)(Streaming(slowHandler)), func(server *httptest.Server) {
+			resp, err := sharedtest.MakeH2CClient().Get(server.URL)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, 2, resp.ProtoMajor)
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, "ok", string(body))
+		})
+	})
+}
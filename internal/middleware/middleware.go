@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/launchdarkly/ld-relay/v7/config"
 	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
 	"github.com/launchdarkly/ld-relay/v7/internal/browser"
 	"github.com/launchdarkly/ld-relay/v7/internal/relayenv"
 	"github.com/launchdarkly/ld-relay/v7/internal/sdks"
+	"github.com/launchdarkly/ld-relay/v7/internal/util"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
 	ld "github.com/launchdarkly/go-server-sdk/v6"
@@ -26,6 +28,7 @@ const (
 	httpStatusMessageNotFullyConfigured   = "Relay Proxy is not yet fully initialized, does not have list of environments yet"
 	httpStatusMessageMissingEnvURLParam   = "URL did not contain an environment ID"
 	httpStatusMessageSDKClientNotInited   = "client was not initialized"
+	httpStatusMessageEventsOnly           = "environment has no SDK key, so it can only be used for forwarding events"
 )
 
 var (
@@ -64,6 +67,17 @@ func Chain(middlewares ...mux.MiddlewareFunc) mux.MiddlewareFunc {
 // using the appropriate kind of credential for the basictypes.SDKKind. If successful, it updates the request context
 // so GetEnvContextInfo will return environment information. If not successful, it returns an error response.
 func SelectEnvironmentByAuthorizationKey(sdkKind basictypes.SDKKind, envs RelayEnvironments) mux.MiddlewareFunc {
+	return selectEnvironment(sdkKind, envs, false)
+}
+
+// SelectEnvironmentForEvents is the same as SelectEnvironmentByAuthorizationKey, except that it also accepts
+// an environment that has no SDK client because it is only used for forwarding events (see
+// relayenv.IsEventsOnly). It should only be used for event endpoints.
+func SelectEnvironmentForEvents(sdkKind basictypes.SDKKind, envs RelayEnvironments) mux.MiddlewareFunc {
+	return selectEnvironment(sdkKind, envs, true)
+}
+
+func selectEnvironment(sdkKind basictypes.SDKKind, envs RelayEnvironments, allowEventsOnly bool) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 			credential, err := sdks.GetCredential(sdkKind, req)
@@ -94,9 +108,16 @@ func SelectEnvironmentByAuthorizationKey(sdkKind basictypes.SDKKind, envs RelayE
 			}
 
 			if clientCtx.GetClient() == nil {
-				w.WriteHeader(http.StatusServiceUnavailable)
-				_, _ = w.Write([]byte(httpStatusMessageSDKClientNotInited))
-				return
+				eventsOnly := relayenv.IsEventsOnly(clientCtx)
+				if !eventsOnly || !allowEventsOnly {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					if eventsOnly {
+						_, _ = w.Write([]byte(httpStatusMessageEventsOnly))
+					} else {
+						_, _ = w.Write([]byte(httpStatusMessageSDKClientNotInited))
+					}
+					return
+				}
 			}
 
 			contextInfo := EnvContextInfo{
@@ -150,9 +171,19 @@ func CORS(next http.Handler) http.Handler {
 	})
 }
 
-// Streaming is a middleware function that sets the appropriate headers on a streaming response.
+// Streaming is a middleware function that sets the appropriate headers on a streaming response. It also
+// removes any connection or HTTP/2 stream deadlines that were set by RequestLimits, since a stream has no
+// fixed duration.
 func Streaming(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if conn := util.GetRequestConn(req); conn != nil {
+			_ = conn.SetReadDeadline(time.Time{})
+			_ = conn.SetWriteDeadline(time.Time{})
+		} else if req.ProtoMajor == 2 {
+			controller := http.NewResponseController(w)
+			_ = controller.SetReadDeadline(time.Time{})
+			_ = controller.SetWriteDeadline(time.Time{})
+		}
 		// If Nginx is being used as a proxy/load balancer, adding this header tells it not to buffer this response because
 		// it is a streaming response. If Nginx is not being used, this header has no effect.
 		w.Header().Add("X-Accel-Buffering", "no")
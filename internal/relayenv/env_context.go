@@ -159,3 +159,14 @@ func GetEnvironmentID(env EnvContext) config.EnvironmentID {
 	}
 	return ""
 }
+
+// IsEventsOnly returns true if the environment has no SDK key, so it has no flag data and can only be used
+// for forwarding events from mobile and client-side SDKs.
+func IsEventsOnly(env EnvContext) bool {
+	for _, c := range env.GetCredentials() {
+		if _, ok := c.(config.SDKKey); ok {
+			return false
+		}
+	}
+	return true
+}
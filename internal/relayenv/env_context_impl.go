@@ -4,10 +4,14 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/auditlog"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
 	"github.com/launchdarkly/ld-relay/v7/internal/bigsegments"
 	"github.com/launchdarkly/ld-relay/v7/internal/events"
 	"github.com/launchdarkly/ld-relay/v7/internal/httpconfig"
@@ -16,6 +20,7 @@ import (
 	"github.com/launchdarkly/ld-relay/v7/internal/store"
 	"github.com/launchdarkly/ld-relay/v7/internal/streams"
 	"github.com/launchdarkly/ld-relay/v7/internal/util"
+	"github.com/launchdarkly/ld-relay/v7/internal/webhooks"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	ldeval "github.com/launchdarkly/go-server-sdk-evaluation/v2"
@@ -66,40 +71,48 @@ type EnvContextImplParams struct {
 	BigSegmentStoreFactory        bigsegments.BigSegmentStoreFactory
 	BigSegmentSynchronizerFactory bigsegments.BigSegmentSynchronizerFactory
 	SDKBigSegmentsConfigFactory   subsystems.ComponentConfigurer[subsystems.BigSegmentsConfiguration] // set only in tests
+	AuditLog                      *auditlog.Log
+	EventSinks                    []events.EventSink
 	UserAgent                     string
 	LogNameMode                   LogNameMode
 	Loggers                       ldlog.Loggers
 }
 
 type envContextImpl struct {
-	mu               sync.RWMutex
-	clients          map[config.SDKKey]sdks.LDClientContext
-	storeAdapter     *store.SSERelayDataStoreAdapter
-	loggers          ldlog.Loggers
-	credentials      map[config.SDKCredential]bool // true if not deprecated
-	identifiers      EnvIdentifiers
-	secureMode       bool
-	envStreams       *streams.EnvStreams
-	streamProviders  []streams.StreamProvider
-	handlers         map[streams.StreamProvider]map[config.SDKCredential]http.Handler
-	jsContext        JSClientContext
-	evaluator        ldeval.Evaluator
-	eventDispatcher  *events.EventDispatcher
-	bigSegmentSync   bigsegments.BigSegmentSynchronizer
-	bigSegmentStore  bigsegments.BigSegmentStore
-	bigSegmentsExist bool
-	sdkBigSegments   *ldstoreimpl.BigSegmentStoreWrapper
-	sdkConfig        ld.Config
-	sdkClientFactory sdks.ClientFactoryFunc
-	sdkInitTimeout   time.Duration
-	metricsManager   *metrics.Manager
-	metricsEnv       *metrics.EnvironmentManager
-	metricsEventPub  events.EventPublisher
-	dataStoreInfo    sdks.DataStoreEnvironmentInfo
-	globalLoggers    ldlog.Loggers
-	ttl              time.Duration
-	initErr          error
-	creationTime     time.Time
+	mu                  sync.RWMutex
+	clients             map[config.SDKKey]sdks.LDClientContext
+	storeAdapter        *store.SSERelayDataStoreAdapter
+	loggers             ldlog.Loggers
+	credentials         map[config.SDKCredential]bool // true if not deprecated
+	identifiers         EnvIdentifiers
+	secureMode          bool
+	envStreams          *streams.EnvStreams
+	streamProviders     []streams.StreamProvider
+	handlers            map[streams.StreamProvider]map[config.SDKCredential]http.Handler
+	maxConnTimes        map[basictypes.StreamKind]time.Duration
+	maxConnJitter       time.Duration
+	maxStreamBufferSize int
+	jsContext           JSClientContext
+	evaluator           ldeval.Evaluator
+	eventDispatcher     *events.EventDispatcher
+	bigSegmentSync      bigsegments.BigSegmentSynchronizer
+	bigSegmentStore     bigsegments.BigSegmentStore
+	bigSegmentsExist    bool
+	webhookNotifier     *webhooks.Notifier
+	auditLog            *auditlog.Log
+	auditSource         auditlog.Source
+	sdkBigSegments      *ldstoreimpl.BigSegmentStoreWrapper
+	sdkConfig           ld.Config
+	sdkClientFactory    sdks.ClientFactoryFunc
+	sdkInitTimeout      time.Duration
+	metricsManager      *metrics.Manager
+	metricsEnv          *metrics.EnvironmentManager
+	metricsEventPub     events.EventPublisher
+	dataStoreInfo       sdks.DataStoreEnvironmentInfo
+	globalLoggers       ldlog.Loggers
+	ttl                 time.Duration
+	initErr             error
+	creationTime        time.Time
 }
 
 // Implementation of the DataStoreQueries interface that the streams package uses as an abstraction of
@@ -151,7 +164,9 @@ func NewEnvContext(
 	}
 
 	credentials := make(map[config.SDKCredential]bool, 3)
-	credentials[envConfig.SDKKey] = true
+	if envConfig.SDKKey != "" {
+		credentials[envConfig.SDKKey] = true
+	}
 	if envConfig.MobileKey != "" {
 		credentials[envConfig.MobileKey] = true
 	}
@@ -160,21 +175,29 @@ func NewEnvContext(
 	}
 
 	envContext := &envContextImpl{
-		identifiers:      params.Identifiers,
-		clients:          make(map[config.SDKKey]sdks.LDClientContext),
-		credentials:      credentials,
-		loggers:          envLoggers,
-		secureMode:       envConfig.SecureMode,
-		streamProviders:  params.StreamProviders,
-		handlers:         make(map[streams.StreamProvider]map[config.SDKCredential]http.Handler),
-		jsContext:        params.JSClientContext,
-		sdkClientFactory: params.ClientFactory,
-		sdkInitTimeout:   allConfig.Main.InitTimeout.GetOrElse(config.DefaultInitTimeout),
-		metricsManager:   params.MetricsManager,
-		globalLoggers:    params.Loggers,
-		ttl:              envConfig.TTL.GetOrElse(0),
-		dataStoreInfo:    params.DataStoreInfo,
-		creationTime:     time.Now(),
+		identifiers:         params.Identifiers,
+		clients:             make(map[config.SDKKey]sdks.LDClientContext),
+		credentials:         credentials,
+		loggers:             envLoggers,
+		secureMode:          envConfig.SecureMode,
+		streamProviders:     params.StreamProviders,
+		handlers:            make(map[streams.StreamProvider]map[config.SDKCredential]http.Handler),
+		maxConnTimes:        make(map[basictypes.StreamKind]time.Duration),
+		maxConnJitter:       allConfig.Main.MaxClientConnectionTimeJitter.GetOrElse(0),
+		maxStreamBufferSize: allConfig.Main.MaxStreamBufferSize.GetOrElse(config.DefaultMaxStreamBufferSize),
+		jsContext:           params.JSClientContext,
+		sdkClientFactory:    params.ClientFactory,
+		sdkInitTimeout:      allConfig.Main.InitTimeout.GetOrElse(config.DefaultInitTimeout),
+		metricsManager:      params.MetricsManager,
+		globalLoggers:       params.Loggers,
+		ttl:                 envConfig.TTL.GetOrElse(0),
+		dataStoreInfo:       params.DataStoreInfo,
+		auditLog:            params.AuditLog,
+		auditSource:         auditlog.SourceStream,
+		creationTime:        time.Now(),
+	}
+	if offlineMode {
+		envContext.auditSource = auditlog.SourceFileData
 	}
 
 	bigSegmentStoreFactory := params.BigSegmentStoreFactory
@@ -194,7 +217,8 @@ func NewEnvContext(
 			factory = bigsegments.DefaultBigSegmentSynchronizerFactory
 		}
 		envContext.bigSegmentSync = factory(
-			httpConfig, bigSegmentStore, allConfig.Main.BaseURI.String(), allConfig.Main.StreamURI.String(),
+			httpConfig.ForDestination(httpconfig.DestinationBigSegments), bigSegmentStore,
+			allConfig.Main.BaseURI.String(), allConfig.Main.StreamURI.String(),
 			envConfig.EnvID, envConfig.SDKKey, envLoggers, logPrefix)
 		thingsToCleanUp.AddFunc(envContext.bigSegmentSync.Close)
 		segmentUpdateCh := envContext.bigSegmentSync.SegmentUpdatesCh()
@@ -226,7 +250,7 @@ func NewEnvContext(
 	envStreams := streams.NewEnvStreams(
 		params.StreamProviders,
 		envContextStoreQueries{envContext},
-		allConfig.Main.HeartbeatInterval.GetOrElse(config.DefaultHeartbeatInterval),
+		makeHeartbeatIntervals(envConfig, allConfig),
 		envLoggers,
 	)
 	envContext.envStreams = envStreams
@@ -240,10 +264,10 @@ func NewEnvContext(
 		envStreams.AddCredential(c)
 	}
 	for _, sp := range params.StreamProviders {
+		envContext.maxConnTimes[sp.Kind()] = getMaxConnTime(sp.Kind(), envConfig, allConfig)
 		handlers := make(map[config.SDKCredential]http.Handler)
 		for c := range credentials {
-			h := sp.Handler(c)
-			if h != nil {
+			if h := envContext.makeStreamHandler(sp, c); h != nil {
 				handlers[c] = h
 			}
 		}
@@ -257,39 +281,80 @@ func NewEnvContext(
 	storeAdapter := store.NewSSERelayDataStoreAdapter(dataStoreFactory, envStreamUpdates)
 	envContext.storeAdapter = storeAdapter
 
+	sinkEnv := events.EventSinkEnvironment{
+		Name:            params.Identifiers.GetDisplayName(),
+		ID:              envConfig.EnvID,
+		OnEventsDropped: envContext.recordDroppedSinkEvents,
+	}
+	var eventSinks []events.EventSink
+	var eventSinkNames []string
+	for _, sink := range params.EventSinks {
+		if sink.AcceptsEnvironment(sinkEnv) {
+			eventSinks = append(eventSinks, sink)
+			eventSinkNames = append(eventSinkNames, sink.Name())
+		}
+	}
+	forwardEvents := allConfig.Events.SendEvents && !offlineMode
+	var eventDestinations []events.EventDestination
+	if forwardEvents {
+		eventDestinations = makeEventDestinations(allConfig, sinkEnv.Name, envLoggers, logPrefix)
+	}
+	if allConfig.Events.SendEvents && offlineMode && len(eventSinks) == 0 {
+		envLoggers.Info("Events will be accepted for this environment, but will be discarded, since offline mode is enabled")
+	}
 	var eventDispatcher *events.EventDispatcher
-	if allConfig.Events.SendEvents {
-		if offlineMode {
-			envLoggers.Info("Events will be accepted for this environment, but will be discarded, since offline mode is enabled")
-		} else {
+	if forwardEvents || len(eventSinks) > 0 {
+		if forwardEvents {
 			envLoggers.Info("Proxying events for this environment")
-			eventLoggers := envLoggers
-			eventLoggers.SetPrefix(logPrefix + " (event proxy)")
-			eventDispatcher = events.NewEventDispatcher(
-				envConfig.SDKKey,
-				envConfig.MobileKey,
-				envConfig.EnvID,
-				envLoggers,
-				allConfig.Events,
-				httpConfig,
-				storeAdapter,
-				0, // 0 here means "use the default interval for any periodic cleanup task you may need to run"
-			)
 		}
+		if len(eventSinks) > 0 {
+			envLoggers.Infof("Writing events for this environment to event sinks: %s", strings.Join(eventSinkNames, ", "))
+		}
+		if len(eventDestinations) > 0 {
+			destNames := make([]string, 0, len(eventDestinations))
+			for _, d := range eventDestinations {
+				destNames = append(destNames, d.Name)
+			}
+			envLoggers.Infof("Also forwarding events for this environment to event destinations: %s", strings.Join(destNames, ", "))
+		}
+		eventLoggers := envLoggers
+		eventLoggers.SetPrefix(logPrefix + " (event proxy)")
+		eventDispatcher = events.NewEventDispatcher(
+			envConfig.SDKKey,
+			envConfig.MobileKey,
+			envConfig.EnvID,
+			envLoggers,
+			allConfig.Events,
+			httpConfig.ForDestination(httpconfig.DestinationEvents),
+			storeAdapter,
+			events.EventDispatcherOptions{
+				DisableForwarding:  !forwardEvents,
+				Sinks:              eventSinks,
+				SinkEnvironment:    sinkEnv,
+				Filter:             events.NewEventFilter(envConfig),
+				Sampler:            events.NewEventSampler(envConfig),
+				OnEventsSampledOut: envContext.recordSampledOutEvents,
+				Destinations:       eventDestinations,
+				OnEventsDelivered:  envContext.recordDeliveredEvents,
+			},
+			0, // 0 here means "use the default interval for any periodic cleanup task you may need to run"
+		)
 	}
 	envContext.eventDispatcher = eventDispatcher
 
 	streamURI := allConfig.Main.StreamURI.String()   // config.ValidateConfig has ensured that this has a value
 	eventsURI := allConfig.Events.EventsURI.String() // ditto
 
-	enableDiagnostics := !allConfig.Main.DisableInternalUsageMetrics && !offlineMode
+	// The usage metrics publisher always uses the SDK key, so an environment that has none can't send them.
+	enableDiagnostics := !allConfig.Main.DisableInternalUsageMetrics && !offlineMode && envConfig.SDKKey != ""
 	var em *metrics.EnvironmentManager
 	if params.MetricsManager != nil {
 		if enableDiagnostics {
 			pubLoggers := envLoggers
 			pubLoggers.SetPrefix(logPrefix + " (usage metrics)")
-			eventsPublisher, err := events.NewHTTPEventPublisher(envConfig.SDKKey, httpConfig, pubLoggers,
-				events.OptionBaseURI(eventsURI))
+			eventsPublisher, err := events.NewHTTPEventPublisher(envConfig.SDKKey,
+				httpConfig.ForDestination(httpconfig.DestinationEvents), pubLoggers,
+				events.OptionBaseURI(eventsURI), events.OptionSendPolicy(events.MakeEventSendPolicy(allConfig.Events)))
 			if err != nil {
 				return nil, errInitPublisher(err)
 			}
@@ -305,13 +370,33 @@ func NewEnvContext(
 	}
 	envContext.metricsEnv = em
 
+	if webhookURL, webhookSecret := getWebhookURLAndSecret(envConfig, allConfig); webhookURL != "" {
+		webhookLoggers := envLoggers
+		webhookLoggers.SetPrefix(logPrefix + " (webhooks)")
+		envContext.webhookNotifier = webhooks.NewNotifier(webhooks.NotifierParams{
+			URI:            webhookURL,
+			Secret:         webhookSecret,
+			QueueSize:      allConfig.Webhooks.QueueSize.GetOrElse(config.DefaultWebhookQueueSize),
+			MaxAttempts:    allConfig.Webhooks.MaxAttempts.GetOrElse(config.DefaultWebhookMaxAttempts),
+			RetryDelay:     allConfig.Webhooks.RetryDelay.GetOrElse(config.DefaultWebhookRetryDelay),
+			HTTPClient:     httpConfig.Client(),
+			UserAgent:      params.UserAgent,
+			GetEnvironment: envContext.getWebhookEnvironmentInfo,
+			MetricsContext: envContext.GetMetricsContext(),
+			Loggers:        webhookLoggers,
+		})
+		thingsToCleanUp.AddFunc(envContext.webhookNotifier.Close)
+	}
+
 	disconnectedStatusTime := allConfig.Main.DisconnectedStatusTime.GetOrElse(config.DefaultDisconnectedStatusTime)
 
+	// The SDK's own diagnostic events use the same HTTP configuration as the stream, rather than
+	// DestinationEvents, since the SDK has only one HTTP configuration; see httpconfig.DestinationStream.
 	envContext.sdkConfig = ld.Config{
 		DataStore:        storeAdapter,
 		DiagnosticOptOut: !enableDiagnostics,
 		Events:           ldcomponents.SendEvents(),
-		HTTP:             httpConfig.SDKHTTPConfigFactory,
+		HTTP:             httpConfig.ForDestination(httpconfig.DestinationStream).SDKHTTPConfigFactory,
 		Logging: ldcomponents.Logging().
 			Loggers(envLoggers).
 			LogDataSourceOutageAsErrorAfter(disconnectedStatusTime),
@@ -357,8 +442,18 @@ func NewEnvContext(
 		}
 	}
 
-	// Connecting may take time, so do this in parallel
-	go envContext.startSDKClient(envConfig.SDKKey, readyCh, allConfig.Main.IgnoreConnectionErrors)
+	if envConfig.SDKKey == "" {
+		// There's no SDK client for an environment that is only used for forwarding events, so it is
+		// ready right away.
+		params.Loggers.Infof("Environment %q has no SDK key, so it will only be used for forwarding events",
+			params.Identifiers.GetDisplayName())
+		if readyCh != nil {
+			go func() { readyCh <- envContext }()
+		}
+	} else {
+		// Connecting may take time, so do this in parallel
+		go envContext.startSDKClient(envConfig.SDKKey, readyCh, allConfig.Main.IgnoreConnectionErrors)
+	}
 
 	thingsToCleanUp.Clear() // we've succeeded so we do not want to throw away these things
 
@@ -455,7 +550,7 @@ func (c *envContextImpl) AddCredential(newCredential config.SDKCredential) {
 	c.credentials[newCredential] = true
 	c.envStreams.AddCredential(newCredential)
 	for streamProvider, handlers := range c.handlers {
-		if h := streamProvider.Handler(newCredential); h != nil {
+		if h := c.makeStreamHandler(streamProvider, newCredential); h != nil {
 			handlers[newCredential] = h
 		}
 	}
@@ -555,6 +650,28 @@ func (c *envContextImpl) GetStreamHandler(streamProvider streams.StreamProvider,
 	return h
 }
 
+// makeStreamHandler returns the StreamProvider's handler for a credential, if any, with this
+// environment's connection time limit for that kind of stream applied to it. Connections that are
+// dropped for falling behind are logged and counted.
+func (c *envContextImpl) makeStreamHandler(sp streams.StreamProvider, credential config.SDKCredential) http.Handler {
+	h := sp.Handler(credential)
+	if h == nil {
+		return nil
+	}
+	kind := sp.Kind()
+	onDropped := func(req *http.Request) {
+		userAgent := getUserAgent(req)
+		c.loggers.Warnf("Dropped %s stream connection (user agent %q) because the client fell too far behind in reading events",
+			kind, userAgent)
+		metrics.IncrementForUserAgent(c.GetMetricsContext(), userAgent, droppedConnsMeasureForKind(kind))
+	}
+	return streams.WithMaxConnTime(
+		streams.WithBufferLimit(h, c.maxStreamBufferSize, onDropped),
+		c.maxConnTimes[kind],
+		c.maxConnJitter,
+	)
+}
+
 func invalidStreamHandler(w http.ResponseWriter, req *http.Request) {
 	w.WriteHeader(http.StatusNotFound)
 }
@@ -574,6 +691,69 @@ func (c *envContextImpl) GetMetricsContext() context.Context {
 	return c.metricsEnv.GetOpenCensusContext()
 }
 
+func (c *envContextImpl) recordSampledOutEvents(
+	req *http.Request,
+	sdkKind basictypes.SDKKind,
+	countsByKind map[string]int,
+) {
+	userAgent := getUserAgent(req)
+	for eventKind, count := range countsByKind {
+		metrics.AddEventCount(c.GetMetricsContext(), userAgent, eventKind, count, sampledOutEventsMeasureForKind(sdkKind))
+	}
+}
+
+// sampledOutEventsMeasureForKind returns the metric that counts sampled-out events for a kind of SDK.
+func sampledOutEventsMeasureForKind(kind basictypes.SDKKind) metrics.Measure {
+	switch kind {
+	case basictypes.MobileSDK:
+		return metrics.SampledOutMobileEvents
+	case basictypes.JSClientSDK:
+		return metrics.SampledOutBrowserEvents
+	default:
+		return metrics.SampledOutServerEvents
+	}
+}
+
+func (c *envContextImpl) recordDeliveredEvents(destination string, eventCount int, success bool) {
+	measure := metrics.EventsDelivered
+	if !success {
+		measure = metrics.EventsFailed
+	}
+	metrics.AddEventDestinationCount(c.GetMetricsContext(), destination, eventCount, measure)
+}
+
+func (c *envContextImpl) recordDroppedSinkEvents(sinkName string, eventCount int) {
+	metrics.AddEventSinkCount(c.GetMetricsContext(), sinkName, eventCount, metrics.EventSinkDrops)
+}
+
+// makeEventDestinations returns the additional event destinations that are configured for an environment,
+// in order of name.
+func makeEventDestinations(
+	allConfig config.Config,
+	envName string,
+	envLoggers ldlog.Loggers,
+	logPrefix string,
+) []events.EventDestination {
+	var ret []events.EventDestination
+	for name, dc := range allConfig.EventDestination {
+		if dc == nil || dc.Environment != envName {
+			continue
+		}
+		loggers := envLoggers
+		loggers.SetPrefix(fmt.Sprintf("%s (event destination %s)", logPrefix, name))
+		ret = append(ret, events.EventDestination{
+			Name:      name,
+			EventsURI: dc.EventsURI.String(),
+			SDKKey:    dc.SDKKey,
+			MobileKey: dc.MobileKey,
+			EnvID:     dc.EnvID,
+			Loggers:   loggers,
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
+
 func (c *envContextImpl) GetTTL() time.Duration {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -641,6 +821,9 @@ func (c *envContextImpl) Close() error {
 	if c.eventDispatcher != nil {
 		c.eventDispatcher.Close()
 	}
+	if c.webhookNotifier != nil {
+		c.webhookNotifier.Close()
+	}
 	if c.bigSegmentSync != nil {
 		c.bigSegmentSync.Close()
 	}
@@ -665,6 +848,34 @@ func (c *envContextImpl) setBigSegmentsExist() {
 	}
 }
 
+func (c *envContextImpl) getWebhookEnvironmentInfo() webhooks.EnvironmentInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return webhooks.EnvironmentInfo{
+		EnvID:    string(c.getEnvIDInternal()),
+		EnvKey:   c.identifiers.EnvKey,
+		EnvName:  c.identifiers.GetDisplayName(),
+		ProjKey:  c.identifiers.ProjKey,
+		ProjName: c.identifiers.ProjName,
+	}
+}
+
+func (c *envContextImpl) getAuditEnvironment() auditlog.Environment {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return auditlog.Environment{Name: c.identifiers.GetDisplayName(), ID: c.getEnvIDInternal()}
+}
+
+// getEnvIDInternal must be called while holding a lock.
+func (c *envContextImpl) getEnvIDInternal() config.EnvironmentID {
+	for cred := range c.credentials {
+		if id, ok := cred.(config.EnvironmentID); ok {
+			return id
+		}
+	}
+	return ""
+}
+
 func (q envContextStoreQueries) IsInitialized() bool {
 	if s := q.context.storeAdapter.GetStore(); s != nil {
 		return s.IsInitialized()
@@ -683,6 +894,12 @@ func (u *envContextStreamUpdates) SendAllDataUpdate(allData []ldstoretypes.Colle
 	// We use this delegator, rather than sending updates directory to context.envStreams, so that we
 	// can detect the presence of a big segment and turn on the big segment synchronizer as needed.
 	u.context.envStreams.SendAllDataUpdate(allData)
+	if u.context.webhookNotifier != nil {
+		u.context.webhookNotifier.AllDataUpdated(allData)
+	}
+	if u.context.auditLog != nil {
+		u.context.auditLog.DataReceived(u.context.getAuditEnvironment(), u.context.auditSource, allData)
+	}
 	if u.context.bigSegmentSync == nil {
 		return
 	}
@@ -706,6 +923,12 @@ func (u *envContextStreamUpdates) SendAllDataUpdate(allData []ldstoretypes.Colle
 func (u *envContextStreamUpdates) SendSingleItemUpdate(kind ldstoretypes.DataKind, key string, item ldstoretypes.ItemDescriptor) {
 	// See comments in SendAllDataUpdate.
 	u.context.envStreams.SendSingleItemUpdate(kind, key, item)
+	if u.context.webhookNotifier != nil {
+		u.context.webhookNotifier.ItemUpdated(kind, key, item)
+	}
+	if u.context.auditLog != nil {
+		u.context.auditLog.ItemReceived(u.context.getAuditEnvironment(), u.context.auditSource, kind, key, item)
+	}
 	if u.context.bigSegmentSync == nil {
 		return
 	}
@@ -726,7 +949,7 @@ func (u *envContextStreamUpdates) InvalidateClientSideState() {
 
 func makeLogPrefix(logNameMode LogNameMode, sdkKey config.SDKKey, envID config.EnvironmentID) string {
 	name := string(sdkKey)
-	if logNameMode == LogNameIsEnvID && envID != "" {
+	if (logNameMode == LogNameIsEnvID || name == "") && envID != "" {
 		name = string(envID)
 	}
 	if len(name) > 4 { // real keys are always longer than this
@@ -734,3 +957,16 @@ func makeLogPrefix(logNameMode LogNameMode, sdkKey config.SDKKey, envID config.E
 	}
 	return fmt.Sprintf("[env: %s]", name)
 }
+
+// getWebhookURLAndSecret returns the webhook URL and secret for this environment, or an empty URL if
+// webhooks are not enabled. A URL set for the environment takes precedence over the global one, and is
+// always paired with the environment's own secret.
+func getWebhookURLAndSecret(envConfig config.EnvConfig, allConfig config.Config) (string, string) {
+	if envConfig.WebhookURL.IsDefined() {
+		return envConfig.WebhookURL.String(), envConfig.WebhookSecret
+	}
+	if allConfig.Webhooks.URL.IsDefined() {
+		return allConfig.Webhooks.URL.String(), allConfig.Webhooks.Secret
+	}
+	return "", ""
+}
@@ -118,6 +118,33 @@ func TestConstructorWithOnlySDKKey(t *testing.T) {
 	assert.Nil(t, env.GetInitError())
 }
 
+func TestConstructorWithNoSDKKey(t *testing.T) {
+	envConfig := st.EnvWithAllCredentials.Config
+	envConfig.SDKKey = ""
+	readyCh := make(chan EnvContext, 1)
+
+	clientCh := make(chan *testclient.FakeLDClient, 1)
+	clientFactory := testclient.FakeLDClientFactoryWithChannel(true, clientCh)
+
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+
+	env := makeBasicEnv(t, envConfig, clientFactory, mockLog.Loggers, readyCh)
+	defer env.Close()
+
+	creds := env.GetCredentials()
+	assert.Len(t, creds, 2)
+	assert.Contains(t, creds, envConfig.MobileKey)
+	assert.Contains(t, creds, envConfig.EnvID)
+	assert.True(t, IsEventsOnly(env))
+
+	assert.Equal(t, env, requireEnvReady(t, readyCh))
+	assert.Nil(t, env.GetClient())
+	assert.Nil(t, env.GetInitError())
+	helpers.AssertNoMoreValues(t, clientCh, time.Millisecond*50) // no SDK client was created
+	mockLog.AssertMessageMatch(t, true, ldlog.Info, "has no SDK key, so it will only be used for forwarding events")
+}
+
 func TestConstructorWithJSClientContext(t *testing.T) {
 	envConfig := st.EnvWithAllCredentials.Config
 	jsClientContext := JSClientContext{Origins: []string{"origin"}}
@@ -160,6 +187,7 @@ func TestLogPrefix(t *testing.T) {
 	testPrefix("env ID not set", LogNameIsEnvID, config.SDKKey("1234567890"), "", "[env: ...7890]")
 	testPrefix("impossibly short SDK key", LogNameIsSDKKey, config.SDKKey("890"), config.EnvironmentID("abcdefghij"), "[env: 890]")
 	testPrefix("impossibly short env ID", LogNameIsEnvID, config.SDKKey("1234567890"), config.EnvironmentID("hij"), "[env: hij]")
+	testPrefix("SDK key not set", LogNameIsSDKKey, "", config.EnvironmentID("abcdefghij"), "[env: ...ghij]")
 }
 
 func TestAddRemoveCredential(t *testing.T) {
@@ -0,0 +1,102 @@
+package relayenv
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
+	"github.com/launchdarkly/ld-relay/v7/internal/metrics"
+	"github.com/launchdarkly/ld-relay/v7/internal/streams"
+
+	ct "github.com/launchdarkly/go-configtypes"
+)
+
+var allStreamKinds = []basictypes.StreamKind{ //nolint:gochecknoglobals
+	basictypes.ServerSideStream,
+	basictypes.ServerSideFlagsOnlyStream,
+	basictypes.MobilePingStream,
+	basictypes.JSClientPingStream,
+}
+
+// makeHeartbeatIntervals computes the heartbeat interval for each kind of stream in this environment.
+//
+// A value set for the environment takes precedence over a value set for the stream kind in the main
+// configuration, which takes precedence over the global HeartbeatInterval.
+func makeHeartbeatIntervals(envConfig config.EnvConfig, allConfig config.Config) streams.HeartbeatIntervals {
+	ret := make(streams.HeartbeatIntervals, len(allStreamKinds))
+	for _, kind := range allStreamKinds {
+		ret[kind] = firstDefinedDuration(
+			envConfig.HeartbeatInterval,
+			heartbeatIntervalForKind(allConfig.Main, kind),
+			allConfig.Main.HeartbeatInterval,
+		).GetOrElse(config.DefaultHeartbeatInterval)
+	}
+	return ret
+}
+
+// getMaxConnTime computes the maximum stream connection time for a kind of stream in this environment,
+// using the same precedence rules as makeHeartbeatIntervals. Zero means there is no limit.
+func getMaxConnTime(kind basictypes.StreamKind, envConfig config.EnvConfig, allConfig config.Config) time.Duration {
+	return firstDefinedDuration(
+		envConfig.MaxClientConnectionTime,
+		maxConnTimeForKind(allConfig.Main, kind),
+		allConfig.Main.MaxClientConnectionTime,
+	).GetOrElse(0)
+}
+
+func heartbeatIntervalForKind(mainConfig config.MainConfig, kind basictypes.StreamKind) ct.OptDuration {
+	switch kind {
+	case basictypes.ServerSideStream, basictypes.ServerSideFlagsOnlyStream:
+		return mainConfig.ServerSideHeartbeatInterval
+	case basictypes.MobilePingStream:
+		return mainConfig.MobileHeartbeatInterval
+	case basictypes.JSClientPingStream:
+		return mainConfig.JSClientHeartbeatInterval
+	default:
+		return ct.OptDuration{}
+	}
+}
+
+func maxConnTimeForKind(mainConfig config.MainConfig, kind basictypes.StreamKind) ct.OptDuration {
+	switch kind {
+	case basictypes.ServerSideStream, basictypes.ServerSideFlagsOnlyStream:
+		return mainConfig.ServerSideMaxClientConnectionTime
+	case basictypes.MobilePingStream:
+		return mainConfig.MobileMaxClientConnectionTime
+	case basictypes.JSClientPingStream:
+		return mainConfig.JSClientMaxClientConnectionTime
+	default:
+		return ct.OptDuration{}
+	}
+}
+
+func firstDefinedDuration(values ...ct.OptDuration) ct.OptDuration {
+	for _, v := range values {
+		if v.IsDefined() {
+			return v
+		}
+	}
+	return ct.OptDuration{}
+}
+
+// droppedConnsMeasureForKind returns the metric that counts dropped connections for a kind of stream.
+func droppedConnsMeasureForKind(kind basictypes.StreamKind) metrics.Measure {
+	switch kind {
+	case basictypes.MobilePingStream:
+		return metrics.DroppedMobileConns
+	case basictypes.JSClientPingStream:
+		return metrics.DroppedBrowserConns
+	default:
+		return metrics.DroppedServerConns
+	}
+}
+
+// getUserAgent returns the SDK's user agent for metrics and log messages, the same way the middleware
+// package does.
+func getUserAgent(req *http.Request) string {
+	if agent := req.Header.Get("X-LaunchDarkly-User-Agent"); agent != "" {
+		return agent
+	}
+	return req.Header.Get("User-Agent")
+}
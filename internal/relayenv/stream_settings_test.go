@@ -0,0 +1,78 @@
+package relayenv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
+	"github.com/launchdarkly/ld-relay/v7/internal/streams"
+
+	ct "github.com/launchdarkly/go-configtypes"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeartbeatIntervals(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		intervals := makeHeartbeatIntervals(config.EnvConfig{}, config.Config{})
+		assert.Equal(t, streams.HeartbeatIntervals{
+			basictypes.ServerSideStream:          config.DefaultHeartbeatInterval,
+			basictypes.ServerSideFlagsOnlyStream: config.DefaultHeartbeatInterval,
+			basictypes.MobilePingStream:          config.DefaultHeartbeatInterval,
+			basictypes.JSClientPingStream:        config.DefaultHeartbeatInterval,
+		}, intervals)
+	})
+
+	t.Run("per-kind settings override global setting", func(t *testing.T) {
+		var allConfig config.Config
+		allConfig.Main.HeartbeatInterval = ct.NewOptDuration(time.Minute)
+		allConfig.Main.ServerSideHeartbeatInterval = ct.NewOptDuration(2 * time.Minute)
+		allConfig.Main.MobileHeartbeatInterval = ct.NewOptDuration(10 * time.Second)
+		intervals := makeHeartbeatIntervals(config.EnvConfig{}, allConfig)
+		assert.Equal(t, streams.HeartbeatIntervals{
+			basictypes.ServerSideStream:          2 * time.Minute,
+			basictypes.ServerSideFlagsOnlyStream: 2 * time.Minute,
+			basictypes.MobilePingStream:          10 * time.Second,
+			basictypes.JSClientPingStream:        time.Minute,
+		}, intervals)
+	})
+
+	t.Run("environment setting overrides main settings", func(t *testing.T) {
+		var allConfig config.Config
+		allConfig.Main.MobileHeartbeatInterval = ct.NewOptDuration(10 * time.Second)
+		envConfig := config.EnvConfig{HeartbeatInterval: ct.NewOptDuration(5 * time.Second)}
+		intervals := makeHeartbeatIntervals(envConfig, allConfig)
+		for _, kind := range allStreamKinds {
+			assert.Equal(t, 5*time.Second, intervals[kind], kind)
+		}
+	})
+}
+
+func TestMaxConnTime(t *testing.T) {
+	t.Run("no limit by default", func(t *testing.T) {
+		for _, kind := range allStreamKinds {
+			assert.Equal(t, time.Duration(0), getMaxConnTime(kind, config.EnvConfig{}, config.Config{}), kind)
+		}
+	})
+
+	t.Run("per-kind settings override global setting", func(t *testing.T) {
+		var allConfig config.Config
+		allConfig.Main.MaxClientConnectionTime = ct.NewOptDuration(time.Hour)
+		allConfig.Main.ServerSideMaxClientConnectionTime = ct.NewOptDuration(30 * time.Minute)
+		allConfig.Main.JSClientMaxClientConnectionTime = ct.NewOptDuration(10 * time.Minute)
+		assert.Equal(t, 30*time.Minute, getMaxConnTime(basictypes.ServerSideStream, config.EnvConfig{}, allConfig))
+		assert.Equal(t, 30*time.Minute, getMaxConnTime(basictypes.ServerSideFlagsOnlyStream, config.EnvConfig{}, allConfig))
+		assert.Equal(t, time.Hour, getMaxConnTime(basictypes.MobilePingStream, config.EnvConfig{}, allConfig))
+		assert.Equal(t, 10*time.Minute, getMaxConnTime(basictypes.JSClientPingStream, config.EnvConfig{}, allConfig))
+	})
+
+	t.Run("environment setting overrides main settings", func(t *testing.T) {
+		var allConfig config.Config
+		allConfig.Main.ServerSideMaxClientConnectionTime = ct.NewOptDuration(30 * time.Minute)
+		envConfig := config.EnvConfig{MaxClientConnectionTime: ct.NewOptDuration(5 * time.Minute)}
+		for _, kind := range allStreamKinds {
+			assert.Equal(t, 5*time.Minute, getMaxConnTime(kind, envConfig, allConfig), kind)
+		}
+	})
+}
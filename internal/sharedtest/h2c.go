@@ -0,0 +1,24 @@
+package sharedtest
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"golang.org/x/net/http2"
+)
+
+// MakeH2CClient returns an HTTP client that always uses HTTP/2 without TLS ("h2c" with prior knowledge),
+// for testing servers that support h2c. Like a service mesh proxy, it multiplexes concurrent requests
+// to the same host over a single connection.
+func MakeH2CClient() *http.Client {
+	return &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, network, addr)
+			},
+		},
+	}
+}
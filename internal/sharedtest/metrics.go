@@ -45,8 +45,10 @@ type TestMetricsRow struct {
 
 // NewTestMetricsExporter creates a TestMetricsExporter.
 func NewTestMetricsExporter() *TestMetricsExporter {
+	// The data channel must be big enough for the first export of every registered view, since ExportView
+	// is called while OpenCensus holds a lock that UnregisterExporter also needs.
 	return &TestMetricsExporter{
-		dataCh:   make(chan TestMetricsData, 10),
+		dataCh:   make(chan TestMetricsData, 100),
 		spansCh:  make(chan *trace.SpanData, 10),
 		lastData: make(TestMetricsData),
 	}
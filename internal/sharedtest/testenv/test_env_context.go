@@ -5,6 +5,7 @@ package testenv
 import (
 	"time"
 
+	"github.com/launchdarkly/ld-relay/v7/config"
 	"github.com/launchdarkly/ld-relay/v7/internal/relayenv"
 	"github.com/launchdarkly/ld-relay/v7/internal/sdks"
 	"github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
@@ -32,6 +33,7 @@ func NewTestEnvContextWithClientFactory(
 	readyCh := make(chan relayenv.EnvContext)
 	_, err := relayenv.NewEnvContext(relayenv.EnvContextImplParams{
 		Identifiers:      relayenv.EnvIdentifiers{ConfiguredName: name},
+		EnvConfig:        config.EnvConfig{SDKKey: config.SDKKey("fake-sdk-key")}, // so that an SDK client is created
 		ClientFactory:    f,
 		DataStoreFactory: dataStoreFactory,
 		UserAgent:        "fake-user-agent",
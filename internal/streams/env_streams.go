@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
@@ -33,6 +34,10 @@ type EnvStreamUpdates interface {
 	InvalidateClientSideState()
 }
 
+// HeartbeatIntervals specifies how often EnvStreams should send heartbeats on each kind of stream. If
+// a stream kind is not in the map, or its interval is zero, no heartbeats are sent on that kind of stream.
+type HeartbeatIntervals map[basictypes.StreamKind]time.Duration
+
 // EnvStreams encapsulates streaming behavior for a specific environment.
 //
 // EnvStreams itself does not know anything about what kind of streams are available; those are
@@ -53,6 +58,7 @@ type EnvStreams struct {
 
 type streamInfo struct {
 	credential        config.SDKCredential
+	kind              basictypes.StreamKind
 	envStreamProvider EnvStreamProvider
 }
 
@@ -64,10 +70,13 @@ type EnvStoreQueries interface {
 }
 
 // NewEnvStreams creates an instance of EnvStreams.
+//
+// A separate heartbeat timer is started for each distinct interval in heartbeatIntervals, so that
+// for instance mobile streams can receive heartbeats more often than server-side streams.
 func NewEnvStreams(
 	streamProviders []StreamProvider,
 	storeQueries EnvStoreQueries,
-	heartbeatInterval time.Duration,
+	heartbeatIntervals HeartbeatIntervals,
 	loggers ldlog.Loggers,
 ) *EnvStreams {
 	es := &EnvStreams{
@@ -77,28 +86,45 @@ func NewEnvStreams(
 		closeCh:         make(chan struct{}),
 	}
 
-	if heartbeatInterval > 0 {
-		heartbeats := time.NewTicker(heartbeatInterval)
+	kindsByInterval := make(map[time.Duration][]basictypes.StreamKind)
+	for kind, interval := range heartbeatIntervals {
+		if interval > 0 {
+			kindsByInterval[interval] = append(kindsByInterval[interval], kind)
+		}
+	}
+
+	if len(kindsByInterval) > 0 {
+		var heartbeatsWG sync.WaitGroup
+		for interval, kinds := range kindsByInterval {
+			heartbeatsWG.Add(1)
+			go es.runHeartbeats(interval, kinds, &heartbeatsWG)
+		}
 		es.heartbeatsDone = make(chan struct{})
 		go func() {
-			for {
-				select {
-				case <-heartbeats.C:
-					for _, esp := range es.getEnvStreamProviders() {
-						esp.SendHeartbeat()
-					}
-				case <-es.closeCh:
-					heartbeats.Stop()
-					close(es.heartbeatsDone)
-					return
-				}
-			}
+			heartbeatsWG.Wait()
+			close(es.heartbeatsDone)
 		}()
 	}
 
 	return es
 }
 
+func (es *EnvStreams) runHeartbeats(interval time.Duration, kinds []basictypes.StreamKind, wg *sync.WaitGroup) {
+	defer wg.Done()
+	heartbeats := time.NewTicker(interval)
+	defer heartbeats.Stop()
+	for {
+		select {
+		case <-heartbeats.C:
+			for _, esp := range es.getEnvStreamProviders(kinds...) {
+				esp.SendHeartbeat()
+			}
+		case <-es.closeCh:
+			return
+		}
+	}
+}
+
 // AddCredential adds an environment credential and creates a corresponding EnvStreamProvider.
 func (es *EnvStreams) AddCredential(credential config.SDKCredential) {
 	if credential == nil {
@@ -107,7 +133,7 @@ func (es *EnvStreams) AddCredential(credential config.SDKCredential) {
 	for _, sp := range es.streamProviders {
 		if esp := sp.Register(credential, es.storeQueries, es.loggers); esp != nil {
 			es.lock.Lock()
-			es.activeStreams = append(es.activeStreams, streamInfo{credential, esp})
+			es.activeStreams = append(es.activeStreams, streamInfo{credential, sp.Kind(), esp})
 			es.lock.Unlock()
 		}
 	}
@@ -170,12 +196,25 @@ func (es *EnvStreams) Close() error {
 	return nil
 }
 
-func (es *EnvStreams) getEnvStreamProviders() []EnvStreamProvider {
+// getEnvStreamProviders returns the active EnvStreamProviders for the specified stream kinds, or for
+// all stream kinds if none are specified.
+func (es *EnvStreams) getEnvStreamProviders(kinds ...basictypes.StreamKind) []EnvStreamProvider {
 	es.lock.RLock()
 	ret := make([]EnvStreamProvider, 0, len(es.activeStreams))
 	for _, s := range es.activeStreams {
-		ret = append(ret, s.envStreamProvider)
+		if len(kinds) == 0 || containsStreamKind(kinds, s.kind) {
+			ret = append(ret, s.envStreamProvider)
+		}
 	}
 	es.lock.RUnlock()
 	return ret
 }
+
+func containsStreamKind(kinds []basictypes.StreamKind, kind basictypes.StreamKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
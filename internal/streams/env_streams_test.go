@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
 	"github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
@@ -21,6 +22,7 @@ import (
 
 type mockStreamProvider struct {
 	credentialOfDesiredType config.SDKCredential
+	kind                    basictypes.StreamKind
 	createdStreams          []*mockEnvStreamProvider
 }
 
@@ -53,6 +55,10 @@ func (p *mockStreamProvider) Register(
 	return esp
 }
 
+func (p *mockStreamProvider) Kind() basictypes.StreamKind {
+	return p.kind
+}
+
 func (p *mockStreamProvider) Close() {}
 
 func (e *mockEnvStreamProvider) SendAllDataUpdate(allData []ldstoretypes.Collection) {
@@ -88,7 +94,7 @@ func TestAddCredential(t *testing.T) {
 	sp2 := &mockStreamProvider{credentialOfDesiredType: config.MobileKey("")}
 
 	store := makeMockStore(nil, nil)
-	es := NewEnvStreams([]StreamProvider{sp1, sp2}, store, 0, ldlog.NewDisabledLoggers())
+	es := NewEnvStreams([]StreamProvider{sp1, sp2}, store, nil, ldlog.NewDisabledLoggers())
 	defer es.Close()
 
 	sdkKey1, sdkKey2 := config.SDKKey("sdk-key1"), config.SDKKey("sdk-key1")
@@ -120,7 +126,7 @@ func TestRemoveCredential(t *testing.T) {
 	sp := &mockStreamProvider{credentialOfDesiredType: config.SDKKey("")}
 
 	store := makeMockStore(nil, nil)
-	es := NewEnvStreams([]StreamProvider{sp}, store, 0, ldlog.NewDisabledLoggers())
+	es := NewEnvStreams([]StreamProvider{sp}, store, nil, ldlog.NewDisabledLoggers())
 	defer es.Close()
 
 	sdkKey1, sdkKey2 := config.SDKKey("sdk-key1"), config.SDKKey("sdk-key2")
@@ -143,7 +149,7 @@ func TestCloseEnvStreamsClosesAll(t *testing.T) {
 	sp := &mockStreamProvider{credentialOfDesiredType: config.SDKKey("")}
 
 	store := makeMockStore(nil, nil)
-	es := NewEnvStreams([]StreamProvider{sp}, store, 0, ldlog.NewDisabledLoggers())
+	es := NewEnvStreams([]StreamProvider{sp}, store, nil, ldlog.NewDisabledLoggers())
 
 	sdkKey1, sdkKey2, sdkKey3 := config.SDKKey("sdk-key1"), config.SDKKey("sdk-key2"), config.SDKKey("sdk-key3")
 	es.AddCredential(sdkKey1)
@@ -169,7 +175,7 @@ func TestSendAllDataUpdateGoesToAllStreams(t *testing.T) {
 	sp := &mockStreamProvider{credentialOfDesiredType: config.SDKKey("")}
 
 	store := makeMockStore(nil, nil)
-	es := NewEnvStreams([]StreamProvider{sp}, store, 0, ldlog.NewDisabledLoggers())
+	es := NewEnvStreams([]StreamProvider{sp}, store, nil, ldlog.NewDisabledLoggers())
 	defer es.Close()
 
 	sdkKey1, sdkKey2, sdkKey3 := config.SDKKey("sdk-key1"), config.SDKKey("sdk-key2"), config.SDKKey("sdk-key3")
@@ -194,7 +200,7 @@ func TestSendSingleItemUpdateGoesToAllStreams(t *testing.T) {
 	sp := &mockStreamProvider{credentialOfDesiredType: config.SDKKey("")}
 
 	store := makeMockStore(nil, nil)
-	es := NewEnvStreams([]StreamProvider{sp}, store, 0, ldlog.NewDisabledLoggers())
+	es := NewEnvStreams([]StreamProvider{sp}, store, nil, ldlog.NewDisabledLoggers())
 	defer es.Close()
 
 	sdkKey1, sdkKey2, sdkKey3 := config.SDKKey("sdk-key1"), config.SDKKey("sdk-key2"), config.SDKKey("sdk-key3")
@@ -219,7 +225,7 @@ func TestInvalidateClientSideStateGoesToAllStreams(t *testing.T) {
 	sp := &mockStreamProvider{credentialOfDesiredType: config.SDKKey("")}
 
 	store := makeMockStore(nil, nil)
-	es := NewEnvStreams([]StreamProvider{sp}, store, 0, ldlog.NewDisabledLoggers())
+	es := NewEnvStreams([]StreamProvider{sp}, store, nil, ldlog.NewDisabledLoggers())
 	defer es.Close()
 
 	sdkKey1, sdkKey2, sdkKey3 := config.SDKKey("sdk-key1"), config.SDKKey("sdk-key2"), config.SDKKey("sdk-key3")
@@ -242,10 +248,10 @@ func TestInvalidateClientSideStateGoesToAllStreams(t *testing.T) {
 func TestHeartbeatsGoToAllStreams(t *testing.T) {
 	heartbeatInterval := time.Millisecond * 20
 
-	sp := &mockStreamProvider{credentialOfDesiredType: config.SDKKey("")}
+	sp := &mockStreamProvider{credentialOfDesiredType: config.SDKKey(""), kind: basictypes.ServerSideStream}
 
 	store := makeMockStore(nil, nil)
-	es := NewEnvStreams([]StreamProvider{sp}, store, heartbeatInterval, ldlog.NewDisabledLoggers())
+	es := NewEnvStreams([]StreamProvider{sp}, store, HeartbeatIntervals{basictypes.ServerSideStream: heartbeatInterval}, ldlog.NewDisabledLoggers())
 	defer es.Close()
 
 	sdkKey1, sdkKey2 := config.SDKKey("sdk-key1"), config.SDKKey("sdk-key2")
@@ -269,13 +275,40 @@ func TestHeartbeatsGoToAllStreams(t *testing.T) {
 	}
 }
 
+func TestHeartbeatIntervalsAreSpecificToStreamKind(t *testing.T) {
+	sp1 := &mockStreamProvider{credentialOfDesiredType: config.SDKKey(""), kind: basictypes.ServerSideStream}
+	sp2 := &mockStreamProvider{credentialOfDesiredType: config.MobileKey(""), kind: basictypes.MobilePingStream}
+	sp3 := &mockStreamProvider{credentialOfDesiredType: config.EnvironmentID(""), kind: basictypes.JSClientPingStream}
+
+	store := makeMockStore(nil, nil)
+	intervals := HeartbeatIntervals{
+		basictypes.ServerSideStream: time.Hour,
+		basictypes.MobilePingStream: time.Millisecond * 20,
+	}
+	es := NewEnvStreams([]StreamProvider{sp1, sp2, sp3}, store, intervals, ldlog.NewDisabledLoggers())
+	defer es.Close()
+
+	es.AddCredential(config.SDKKey("sdk-key"))
+	es.AddCredential(config.MobileKey("mob-key"))
+	es.AddCredential(config.EnvironmentID("env-id"))
+
+	require.Len(t, sp1.createdStreams, 1)
+	require.Len(t, sp2.createdStreams, 1)
+	require.Len(t, sp3.createdStreams, 1)
+
+	assert.Eventually(t, func() bool { return sp2.createdStreams[0].getNumHeartbeats() >= 2 },
+		time.Second, time.Millisecond*20, "Waited to see at least 2 heartbeats on mobile stream")
+	assert.Equal(t, 0, sp1.createdStreams[0].getNumHeartbeats())
+	assert.Equal(t, 0, sp3.createdStreams[0].getNumHeartbeats())
+}
+
 func TestHeartbeatsAreStopped(t *testing.T) {
 	heartbeatInterval := time.Millisecond * 20
 
-	sp := &mockStreamProvider{credentialOfDesiredType: config.SDKKey("")}
+	sp := &mockStreamProvider{credentialOfDesiredType: config.SDKKey(""), kind: basictypes.ServerSideStream}
 
 	store := makeMockStore(nil, nil)
-	es := NewEnvStreams([]StreamProvider{sp}, store, heartbeatInterval, ldlog.NewDisabledLoggers())
+	es := NewEnvStreams([]StreamProvider{sp}, store, HeartbeatIntervals{basictypes.ServerSideStream: heartbeatInterval}, ldlog.NewDisabledLoggers())
 
 	es.AddCredential(config.SDKKey("sdk-key1"))
 
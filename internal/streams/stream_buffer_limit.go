@@ -0,0 +1,202 @@
+package streams
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var errStreamBufferLimitExceeded = errors.New("stream client fell too far behind")
+
+// WithBufferLimit wraps a stream handler so that a client that is not reading events as fast as they
+// are being published cannot make Relay hold an unlimited amount of data for it.
+//
+// Output from the handler is queued in memory and written to the client by a separate goroutine, so the
+// SSE server never waits on a slow client. If the amount of data that has been queued but not yet
+// written to the client would exceed maxBufferSize bytes, the queued data is discarded, the stream is
+// ended, and onDropped is called.
+//
+// The limit applies to complete events: the SSE server writes each event in several pieces and then
+// flushes, so everything written since the last flush belongs to one event. That event is always
+// accepted if nothing from an earlier event is still queued, so that an initial "put" event larger than
+// the limit does not cause every connection to be dropped.
+//
+// A client that has fallen that far behind is probably not reading at all, so the writer goroutine may
+// be blocked in a write to it. The write deadline for the response is set (see
+// http.ResponseController.SetWriteDeadline) so that the write fails immediately; for HTTP/1 this ends the
+// connection, and for HTTP/2 it resets only this stream. The handler always waits for the goroutine to
+// exit before returning, since the ResponseWriter must not be used after that. If the ResponseWriter
+// does not support write deadlines, the handler cannot return until the blocked write does.
+//
+// If maxBufferSize is zero or negative, the handler is returned unchanged.
+func WithBufferLimit(handler http.Handler, maxBufferSize int, onDropped func(*http.Request)) http.Handler {
+	if maxBufferSize <= 0 {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithCancel(req.Context())
+		defer cancel()
+		bw := newBufferLimitWriter(w, maxBufferSize, cancel)
+		handler.ServeHTTP(bw, req.WithContext(ctx))
+		if bw.close() {
+			onDropped(req)
+		}
+	})
+}
+
+// bufferLimitWriter is the http.ResponseWriter that WithBufferLimit passes to the stream handler.
+type bufferLimitWriter struct {
+	target        http.ResponseWriter
+	controller    *http.ResponseController
+	maxBufferSize int
+	endStream     func()
+	pending       [][]byte
+	pendingBytes  int // includes data that the writer goroutine has taken but not yet finished writing
+	eventBytes    int // data written since the last flush, which is all part of the same event
+	needFlush     bool
+	writing       bool
+	closed        bool
+	dropped       bool
+	failed        bool
+	aborted       bool
+	done          chan struct{}
+	lock          sync.Mutex
+	cond          *sync.Cond
+}
+
+func newBufferLimitWriter(
+	target http.ResponseWriter,
+	maxBufferSize int,
+	endStream func(),
+) *bufferLimitWriter {
+	w := &bufferLimitWriter{
+		target:        target,
+		controller:    http.NewResponseController(target),
+		maxBufferSize: maxBufferSize,
+		endStream:     endStream,
+		done:          make(chan struct{}),
+	}
+	w.cond = sync.NewCond(&w.lock)
+	go w.run()
+	return w
+}
+
+func (w *bufferLimitWriter) Header() http.Header {
+	return w.target.Header()
+}
+
+// WriteHeader is passed through directly, since it is always called before anything has been written.
+func (w *bufferLimitWriter) WriteHeader(statusCode int) {
+	w.target.WriteHeader(statusCode)
+}
+
+func (w *bufferLimitWriter) Write(data []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.closed || w.dropped || w.failed {
+		return 0, errStreamBufferLimitExceeded
+	}
+	if w.pendingBytes > w.eventBytes && w.pendingBytes+len(data) > w.maxBufferSize {
+		w.dropped = true
+		w.pending = nil
+		w.abortWrite()
+		w.endStream()
+		w.cond.Broadcast()
+		return 0, errStreamBufferLimitExceeded
+	}
+	w.pending = append(w.pending, append([]byte(nil), data...))
+	w.pendingBytes += len(data)
+	w.eventBytes += len(data)
+	w.cond.Broadcast()
+	return len(data), nil
+}
+
+func (w *bufferLimitWriter) Flush() {
+	w.lock.Lock()
+	w.needFlush = true
+	w.eventBytes = 0
+	w.cond.Broadcast()
+	w.lock.Unlock()
+}
+
+// close stops the writer goroutine, discarding anything that has not yet been written, and waits for it
+// to exit so that nothing is written to the target after the handler returns. If the goroutine is in the
+// middle of a write, that write is made to fail rather than waiting for the client to read it. It returns
+// true if the stream was ended because the buffer limit was exceeded.
+func (w *bufferLimitWriter) close() bool {
+	w.lock.Lock()
+	w.closed = true
+	w.pending = nil
+	if w.writing {
+		w.abortWrite()
+	}
+	w.cond.Broadcast()
+	dropped := w.dropped
+	w.lock.Unlock()
+	<-w.done
+	return dropped
+}
+
+// abortWrite makes any write that the writer goroutine is blocked in, or makes later, fail immediately.
+// It must be called with the lock held, and only before the handler returns.
+func (w *bufferLimitWriter) abortWrite() {
+	if !w.aborted {
+		w.aborted = true
+		_ = w.controller.SetWriteDeadline(time.Now())
+	}
+}
+
+func (w *bufferLimitWriter) isStopped() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.closed || w.dropped
+}
+
+func (w *bufferLimitWriter) run() {
+	defer close(w.done)
+	for {
+		w.lock.Lock()
+		for len(w.pending) == 0 && !w.needFlush && !w.closed && !w.dropped {
+			w.cond.Wait()
+		}
+		if w.closed || w.dropped {
+			w.lock.Unlock()
+			return
+		}
+		batch := w.pending
+		w.pending = nil
+		w.needFlush = false
+		w.writing = true
+		w.lock.Unlock()
+
+		written := 0
+		var err error
+		for _, data := range batch {
+			if w.isStopped() { // no point in writing anything more
+				break
+			}
+			if _, err = w.target.Write(data); err != nil {
+				break
+			}
+			written += len(data)
+		}
+		if err == nil && !w.isStopped() {
+			if err = w.controller.Flush(); errors.Is(err, http.ErrNotSupported) {
+				err = nil
+			}
+		}
+
+		w.lock.Lock()
+		w.writing = false
+		w.pendingBytes -= written
+		if err != nil {
+			w.failed = true
+			w.endStream()
+			w.lock.Unlock()
+			return
+		}
+		w.lock.Unlock()
+	}
+}
@@ -0,0 +1,295 @@
+package streams
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
+
+	"github.com/launchdarkly/eventsource"
+	helpers "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// slowStreamWriter simulates a client that is not reading: writes block until release is called, or
+// until the write deadline is set.
+type slowStreamWriter struct {
+	header       http.Header
+	releaseCh    chan struct{}
+	deadlineCh   chan struct{}
+	deadlineOnce sync.Once
+	writeErr     error
+	written      []byte
+	lock         sync.Mutex
+}
+
+func newSlowStreamWriter() *slowStreamWriter {
+	return &slowStreamWriter{header: make(http.Header), releaseCh: make(chan struct{}), deadlineCh: make(chan struct{})}
+}
+
+func (w *slowStreamWriter) Header() http.Header { return w.header }
+func (w *slowStreamWriter) WriteHeader(int)     {}
+func (w *slowStreamWriter) Flush()              {}
+
+func (w *slowStreamWriter) SetWriteDeadline(time.Time) error {
+	w.deadlineOnce.Do(func() { close(w.deadlineCh) })
+	return nil
+}
+
+func (w *slowStreamWriter) Write(data []byte) (int, error) {
+	select {
+	case <-w.releaseCh:
+	case <-w.deadlineCh:
+		return 0, os.ErrDeadlineExceeded
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.writeErr != nil {
+		return 0, w.writeErr
+	}
+	w.written = append(w.written, data...)
+	return len(data), nil
+}
+
+func (w *slowStreamWriter) release() { close(w.releaseCh) }
+
+func (w *slowStreamWriter) getWritten() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return string(w.written)
+}
+
+// connStreamWriter writes directly to a connection, like the ResponseWriter for an HTTP/1 request.
+type connStreamWriter struct {
+	header http.Header
+	conn   net.Conn
+}
+
+func (w *connStreamWriter) Header() http.Header                { return w.header }
+func (w *connStreamWriter) WriteHeader(int)                    {}
+func (w *connStreamWriter) Write(data []byte) (int, error)     { return w.conn.Write(data) }
+func (w *connStreamWriter) SetWriteDeadline(t time.Time) error { return w.conn.SetWriteDeadline(t) }
+
+// singleEventRepository is an eventsource.Repository that sends the same event to each new subscriber,
+// like the initial "put" event of a Relay stream.
+type singleEventRepository struct {
+	event eventsource.Event
+}
+
+func (r singleEventRepository) Replay(channel, id string) chan eventsource.Event {
+	ch := make(chan eventsource.Event, 1)
+	ch <- r.event
+	close(ch)
+	return ch
+}
+
+// noDeadlineStreamWriter hides the SetWriteDeadline method of the writer it wraps.
+type noDeadlineStreamWriter struct {
+	http.ResponseWriter
+}
+
+func TestWithBufferLimit(t *testing.T) {
+	runHandler := func(handler http.Handler, w http.ResponseWriter) <-chan struct{} {
+		doneCh := make(chan struct{})
+		go func() {
+			req, _ := http.NewRequest("GET", "", nil)
+			handler.ServeHTTP(w, req)
+			close(doneCh)
+		}()
+		return doneCh
+	}
+
+	writeUntilDropped := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		for i := 0; i < 10; i++ {
+			if _, err := w.Write([]byte("abc")); err != nil {
+				break
+			}
+			w.(http.Flusher).Flush()
+		}
+		<-req.Context().Done()
+	})
+
+	t.Run("zero limit returns same handler", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})
+		wrapped := WithBufferLimit(handler, 0, nil)
+		assert.Equal(t, reflect.ValueOf(handler).Pointer(), reflect.ValueOf(wrapped).Pointer())
+	})
+
+	t.Run("output is written to client", func(t *testing.T) {
+		writtenCh := make(chan struct{})
+		handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, _ = w.Write([]byte("abc"))
+			_, _ = w.Write([]byte("def"))
+			w.(http.Flusher).Flush()
+			<-writtenCh
+		})
+		dropped := false
+		w := newSlowStreamWriter()
+		w.release()
+		doneCh := runHandler(WithBufferLimit(handler, 100, func(*http.Request) { dropped = true }), w)
+		require.Eventually(t, func() bool { return w.getWritten() == "abcdef" }, time.Second, time.Millisecond)
+		close(writtenCh)
+		helpers.AssertChannelClosed(t, doneCh, time.Second)
+		assert.False(t, dropped)
+	})
+
+	t.Run("single event larger than limit is accepted", func(t *testing.T) {
+		// The SSE server writes the event name, the "data:" prefix, and the data separately, so the whole
+		// event has to be exempt from the limit, not just the single write of its data.
+		server := newSSEServer(0)
+		bigData := strings.Repeat("x", 2000)
+		server.Register("channel", singleEventRepository{testEvent{event: "put", data: bigData}})
+		dropped := false
+		w := newSlowStreamWriter()
+		doneCh := runHandler(WithBufferLimit(server.Handler("channel"), 1000, func(*http.Request) { dropped = true }), w)
+
+		time.Sleep(time.Millisecond * 50) // so that the whole event is queued before anything is written
+		w.release()
+		require.Eventually(t, func() bool { return strings.Contains(w.getWritten(), "data: "+bigData+"\n") },
+			time.Second, time.Millisecond)
+		server.Close() // ends the stream
+		helpers.AssertChannelClosed(t, doneCh, time.Second)
+		assert.False(t, dropped)
+	})
+
+	t.Run("stream is ended and reported when client falls behind by more than limit", func(t *testing.T) {
+		droppedCh := make(chan struct{}, 1)
+		w := newSlowStreamWriter()
+		doneCh := runHandler(WithBufferLimit(writeUntilDropped, 10, func(*http.Request) { droppedCh <- struct{}{} }), w)
+
+		w.release()
+		helpers.AssertChannelClosed(t, doneCh, time.Second)
+		helpers.RequireValue(t, droppedCh, time.Second)
+		assert.Less(t, len(w.getWritten()), 30)
+	})
+
+	t.Run("handler returns when client falls behind and never reads", func(t *testing.T) {
+		droppedCh := make(chan struct{}, 1)
+		w := newSlowStreamWriter()
+		doneCh := runHandler(WithBufferLimit(writeUntilDropped, 10, func(*http.Request) { droppedCh <- struct{}{} }), w)
+
+		helpers.AssertChannelClosed(t, doneCh, time.Second)
+		helpers.RequireValue(t, droppedCh, time.Second)
+		assert.Equal(t, "", w.getWritten())
+	})
+
+	t.Run("blocked write on connection fails when client falls behind", func(t *testing.T) {
+		serverConn, clientConn := net.Pipe() // nothing ever reads from clientConn
+		defer serverConn.Close()
+		defer clientConn.Close()
+		droppedCh := make(chan struct{}, 1)
+		w := &connStreamWriter{header: make(http.Header), conn: serverConn}
+		doneCh := runHandler(WithBufferLimit(writeUntilDropped, 10, func(*http.Request) { droppedCh <- struct{}{} }), w)
+
+		helpers.AssertChannelClosed(t, doneCh, time.Second)
+		helpers.RequireValue(t, droppedCh, time.Second)
+	})
+
+	t.Run("handler waits for blocked write if write deadlines are not supported", func(t *testing.T) {
+		droppedCh := make(chan struct{}, 1)
+		w := newSlowStreamWriter()
+		handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, _ = w.Write([]byte("abc"))
+			w.(http.Flusher).Flush()
+			time.Sleep(time.Millisecond * 10) // so that the writer goroutine is blocked in writing this
+			writeUntilDropped(w, req)
+		})
+		doneCh := runHandler(WithBufferLimit(handler, 10, func(*http.Request) { droppedCh <- struct{}{} }),
+			noDeadlineStreamWriter{w})
+
+		helpers.AssertChannelNotClosed(t, doneCh, time.Millisecond*50)
+		w.release()
+		helpers.AssertChannelClosed(t, doneCh, time.Second)
+		helpers.RequireValue(t, droppedCh, time.Second)
+	})
+
+	t.Run("blocked HTTP/2 write fails when client falls behind", func(t *testing.T) {
+		// Over HTTP/2 the connection is shared with other requests, so only this stream can be ended, and
+		// any use of the ResponseWriter after the handler has returned would cause a panic.
+		droppedCh := make(chan struct{}, 1)
+		handlerDoneCh := make(chan struct{})
+		handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			chunk := make([]byte, 64*1024)
+			for {
+				if _, err := w.Write(chunk); err != nil {
+					break
+				}
+				w.(http.Flusher).Flush()
+				// Writing slowly means that the limit is only exceeded once the client's flow control window
+				// is full, so the writer goroutine is blocked.
+				time.Sleep(time.Millisecond)
+			}
+			<-req.Context().Done()
+		})
+		wrapped := WithBufferLimit(handler, 1024*1024, func(*http.Request) { droppedCh <- struct{}{} })
+		server := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			wrapped.ServeHTTP(w, req)
+			close(handlerDoneCh)
+		}), &http2.Server{}))
+		defer server.Close()
+
+		resp, err := sharedtest.MakeH2CClient().Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close() // the body is never read
+		assert.Equal(t, 2, resp.ProtoMajor)
+
+		helpers.RequireValue(t, droppedCh, time.Second*5, "connection was not reported as dropped")
+		helpers.AssertChannelClosed(t, handlerDoneCh, time.Second)
+	})
+
+	t.Run("stream is ended but not reported as dropped if write fails", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, _ = w.Write([]byte("abc"))
+			<-req.Context().Done()
+		})
+		dropped := false
+		w := newSlowStreamWriter()
+		w.writeErr = errors.New("sorry")
+		w.release()
+		doneCh := runHandler(WithBufferLimit(handler, 10, func(*http.Request) { dropped = true }), w)
+		helpers.AssertChannelClosed(t, doneCh, time.Second)
+		assert.False(t, dropped)
+	})
+
+	t.Run("stream that ends normally is not reported as dropped", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, _ = w.Write([]byte("abc"))
+		})
+		dropped := false
+		doneCh := runHandler(WithBufferLimit(handler, 10, func(*http.Request) { dropped = true }), httptest.NewRecorder())
+		helpers.AssertChannelClosed(t, doneCh, time.Second)
+		assert.False(t, dropped)
+	})
+
+	t.Run("works with SSE server when client is not reading", func(t *testing.T) {
+		server := newSSEServer(0)
+		defer server.Close()
+		droppedCh := make(chan struct{}, 1)
+		handler := WithBufferLimit(server.Handler("channel"), 100, func(*http.Request) { droppedCh <- struct{}{} })
+		w := newSlowStreamWriter()
+		doneCh := runHandler(handler, w)
+
+		// Publishing many more events than the SSE server's own buffer size would make it drop the
+		// subscriber if the handler were blocked on writing to the client.
+		for i := 0; i < 200; i++ {
+			server.Publish([]string{"channel"}, testEvent{event: "e", data: "0123456789"})
+			time.Sleep(time.Millisecond)
+		}
+		w.release()
+		helpers.AssertChannelClosed(t, doneCh, time.Second)
+		helpers.RequireValue(t, droppedCh, time.Second, "connection was not reported as dropped")
+	})
+}
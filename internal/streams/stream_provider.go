@@ -1,6 +1,8 @@
 package streams
 
 import (
+	"context"
+	"math/rand"
 	"net/http"
 	"time"
 
@@ -29,6 +31,9 @@ type StreamProvider interface {
 	// return nil if it does not support this type of credential.
 	Register(credential config.SDKCredential, store EnvStoreQueries, loggers ldlog.Loggers) EnvStreamProvider
 
+	// Kind returns the kind of stream endpoint that this StreamProvider implements.
+	Kind() basictypes.StreamKind
+
 	// Close tells the StreamProvider to release all of its resources and close all connections.
 	Close()
 }
@@ -46,6 +51,10 @@ type EnvStreamProvider interface {
 }
 
 // NewStreamProvider creates a StreamProvider implementation for the specified kind of stream endpoint.
+//
+// If maxConnTime is non-zero, every connection to the provider's handlers is closed after that amount
+// of time. Relay itself passes zero here and applies connection time limits per environment with
+// WithMaxConnTime instead, since those limits can vary by environment and include a random jitter.
 func NewStreamProvider(kind basictypes.StreamKind, maxConnTime time.Duration) StreamProvider {
 	switch kind {
 	case basictypes.ServerSideFlagsOnlyStream:
@@ -78,6 +87,25 @@ func newSSEServer(maxConnTime time.Duration) *eventsource.Server {
 	return s
 }
 
+// WithMaxConnTime wraps a stream handler so that each connection is closed after maxConnTime plus a
+// random additional amount of time between zero and jitter. Randomizing the connection lifetime keeps
+// SDK clients that connected at the same time from all reconnecting at the same time. If maxConnTime
+// is zero, the handler is returned unchanged.
+func WithMaxConnTime(handler http.Handler, maxConnTime, jitter time.Duration) http.Handler {
+	if maxConnTime <= 0 {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		connTime := maxConnTime
+		if jitter > 0 {
+			connTime += time.Duration(rand.Int63n(int64(jitter))) //nolint:gosec // doesn't need to be cryptographically secure
+		}
+		ctx, cancel := context.WithTimeout(req.Context(), connTime)
+		defer cancel()
+		handler.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
 func removeDeleted(items []ldstoretypes.KeyedItemDescriptor) []ldstoretypes.KeyedItemDescriptor {
 	var ret []ldstoretypes.KeyedItemDescriptor
 	for i, keyedItem := range items {
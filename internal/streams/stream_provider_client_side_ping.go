@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
 
 	"github.com/launchdarkly/eventsource"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
@@ -64,6 +65,13 @@ func (s *clientSidePingStreamProvider) Register(
 	return nil
 }
 
+func (s *clientSidePingStreamProvider) Kind() basictypes.StreamKind {
+	if s.isJSClient {
+		return basictypes.JSClientPingStream
+	}
+	return basictypes.MobilePingStream
+}
+
 func (s *clientSidePingStreamProvider) Close() {
 	s.closeOnce.Do(func() {
 		s.server.Close()
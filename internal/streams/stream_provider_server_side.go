@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
 	"golang.org/x/sync/singleflight"
 
 	"github.com/launchdarkly/eventsource"
@@ -53,6 +54,10 @@ func (s *serverSideStreamProvider) Register(
 	return nil
 }
 
+func (s *serverSideStreamProvider) Kind() basictypes.StreamKind {
+	return basictypes.ServerSideStream
+}
+
 func (s *serverSideStreamProvider) Close() {
 	s.closeOnce.Do(func() {
 		s.server.Close()
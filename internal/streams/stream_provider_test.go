@@ -2,6 +2,7 @@ package streams
 
 import (
 	"net/http"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -147,3 +148,38 @@ func verifyHandlerHeartbeat(
 		}
 	})
 }
+
+func TestWithMaxConnTime(t *testing.T) {
+	waitForRequestEnd := func(t *testing.T, handler http.Handler) time.Duration {
+		req, _ := http.NewRequest("GET", "", nil)
+		startTime := time.Now()
+		doneCh := make(chan struct{})
+		go func() {
+			handler.ServeHTTP(nil, req)
+			close(doneCh)
+		}()
+		helpers.AssertChannelClosed(t, doneCh, time.Second, "timed out waiting for handler to end")
+		return time.Since(startTime)
+	}
+	blockingHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+	})
+
+	t.Run("zero time returns same handler", func(t *testing.T) {
+		handler := WithMaxConnTime(blockingHandler, 0, time.Hour)
+		assert.Equal(t, reflect.ValueOf(blockingHandler).Pointer(), reflect.ValueOf(handler).Pointer())
+	})
+
+	t.Run("request context is cancelled after time limit", func(t *testing.T) {
+		maxConnTime := time.Millisecond * 50
+		elapsed := waitForRequestEnd(t, WithMaxConnTime(blockingHandler, maxConnTime, 0))
+		assert.GreaterOrEqual(t, elapsed, maxConnTime)
+	})
+
+	t.Run("jitter is added to time limit", func(t *testing.T) {
+		maxConnTime, jitter := time.Millisecond*50, time.Millisecond*100
+		elapsed := waitForRequestEnd(t, WithMaxConnTime(blockingHandler, maxConnTime, jitter))
+		assert.GreaterOrEqual(t, elapsed, maxConnTime)
+		assert.Less(t, elapsed, maxConnTime+jitter+time.Millisecond*200)
+	})
+}
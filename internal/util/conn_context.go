@@ -0,0 +1,47 @@
+package util
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+type connContextKeyType struct{}
+
+var connContextKey connContextKeyType //nolint:gochecknoglobals
+
+// WithConn returns a new Context with the network connection added. This is meant to be used as the
+// ConnContext function of an http.Server, so that handlers can set deadlines for the connection (see
+// GetRequestConn). If the Relay handler is hosted by a server that does not do this, as it may be if
+// Relay is embedded in another application, those deadlines have no effect.
+func WithConn(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey, conn)
+}
+
+// GetRequestConn returns the connection that the request was received on, if it is known and if it
+// belongs to this request. With HTTP/2, the connection is shared by many requests, so we can't set
+// deadlines for it, and this returns nil.
+func GetRequestConn(req *http.Request) net.Conn {
+	if req.ProtoMajor != 1 {
+		return nil
+	}
+	conn, _ := req.Context().Value(connContextKey).(net.Conn)
+	return conn
+}
+
+// WithoutConn returns a new Context in which the connection that was added by WithConn is no longer
+// visible, so that GetRequestConn returns nil. This is for a connection that is about to be shared by
+// many requests, such as an h2c upgrade, since a deadline that was set for one of those requests would
+// also apply to all of the others.
+func WithoutConn(ctx context.Context) context.Context {
+	return context.WithValue(ctx, connContextKey, nil)
+}
+
+// ClearConnDeadlines removes any read or write deadlines from the connection that the request was
+// received on, if it is known.
+func ClearConnDeadlines(req *http.Request) {
+	if conn, ok := req.Context().Value(connContextKey).(net.Conn); ok {
+		_ = conn.SetDeadline(time.Time{})
+	}
+}
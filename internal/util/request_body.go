@@ -0,0 +1,50 @@
+package util
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrRequestBodyTooLarge is the error returned when reading a request body that was wrapped with
+// LimitRequestBody, if the body is longer than the limit.
+var ErrRequestBodyTooLarge = errors.New("request body too large")
+
+type limitedRequestBody struct {
+	body      io.ReadCloser
+	remaining int64
+	err       error
+}
+
+// LimitRequestBody wraps a request body so that reading more than maxBytes from it causes an
+// ErrRequestBodyTooLarge error. This is similar to http.MaxBytesReader, but the error can be checked
+// with errors.Is in all supported Go versions.
+func LimitRequestBody(body io.ReadCloser, maxBytes int64) io.ReadCloser {
+	return &limitedRequestBody{body: body, remaining: maxBytes}
+}
+
+func (b *limitedRequestBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	// Read one byte more than the limit, so we can tell whether the body is exactly at the limit or over it.
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.body.Read(p)
+	if int64(n) <= b.remaining {
+		b.remaining -= int64(n)
+		b.err = err
+		return n, err
+	}
+	n = int(b.remaining)
+	b.remaining = 0
+	b.err = ErrRequestBodyTooLarge
+	return n, b.err
+}
+
+func (b *limitedRequestBody) Close() error {
+	return b.body.Close()
+}
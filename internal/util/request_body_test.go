@@ -0,0 +1,47 @@
+package util
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitRequestBody(t *testing.T) {
+	t.Run("body shorter than limit", func(t *testing.T) {
+		data, err := io.ReadAll(LimitRequestBody(io.NopCloser(strings.NewReader("abc")), 4))
+		assert.NoError(t, err)
+		assert.Equal(t, "abc", string(data))
+	})
+
+	t.Run("body exactly at limit", func(t *testing.T) {
+		data, err := io.ReadAll(LimitRequestBody(io.NopCloser(strings.NewReader("abcd")), 4))
+		assert.NoError(t, err)
+		assert.Equal(t, "abcd", string(data))
+	})
+
+	t.Run("body longer than limit", func(t *testing.T) {
+		body := LimitRequestBody(io.NopCloser(strings.NewReader("abcde")), 4)
+		data, err := io.ReadAll(body)
+		assert.Equal(t, ErrRequestBodyTooLarge, err)
+		assert.Equal(t, "abcd", string(data))
+
+		n, err := body.Read(make([]byte, 10))
+		assert.Equal(t, 0, n)
+		assert.Equal(t, ErrRequestBodyTooLarge, err)
+	})
+
+	t.Run("small reads", func(t *testing.T) {
+		body := LimitRequestBody(io.NopCloser(strings.NewReader("abcde")), 4)
+		buf := make([]byte, 1)
+		for _, expected := range "abcd" {
+			n, err := body.Read(buf)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, n)
+			assert.Equal(t, byte(expected), buf[0])
+		}
+		_, err := body.Read(buf)
+		assert.Equal(t, ErrRequestBodyTooLarge, err)
+	})
+}
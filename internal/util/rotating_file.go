@@ -0,0 +1,155 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// RotatingFile is an io.WriteCloser that appends to a file, and renames it to a numbered backup when
+// it would exceed a maximum size or, if a rotation interval is set, when it has been open for longer
+// than that interval. The most recent backup is path.1, the next is path.2, and so on; backups beyond
+// the maximum number are deleted. It is safe for concurrent use.
+//
+// Rotation by interval happens on a timer, so a file that is not being written to is still rotated on
+// time; but an empty file is left alone, rather than creating an empty backup.
+type RotatingFile struct {
+	path           string
+	maxSize        int64
+	maxBackups     int
+	rotateInterval time.Duration
+	file           *os.File
+	size           int64
+	openedAt       time.Time
+	now            func() time.Time
+	timer          *time.Timer
+	timerGen       int
+	lock           sync.Mutex
+}
+
+// NewRotatingFile opens or creates a RotatingFile. If rotateInterval is zero, the file is only rotated
+// based on its size.
+func NewRotatingFile(path string, maxSize int64, maxBackups int, rotateInterval time.Duration) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path:           path,
+		maxSize:        maxSize,
+		maxBackups:     maxBackups,
+		rotateInterval: rotateInterval,
+		now:            time.Now,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = f.now()
+	f.scheduleRotation()
+	return nil
+}
+
+// scheduleRotation starts the timer for rotation by interval, replacing any previous one. The generation
+// number lets a timer that has already fired, and is waiting for the lock, see that it is out of date.
+func (f *RotatingFile) scheduleRotation() {
+	f.stopTimer()
+	if f.rotateInterval <= 0 {
+		return
+	}
+	gen := f.timerGen
+	f.timer = time.AfterFunc(f.rotateInterval, func() { f.rotateOnTimer(gen) })
+}
+
+func (f *RotatingFile) stopTimer() {
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+	f.timerGen++
+}
+
+func (f *RotatingFile) rotateOnTimer(gen int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if gen != f.timerGen || f.file == nil {
+		return
+	}
+	if f.size == 0 {
+		f.openedAt = f.now()
+		f.scheduleRotation()
+		return
+	}
+	_ = f.rotate() // if this fails, the next Write will try to reopen the file
+}
+
+// Write writes the data as a single unit, so a write that is larger than the maximum size still goes
+// into one file rather than being split.
+func (f *RotatingFile) Write(data []byte) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	if f.size > 0 && (f.size+int64(len(data)) > f.maxSize || f.intervalElapsed()) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *RotatingFile) intervalElapsed() bool {
+	return f.rotateInterval > 0 && f.now().Sub(f.openedAt) >= f.rotateInterval
+}
+
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+	_ = os.Remove(f.backupPath(f.maxBackups))
+	for i := f.maxBackups - 1; i >= 1; i-- {
+		if _, err := os.Stat(f.backupPath(i)); err == nil {
+			if err := os.Rename(f.backupPath(i), f.backupPath(i+1)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := os.Rename(f.path, f.backupPath(1)); err != nil {
+		return err
+	}
+	return f.open()
+}
+
+func (f *RotatingFile) backupPath(n int) string {
+	return fmt.Sprintf("%s.%d", f.path, n)
+}
+
+// Close closes the file. A subsequent Write will reopen it.
+func (f *RotatingFile) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.stopTimer()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -0,0 +1,95 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readFileOrEmpty(path string) string {
+	data, _ := os.ReadFile(path)
+	return string(data)
+}
+
+func TestRotatingFile(t *testing.T) {
+	t.Run("appends to existing file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "audit.log")
+		require.NoError(t, os.WriteFile(path, []byte("abc"), 0600))
+		f, err := NewRotatingFile(path, 100, 2, 0)
+		require.NoError(t, err)
+		_, err = f.Write([]byte("def"))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		assert.Equal(t, "abcdef", readFileOrEmpty(path))
+	})
+
+	t.Run("rotates when size would exceed limit", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "audit.log")
+		f, err := NewRotatingFile(path, 5, 2, 0)
+		require.NoError(t, err)
+		for _, s := range []string{"aaa", "bbb", "ccc", "ddd"} {
+			_, err = f.Write([]byte(s))
+			require.NoError(t, err)
+		}
+		require.NoError(t, f.Close())
+		assert.Equal(t, "ddd", readFileOrEmpty(path))
+		assert.Equal(t, "ccc", readFileOrEmpty(path+".1"))
+		assert.Equal(t, "bbb", readFileOrEmpty(path+".2"))
+		assert.NoFileExists(t, path+".3")
+	})
+
+	t.Run("write larger than limit is not split", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "audit.log")
+		f, err := NewRotatingFile(path, 5, 1, 0)
+		require.NoError(t, err)
+		_, err = f.Write([]byte("0123456789"))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		assert.Equal(t, "0123456789", readFileOrEmpty(path))
+		assert.NoFileExists(t, path+".1")
+	})
+
+	t.Run("rotates when interval has elapsed", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "audit.log")
+		f, err := NewRotatingFile(path, 100, 2, time.Hour)
+		require.NoError(t, err)
+		now := time.Now()
+		f.now = func() time.Time { return now }
+		f.openedAt = now
+		_, err = f.Write([]byte("aaa"))
+		require.NoError(t, err)
+		now = now.Add(time.Minute)
+		_, err = f.Write([]byte("bbb"))
+		require.NoError(t, err)
+		now = now.Add(time.Hour)
+		_, err = f.Write([]byte("ccc"))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		assert.Equal(t, "ccc", readFileOrEmpty(path))
+		assert.Equal(t, "aaabbb", readFileOrEmpty(path+".1"))
+	})
+
+	t.Run("rotates on a timer without any further writes", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "audit.log")
+		f, err := NewRotatingFile(path, 100, 2, time.Millisecond*50)
+		require.NoError(t, err)
+		defer f.Close()
+		_, err = f.Write([]byte("aaa"))
+		require.NoError(t, err)
+		require.Eventually(t, func() bool { return readFileOrEmpty(path+".1") == "aaa" }, time.Second, time.Millisecond*10)
+		assert.Equal(t, "", readFileOrEmpty(path))
+
+		// the new file is empty, so it is not rotated again
+		time.Sleep(time.Millisecond * 150)
+		assert.NoFileExists(t, path+".2")
+	})
+
+	t.Run("error if file cannot be opened", func(t *testing.T) {
+		_, err := NewRotatingFile(filepath.Join(t.TempDir(), "no-such-dir", "audit.log"), 5, 1, 0)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,263 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/internal/metrics"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+)
+
+const (
+	// SignatureHeader is the request header containing the HMAC-SHA256 signature of the request body,
+	// computed with the webhook secret, in the form "sha256=" followed by the hex-encoded signature.
+	SignatureHeader = "X-LD-Relay-Signature"
+
+	flagKind = "flag"
+)
+
+// EnvironmentInfo describes the environment in a FlagChange.
+type EnvironmentInfo struct {
+	EnvID    string `json:"envId,omitempty"`
+	EnvKey   string `json:"envKey,omitempty"`
+	EnvName  string `json:"envName,omitempty"`
+	ProjKey  string `json:"projKey,omitempty"`
+	ProjName string `json:"projName,omitempty"`
+}
+
+// FlagChange is the JSON payload of a webhook notification.
+type FlagChange struct {
+	Kind        string                     `json:"kind"`
+	Key         string                     `json:"key"`
+	OldVersion  int                        `json:"oldVersion,omitempty"`
+	NewVersion  int                        `json:"newVersion"`
+	Deleted     bool                       `json:"deleted,omitempty"`
+	Environment EnvironmentInfo            `json:"environment"`
+	Timestamp   ldtime.UnixMillisecondTime `json:"timestamp"`
+}
+
+// NotifierParams contains the parameters for NewNotifier.
+type NotifierParams struct {
+	// URI is the webhook URL.
+	URI string
+	// Secret is the key for signing each request body.
+	Secret string
+	// QueueSize is the maximum number of notifications waiting to be delivered; more are discarded.
+	QueueSize int
+	// MaxAttempts is the maximum number of times to try delivering each notification.
+	MaxAttempts int
+	// RetryDelay is the delay before the first retry; it doubles for each subsequent retry.
+	RetryDelay time.Duration
+	// HTTPClient is the client to use for requests.
+	HTTPClient *http.Client
+	// UserAgent is the User-Agent header value.
+	UserAgent string
+	// GetEnvironment returns the current identifiers of the environment.
+	GetEnvironment func() EnvironmentInfo
+	// MetricsContext is the OpenCensus context for the environment's metrics.
+	MetricsContext context.Context
+	// Loggers is used for logging.
+	Loggers ldlog.Loggers
+}
+
+// Notifier detects flag changes in the data that Relay receives for an environment, and posts a
+// FlagChange for each one to a webhook URL.
+//
+// Notifications are queued and delivered in order by a single worker goroutine, so a slow or failing
+// webhook receiver does not hold up Relay's data updates; if the queue is full, new notifications are
+// discarded.
+type Notifier struct {
+	params        NotifierParams
+	knownVersions map[string]int
+	initialized   bool
+	queue         chan FlagChange
+	closeCh       chan struct{}
+	closeOnce     sync.Once
+	wg            sync.WaitGroup
+	lock          sync.Mutex
+}
+
+// NewNotifier creates a Notifier and starts its worker goroutine.
+func NewNotifier(params NotifierParams) *Notifier {
+	if params.HTTPClient == nil {
+		params.HTTPClient = http.DefaultClient
+	}
+	if params.MetricsContext == nil {
+		params.MetricsContext = context.Background()
+	}
+	if params.MaxAttempts < 1 {
+		params.MaxAttempts = 1
+	}
+	n := &Notifier{
+		params:        params,
+		knownVersions: make(map[string]int),
+		queue:         make(chan FlagChange, params.QueueSize),
+		closeCh:       make(chan struct{}),
+	}
+	n.wg.Add(1)
+	go n.run()
+	return n
+}
+
+// AllDataUpdated is called whenever Relay receives a full set of data for the environment. The first
+// time, this just records the current flag versions; after that, a notification is sent for each flag
+// that was added, changed, or removed.
+func (n *Notifier) AllDataUpdated(allData []ldstoretypes.Collection) {
+	var flags []ldstoretypes.KeyedItemDescriptor
+	for _, coll := range allData {
+		if coll.Kind == ldstoreimpl.Features() {
+			flags = coll.Items
+		}
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	notify := n.initialized
+	n.initialized = true
+	newVersions := make(map[string]int, len(flags))
+	for _, f := range flags {
+		if f.Item.Item == nil {
+			continue
+		}
+		newVersions[f.Key] = f.Item.Version
+		if oldVersion, found := n.knownVersions[f.Key]; notify && (!found || oldVersion != f.Item.Version) {
+			n.enqueue(FlagChange{Key: f.Key, OldVersion: oldVersion, NewVersion: f.Item.Version})
+		}
+	}
+	if notify {
+		for key, oldVersion := range n.knownVersions {
+			if _, found := newVersions[key]; !found {
+				n.enqueue(FlagChange{Key: key, OldVersion: oldVersion, Deleted: true})
+			}
+		}
+	}
+	n.knownVersions = newVersions
+}
+
+// ItemUpdated is called whenever Relay receives an update for a single flag or segment. Updates that
+// are not newer than the last known version of the flag are ignored.
+func (n *Notifier) ItemUpdated(kind ldstoretypes.DataKind, key string, item ldstoretypes.ItemDescriptor) {
+	if kind != ldstoreimpl.Features() {
+		return
+	}
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	oldVersion, found := n.knownVersions[key]
+	if found && item.Version <= oldVersion {
+		return
+	}
+	if item.Item == nil {
+		delete(n.knownVersions, key)
+		if found {
+			n.enqueue(FlagChange{Key: key, OldVersion: oldVersion, NewVersion: item.Version, Deleted: true})
+		}
+		return
+	}
+	n.knownVersions[key] = item.Version
+	n.enqueue(FlagChange{Key: key, OldVersion: oldVersion, NewVersion: item.Version})
+}
+
+// Close stops the worker goroutine. Notifications that have not yet been delivered are discarded.
+func (n *Notifier) Close() {
+	n.closeOnce.Do(func() {
+		close(n.closeCh)
+	})
+	n.wg.Wait()
+}
+
+// enqueue must be called while holding the lock, so that notifications are queued in the same order
+// as the updates that caused them.
+func (n *Notifier) enqueue(change FlagChange) {
+	change.Kind = flagKind
+	change.Timestamp = ldtime.UnixMillisNow()
+	if n.params.GetEnvironment != nil {
+		change.Environment = n.params.GetEnvironment()
+	}
+	select {
+	case n.queue <- change:
+	default:
+		n.params.Loggers.Warnf("Webhook queue is full; discarding notification for flag %q", change.Key)
+		metrics.Increment(n.params.MetricsContext, metrics.WebhookDrops)
+	}
+}
+
+func (n *Notifier) run() {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-n.closeCh:
+			return
+		case change := <-n.queue:
+			n.deliver(change)
+		}
+	}
+}
+
+func (n *Notifier) deliver(change FlagChange) {
+	body, _ := json.Marshal(change)
+	delay := n.params.RetryDelay
+	for attempt := 1; ; attempt++ {
+		canRetry, err := n.post(body)
+		if err == nil {
+			metrics.Increment(n.params.MetricsContext, metrics.WebhookDeliveries)
+			return
+		}
+		if !canRetry || attempt >= n.params.MaxAttempts {
+			n.params.Loggers.Errorf("Failed to deliver webhook notification for flag %q: %s", change.Key, err)
+			metrics.Increment(n.params.MetricsContext, metrics.WebhookFailures)
+			return
+		}
+		n.params.Loggers.Warnf("Failed to deliver webhook notification for flag %q (will retry): %s", change.Key, err)
+		select {
+		case <-n.closeCh:
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// post makes a single delivery attempt. If it fails, the returned bool indicates whether the error
+// might be temporary.
+func (n *Notifier) post(body []byte) (bool, error) {
+	req, err := http.NewRequest("POST", n.params.URI, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", n.params.UserAgent)
+	req.Header.Set(SignatureHeader, Sign(n.params.Secret, body))
+	resp, err := n.params.HTTPClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	canRetry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode == http.StatusRequestTimeout
+	return canRetry, fmt.Errorf("HTTP error %d", resp.StatusCode)
+}
+
+// Sign computes the value of the SignatureHeader for a request body. Receivers can verify a notification
+// by computing the same value with the shared secret and comparing it with hmac.Equal.
+func Sign(secret string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	_, _ = h.Write(body)
+	return "sha256=" + hex.EncodeToString(h.Sum(nil))
+}
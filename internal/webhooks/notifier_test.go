@@ -0,0 +1,208 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+	helpers "github.com/launchdarkly/go-test-helpers/v3"
+	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "my-secret"
+
+var testEnvInfo = EnvironmentInfo{EnvID: "env-id", EnvKey: "env-key", EnvName: "Env Name", ProjKey: "proj-key"} //nolint:gochecknoglobals
+
+func makeParams(uri string, loggers ldlog.Loggers) NotifierParams {
+	return NotifierParams{
+		URI:            uri,
+		Secret:         testSecret,
+		QueueSize:      10,
+		MaxAttempts:    3,
+		RetryDelay:     time.Millisecond,
+		UserAgent:      "FakeRelay/1.0",
+		GetEnvironment: func() EnvironmentInfo { return testEnvInfo },
+		Loggers:        loggers,
+	}
+}
+
+func makeAllData(flagVersions map[string]int) []ldstoretypes.Collection {
+	var items []ldstoretypes.KeyedItemDescriptor
+	for key, version := range flagVersions {
+		flag := ldbuilders.NewFlagBuilder(key).Version(version).Build()
+		items = append(items, ldstoretypes.KeyedItemDescriptor{Key: key, Item: sharedtest.FlagDesc(flag)})
+	}
+	return []ldstoretypes.Collection{
+		{Kind: ldstoreimpl.Features(), Items: items},
+		{Kind: ldstoreimpl.Segments(), Items: nil},
+	}
+}
+
+func flagDesc(key string, version int) ldstoretypes.ItemDescriptor {
+	return sharedtest.FlagDesc(ldbuilders.NewFlagBuilder(key).Version(version).Build())
+}
+
+func requireChange(t *testing.T, requestsCh <-chan httphelpers.HTTPRequestInfo) FlagChange {
+	r := helpers.RequireValue(t, requestsCh, time.Second)
+	var change FlagChange
+	require.NoError(t, json.Unmarshal(r.Body, &change))
+	return change
+}
+
+func TestNotifier(t *testing.T) {
+	withNotifier := func(t *testing.T, handler http.Handler, action func(*Notifier, <-chan httphelpers.HTTPRequestInfo)) {
+		mockLog := ldlogtest.NewMockLog()
+		defer mockLog.DumpIfTestFailed(t)
+		recorder, requestsCh := httphelpers.RecordingHandler(handler)
+		httphelpers.WithServer(recorder, func(server *httptest.Server) {
+			n := NewNotifier(makeParams(server.URL, mockLog.Loggers))
+			defer n.Close()
+			action(n, requestsCh)
+		})
+	}
+
+	t.Run("initial data does not cause notifications", func(t *testing.T) {
+		withNotifier(t, httphelpers.HandlerWithStatus(200), func(n *Notifier, requestsCh <-chan httphelpers.HTTPRequestInfo) {
+			n.AllDataUpdated(makeAllData(map[string]int{"flag1": 1, "flag2": 1}))
+			helpers.AssertNoMoreValues(t, requestsCh, time.Millisecond*50)
+		})
+	})
+
+	t.Run("request properties", func(t *testing.T) {
+		withNotifier(t, httphelpers.HandlerWithStatus(200), func(n *Notifier, requestsCh <-chan httphelpers.HTTPRequestInfo) {
+			n.AllDataUpdated(makeAllData(map[string]int{"flag1": 1}))
+			n.ItemUpdated(ldstoreimpl.Features(), "flag1", flagDesc("flag1", 2))
+
+			r := helpers.RequireValue(t, requestsCh, time.Second)
+			assert.Equal(t, "POST", r.Request.Method)
+			assert.Equal(t, "application/json", r.Request.Header.Get("Content-Type"))
+			assert.Equal(t, "FakeRelay/1.0", r.Request.Header.Get("User-Agent"))
+			assert.Equal(t, "", r.Request.Header.Get("Authorization"))
+			assert.Equal(t, Sign(testSecret, r.Body), r.Request.Header.Get(SignatureHeader))
+
+			var change FlagChange
+			require.NoError(t, json.Unmarshal(r.Body, &change))
+			assert.Equal(t, "flag", change.Kind)
+			assert.Equal(t, "flag1", change.Key)
+			assert.Equal(t, 1, change.OldVersion)
+			assert.Equal(t, 2, change.NewVersion)
+			assert.False(t, change.Deleted)
+			assert.Equal(t, testEnvInfo, change.Environment)
+			assert.NotZero(t, change.Timestamp)
+		})
+	})
+
+	t.Run("full data update notifies for added, changed, and deleted flags", func(t *testing.T) {
+		withNotifier(t, httphelpers.HandlerWithStatus(200), func(n *Notifier, requestsCh <-chan httphelpers.HTTPRequestInfo) {
+			n.AllDataUpdated(makeAllData(map[string]int{"flag1": 1, "flag2": 1, "flag3": 1}))
+			n.AllDataUpdated(makeAllData(map[string]int{"flag1": 1, "flag2": 2, "flag4": 1}))
+
+			changes := make(map[string]FlagChange)
+			for i := 0; i < 3; i++ {
+				change := requireChange(t, requestsCh)
+				changes[change.Key] = change
+			}
+			helpers.AssertNoMoreValues(t, requestsCh, time.Millisecond*50)
+			assert.Equal(t, 1, changes["flag2"].OldVersion)
+			assert.Equal(t, 2, changes["flag2"].NewVersion)
+			assert.True(t, changes["flag3"].Deleted)
+			assert.Equal(t, 1, changes["flag3"].OldVersion)
+			assert.Equal(t, 0, changes["flag4"].OldVersion)
+			assert.Equal(t, 1, changes["flag4"].NewVersion)
+		})
+	})
+
+	t.Run("single item updates", func(t *testing.T) {
+		withNotifier(t, httphelpers.HandlerWithStatus(200), func(n *Notifier, requestsCh <-chan httphelpers.HTTPRequestInfo) {
+			n.AllDataUpdated(makeAllData(map[string]int{"flag1": 2}))
+			n.ItemUpdated(ldstoreimpl.Features(), "flag1", flagDesc("flag1", 1))         // outdated
+			n.ItemUpdated(ldstoreimpl.Segments(), "segment1", sharedtest.DeletedItem(1)) // not a flag
+			n.ItemUpdated(ldstoreimpl.Features(), "flag1", sharedtest.DeletedItem(3))    // deleted
+			n.ItemUpdated(ldstoreimpl.Features(), "flag2", flagDesc("flag2", 1))         // new
+
+			change1 := requireChange(t, requestsCh)
+			assert.Equal(t, "flag1", change1.Key)
+			assert.True(t, change1.Deleted)
+			assert.Equal(t, 3, change1.NewVersion)
+			change2 := requireChange(t, requestsCh)
+			assert.Equal(t, "flag2", change2.Key)
+			assert.Equal(t, 1, change2.NewVersion)
+			helpers.AssertNoMoreValues(t, requestsCh, time.Millisecond*50)
+		})
+	})
+
+	t.Run("retries after recoverable error", func(t *testing.T) {
+		handler := httphelpers.SequentialHandler(httphelpers.HandlerWithStatus(503), httphelpers.HandlerWithStatus(200))
+		withNotifier(t, handler, func(n *Notifier, requestsCh <-chan httphelpers.HTTPRequestInfo) {
+			n.AllDataUpdated(makeAllData(nil))
+			n.ItemUpdated(ldstoreimpl.Features(), "flag1", flagDesc("flag1", 1))
+			r1 := helpers.RequireValue(t, requestsCh, time.Second)
+			r2 := helpers.RequireValue(t, requestsCh, time.Second)
+			assert.Equal(t, r1.Body, r2.Body)
+			helpers.AssertNoMoreValues(t, requestsCh, time.Millisecond*50)
+		})
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		withNotifier(t, httphelpers.HandlerWithStatus(500), func(n *Notifier, requestsCh <-chan httphelpers.HTTPRequestInfo) {
+			n.AllDataUpdated(makeAllData(nil))
+			n.ItemUpdated(ldstoreimpl.Features(), "flag1", flagDesc("flag1", 1))
+			for i := 0; i < 3; i++ {
+				helpers.RequireValue(t, requestsCh, time.Second)
+			}
+			helpers.AssertNoMoreValues(t, requestsCh, time.Millisecond*50)
+		})
+	})
+
+	t.Run("does not retry after unrecoverable error", func(t *testing.T) {
+		withNotifier(t, httphelpers.HandlerWithStatus(400), func(n *Notifier, requestsCh <-chan httphelpers.HTTPRequestInfo) {
+			n.AllDataUpdated(makeAllData(nil))
+			n.ItemUpdated(ldstoreimpl.Features(), "flag1", flagDesc("flag1", 1))
+			helpers.RequireValue(t, requestsCh, time.Second)
+			helpers.AssertNoMoreValues(t, requestsCh, time.Millisecond*50)
+		})
+	})
+}
+
+func TestNotifierDiscardsNotificationsWhenQueueIsFull(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+	releaseCh := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-releaseCh
+		w.WriteHeader(200)
+	})
+	recorder, requestsCh := httphelpers.RecordingHandler(handler)
+	httphelpers.WithServer(recorder, func(server *httptest.Server) {
+		params := makeParams(server.URL, mockLog.Loggers)
+		params.QueueSize = 1
+		n := NewNotifier(params)
+		defer n.Close()
+		defer close(releaseCh)
+
+		n.AllDataUpdated(makeAllData(nil))
+		n.ItemUpdated(ldstoreimpl.Features(), "flag1", flagDesc("flag1", 1))
+		<-requestsCh                                                         // worker is now blocked delivering flag1
+		n.ItemUpdated(ldstoreimpl.Features(), "flag2", flagDesc("flag2", 1)) // queued
+		n.ItemUpdated(ldstoreimpl.Features(), "flag3", flagDesc("flag3", 1)) // discarded
+
+		mockLog.AssertMessageMatch(t, true, ldlog.Warn, `queue is full; discarding notification for flag "flag3"`)
+	})
+}
+
+func TestSign(t *testing.T) {
+	// expected value computed with: printf 'hello' | openssl dgst -sha256 -hmac my-secret
+	assert.Equal(t, "sha256=7c1ce32402750db1149385ac20603beeaca8909906d1e81c08f4f5c7db8fbe94", Sign(testSecret, []byte("hello")))
+}
@@ -0,0 +1,3 @@
+// Package webhooks contains the internal implementation of flag change notifications that Relay sends
+// to configured webhook URLs.
+package webhooks
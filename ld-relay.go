@@ -1,12 +1,15 @@
 package main
 
 import (
+	"fmt"
+	"net/http"
 	"os"
 
 	_ "github.com/kardianos/minwinsvc"
 
 	"github.com/launchdarkly/ld-relay/v7/config"
 	"github.com/launchdarkly/ld-relay/v7/internal/application"
+	"github.com/launchdarkly/ld-relay/v7/internal/configdump"
 	"github.com/launchdarkly/ld-relay/v7/internal/logging"
 	"github.com/launchdarkly/ld-relay/v7/relay"
 	"github.com/launchdarkly/ld-relay/v7/relay/version"
@@ -30,6 +33,20 @@ func main() {
 		os.Exit(0)
 	}
 
+	if opts.CheckConfig {
+		if !application.CheckConfig(opts, os.Stdout, os.Stderr) {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if opts.ReplayEvents {
+		if !application.ReplayEvents(opts, os.Stdout, os.Stderr) {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	loggers.Infof(
 		"Starting LaunchDarkly relay version %s with %s\n",
 		application.DescribeRelayVersion(version.Version),
@@ -37,18 +54,28 @@ func main() {
 	)
 
 	if opts.ConfigFile != "" {
-		if err := config.LoadConfigFile(&c, opts.ConfigFile, loggers); err != nil {
+		err := config.TrackPropertySource(&c, config.PropertySourceFile, func() error {
+			return config.LoadConfigFile(&c, opts.ConfigFile, loggers)
+		})
+		if err != nil {
 			loggers.Errorf("Error loading config file: %s", err)
 			os.Exit(1)
 		}
 	}
 	if opts.UseEnvironment {
-		if err := config.LoadConfigFromEnvironment(&c, loggers); err != nil {
+		err := config.TrackPropertySource(&c, config.PropertySourceEnvironment, func() error {
+			return config.LoadConfigFromEnvironment(&c, loggers)
+		})
+		if err != nil {
 			loggers.Errorf("Configuration error: %s", err)
 			os.Exit(1)
 		}
 	}
 
+	if opts.PrintConfig {
+		configdump.Write(c, os.Stdout)
+	}
+
 	r, err := relay.NewRelay(c, loggers, nil)
 	if err != nil {
 		loggers.Errorf("Unable to create relay: %s", err)
@@ -59,20 +86,109 @@ func main() {
 		os.Exit(0)
 	}
 
-	port := c.Main.Port.GetOrElse(config.DefaultPort)
+	activated, err := application.SystemdListeners()
+	if err != nil {
+		loggers.Errorf("Error using sockets from systemd: %s", err)
+		os.Exit(1)
+	}
 
-	_, errs := application.StartHTTPServer(
-		port,
-		r,
-		c.Main.TLSEnabled,
-		c.Main.TLSCert,
-		c.Main.TLSKey,
-		c.Main.TLSMinVersion.Get(),
-		loggers,
-	)
+	serverErrs := make(chan error)
+	watchServer := func(description string, errs <-chan error) {
+		go func() {
+			for err := range errs {
+				serverErrs <- fmt.Errorf("%s: %w", description, err)
+			}
+		}()
+	}
+
+	serverOptions := application.MakeHTTPServerOptions(c.Main)
+	var mainHandler http.Handler = r
+	if c.Main.H2CEnabled {
+		mainHandler = application.H2CHandler(mainHandler, serverOptions)
+	}
+
+	mainListeners, activatedListeners, err := application.MatchSystemdListeners(activated, c, loggers)
+	if err != nil {
+		loggers.Errorf("Error using sockets from systemd: %s", err)
+		os.Exit(1)
+	}
+	if len(mainListeners) == 0 && c.Main.SocketPath != "" {
+		listener, err := application.ListenUnixSocket(c.Main.SocketPath,
+			os.FileMode(c.Main.SocketMode.GetOrElse(config.DefaultSocketMode)))
+		if err != nil {
+			loggers.Errorf("Error starting http listener on socket: %s  %s", c.Main.SocketPath, err)
+			os.Exit(1)
+		}
+		mainListeners = append(mainListeners, listener)
+	}
+	for _, listener := range mainListeners {
+		_, errs := application.StartHTTPServerOnListener(
+			listener,
+			mainHandler,
+			c.Main.TLSEnabled,
+			c.Main.TLSCert,
+			c.Main.TLSKey,
+			c.Main.TLSMinVersion.Get(),
+			serverOptions,
+			loggers,
+		)
+		watchServer(listener.Addr().String(), errs)
+	}
+	if len(mainListeners) == 0 {
+		port := c.Main.Port.GetOrElse(config.DefaultPort)
+		_, errs := application.StartHTTPServer(
+			port,
+			mainHandler,
+			c.Main.TLSEnabled,
+			c.Main.TLSCert,
+			c.Main.TLSKey,
+			c.Main.TLSMinVersion.Get(),
+			serverOptions,
+			loggers,
+		)
+		watchServer(fmt.Sprintf("port %d", port), errs)
+	}
+
+	for name, listenerConfig := range c.Listener {
+		if listenerConfig == nil {
+			continue
+		}
+		listenerHandler := r.ListenerHandler(name)
+		if listenerConfig.H2CEnabled {
+			listenerHandler = application.H2CHandler(listenerHandler, serverOptions)
+		}
+		for _, listener := range activatedListeners[name] {
+			_, errs := application.StartHTTPServerOnListener(
+				listener,
+				listenerHandler,
+				listenerConfig.TLSEnabled,
+				listenerConfig.TLSCert,
+				listenerConfig.TLSKey,
+				listenerConfig.TLSMinVersion.Get(),
+				serverOptions,
+				loggers,
+			)
+			watchServer(fmt.Sprintf("%q %s", name, listener.Addr()), errs)
+		}
+		if len(activatedListeners[name]) != 0 {
+			continue
+		}
+		listenerPort := listenerConfig.Port.GetOrElse(0)
+		_, errs := application.StartHTTPServer(
+			listenerPort,
+			listenerHandler,
+			listenerConfig.TLSEnabled,
+			listenerConfig.TLSCert,
+			listenerConfig.TLSKey,
+			listenerConfig.TLSMinVersion.Get(),
+			serverOptions,
+			loggers,
+		)
+		watchServer(fmt.Sprintf("%q port %d", name, listenerPort), errs)
+	}
 
-	for err := range errs {
-		loggers.Errorf("Error starting http listener on port: %d  %s", port, err)
+	for err := range serverErrs {
+		loggers.Errorf("Error starting http listener %s", err)
 		os.Exit(1)
 	}
 }
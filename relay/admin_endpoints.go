@@ -0,0 +1,136 @@
+package relay
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/internal/configdump"
+	"github.com/launchdarkly/ld-relay/v7/internal/events"
+	"github.com/launchdarkly/ld-relay/v7/internal/util"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// AdminPathPrefix is the URL path prefix for administrative endpoints, which are only enabled if
+	// Main.AdminKey is set, and require that key in the Authorization header.
+	AdminPathPrefix = "/admin"
+
+	adminConfigPath         = "/config"
+	adminRejectedEventsPath = "/rejected-events"
+	adminEventCapturePath   = "/event-capture/{envName}"
+)
+
+// requireAdminKey is middleware that rejects any request whose Authorization header is not the admin key.
+func requireAdminKey(key string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			authKey := req.Header.Get("Authorization")
+			if authKey == "" || subtle.ConstantTimeCompare([]byte(authKey), []byte(key)) != 1 {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// adminConfigHandler shows the effective configuration in the same format as the --print-config option.
+func adminConfigHandler(r *Relay) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		configdump.Write(r.config, w)
+	})
+}
+
+// adminRejectedEventsHandler shows the most recent analytics events that were rejected by event validation,
+// for each environment, keyed by the environment's display name. Environments that do not have event
+// forwarding enabled are omitted.
+func adminRejectedEventsHandler(r *Relay) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		resp := make(map[string][]events.RejectedEvent)
+		for _, env := range r.getAllEnvironments() {
+			if dispatcher := env.GetEventDispatcher(); dispatcher != nil {
+				rejected := dispatcher.GetRecentRejectedEvents()
+				if rejected == nil {
+					rejected = []events.RejectedEvent{}
+				}
+				resp[env.GetIdentifiers().GetDisplayName()] = rejected
+			}
+		}
+		data, _ := json.Marshal(resp)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(data)
+	})
+}
+
+// adminEventCaptureHandler controls event capture for the environment whose display name is in the path.
+// POST starts a capture, with optional "duration" and "maxBytes" query parameters; GET returns the captured
+// payloads; DELETE stops the capture and discards the payloads.
+func adminEventCaptureHandler(r *Relay) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		envName := mux.Vars(req)["envName"]
+		var dispatcher *events.EventDispatcher
+		for _, env := range r.getAllEnvironments() {
+			if env.GetIdentifiers().GetDisplayName() == envName {
+				if dispatcher = env.GetEventDispatcher(); dispatcher == nil {
+					writeAdminError(w, http.StatusNotFound, fmt.Sprintf("event forwarding is not enabled for environment %q", envName))
+					return
+				}
+				break
+			}
+		}
+		if dispatcher == nil {
+			writeAdminError(w, http.StatusNotFound, fmt.Sprintf("unknown environment %q", envName))
+			return
+		}
+
+		switch req.Method {
+		case "POST":
+			duration := events.DefaultEventCaptureDuration
+			if value := req.URL.Query().Get("duration"); value != "" {
+				d, err := time.ParseDuration(value)
+				if err != nil || d <= 0 || d > events.MaxEventCaptureDuration {
+					writeAdminError(w, http.StatusBadRequest,
+						fmt.Sprintf("duration must be a positive duration no longer than %s", events.MaxEventCaptureDuration))
+					return
+				}
+				duration = d
+			}
+			maxBytes := events.DefaultEventCaptureMaxBytes
+			if value := req.URL.Query().Get("maxBytes"); value != "" {
+				n, err := strconv.Atoi(value)
+				if err != nil || n <= 0 || n > events.MaxEventCaptureMaxBytes {
+					writeAdminError(w, http.StatusBadRequest,
+						fmt.Sprintf("maxBytes must be a positive number no greater than %d", events.MaxEventCaptureMaxBytes))
+					return
+				}
+				maxBytes = n
+			}
+			dispatcher.StartCapture(duration, maxBytes)
+		case "DELETE":
+			dispatcher.StopCapture()
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		bundle := dispatcher.GetCapture()
+		bundle.Environment = envName
+		data, _ := json.Marshal(bundle)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(data)
+	})
+}
+
+func writeAdminError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(util.ErrorJSONMsg(message))
+}
@@ -0,0 +1,164 @@
+package relay
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	c "github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
+	"github.com/launchdarkly/ld-relay/v7/internal/events"
+	"github.com/launchdarkly/ld-relay/v7/internal/sdks"
+	st "github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAdminKey = "admin-key"
+
+func TestAdminConfigEndpoint(t *testing.T) {
+	var config c.Config
+	config.Main.AdminKey = testAdminKey
+	config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		t.Run("success", func(t *testing.T) {
+			r, _ := http.NewRequest("GET", "http://localhost/admin/config", nil)
+			r.Header.Set("Authorization", testAdminKey)
+			result, body := st.DoRequest(r, p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			assert.Equal(t, "text/plain; charset=utf-8", result.Header.Get("Content-Type"))
+			assert.Contains(t, string(body), "[Environment \""+st.EnvMain.Name+"\"]\n")
+			assert.Contains(t, string(body), "SDKKey = "+sdks.ObscureKey(string(st.EnvMain.Config.SDKKey))+"\n")
+			assert.Contains(t, string(body), "AdminKey = ********\n")
+			assert.NotContains(t, string(body), testAdminKey)
+		})
+
+		t.Run("wrong key", func(t *testing.T) {
+			for _, key := range []string{"", "wrong-key", string(st.EnvMain.Config.SDKKey)} {
+				r, _ := http.NewRequest("GET", "http://localhost/admin/config", nil)
+				if key != "" {
+					r.Header.Set("Authorization", key)
+				}
+				result, _ := st.DoRequest(r, p.relay)
+				assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+			}
+		})
+	})
+}
+
+func TestAdminRejectedEventsEndpoint(t *testing.T) {
+	var config c.Config
+	config.Main.AdminKey = testAdminKey
+	config.Environment = st.MakeEnvConfigs(st.EnvMain)
+	config.Events.EnableValidation = true
+
+	relayEventsTest(t, config, func(p relayEventsTestParams) {
+		header := make(http.Header)
+		header.Set("Authorization", string(st.EnvMain.Config.SDKKey))
+		eventData := []byte(`[{"kind":"custom","creationDate":1000},{"kind":"identify","creationDate":1000,"context":{"key":"a"}}]`)
+		result, body := st.DoRequest(st.BuildRequest("POST", "http://localhost/bulk", eventData, header), p.relay)
+		require.Equal(t, http.StatusAccepted, result.StatusCode)
+		assert.JSONEq(t, `{"accepted":1,"rejected":1}`, string(body))
+
+		r, _ := http.NewRequest("GET", "http://localhost/admin/rejected-events", nil)
+		r.Header.Set("Authorization", testAdminKey)
+		result, body = st.DoRequest(r, p.relay)
+		require.Equal(t, http.StatusOK, result.StatusCode)
+		assert.Equal(t, "application/json", result.Header.Get("Content-Type"))
+
+		var rejected map[string][]events.RejectedEvent
+		require.NoError(t, json.Unmarshal(body, &rejected))
+		require.Len(t, rejected[st.EnvMain.Name], 1)
+		e := rejected[st.EnvMain.Name][0]
+		assert.Equal(t, basictypes.ServerSDK, e.SDKKind)
+		assert.Equal(t, "custom event must have a key property of type string", e.Reason)
+		assert.Equal(t, `{"kind":"custom","creationDate":1000}`, e.Data)
+	})
+}
+
+func TestAdminEventCaptureEndpoint(t *testing.T) {
+	var config c.Config
+	config.Main.AdminKey = testAdminKey
+	config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+	relayEventsTest(t, config, func(p relayEventsTestParams) {
+		captureURL := "http://localhost/admin/event-capture/" + url.PathEscape(st.EnvMain.Name)
+		adminRequest := func(method, url string) (*http.Response, []byte) {
+			r, _ := http.NewRequest(method, url, nil)
+			r.Header.Set("Authorization", testAdminKey)
+			return st.DoRequest(r, p.relay)
+		}
+
+		result, body := adminRequest("POST", captureURL+"?duration=1m")
+		require.Equal(t, http.StatusOK, result.StatusCode)
+		var bundle events.EventCaptureBundle
+		require.NoError(t, json.Unmarshal(body, &bundle))
+		assert.True(t, bundle.Active)
+		assert.Equal(t, st.EnvMain.Name, bundle.Environment)
+
+		header := make(http.Header)
+		header.Set("Authorization", string(st.EnvMain.Config.SDKKey))
+		eventData := []byte(`[{"kind":"custom","key":"a","creationDate":1000}]`)
+		result, _ = st.DoRequest(st.BuildRequest("POST", "http://localhost/bulk", eventData, header), p.relay)
+		require.Equal(t, http.StatusAccepted, result.StatusCode)
+
+		result, body = adminRequest("GET", captureURL)
+		require.Equal(t, http.StatusOK, result.StatusCode)
+		assert.Equal(t, "application/json", result.Header.Get("Content-Type"))
+		require.NoError(t, json.Unmarshal(body, &bundle))
+		require.Len(t, bundle.Payloads, 1)
+		assert.Equal(t, basictypes.ServerSDK, bundle.Payloads[0].SDKKind)
+		assert.Equal(t, string(eventData), bundle.Payloads[0].Body)
+		assert.Equal(t, "", bundle.Payloads[0].Headers.Get("Authorization"))
+
+		result, _ = adminRequest("DELETE", captureURL)
+		assert.Equal(t, http.StatusNoContent, result.StatusCode)
+
+		result, body = adminRequest("GET", captureURL)
+		require.Equal(t, http.StatusOK, result.StatusCode)
+		require.NoError(t, json.Unmarshal(body, &bundle))
+		assert.False(t, bundle.Active)
+		assert.Len(t, bundle.Payloads, 0)
+	})
+}
+
+func TestAdminEventCaptureEndpointErrors(t *testing.T) {
+	var config c.Config
+	config.Main.AdminKey = testAdminKey
+	config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+	relayEventsTest(t, config, func(p relayEventsTestParams) {
+		captureURL := "http://localhost/admin/event-capture/" + url.PathEscape(st.EnvMain.Name)
+		for _, tc := range []struct {
+			method, url string
+			status      int
+		}{
+			{"GET", "http://localhost/admin/event-capture/unknown", http.StatusNotFound},
+			{"POST", captureURL + "?duration=forever", http.StatusBadRequest},
+			{"POST", captureURL + "?duration=2h", http.StatusBadRequest},
+			{"POST", captureURL + "?maxBytes=0", http.StatusBadRequest},
+		} {
+			t.Run(tc.method+" "+tc.url, func(t *testing.T) {
+				r, _ := http.NewRequest(tc.method, tc.url, nil)
+				r.Header.Set("Authorization", testAdminKey)
+				result, _ := st.DoRequest(r, p.relay)
+				assert.Equal(t, tc.status, result.StatusCode)
+			})
+		}
+	})
+}
+
+func TestAdminEndpointsAreDisabledWithoutAdminKey(t *testing.T) {
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		r, _ := http.NewRequest("GET", "http://localhost/admin/config", nil)
+		r.Header.Set("Authorization", string(st.EnvMain.Config.SDKKey))
+		result, _ := st.DoRequest(r, p.relay)
+		assert.NotEqual(t, http.StatusOK, result.StatusCode)
+	})
+}
@@ -2,6 +2,7 @@ package relay
 
 import (
 	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/auditlog"
 	"github.com/launchdarkly/ld-relay/v7/internal/envfactory"
 )
 
@@ -29,13 +30,17 @@ func (a *relayAutoConfigActions) AddEnvironment(params envfactory.EnvironmentPar
 	env, _, err := a.r.addEnvironment(params.Identifiers, envConfig, nil)
 	if err != nil {
 		a.r.loggers.Errorf(logMsgAutoConfEnvInitError, params.Identifiers.GetDisplayName(), err)
+		return
 	}
+	defer a.r.environmentsChanged()
+	a.audit(auditlog.ActionAddEnvironment, params, nil)
 
 	if params.ExpiringSDKKey != "" {
 		if foundEnvWithOldKey, _ := a.r.getEnvironment(params.ExpiringSDKKey); foundEnvWithOldKey == nil {
 			env.AddCredential(params.ExpiringSDKKey)
 			env.DeprecateCredential(params.ExpiringSDKKey)
 			a.r.addedEnvironmentCredential(env, params.ExpiringSDKKey) // this updates the index we use for authenticating requests
+			a.audit(auditlog.ActionDeprecateCredential, params, params.ExpiringSDKKey)
 		}
 	}
 }
@@ -47,6 +52,8 @@ func (a *relayAutoConfigActions) UpdateEnvironment(params envfactory.Environment
 		a.AddEnvironment(params)
 		return
 	}
+	defer a.r.environmentsChanged()
+	a.audit(auditlog.ActionUpdateEnvironment, params, nil)
 
 	env.SetIdentifiers(params.Identifiers)
 	env.SetTTL(params.TTL)
@@ -66,19 +73,24 @@ func (a *relayAutoConfigActions) UpdateEnvironment(params envfactory.Environment
 	if params.SDKKey != oldSDKKey {
 		env.AddCredential(params.SDKKey)
 		a.r.addedEnvironmentCredential(env, params.SDKKey) // this updates the index we use for authenticating requests
+		a.audit(auditlog.ActionAddCredential, params, params.SDKKey)
 		if params.ExpiringSDKKey == oldSDKKey {
 			env.DeprecateCredential(oldSDKKey)
+			a.audit(auditlog.ActionDeprecateCredential, params, oldSDKKey)
 		} else {
 			a.r.removingEnvironmentCredential(oldSDKKey)
 			env.RemoveCredential(oldSDKKey)
+			a.audit(auditlog.ActionRemoveCredential, params, oldSDKKey)
 		}
 	}
 
 	if params.MobileKey != oldMobileKey {
 		env.AddCredential(params.MobileKey)
 		a.r.addedEnvironmentCredential(env, params.MobileKey)
+		a.audit(auditlog.ActionAddCredential, params, params.MobileKey)
 		a.r.removingEnvironmentCredential(oldMobileKey)
 		env.RemoveCredential(oldMobileKey)
+		a.audit(auditlog.ActionRemoveCredential, params, oldMobileKey)
 	}
 }
 
@@ -88,7 +100,10 @@ func (a *relayAutoConfigActions) DeleteEnvironment(id config.EnvironmentID) {
 		a.r.loggers.Warnf(logMsgAutoConfDeleteUnknownEnv, id)
 		return
 	}
+	identifiers := env.GetIdentifiers()
 	a.r.removeEnvironment(env)
+	a.r.environmentsChanged()
+	a.r.auditEnvironmentChange(auditlog.ActionDeleteEnvironment, auditlog.SourceAutoConfig, identifiers, id, nil)
 }
 
 func (a *relayAutoConfigActions) ReceivedAllEnvironments() {
@@ -104,4 +119,9 @@ func (a *relayAutoConfigActions) KeyExpired(id config.EnvironmentID, oldKey conf
 	}
 	a.r.removingEnvironmentCredential(oldKey)
 	env.RemoveCredential(oldKey)
+	a.r.auditEnvironmentChange(auditlog.ActionRemoveCredential, auditlog.SourceAutoConfig, env.GetIdentifiers(), id, oldKey)
+}
+
+func (a *relayAutoConfigActions) audit(action auditlog.Action, params envfactory.EnvironmentParams, credential config.SDKCredential) {
+	a.r.auditEnvironmentChange(action, auditlog.SourceAutoConfig, params.Identifiers, params.EnvID, credential)
 }
@@ -0,0 +1,200 @@
+package relay
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/autoconfig"
+	"github.com/launchdarkly/ld-relay/v7/internal/envfactory"
+	"github.com/launchdarkly/ld-relay/v7/internal/relayenv"
+
+	"github.com/launchdarkly/eventsource"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+)
+
+// This file implements the upstream side of Relay tiering: a Relay instance that has
+// Downstream.AutoConfigKey set serves a stream at the same path that LaunchDarkly uses for
+// auto-configuration, so that other Relay instances can be pointed at it (by setting their StreamURI
+// to this Relay's URL, and their AutoConfig.Key to the downstream key) and will then receive the same
+// set of environments that this Relay has, whether those came from static configuration, from
+// auto-configuration, or from a file data source. The downstream Relays then get their flag data from
+// this Relay's /all stream, and can send events to this Relay as well.
+//
+// The stream uses the same "put" message format as LaunchDarkly's auto-configuration stream. Every time
+// the set of environments changes, we send the full set again; the downstream StreamManager compares it
+// to what it already has, so it only sees added, changed, or removed environments.
+
+const (
+	// DownstreamAutoConfigPath is the path of the auto-configuration stream endpoint that is enabled by
+	// setting Downstream.AutoConfigKey. It is the same as LaunchDarkly's endpoint path.
+	DownstreamAutoConfigPath = "/relay_auto_config"
+
+	downstreamAutoConfigChannel = "relay_auto_config"
+
+	logMsgDownstreamEnvWithoutID = "Environment %q has no environment ID, so it cannot be provided to downstream Relay instances"
+)
+
+// downstreamAutoConfigServer publishes this Relay's environments to downstream Relay instances.
+type downstreamAutoConfigServer struct {
+	key         config.AutoConfigKey
+	getEnvs     func() []relayenv.EnvContext
+	server      *eventsource.Server
+	lastReps    map[config.EnvironmentID]envfactory.EnvironmentRep
+	warnedNoID  map[string]bool
+	nextVersion int
+	closeCh     chan struct{}
+	closeOnce   sync.Once
+	lock        sync.Mutex
+	loggers     ldlog.Loggers
+}
+
+func newDownstreamAutoConfigServer(
+	key config.AutoConfigKey,
+	getEnvs func() []relayenv.EnvContext,
+	heartbeatInterval time.Duration,
+	loggers ldlog.Loggers,
+) *downstreamAutoConfigServer {
+	s := &downstreamAutoConfigServer{
+		key:        key,
+		getEnvs:    getEnvs,
+		server:     eventsource.NewServer(),
+		lastReps:   make(map[config.EnvironmentID]envfactory.EnvironmentRep),
+		warnedNoID: make(map[string]bool),
+		// Versions are based on the clock so that they keep increasing even if this Relay restarts while
+		// a downstream Relay stays up; the downstream Relay ignores updates whose version is not higher.
+		nextVersion: int(time.Now().UnixMilli()),
+		closeCh:     make(chan struct{}),
+		loggers:     loggers,
+	}
+	s.server.Gzip = false
+	s.server.ReplayAll = true
+	s.server.Register(downstreamAutoConfigChannel, s)
+	go s.runHeartbeats(heartbeatInterval)
+	return s
+}
+
+// ServeHTTP checks the downstream key and then serves the stream.
+func (s *downstreamAutoConfigServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	authKey := req.Header.Get("Authorization")
+	if authKey == "" || subtle.ConstantTimeCompare([]byte(authKey), []byte(s.key.GetAuthorizationHeaderValue())) != 1 {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	s.server.Handler(downstreamAutoConfigChannel)(w, req)
+}
+
+// Replay implements eventsource.Repository, so that each new downstream connection immediately gets
+// the current set of environments.
+func (s *downstreamAutoConfigServer) Replay(channel, id string) chan eventsource.Event {
+	out := make(chan eventsource.Event, 1)
+	out <- s.makePutEvent()
+	close(out)
+	return out
+}
+
+// environmentsChanged should be called whenever an environment has been added, updated, or removed.
+func (s *downstreamAutoConfigServer) environmentsChanged() {
+	s.server.Publish([]string{downstreamAutoConfigChannel}, s.makePutEvent())
+}
+
+func (s *downstreamAutoConfigServer) close() {
+	s.closeOnce.Do(func() {
+		close(s.closeCh)
+		s.server.Close()
+	})
+}
+
+func (s *downstreamAutoConfigServer) runHeartbeats(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closeCh:
+			return
+		case <-ticker.C:
+			s.server.PublishComment([]string{downstreamAutoConfigChannel}, "")
+		}
+	}
+}
+
+func (s *downstreamAutoConfigServer) makePutEvent() eventsource.Event {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	reps := make(map[config.EnvironmentID]envfactory.EnvironmentRep)
+	for _, env := range s.getEnvs() {
+		rep, ok := s.makeEnvironmentRep(env)
+		if !ok {
+			continue
+		}
+		// Keep the previous version number if nothing has changed, so that the downstream Relay will
+		// recognize the environment as unchanged; otherwise assign a new, higher version.
+		if last, found := s.lastReps[rep.EnvID]; found {
+			rep.Version = last.Version
+			if last == rep {
+				reps[rep.EnvID] = rep
+				continue
+			}
+		}
+		s.nextVersion++
+		rep.Version = s.nextVersion
+		reps[rep.EnvID] = rep
+	}
+	s.lastReps = reps
+
+	data, _ := json.Marshal(autoconfig.PutMessageData{
+		Path: "/",
+		Data: autoconfig.PutContent{Environments: reps},
+	})
+	return downstreamAutoConfigEvent{name: autoconfig.PutEvent, data: string(data)}
+}
+
+// makeEnvironmentRep describes an environment in the auto-configuration format. Deprecated SDK keys
+// are not included, since their expiry times are only known to the component that received them; a
+// downstream Relay only accepts the current SDK key for each environment.
+func (s *downstreamAutoConfigServer) makeEnvironmentRep(env relayenv.EnvContext) (envfactory.EnvironmentRep, bool) {
+	identifiers := env.GetIdentifiers()
+	var rep envfactory.EnvironmentRep
+	for _, c := range env.GetCredentials() {
+		switch c := c.(type) {
+		case config.SDKKey:
+			rep.SDKKey.Value = c
+		case config.MobileKey:
+			rep.MobKey = c
+		case config.EnvironmentID:
+			rep.EnvID = c
+		}
+	}
+	if rep.EnvID == "" || rep.SDKKey.Value == "" {
+		if name := identifiers.GetDisplayName(); !s.warnedNoID[name] {
+			s.warnedNoID[name] = true
+			s.loggers.Warnf(logMsgDownstreamEnvWithoutID, name)
+		}
+		return rep, false
+	}
+	rep.EnvKey = identifiers.EnvKey
+	rep.EnvName = identifiers.EnvName
+	if rep.EnvName == "" {
+		rep.EnvName = identifiers.ConfiguredName
+	}
+	rep.ProjKey = identifiers.ProjKey
+	rep.ProjName = identifiers.ProjName
+	// DefaultTTL is in minutes, so a partial minute is rounded up rather than down; otherwise a TTL of less
+	// than a minute would become no TTL at all in the downstream Relay.
+	rep.DefaultTTL = int((env.GetTTL() + time.Minute - 1) / time.Minute)
+	rep.SecureMode = env.IsSecureMode()
+	return rep, true
+}
+
+type downstreamAutoConfigEvent struct {
+	name string
+	data string
+}
+
+func (e downstreamAutoConfigEvent) Event() string { return e.name }
+func (e downstreamAutoConfigEvent) Id() string    { return "" } //nolint:golint,stylecheck
+func (e downstreamAutoConfigEvent) Data() string  { return e.data }
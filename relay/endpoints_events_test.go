@@ -16,6 +16,7 @@ import (
 	st "github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
 
 	ct "github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	helpers "github.com/launchdarkly/go-test-helpers/v3"
 	m "github.com/launchdarkly/go-test-helpers/v3/matchers"
 
@@ -294,3 +295,57 @@ func TestEndpointsEventProxyJSClient(t *testing.T) {
 		})
 	})
 }
+
+func TestEndpointsEventProxyForEnvironmentWithNoSDKKey(t *testing.T) {
+	env := st.EnvWithAllCredentials
+	mobileKey, envID := env.Config.MobileKey, env.Config.EnvID
+	var config c.Config
+	config.Environment = map[string]*c.EnvConfig{env.Name: {MobileKey: mobileKey, EnvID: envID}}
+	eventData := makeTestFeatureEventPayload("me")
+
+	relayEventsTest(t, config, func(p relayEventsTestParams) {
+		t.Run("mobile events", func(t *testing.T) {
+			header := make(http.Header)
+			header.Set("Authorization", string(mobileKey))
+			header.Set(events.EventSchemaHeader, strconv.Itoa(events.SummaryEventsSchemaVersion))
+			result, _ := st.DoRequest(st.BuildRequest("POST", "http://localhost/mobile/events/bulk", eventData, header), p.relay)
+
+			if assert.Equal(t, http.StatusAccepted, result.StatusCode) {
+				event := p.requirePublishedEvent(t, eventData)
+				assert.Equal(t, "/mobile", event.url)
+				assert.Equal(t, string(mobileKey), event.authKey)
+			}
+		})
+
+		t.Run("client-side events", func(t *testing.T) {
+			header := make(http.Header)
+			header.Set(events.EventSchemaHeader, strconv.Itoa(events.SummaryEventsSchemaVersion))
+			result, _ := st.DoRequest(st.BuildRequest("POST", "http://localhost/events/bulk/"+string(envID), eventData, header), p.relay)
+
+			if assert.Equal(t, http.StatusAccepted, result.StatusCode) {
+				event := p.requirePublishedEvent(t, eventData)
+				assert.Equal(t, "/events/bulk/"+string(envID), event.url)
+			}
+		})
+
+		t.Run("flag evaluations are not available", func(t *testing.T) {
+			header := make(http.Header)
+			header.Set("Authorization", string(mobileKey))
+			result, body := st.DoRequest(st.BuildRequest("REPORT", "http://localhost/msdk/evalx/context", []byte(`{"key":"me"}`), header), p.relay)
+
+			assert.Equal(t, http.StatusServiceUnavailable, result.StatusCode)
+			assert.Contains(t, string(body), "can only be used for forwarding events")
+		})
+
+		t.Run("status", func(t *testing.T) {
+			result, body := st.DoRequest(st.BuildRequest("GET", "http://localhost/status", nil, nil), p.relay)
+
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			status := ldvalue.Parse(body)
+			assert.Equal(t, "healthy", status.GetByKey("status").StringValue())
+			envStatus := status.GetByKey("environments").GetByKey(env.Name)
+			assert.Equal(t, "connected", envStatus.GetByKey("status").StringValue())
+			assert.Equal(t, "OFF", envStatus.GetByKey("connectionStatus").GetByKey("state").StringValue())
+		})
+	})
+}
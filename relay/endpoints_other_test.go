@@ -11,6 +11,7 @@ import (
 	st "github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
 
 	ct "github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
 	m "github.com/launchdarkly/go-test-helpers/v3/matchers"
 
 	"github.com/gorilla/mux"
@@ -57,3 +58,26 @@ func TestEndpointsJSClientGoals(t *testing.T) {
 		})
 	})
 }
+
+func TestEndpointsJSClientGoalsUsesProxyConfig(t *testing.T) {
+	env := st.EnvClientSide
+	envID := env.Config.EnvID
+
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(http.StatusOK))
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		var config c.Config
+		config.Main.BaseURI, _ = ct.NewOptURLAbsoluteFromString(server.URL)
+		config.Environment = st.MakeEnvConfigs(env)
+		config.Proxy.Headers = ct.NewOptStringList([]string{"X-Egress-Tenant: tenant1"})
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			r := st.BuildRequest("GET", fmt.Sprintf("http://localhost/sdk/goals/%s", envID), nil, nil)
+			result, _ := st.DoRequest(r, p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+
+			req := <-requestsCh
+			assert.Equal(t, "/sdk/goals/"+string(envID), req.Request.URL.Path)
+			assert.Equal(t, "tenant1", req.Request.Header.Get("X-Egress-Tenant"))
+		})
+	})
+}
@@ -64,7 +64,13 @@ func statusHandler(relay *Relay) http.Handler {
 			}
 
 			client := clientCtx.GetClient()
-			if client == nil {
+			if client == nil && relayenv.IsEventsOnly(clientCtx) {
+				// An environment that is only used for forwarding events never has flag data, so it is
+				// not considered disconnected.
+				status.Status = statusEnvConnected
+				status.ConnectionStatus.State = interfaces.DataSourceStateOff
+				status.ConnectionStatus.StateSince = ldtime.UnixMillisFromTime(clientCtx.GetCreationTime())
+			} else if client == nil {
 				status.Status = statusEnvDisconnected
 				status.ConnectionStatus.State = interfaces.DataSourceStateInitializing
 				status.ConnectionStatus.StateSince = ldtime.UnixMillisFromTime(clientCtx.GetCreationTime())
@@ -6,6 +6,7 @@ import (
 	"github.com/launchdarkly/ld-relay/v7/internal/envfactory"
 
 	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/auditlog"
 	"github.com/launchdarkly/ld-relay/v7/internal/filedata"
 
 	ld "github.com/launchdarkly/go-server-sdk/v6"
@@ -52,6 +53,8 @@ func (a *relayFileDataActions) AddEnvironment(ae filedata.ArchiveEnvironment) {
 		a.r.loggers.Errorf(logMsgAutoConfEnvInitError, ae.Params.Identifiers.GetDisplayName(), err)
 		return
 	}
+	defer a.r.environmentsChanged()
+	a.r.auditEnvironmentChange(auditlog.ActionAddEnvironment, auditlog.SourceFileData, ae.Params.Identifiers, ae.Params.EnvID, nil)
 	select {
 	case updates := <-updatesCh:
 		if a.envUpdates == nil {
@@ -80,6 +83,8 @@ func (a *relayFileDataActions) UpdateEnvironment(ae filedata.ArchiveEnvironment)
 	env.SetIdentifiers(ae.Params.Identifiers)
 	env.SetTTL(ae.Params.TTL)
 	env.SetSecureMode(ae.Params.SecureMode)
+	a.r.environmentsChanged()
+	a.r.auditEnvironmentChange(auditlog.ActionUpdateEnvironment, auditlog.SourceFileData, ae.Params.Identifiers, ae.Params.EnvID, nil)
 
 	// SDKData will be non-nil only if the flag/segment data for the environment has actually changed.
 	if ae.SDKData != nil {
@@ -94,8 +99,11 @@ func (a *relayFileDataActions) EnvironmentFailed(id config.EnvironmentID, err er
 func (a *relayFileDataActions) DeleteEnvironment(id config.EnvironmentID) {
 	env, _ := a.r.getEnvironment(id)
 	if env != nil {
+		identifiers := env.GetIdentifiers()
 		a.r.removeEnvironment(env)
 		delete(a.envUpdates, id)
+		a.r.environmentsChanged()
+		a.r.auditEnvironmentChange(auditlog.ActionDeleteEnvironment, auditlog.SourceFileData, identifiers, id, nil)
 	}
 }
 
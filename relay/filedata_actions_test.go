@@ -1,13 +1,18 @@
 package relay
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"sort"
 	"testing"
 	"time"
 
 	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
+	"github.com/launchdarkly/ld-relay/v7/internal/events"
 	"github.com/launchdarkly/ld-relay/v7/internal/filedata"
 	"github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
 	"github.com/launchdarkly/ld-relay/v7/internal/sharedtest/testclient"
@@ -205,3 +210,38 @@ func TestOfflineModeEventsAreAcceptedAndDiscardedIfSendEventsIsTrue(t *testing.T
 		})
 	})
 }
+
+func TestOfflineModeEventsAreWrittenToEventSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.json")
+	var allConfig config.Config
+	allConfig.EventSink = map[string]*config.EventSinkConfig{
+		"archive": {Type: config.EventSinkTypeFile, File: path},
+	}
+
+	offlineModeTest(t, allConfig, func(p offlineModeTestParams) {
+		p.updateHandler.AddEnvironment(testFileDataEnv1)
+		_ = p.awaitClient()
+
+		rr := httptest.NewRecorder()
+		headers := make(http.Header)
+		headers.Add("Content-Type", "application/json")
+		headers.Add("Authorization", string(testFileDataEnv1.Params.SDKKey))
+		body := `[{"kind":"identify","creationDate":1000,"key":"userkey","user":{"key":"userkey"}}]`
+		req := sharedtest.BuildRequest("POST", "http://localhost/bulk", []byte(body), headers)
+		p.relay.Handler.ServeHTTP(rr, req)
+		require.Equal(t, 202, rr.Result().StatusCode)
+
+		// the sink writes events in the background
+		var data []byte
+		require.Eventually(t, func() bool {
+			data, _ = os.ReadFile(path)
+			return len(data) > 0
+		}, time.Second, time.Millisecond*10)
+		var record events.EventSinkRecord
+		require.NoError(t, json.Unmarshal(data, &record))
+		assert.Equal(t, testFileDataEnv1.Params.EnvID, record.EnvID)
+		assert.Equal(t, basictypes.ServerSDK, record.SDKKind)
+		assert.JSONEq(t, `{"kind":"identify","creationDate":1000,"key":"userkey","user":{"key":"userkey"}}`,
+			string(record.Event))
+	})
+}
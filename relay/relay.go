@@ -6,17 +6,21 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gregjones/httpcache"
 	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/auditlog"
 	"github.com/launchdarkly/ld-relay/v7/internal/autoconfig"
 	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
+	"github.com/launchdarkly/ld-relay/v7/internal/events"
 	"github.com/launchdarkly/ld-relay/v7/internal/filedata"
 	"github.com/launchdarkly/ld-relay/v7/internal/httpconfig"
 	"github.com/launchdarkly/ld-relay/v7/internal/metrics"
+	"github.com/launchdarkly/ld-relay/v7/internal/middleware"
 	"github.com/launchdarkly/ld-relay/v7/internal/relayenv"
 	"github.com/launchdarkly/ld-relay/v7/internal/sdks"
 	"github.com/launchdarkly/ld-relay/v7/internal/streams"
@@ -36,8 +40,8 @@ var (
 // It can also be referenced externally in order to embed Relay Proxy functionality into a customized
 // application; see docs/in-app.md.
 //
-// This type deliberately exports no methods other than ServeHTTP and Close. Everything else is an
-// implementation detail which is subject to change.
+// This type deliberately exports no methods other than ServeHTTP, ListenerHandler, and Close. Everything
+// else is an implementation detail which is subject to change.
 type Relay struct {
 	http.Handler
 	allEnvironments               []relayenv.EnvContext
@@ -51,12 +55,18 @@ type Relay struct {
 	clientInitCh                  chan relayenv.EnvContext
 	fullyConfigured               bool
 	clientSideSDKBaseURL          url.URL
+	clientSideProxyTransport      http.RoundTripper
 	version                       string
 	userAgent                     string
 	envLogNameMode                relayenv.LogNameMode
 	closed                        bool
 	lock                          sync.RWMutex
 	autoConfigStream              *autoconfig.StreamManager
+	downstreamAutoConfig          *downstreamAutoConfigServer
+	auditLog                      *auditlog.Log
+	eventSinks                    []events.EventSink
+	secretRefresher               *secretRefresher
+	listenerHandlers              map[string]http.Handler
 	archiveManager                filedata.ArchiveManagerInterface
 	config                        config.Config
 	loggers                       ldlog.Loggers
@@ -72,6 +82,7 @@ type relayInternalOptions struct {
 	loggers               ldlog.Loggers
 	clientFactory         sdks.ClientFactoryFunc
 	archiveManagerFactory func(string, filedata.UpdateHandler, ldlog.Loggers) (filedata.ArchiveManagerInterface, error)
+	secretRefreshInterval time.Duration
 }
 
 // NewRelay creates a new Relay given a configuration and a method to create a client.
@@ -134,21 +145,56 @@ func newRelayInternal(c config.Config, options relayInternalOptions) (*Relay, er
 	}
 	thingsToCleanUp.AddFunc(metricsManager.Close)
 
-	clientInitCh := make(chan relayenv.EnvContext, len(c.Environment))
+	auditLog, err := auditlog.NewLog(c.AuditLog, loggers)
+	if err != nil {
+		return nil, errNewAuditLogFailed(err)
+	}
+	if auditLog != nil {
+		thingsToCleanUp.AddCloser(auditLog)
+	}
 
-	maxConnTime := c.Main.MaxClientConnectionTime.GetOrElse(0)
+	sinkNames := make([]string, 0, len(c.EventSink))
+	for name, sinkConfig := range c.EventSink {
+		if sinkConfig != nil {
+			sinkNames = append(sinkNames, name)
+		}
+	}
+	sort.Strings(sinkNames)
+	eventSinks := make([]events.EventSink, 0, len(sinkNames))
+	for _, name := range sinkNames {
+		sink, err := events.NewEventSink(name, *c.EventSink[name], loggers)
+		if err != nil {
+			return nil, errNewEventSinkFailed(name, err)
+		}
+		thingsToCleanUp.AddCloser(sink)
+		eventSinks = append(eventSinks, sink)
+	}
+
+	clientInitCh := make(chan relayenv.EnvContext, len(c.Environment))
 
 	userAgent := "LDRelay/" + version.Version
 
+	// This is used for requests that are proxied directly to LaunchDarkly (see JSClientContext.Proxy)
+	// rather than made by an SDK client, so it has no credential of its own.
+	clientSideHTTPConfig, err := httpconfig.NewHTTPConfig(c.Proxy, nil, userAgent, loggers)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stream connection time limits are not set on the stream providers, because they can vary by
+	// environment; each EnvContext applies its own limits to the stream handlers.
 	r := &Relay{
 		envsByCredential:              make(map[config.SDKCredential]relayenv.EnvContext),
-		serverSideStreamProvider:      streams.NewStreamProvider(basictypes.ServerSideStream, maxConnTime),
-		serverSideFlagsStreamProvider: streams.NewStreamProvider(basictypes.ServerSideFlagsOnlyStream, maxConnTime),
-		mobileStreamProvider:          streams.NewStreamProvider(basictypes.MobilePingStream, maxConnTime),
-		jsClientStreamProvider:        streams.NewStreamProvider(basictypes.JSClientPingStream, maxConnTime),
+		serverSideStreamProvider:      streams.NewStreamProvider(basictypes.ServerSideStream, 0),
+		serverSideFlagsStreamProvider: streams.NewStreamProvider(basictypes.ServerSideFlagsOnlyStream, 0),
+		mobileStreamProvider:          streams.NewStreamProvider(basictypes.MobilePingStream, 0),
+		jsClientStreamProvider:        streams.NewStreamProvider(basictypes.JSClientPingStream, 0),
 		metricsManager:                metricsManager,
+		auditLog:                      auditLog,
+		eventSinks:                    eventSinks,
 		clientFactory:                 clientFactory,
 		clientInitCh:                  clientInitCh,
+		clientSideProxyTransport:      clientSideHTTPConfig.ForDestination(httpconfig.DestinationBase).Transport(),
 		version:                       version.Version,
 		userAgent:                     userAgent,
 		envLogNameMode:                logNameMode,
@@ -160,6 +206,21 @@ func newRelayInternal(c config.Config, options relayInternalOptions) (*Relay, er
 
 	r.clientSideSDKBaseURL = *c.Main.ClientSideBaseURI.Get() // config.ValidateConfig has ensured that this has a value
 
+	if c.Downstream.AutoConfigKey != "" {
+		// A downstream Relay subscribes to this stream the way a server-side SDK would, so it uses the same
+		// heartbeat interval as the server-side streams.
+		heartbeatInterval := c.Main.ServerSideHeartbeatInterval
+		if !heartbeatInterval.IsDefined() {
+			heartbeatInterval = c.Main.HeartbeatInterval
+		}
+		r.downstreamAutoConfig = newDownstreamAutoConfigServer(
+			c.Downstream.AutoConfigKey,
+			r.getAllEnvironments,
+			heartbeatInterval.GetOrElse(config.DefaultHeartbeatInterval),
+			loggers,
+		)
+	}
+
 	for envName, envConfig := range c.Environment {
 		env, resultCh, err := r.addEnvironment(relayenv.EnvIdentifiers{ConfiguredName: envName}, *envConfig, nil)
 		if err != nil {
@@ -190,7 +251,7 @@ func newRelayInternal(c config.Config, options relayInternalOptions) (*Relay, er
 			c.AutoConfig.Key,
 			c.Main.StreamURI.String(),
 			&relayAutoConfigActions{r},
-			httpConfig,
+			httpConfig.ForDestination(httpconfig.DestinationAutoConfig),
 			0,
 			loggers,
 		)
@@ -225,6 +286,8 @@ func newRelayInternal(c config.Config, options relayInternalOptions) (*Relay, er
 		thingsToCleanUp.AddCloser(archiveManager)
 	}
 
+	r.secretRefresher = newSecretRefresher(r, c, options.secretRefreshInterval, loggers)
+
 	if c.Main.ExitAlways {
 		options.loggers.Info("Running in one-shot mode - will exit immediately after initializing environments")
 		// Just wait until all clients have either started or failed, then exit without bothering
@@ -235,7 +298,14 @@ func newRelayInternal(c config.Config, options relayInternalOptions) (*Relay, er
 		}
 	}
 
-	r.Handler = r.makeRouter()
+	requestLimits := middleware.RequestLimits(c.Main.ReadTimeout.GetOrElse(0), c.Main.WriteTimeout.GetOrElse(0))
+	r.Handler = requestLimits(r.makeRouter())
+	r.listenerHandlers = make(map[string]http.Handler, len(c.Listener))
+	for name, listenerConfig := range c.Listener {
+		if listenerConfig != nil {
+			r.listenerHandlers[name] = requestLimits(r.makeRouterForGroups(listenerConfig.Routes.Values()))
+		}
+	}
 	thingsToCleanUp.Clear() // we succeeded, don't close anything
 	return r, nil
 }
@@ -246,6 +316,13 @@ func defaultArchiveManagerFactory(filePath string, handler filedata.UpdateHandle
 	return am, err
 }
 
+// ListenerHandler returns the HTTP handler for an additional listener that was defined in the
+// configuration with a [Listener "name"] section. It serves only the route groups that were specified
+// for that listener. If there is no such listener, it returns nil.
+func (r *Relay) ListenerHandler(name string) http.Handler {
+	return r.listenerHandlers[name]
+}
+
 // Close shuts down components created by the Relay Proxy.
 //
 // This includes dropping all connections to the LaunchDarkly services and to SDK clients,
@@ -268,12 +345,18 @@ func (r *Relay) Close() error {
 
 	r.metricsManager.Close()
 
+	if r.secretRefresher != nil {
+		r.secretRefresher.close()
+	}
 	if r.autoConfigStream != nil {
 		r.autoConfigStream.Close()
 	}
 	if r.archiveManager != nil {
 		_ = r.archiveManager.Close()
 	}
+	if r.downstreamAutoConfig != nil {
+		r.downstreamAutoConfig.close()
+	}
 
 	for _, env := range envs {
 		if err := env.Close(); err != nil {
@@ -285,6 +368,14 @@ func (r *Relay) Close() error {
 		sp.Close()
 	}
 
+	if r.auditLog != nil {
+		_ = r.auditLog.Close()
+	}
+
+	for _, sink := range r.eventSinks {
+		_ = sink.Close()
+	}
+
 	return nil
 }
 
@@ -349,6 +440,7 @@ func (r *Relay) addEnvironment(
 		jsClientContext.Headers = envConfig.AllowedHeader.Values()
 
 		cachingTransport := httpcache.NewMemoryCacheTransport()
+		cachingTransport.Transport = r.clientSideProxyTransport
 		jsClientContext.Proxy = &httputil.ReverseProxy{
 			Director: func(req *http.Request) {
 				url := req.URL
@@ -386,6 +478,8 @@ func (r *Relay) addEnvironment(
 		StreamProviders:  r.allStreamProviders(),
 		JSClientContext:  jsClientContext,
 		MetricsManager:   r.metricsManager,
+		AuditLog:         r.auditLog,
+		EventSinks:       r.eventSinks,
 		UserAgent:        r.userAgent,
 		LogNameMode:      r.envLogNameMode,
 		Loggers:          r.loggers,
@@ -395,7 +489,9 @@ func (r *Relay) addEnvironment(
 	}
 
 	r.allEnvironments = append(r.allEnvironments, clientContext)
-	r.envsByCredential[envConfig.SDKKey] = clientContext
+	if envConfig.SDKKey != "" {
+		r.envsByCredential[envConfig.SDKKey] = clientContext
+	}
 	if envConfig.MobileKey != "" {
 		r.envsByCredential[envConfig.MobileKey] = clientContext
 	}
@@ -468,6 +564,34 @@ func (r *Relay) removingEnvironmentCredential(oldCredential config.SDKCredential
 	r.lock.Unlock()
 }
 
+// environmentsChanged notifies downstream Relay instances, if any, that an environment has been added,
+// updated, or removed. This must not be called while holding the Relay's lock.
+func (r *Relay) environmentsChanged() {
+	if r.downstreamAutoConfig != nil {
+		r.downstreamAutoConfig.environmentsChanged()
+	}
+}
+
+// auditEnvironmentChange records a change to an environment's configuration in the audit log, if the
+// audit log is enabled. The credential parameter is only used for credential actions, and can be nil.
+func (r *Relay) auditEnvironmentChange(
+	action auditlog.Action,
+	source auditlog.Source,
+	identifiers relayenv.EnvIdentifiers,
+	envID config.EnvironmentID,
+	credential config.SDKCredential,
+) {
+	if r.auditLog == nil {
+		return
+	}
+	var credentialDesc string
+	if credential != nil {
+		credentialDesc = sdks.ObscureKey(credential.GetAuthorizationHeaderValue())
+	}
+	r.auditLog.EnvironmentChanged(action, auditlog.Environment{Name: identifiers.GetDisplayName(), ID: envID},
+		source, credentialDesc)
+}
+
 // waitForAllClients blocks until all environments that were in the initial configuration have
 // reported back as either successfully connected or failed, or until the specified timeout (if the
 // timeout is non-zero).
@@ -0,0 +1,73 @@
+package relay
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/internal/auditlog"
+	"github.com/launchdarkly/ld-relay/v7/internal/sdks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAuditLog(t *testing.T, path string) []auditlog.Entry {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var ret []auditlog.Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e auditlog.Entry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		ret = append(ret, e)
+	}
+	return ret
+}
+
+func TestAutoConfigChangesAreWrittenToAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	config := testAutoConfDefaultConfig
+	config.AuditLog.File = path
+
+	initialEvent := makeAutoConfPutEvent(testAutoConfEnv1)
+	autoConfTest(t, config, &initialEvent, func(p autoConfTestParams) {
+		client1 := p.awaitClient()
+		env := p.awaitEnvironment(testAutoConfEnv1.id)
+
+		modified := makeEnvWithModifiedSDKKey(testAutoConfEnv1)
+		p.stream.Enqueue(makeAutoConfPatchEvent(modified))
+		_ = p.awaitClient()
+		client1.AwaitClose(t, time.Second)
+		p.awaitCredentialsUpdated(env, modified.params())
+
+		p.stream.Enqueue(makeAutoConfDeleteEvent(testAutoConfEnv1.id, modified.version+1))
+		p.shouldNotHaveEnvironment(testAutoConfEnv1.id, time.Second)
+
+		var actions []auditlog.Action
+		require.Eventually(t, func() bool {
+			actions = nil
+			for _, e := range readAuditLog(t, path) {
+				assert.Equal(t, auditlog.SourceAutoConfig, e.Source)
+				assert.Equal(t, testAutoConfEnv1.id, e.EnvID)
+				actions = append(actions, e.Action)
+			}
+			return len(actions) == 5
+		}, time.Second, time.Millisecond*10)
+		assert.Equal(t, []auditlog.Action{
+			auditlog.ActionAddEnvironment,
+			auditlog.ActionUpdateEnvironment,
+			auditlog.ActionAddCredential,
+			auditlog.ActionRemoveCredential,
+			auditlog.ActionDeleteEnvironment,
+		}, actions)
+
+		entries := readAuditLog(t, path)
+		assert.Equal(t, sdks.ObscureKey(string(modified.sdkKey)), entries[2].Credential)
+		assert.Equal(t, sdks.ObscureKey(string(testAutoConfEnv1.sdkKey)), entries[3].Credential)
+	})
+}
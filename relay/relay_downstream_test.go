@@ -0,0 +1,195 @@
+package relay
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	c "github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/autoconfig"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
+	st "github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
+
+	"github.com/launchdarkly/eventsource"
+	ct "github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
+	helpers "github.com/launchdarkly/go-test-helpers/v3"
+	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// These tests chain two Relay instances together: an upstream Relay that uses static environments
+// and has Downstream.AutoConfigKey set, and a downstream Relay that gets its environments and data
+// from the upstream Relay instead of from LaunchDarkly.
+
+const testDownstreamKey = c.AutoConfigKey("downstream-key")
+
+func withUpstreamRelay(t *testing.T, action func(upstream relayTestParams, upstreamURL string)) {
+	config := c.Config{
+		Environment: st.MakeEnvConfigs(st.EnvWithAllCredentials, st.EnvMain),
+		Downstream:  c.DownstreamConfig{AutoConfigKey: testDownstreamKey},
+	}
+	withStartedRelay(t, config, func(p relayTestParams) {
+		httphelpers.WithServer(p.relay, func(server *httptest.Server) {
+			action(p, server.URL)
+		})
+	})
+}
+
+func withDownstreamRelay(t *testing.T, upstreamURL string, action func(downstream relayTestParams)) {
+	upstreamURI, _ := ct.NewOptURLAbsoluteFromString(upstreamURL)
+	config := c.Config{
+		Main:       c.MainConfig{BaseURI: upstreamURI, StreamURI: upstreamURI},
+		AutoConfig: c.AutoConfigConfig{Key: testDownstreamKey},
+		Events:     c.EventsConfig{EventsURI: upstreamURI},
+	}
+	behavior := relayTestBehavior{useRealSDKClient: true, skipWaitForEnvironments: true}
+	withStartedRelayCustom(t, config, behavior, action)
+}
+
+func TestDownstreamRelayReceivesEnvironmentsAndDataFromUpstreamRelay(t *testing.T) {
+	testEnv := st.EnvWithAllCredentials
+	withUpstreamRelay(t, func(upstream relayTestParams, upstreamURL string) {
+		withDownstreamRelay(t, upstreamURL, func(downstream relayTestParams) {
+			h := relayTestHelper{t: t, relay: downstream.relay}
+			env := h.awaitEnvironment(testEnv.Config.EnvID)
+
+			assert.Equal(t, testEnv.Name, env.GetIdentifiers().EnvName)
+			assert.ElementsMatch(t,
+				[]c.SDKCredential{testEnv.Config.SDKKey, testEnv.Config.MobileKey, testEnv.Config.EnvID},
+				env.GetCredentials())
+
+			require.Eventually(t, func() bool {
+				flags, _ := env.GetStore().GetAll(ldstoreimpl.Features())
+				return len(flags) == len(st.AllData[0].Items)
+			}, time.Second*5, time.Millisecond*10, "timed out waiting for flag data from upstream Relay")
+
+			// The upstream environment without an environment ID can't be provided to downstream Relays
+			assert.Len(t, downstream.relay.getAllEnvironments(), 1)
+			upstream.mockLog.AssertMessageMatch(t, true, ldlog.Warn, "has no environment ID")
+
+			// The downstream Relay serves the environment's streams to SDKs
+			httphelpers.WithServer(downstream.relay, func(server *httptest.Server) {
+				req := st.MakeSDKStreamEndpointRequest(server.URL, basictypes.ServerSideStream, testEnv, "", 0)
+				stream, err := eventsource.SubscribeWithRequest("", req)
+				require.NoError(t, err)
+				defer stream.Close()
+				event := helpers.RequireValue(t, stream.Events, time.Second*5, "timed out waiting for stream event")
+				assert.Equal(t, "put", event.Event())
+			})
+		})
+	})
+}
+
+func TestDownstreamRelayRemovesEnvironmentRemovedFromUpstreamRelay(t *testing.T) {
+	testEnv := st.EnvWithAllCredentials
+	withUpstreamRelay(t, func(upstream relayTestParams, upstreamURL string) {
+		withDownstreamRelay(t, upstreamURL, func(downstream relayTestParams) {
+			h := relayTestHelper{t: t, relay: downstream.relay}
+			_ = h.awaitEnvironment(testEnv.Config.EnvID)
+
+			upstreamEnv, _ := upstream.relay.getEnvironment(testEnv.Config.EnvID)
+			require.NotNil(t, upstreamEnv)
+			upstream.relay.removeEnvironment(upstreamEnv)
+			upstream.relay.environmentsChanged()
+
+			h.shouldNotHaveEnvironment(testEnv.Config.EnvID, time.Second*5)
+		})
+	})
+}
+
+func TestDownstreamAutoConfigEndpointRequiresKey(t *testing.T) {
+	withUpstreamRelay(t, func(upstream relayTestParams, upstreamURL string) {
+		for _, key := range []string{"", "wrong-key"} {
+			req, _ := http.NewRequest("GET", upstreamURL+DownstreamAutoConfigPath, nil)
+			if key != "" {
+				req.Header.Set("Authorization", key)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		}
+	})
+}
+
+func TestDownstreamAutoConfigEndpointUsesServerSideHeartbeatInterval(t *testing.T) {
+	config := c.Config{
+		Main:        c.MainConfig{ServerSideHeartbeatInterval: ct.NewOptDuration(time.Millisecond * 10)},
+		Environment: st.MakeEnvConfigs(st.EnvWithAllCredentials),
+		Downstream:  c.DownstreamConfig{AutoConfigKey: testDownstreamKey},
+	}
+	withStartedRelay(t, config, func(p relayTestParams) {
+		httphelpers.WithServer(p.relay, func(server *httptest.Server) {
+			req, _ := http.NewRequest("GET", server.URL+DownstreamAutoConfigPath, nil)
+			req.Header.Set("Authorization", string(testDownstreamKey))
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			heartbeatCh := make(chan struct{}, 1)
+			go func() {
+				scanner := bufio.NewScanner(resp.Body)
+				for scanner.Scan() {
+					if scanner.Text() == ":" {
+						heartbeatCh <- struct{}{}
+						return
+					}
+				}
+			}()
+			helpers.RequireValue(t, heartbeatCh, time.Second, "did not receive heartbeat")
+		})
+	})
+}
+
+func TestDownstreamAutoConfigEndpointKeepsVersionsOfUnchangedEnvironments(t *testing.T) {
+	testEnv := st.EnvWithAllCredentials
+	withUpstreamRelay(t, func(upstream relayTestParams, upstreamURL string) {
+		getPut := func() autoconfig.PutMessageData {
+			var data autoconfig.PutMessageData
+			require.NoError(t, json.Unmarshal([]byte(upstream.relay.downstreamAutoConfig.makePutEvent().Data()), &data))
+			return data
+		}
+		first := getPut()
+		rep := first.Data.Environments[testEnv.Config.EnvID]
+		assert.Equal(t, testEnv.Config.SDKKey, rep.SDKKey.Value)
+		assert.Equal(t, testEnv.Config.MobileKey, rep.MobKey)
+
+		second := getPut()
+		assert.Equal(t, first, second)
+
+		env, _ := upstream.relay.getEnvironment(testEnv.Config.EnvID)
+		env.SetSecureMode(true)
+		third := getPut()
+		newRep := third.Data.Environments[testEnv.Config.EnvID]
+		assert.True(t, newRep.SecureMode)
+		assert.Greater(t, newRep.Version, rep.Version)
+	})
+}
+
+func TestDownstreamAutoConfigEndpointRoundsTTLUpToWholeMinutes(t *testing.T) {
+	testEnv := st.EnvWithAllCredentials
+	withUpstreamRelay(t, func(upstream relayTestParams, upstreamURL string) {
+		env, _ := upstream.relay.getEnvironment(testEnv.Config.EnvID)
+		require.NotNil(t, env)
+		for ttl, expectedMinutes := range map[time.Duration]int{
+			0:                0,
+			time.Second * 30: 1,
+			time.Minute:      1,
+			time.Second * 90: 2,
+			time.Minute * 10: 10,
+		} {
+			env.SetTTL(ttl)
+			var data autoconfig.PutMessageData
+			require.NoError(t, json.Unmarshal([]byte(upstream.relay.downstreamAutoConfig.makePutEvent().Data()), &data))
+			assert.Equal(t, expectedMinutes, data.Data.Environments[testEnv.Config.EnvID].DefaultTTL, "TTL %s", ttl)
+		}
+	})
+}
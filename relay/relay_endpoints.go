@@ -4,6 +4,7 @@ import (
 	"crypto/sha1" //nolint:gosec // we're not using SHA1 for encryption, just for generating an insecure hash
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -43,7 +44,13 @@ func getClientSideContextProperties(
 			_, _ = w.Write([]byte("Content-Type must be application/json."))
 			return ldContext, false
 		}
-		body, _ := io.ReadAll(req.Body)
+		body, err := io.ReadAll(req.Body)
+		if errors.Is(err, util.ErrRequestBodyTooLarge) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			_, _ = w.Write(util.ErrorJSONMsg(err.Error()))
+			return ldContext, false
+		}
 		contextDecodeErr = json.Unmarshal(body, &ldContext)
 	} else {
 		base64Context := mux.Vars(req)["context"] // this assumes we have used {context} as a placeholder in the route
@@ -146,16 +153,16 @@ func pollSegmentHandler(w http.ResponseWriter, req *http.Request) {
 // events.ld.com/events/diagnostic/{envId} (JS)
 func bulkEventHandler(sdkKind basictypes.SDKKind, eventsKind ldevents.EventDataKind, offline bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		if offline {
+		clientCtx := middleware.GetEnvContextInfo(req.Context())
+		dispatcher := clientCtx.Env.GetEventDispatcher()
+		if dispatcher == nil && offline {
+			// In offline mode, events are only kept if there is an event sink for this environment
 			w.WriteHeader(http.StatusAccepted)
 			if req.Body != nil {
 				_ = req.Body.Close()
 			}
 			return
 		}
-
-		clientCtx := middleware.GetEnvContextInfo(req.Context())
-		dispatcher := clientCtx.Env.GetEventDispatcher()
 		if dispatcher == nil {
 			w.WriteHeader(http.StatusServiceUnavailable)
 			_, _ = w.Write(util.ErrorJSONMsg("Event proxy is not enabled for this environment"))
@@ -18,3 +18,11 @@ func errNewClientContextFailed(envName string, err error) error {
 func errNewMetricsManagerFailed(err error) error {
 	return fmt.Errorf("unable to create metrics manager: %w", err)
 }
+
+func errNewAuditLogFailed(err error) error {
+	return fmt.Errorf("unable to create audit log: %w", err)
+}
+
+func errNewEventSinkFailed(name string, err error) error {
+	return fmt.Errorf("unable to create event sink %q: %w", name, err)
+}
@@ -0,0 +1,70 @@
+package relay
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	c "github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/application"
+	"github.com/launchdarkly/ld-relay/v7/internal/basictypes"
+	st "github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
+
+	"github.com/launchdarkly/eventsource"
+	helpers "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// These tests verify that stream endpoints work over HTTP/2 without TLS, as they would behind a service
+// mesh proxy: each event must be flushed to the client as soon as it is written, and many streams can
+// share one connection.
+
+func TestStreamsOverH2C(t *testing.T) {
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(st.EnvMain, st.EnvMobile)
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		var connCount int32
+		server := httptest.NewUnstartedServer(application.H2CHandler(p.relay, application.HTTPServerOptions{}))
+		server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				atomic.AddInt32(&connCount, 1)
+			}
+		}
+		server.Start()
+		defer server.Close()
+		client := st.MakeH2CClient()
+
+		requests := []*http.Request{
+			st.MakeSDKStreamEndpointRequest(server.URL, basictypes.ServerSideStream, st.EnvMain, st.SimpleUserJSON, 0),
+			st.MakeSDKStreamEndpointRequest(server.URL, basictypes.ServerSideFlagsOnlyStream, st.EnvMain, st.SimpleUserJSON, 0),
+			st.MakeSDKStreamEndpointRequest(server.URL, basictypes.MobilePingStream, st.EnvMobile, st.SimpleUserJSON, 0),
+		}
+		expectedEvents := []string{"put", "put", "ping"}
+
+		for i, req := range requests {
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, 2, resp.ProtoMajor)
+
+			// The stream stays open, so we'll only see the initial event if it was flushed.
+			eventCh := make(chan eventsource.Event, 1)
+			go func() {
+				if event, err := eventsource.NewDecoder(resp.Body).Decode(); err == nil {
+					eventCh <- event
+				}
+			}()
+			event := helpers.RequireValue(t, eventCh, time.Second*3, "timed out waiting for initial event")
+			assert.Equal(t, expectedEvents[i], event.Event(), "%s %s", req.Method, req.URL.Path)
+		}
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&connCount))
+	})
+}
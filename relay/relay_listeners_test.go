@@ -0,0 +1,112 @@
+package relay
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	c "github.com/launchdarkly/ld-relay/v7/config"
+	st "github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
+
+	ct "github.com/launchdarkly/go-configtypes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeListenerTestRequests() (statusReq, flagsReq, eventsReq *http.Request) {
+	statusReq, _ = http.NewRequest("GET", "http://localhost/status", nil)
+	flagsReq, _ = http.NewRequest("GET", "http://localhost/sdk/flags", nil)
+	flagsReq.Header.Set("Authorization", string(st.EnvMain.Config.SDKKey))
+	// Event forwarding isn't enabled in these tests, so the events endpoint returns a 503 rather than a 404
+	// if the route exists.
+	eventsReq, _ = http.NewRequest("POST", "http://localhost/bulk", bytes.NewBufferString("[]"))
+	eventsReq.Header.Set("Authorization", string(st.EnvMain.Config.SDKKey))
+	eventsReq.Header.Set("Content-Type", "application/json")
+	return
+}
+
+func TestListenerServesOnlyItsRouteGroups(t *testing.T) {
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(st.EnvMain)
+	config.Listener = map[string]*c.ListenerConfig{
+		"status": {
+			Port:   mustOptIntGreaterThanZero(8031),
+			Routes: ct.NewOptStringList([]string{c.RouteGroupStatus}),
+		},
+		"sdk": {
+			Port:   mustOptIntGreaterThanZero(8032),
+			Routes: ct.NewOptStringList([]string{c.RouteGroupServerSide, c.RouteGroupEvents}),
+		},
+	}
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		t.Run("status listener", func(t *testing.T) {
+			handler := p.relay.ListenerHandler("status")
+			require.NotNil(t, handler)
+			statusReq, flagsReq, eventsReq := makeListenerTestRequests()
+
+			result, _ := st.DoRequest(statusReq, handler)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			result, _ = st.DoRequest(flagsReq, handler)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+			result, _ = st.DoRequest(eventsReq, handler)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+		})
+
+		t.Run("SDK listener", func(t *testing.T) {
+			handler := p.relay.ListenerHandler("sdk")
+			require.NotNil(t, handler)
+			statusReq, flagsReq, eventsReq := makeListenerTestRequests()
+
+			result, _ := st.DoRequest(statusReq, handler)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+			result, _ = st.DoRequest(flagsReq, handler)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			result, _ = st.DoRequest(eventsReq, handler)
+			assert.Equal(t, http.StatusServiceUnavailable, result.StatusCode)
+		})
+
+		t.Run("main port still serves all routes", func(t *testing.T) {
+			statusReq, flagsReq, eventsReq := makeListenerTestRequests()
+
+			result, _ := st.DoRequest(statusReq, p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			result, _ = st.DoRequest(flagsReq, p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			result, _ = st.DoRequest(eventsReq, p.relay)
+			assert.Equal(t, http.StatusServiceUnavailable, result.StatusCode)
+		})
+
+		t.Run("unknown listener", func(t *testing.T) {
+			assert.Nil(t, p.relay.ListenerHandler("other"))
+		})
+	})
+}
+
+func TestMainPortServesOnlyConfiguredRouteGroups(t *testing.T) {
+	var config c.Config
+	config.Main.Routes = ct.NewOptStringList([]string{c.RouteGroupServerSide, c.RouteGroupEvents})
+	config.Environment = st.MakeEnvConfigs(st.EnvMain)
+	config.Listener = map[string]*c.ListenerConfig{
+		"internal": {
+			Port:   mustOptIntGreaterThanZero(8031),
+			Routes: ct.NewOptStringList([]string{c.RouteGroupStatus}),
+		},
+	}
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		statusReq, flagsReq, eventsReq := makeListenerTestRequests()
+
+		result, _ := st.DoRequest(statusReq, p.relay)
+		assert.Equal(t, http.StatusNotFound, result.StatusCode)
+		result, _ = st.DoRequest(flagsReq, p.relay)
+		assert.Equal(t, http.StatusOK, result.StatusCode)
+		result, _ = st.DoRequest(eventsReq, p.relay)
+		assert.Equal(t, http.StatusServiceUnavailable, result.StatusCode)
+
+		statusReq, _, _ = makeListenerTestRequests()
+		result, _ = st.DoRequest(statusReq, p.relay.ListenerHandler("internal"))
+		assert.Equal(t, http.StatusOK, result.StatusCode)
+	})
+}
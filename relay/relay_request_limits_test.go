@@ -0,0 +1,55 @@
+package relay
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	c "github.com/launchdarkly/ld-relay/v7/config"
+	st "github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestBodySizeLimit(t *testing.T) {
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(st.EnvMain)
+	config.Main.MaxRequestBodySize = mustOptIntGreaterThanZero(100)
+	tooBig := `[` + strings.Repeat(`{"kind":"identify"},`, 10) + `{}]`
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		t.Run("body with Content-Length", func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "http://localhost/bulk", bytes.NewBufferString(tooBig))
+			req.Header.Set("Authorization", string(st.EnvMain.Config.SDKKey))
+			req.Header.Set("Content-Type", "application/json")
+			result, _ := st.DoRequest(req, p.relay)
+			assert.Equal(t, http.StatusRequestEntityTooLarge, result.StatusCode)
+		})
+
+		t.Run("REPORT body without Content-Length", func(t *testing.T) {
+			req, _ := http.NewRequest("REPORT", "http://localhost/sdk/evalx/user", bytes.NewBufferString(tooBig))
+			req.ContentLength = -1
+			req.Header.Set("Authorization", string(st.EnvMain.Config.SDKKey))
+			req.Header.Set("Content-Type", "application/json")
+			result, _ := st.DoRequest(req, p.relay)
+			assert.Equal(t, http.StatusRequestEntityTooLarge, result.StatusCode)
+		})
+
+		t.Run("body within limit", func(t *testing.T) {
+			req, _ := http.NewRequest("REPORT", "http://localhost/sdk/evalx/user", bytes.NewBufferString(st.SimpleUserJSON))
+			req.Header.Set("Authorization", string(st.EnvMain.Config.SDKKey))
+			req.Header.Set("Content-Type", "application/json")
+			result, _ := st.DoRequest(req, p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+		})
+
+		t.Run("endpoint that does not take a body is not limited", func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "http://localhost/sdk/evalx/contexts/"+st.ToBase64(st.SimpleUserJSON),
+				bytes.NewBufferString(tooBig))
+			req.Header.Set("Authorization", string(st.EnvMain.Config.SDKKey))
+			result, _ := st.DoRequest(req, p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+		})
+	})
+}
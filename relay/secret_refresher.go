@@ -0,0 +1,140 @@
+package relay
+
+import (
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/auditlog"
+	"github.com/launchdarkly/ld-relay/v7/internal/sdks"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+)
+
+const (
+	defaultSecretRefreshInterval = 10 * time.Second
+
+	secretFieldSDKKey    = "SDKKey"
+	secretFieldMobileKey = "MobileKey"
+)
+
+// secretRefresher periodically re-reads configuration credentials that were given as file references
+// (see config.SecretRef). If the SDK key or mobile key of a configured environment changes, the new key
+// replaces the old one just as it would if auto-configuration had reported a key change. Other
+// credentials are only used at startup, so for those we just log a warning that a restart is needed.
+type secretRefresher struct {
+	relay     *Relay
+	refs      []config.SecretRef
+	values    []string
+	interval  time.Duration
+	closeCh   chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+	loggers   ldlog.Loggers
+}
+
+// newSecretRefresher starts a secretRefresher, or returns nil if the configuration has no file references.
+func newSecretRefresher(r *Relay, c config.Config, interval time.Duration, loggers ldlog.Loggers) *secretRefresher {
+	s := &secretRefresher{
+		relay:    r,
+		interval: interval,
+		closeCh:  make(chan struct{}),
+		loggers:  loggers,
+	}
+	if s.interval <= 0 {
+		s.interval = defaultSecretRefreshInterval
+	}
+	for _, ref := range c.GetSecretRefs() {
+		if ref.FilePath() == "" {
+			continue // environment variables can't change while we're running
+		}
+		var value string
+		if envConfig := c.Environment[ref.EnvName]; envConfig != nil && ref.Field == secretFieldSDKKey {
+			value = string(envConfig.SDKKey)
+		} else if envConfig != nil && ref.Field == secretFieldMobileKey {
+			value = string(envConfig.MobileKey)
+		} else {
+			value, _ = ref.Resolve() // used only for detecting changes
+		}
+		s.refs = append(s.refs, ref)
+		s.values = append(s.values, value)
+	}
+	if len(s.refs) == 0 {
+		return nil
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+func (s *secretRefresher) run() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closeCh:
+			return
+		case <-ticker.C:
+			s.refresh()
+		}
+	}
+}
+
+func (s *secretRefresher) refresh() {
+	for i, ref := range s.refs {
+		newValue, err := ref.Resolve()
+		if err != nil {
+			// The file might be in the middle of being replaced, so we'll just try again next time.
+			s.loggers.Warnf("Unable to re-read %s: %s", ref.Ref, err)
+			continue
+		}
+		oldValue := s.values[i]
+		if newValue == oldValue {
+			continue
+		}
+		s.values[i] = newValue
+		switch {
+		case ref.EnvName != "" && ref.Field == secretFieldSDKKey:
+			s.relay.replaceConfiguredEnvironmentCredential(ref.EnvName, config.SDKKey(oldValue), config.SDKKey(newValue))
+		case ref.EnvName != "" && ref.Field == secretFieldMobileKey:
+			s.relay.replaceConfiguredEnvironmentCredential(ref.EnvName, config.MobileKey(oldValue), config.MobileKey(newValue))
+		case ref.EnvName != "":
+			s.loggers.Warnf("%s for environment %q has changed in %s; restart Relay to use the new value",
+				ref.Field, ref.EnvName, ref.Ref)
+		default:
+			s.loggers.Warnf("%s has changed in %s; restart Relay to use the new value", ref.Field, ref.Ref)
+		}
+	}
+}
+
+func (s *secretRefresher) close() {
+	s.closeOnce.Do(func() {
+		close(s.closeCh)
+	})
+	s.wg.Wait()
+}
+
+// replaceConfiguredEnvironmentCredential switches an environment from the configuration file to a new
+// SDK key or mobile key, and stops accepting the old one.
+func (r *Relay) replaceConfiguredEnvironmentCredential(envName string, oldCredential, newCredential config.SDKCredential) {
+	env, _ := r.getEnvironment(oldCredential)
+	if env == nil {
+		r.loggers.Warnf("Environment %q was not found when trying to change its credential", envName)
+		return
+	}
+	var envID config.EnvironmentID
+	if envConfig := r.config.Environment[envName]; envConfig != nil {
+		envID = envConfig.EnvID
+	}
+	r.loggers.Infof("Credential for environment %q has changed; now using %s", envName,
+		sdks.ObscureKey(newCredential.GetAuthorizationHeaderValue()))
+	defer r.environmentsChanged()
+
+	env.AddCredential(newCredential)
+	r.addedEnvironmentCredential(env, newCredential) // this updates the index we use for authenticating requests
+	r.auditEnvironmentChange(auditlog.ActionAddCredential, auditlog.SourceSecretFile, env.GetIdentifiers(), envID, newCredential)
+	r.removingEnvironmentCredential(oldCredential)
+	env.RemoveCredential(oldCredential)
+	r.auditEnvironmentChange(auditlog.ActionRemoveCredential, auditlog.SourceSecretFile, env.GetIdentifiers(), envID, oldCredential)
+}
@@ -0,0 +1,78 @@
+package relay
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	c "github.com/launchdarkly/ld-relay/v7/config"
+	"github.com/launchdarkly/ld-relay/v7/internal/sharedtest/testclient"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeRelayWithSecretFiles(t *testing.T, config c.Config, loggers ldlog.Loggers) *Relay {
+	require.NoError(t, c.ValidateConfig(&config, loggers))
+	relay, err := newRelayInternal(config, relayInternalOptions{
+		clientFactory:         testclient.FakeLDClientFactory(true),
+		loggers:               loggers,
+		secretRefreshInterval: time.Millisecond * 10,
+	})
+	require.NoError(t, err)
+	return relay
+}
+
+func TestConfiguredEnvironmentKeysAreReplacedWhenSecretFilesChange(t *testing.T) {
+	dir := t.TempDir()
+	sdkKeyPath, mobileKeyPath := filepath.Join(dir, "sdk-key"), filepath.Join(dir, "mobile-key")
+	require.NoError(t, os.WriteFile(sdkKeyPath, []byte("sdk-key-1\n"), 0600))
+	require.NoError(t, os.WriteFile(mobileKeyPath, []byte("mob-key-1\n"), 0600))
+
+	config := c.Config{Environment: map[string]*c.EnvConfig{
+		"earth": {SDKKey: c.SDKKey("file:" + sdkKeyPath), MobileKey: c.MobileKey("file:" + mobileKeyPath)},
+	}}
+	relay := makeRelayWithSecretFiles(t, config, ldlog.NewDisabledLoggers())
+	defer relay.Close()
+
+	env, _ := relay.getEnvironment(c.SDKKey("sdk-key-1"))
+	require.NotNil(t, env)
+
+	require.NoError(t, os.WriteFile(sdkKeyPath, []byte("sdk-key-2\n"), 0600))
+	require.NoError(t, os.WriteFile(mobileKeyPath, []byte("mob-key-2\n"), 0600))
+
+	require.Eventually(t, func() bool {
+		newEnv, _ := relay.getEnvironment(c.SDKKey("sdk-key-2"))
+		newMobileEnv, _ := relay.getEnvironment(c.MobileKey("mob-key-2"))
+		return newEnv == env && newMobileEnv == env
+	}, time.Second, time.Millisecond*10)
+
+	oldEnv, _ := relay.getEnvironment(c.SDKKey("sdk-key-1"))
+	assert.Nil(t, oldEnv)
+	oldMobileEnv, _ := relay.getEnvironment(c.MobileKey("mob-key-1"))
+	assert.Nil(t, oldMobileEnv)
+	assert.ElementsMatch(t, []c.SDKCredential{c.SDKKey("sdk-key-2"), c.MobileKey("mob-key-2")}, env.GetCredentials())
+}
+
+func TestChangeToOtherSecretFileIsLogged(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+	path := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(path, []byte("pass1"), 0600))
+
+	config := c.Config{
+		Environment: map[string]*c.EnvConfig{"earth": {SDKKey: c.SDKKey("sdk-key")}},
+		Proxy:       c.ProxyConfig{Password: "file:" + path},
+	}
+	relay := makeRelayWithSecretFiles(t, config, mockLog.Loggers)
+	defer relay.Close()
+
+	require.NoError(t, os.WriteFile(path, []byte("pass2"), 0600))
+	require.Eventually(t, func() bool {
+		return mockLog.HasMessageMatch(ldlog.Warn, "Proxy.Password has changed in file:.*; restart Relay")
+	}, time.Second, time.Millisecond*10)
+}
@@ -13,6 +13,8 @@ import (
 	"github.com/launchdarkly/ld-relay/v7/internal/relayenv"
 	"github.com/launchdarkly/ld-relay/v7/internal/sharedtest"
 
+	ct "github.com/launchdarkly/go-configtypes"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -134,3 +136,11 @@ func credentialsAsSet(cs ...c.SDKCredential) map[c.SDKCredential]struct{} {
 	}
 	return ret
 }
+
+func mustOptIntGreaterThanZero(n int) ct.OptIntGreaterThanZero {
+	o, err := ct.NewOptIntGreaterThanZero(n)
+	if err != nil {
+		panic(err)
+	}
+	return o
+}